@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/rpc"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/config"
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/state"
+	"github.com/imdea-software/swiftpaxos/transport"
+)
+
+// adminUsage lists the commands of the admin participant.
+const adminUsage = `admin commands:
+  status                 replicas with their role, term or ballot, commit and apply positions and liveness
+  stats [ID]             counters and message drops of all the replicas, or of replica ID
+  leader ID              make replica ID the leader
+  faults FAULT...        inject faults (see -run faults)
+  members [CHANGE]       show or change the members (see -run members)
+  snapshot ID [PATH]     have replica ID write its state machine to PATH, on its host
+  get ID KEY             the value of KEY in the state machine of replica ID`
+
+// adminTimeout bounds the calls to the replicas.
+const adminTimeout = 10 * time.Second
+
+// runAdmin runs the admin command of args against the cluster of c, through
+// the RPC endpoints of the master and of the replicas, and writes its
+// result to out.
+func runAdmin(c *config.Config, args []string, out io.Writer) error {
+	cmd := "status"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+	switch cmd {
+	case "status":
+		return adminStatus(c, out)
+	case "stats":
+		return adminStats(c, args, out)
+	case "leader":
+		reply, err := transferLeader(c, args)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "leader", reply.Leader)
+	case "faults":
+		faults, err := injectFaults(c, args)
+		if err != nil {
+			return err
+		}
+		for _, f := range faults {
+			fmt.Fprintln(out, f)
+		}
+	case "members":
+		reply, err := changeMembership(c, args)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "members", reply.Members, "learners", reply.Learners, "leader", reply.Leader)
+	case "snapshot":
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New("snapshot: want ID [PATH]")
+		}
+		sa := &defs.SnapshotArgs{}
+		if len(args) == 2 {
+			sa.Path = args[1]
+		}
+		reply := &defs.SnapshotReply{}
+		if err := callReplica(c, args[0], "Replica.Snapshot", sa, reply); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%d keys written to %s\n", reply.Keys, reply.Path)
+	case "get":
+		if len(args) != 2 {
+			return errors.New("get: want ID KEY")
+		}
+		k, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("get: invalid key %q", args[1])
+		}
+		reply := &defs.GetValueReply{}
+		if err := callReplica(c, args[0], "Replica.GetValue", &defs.GetValueArgs{Key: state.Key(k)}, reply); err != nil {
+			return err
+		}
+		if !reply.Found {
+			fmt.Fprintf(out, "%d: not found\n", k)
+			return nil
+		}
+		fmt.Fprintf(out, "%d: %s (%q)\n", k, reply.Value.String(), []byte(reply.Value))
+	default:
+		return fmt.Errorf("unknown admin command %q\n%s", cmd, adminUsage)
+	}
+	return nil
+}
+
+// adminStatus writes a line per replica, with the liveness known by the
+// master and the status reported by the replica.
+func adminStatus(c *config.Config, out io.Writer) error {
+	list, err := replicaList(c)
+	if err != nil {
+		return err
+	}
+	gl := &defs.GetLeaderReply{}
+	if err := callMaster(c, "Master.GetLeader", &defs.GetLeaderArgs{}, gl); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tALIAS\tADDRESS\tALIVE\tROLE\tTERM\tCOMMIT\tAPPLIED")
+	for i, addr := range list.ReplicaList {
+		alive := i < len(list.AliveList) && list.AliveList[i]
+		s := &defs.StatusReply{}
+		if err := callReplicaAt(c, rpcAddr(list, i), "Replica.Status", &defs.StatusArgs{}, s); err != nil {
+			fmt.Fprintf(w, "%d\t-\t%s\t%v\t(%v)\t\t\t\n", i, addr, alive, err)
+			continue
+		}
+		role := s.Role
+		if role == "" {
+			role = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%v\t%s\t%s\t%s\t%s\n", i, s.Alias, addr, alive, role,
+			position(s.Term), position(s.Commit), position(s.Applied))
+	}
+	fmt.Fprintf(w, "leader (master): %d\n", gl.LeaderId)
+	return w.Flush()
+}
+
+// adminStats writes the counters of the replicas, or of the replica of
+// args.
+func adminStats(c *config.Config, args []string, out io.Writer) error {
+	if len(args) > 1 {
+		return errors.New("stats: want [ID]")
+	}
+	list, err := replicaList(c)
+	if err != nil {
+		return err
+	}
+	for i := range list.ReplicaList {
+		if len(args) == 1 && args[0] != strconv.Itoa(i) {
+			continue
+		}
+		s := &defs.StatusReply{}
+		if err := callReplicaAt(c, rpcAddr(list, i), "Replica.Status", &defs.StatusArgs{}, s); err != nil {
+			fmt.Fprintf(out, "replica %d: %v\n", i, err)
+			continue
+		}
+		names := make([]string, 0, len(s.Stats))
+		for name := range s.Stats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(out, "replica %d %s %d\n", i, name, s.Stats[name])
+		}
+	}
+	return nil
+}
+
+func position(p int32) string {
+	if p < 0 {
+		return "-"
+	}
+	return strconv.Itoa(int(p))
+}
+
+// replicaList returns the replicas registered with the master.
+func replicaList(c *config.Config) (*defs.GetReplicaListReply, error) {
+	list := &defs.GetReplicaListReply{}
+	if err := callMaster(c, "Master.GetReplicaList", &defs.GetReplicaListArgs{}, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// callReplica calls method on the replica of index id, found through the
+// master.
+func callReplica(c *config.Config, id, method string, args, reply interface{}) error {
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return fmt.Errorf("invalid replica %q", id)
+	}
+	list, err := replicaList(c)
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= len(list.ReplicaList) {
+		return fmt.Errorf("no replica %d", i)
+	}
+	return callReplicaAt(c, rpcAddr(list, i), method, args, reply)
+}
+
+// rpcAddr returns the RPC endpoint of replica i of list, or, from a master
+// that does not give them, the address of the replica with its port plus
+// 1000.
+func rpcAddr(list *defs.GetReplicaListReply, i int) string {
+	if i < len(list.RPCList) && list.RPCList[i] != "" {
+		return list.RPCList[i]
+	}
+	addr := list.ReplicaList[i]
+	j := strings.LastIndex(addr, ":")
+	port, err := strconv.Atoi(addr[j+1:])
+	if j < 0 || err != nil {
+		return addr
+	}
+	return fmt.Sprintf("%s:%d", addr[:j], port+config.RPCPortOffset)
+}
+
+// callReplicaAt calls method on the RPC endpoint rpcAddr of a replica.
+func callReplicaAt(c *config.Config, rpcAddr, method string, args, reply interface{}) error {
+	r, err := transport.DialHTTP(transport.OrTCP(c.Transport), rpcAddr, adminTimeout)
+	if err != nil {
+		return fmt.Errorf("cannot connect to %s: %v", rpcAddr, err)
+	}
+	defer r.Close()
+	call := r.Go(method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-time.After(adminTimeout):
+		return fmt.Errorf("%s: no reply from %s", method, rpcAddr)
+	}
+}
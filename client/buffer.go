@@ -40,6 +40,9 @@ type BufferClient struct {
 
 	// KeyGenerator for Zipf/uniform key distribution
 	keyGen KeyGenerator
+
+	// ValueSizeGenerator for write payload sizes (nil = constant psize)
+	valSize ValueSizeGenerator
 }
 
 // NewBufferClientWithConns creates a minimal BufferClient backed by the given
@@ -89,12 +92,18 @@ func (c *BufferClient) Pipeline(syncFreq int, window int32) {
 	c.window = window
 }
 
-// SetKeyGenerator sets a KeyGenerator for key distribution (Zipf or Uniform).
+// SetKeyGenerator sets a KeyGenerator for key distribution (see NewKeyGeneratorDist).
 // If set, genGetKey will use the KeyGenerator instead of UUID-based keys.
 func (c *BufferClient) SetKeyGenerator(kg KeyGenerator) {
 	c.keyGen = kg
 }
 
+// SetValueSizeGenerator sets a ValueSizeGenerator for write payload sizes.
+// If not set, every write carries a psize-byte payload.
+func (c *BufferClient) SetValueSizeGenerator(vs ValueSizeGenerator) {
+	c.valSize = vs
+}
+
 func (c *BufferClient) RegisterReply(val state.Value, seqnum int32) {
 	t := time.Now()
 	c.Reply <- &ReqReply{
@@ -125,8 +134,7 @@ func (c *BufferClient) Scan(key, count int64) []byte {
 // Assumed to be connected
 func (c *BufferClient) Loop() {
 	getKey := c.genGetKey()
-	val := make([]byte, c.psize)
-	c.rand.Read(val)
+	getValue := c.genGetValue()
 
 	var cmdM sync.Mutex
 	cmdNum := int32(0)
@@ -162,8 +170,8 @@ func (c *BufferClient) Loop() {
 	}()
 
 	for i := 0; i <= c.reqNum; i++ {
-		key := getKey()
 		write := c.randomTrue(c.writes)
+		key := getKey(write)
 		c.reqTime[i] = time.Now()
 
 		// Ignore first request
@@ -172,7 +180,7 @@ func (c *BufferClient) Loop() {
 		}
 
 		if write {
-			c.SendWrite(key, state.Value(val))
+			c.SendWrite(key, getValue())
 			// TODO: if the return value != i, something's wrong
 		} else {
 			c.SendRead(key)
@@ -217,6 +225,10 @@ func (c *BufferClient) WaitReplies(waitFrom int) {
 				c.Printf("WaitReplies reader for replica %d exiting after %d replies: %v", waitFrom, replyCount, err)
 				break
 			}
+			if r.OK == defs.BUSY {
+				c.RetryBusy(r.CommandId)
+				continue
+			}
 			if r.OK != defs.TRUE {
 				c.Printf("WaitReplies reader for replica %d got faulty reply after %d replies", waitFrom, replyCount)
 				break
@@ -228,20 +240,24 @@ func (c *BufferClient) WaitReplies(waitFrom int) {
 			}(r.Value, r.CommandId)
 		}
 		// Notify protocol client that this reader is dead (same as RegisterRPCTable)
-		select {
-		case c.ReaderDead <- waitFrom:
-		default:
-		}
+		c.readerDead(waitFrom)
 	}()
 }
 
-func (c *BufferClient) genGetKey() func() int64 {
+// genGetKey returns a function that picks the key of the next command.
+// write tells whether the command is a write, which matters for generators
+// implementing WriteKeyGenerator (e.g. the "latest" distribution).
+func (c *BufferClient) genGetKey() func(write bool) int64 {
 	key := int64(uuid.New().Time())
-	getKey := func() int64 {
+	wkg, _ := c.keyGen.(WriteKeyGenerator)
+	getKey := func(write bool) int64 {
 		if c.randomTrue(c.conflict) {
 			return c.conflictKey
 		}
-		// Use KeyGenerator if configured (Zipf or Uniform distribution)
+		// Use KeyGenerator if configured (see NewKeyGeneratorDist)
+		if write && wkg != nil {
+			return wkg.NextWriteKey()
+		}
 		if c.keyGen != nil {
 			return c.keyGen.NextKey()
 		}
@@ -253,6 +269,24 @@ func (c *BufferClient) genGetKey() func() int64 {
 	return getKey
 }
 
+// genGetValue returns a function that produces the payload of the next write.
+// All payloads are prefixes of a single random buffer of the maximum size,
+// so varying the size does not allocate per command.
+func (c *BufferClient) genGetValue() func() state.Value {
+	if c.valSize == nil {
+		val := make([]byte, c.psize)
+		c.rand.Read(val)
+		return func() state.Value {
+			return state.Value(val)
+		}
+	}
+	val := make([]byte, c.valSize.MaxSize())
+	c.rand.Read(val)
+	return func() state.Value {
+		return state.Value(val[:c.valSize.NextSize()])
+	}
+}
+
 func (c *BufferClient) randomTrue(prob int) bool {
 	if prob >= 100 {
 		return true
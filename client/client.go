@@ -2,24 +2,25 @@ package client
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"io"
 	"math"
 	"net"
-	"net/http"
 	"net/rpc"
-	"os/exec"
-	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/imdea-software/swiftpaxos/dlog"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
 	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/sim"
 	"github.com/imdea-software/swiftpaxos/state"
+	"github.com/imdea-software/swiftpaxos/transport"
 )
 
 type Client struct {
@@ -28,8 +29,21 @@ type Client struct {
 	ClientId  int32
 	LeaderId  int
 	ClosestId int // also co-located
+	// ReadId is the closest replica that has the log and the state
+	// machine, i.e. the closest member or learner that is no witness, for
+	// weak reads (raft-ht, curp-ht and curp-ho)
+	ReadId int
 
 	Ping []float64
+	// Members are the replicas that vote (raft and raft-ht), nil if all
+	// replicas do; see RefreshMembers
+	Members []bool
+	// Learners are the replicas that get the log without voting (raft and
+	// raft-ht), nil if none does
+	Learners []bool
+	// Witnesses are the replicas that only record the unsynced commands
+	// (curp, curp-ht and curp-ho), nil if none does
+	Witnesses []bool
 
 	Fast       bool
 	Verbose    bool
@@ -45,11 +59,53 @@ type Client struct {
 	server     string // co-located with
 	masterPort int
 	masterAddr string
-	replicas   []string
+	// addresses of all the masters (nil = masterAddr:masterPort), and the
+	// index of the one dialed
+	masterAddrs []string
+	masterIdx   int
+	replicas    []string
+	transport   transport.Transport
+	clock       sim.Clock
+	// protocol and cluster sent in the handshake with the replicas
+	protocol string
+	cluster  string
+	// codes of the messages of the replicas, from the handshake
+	messages map[string]fastrpc.Code
 
 	// ReaderDead receives the replica index when a reader goroutine exits (EOF/error).
 	// Protocol clients can listen on this channel to detect dead replicas.
 	ReaderDead chan int
+
+	// number of replica connections lost before Disconnect (accessed atomically)
+	connErrors   int64
+	disconnected int32
+
+	// serializes the writes of SendProposal and SendMsg, which also happen
+	// on retries
+	wmu sync.Mutex
+	// last proposals sent, by CommandId modulo busyRing, for retries
+	sentMu sync.Mutex
+	sent   [busyRing]sentProposal
+	// number of proposals rejected as "server busy" (accessed atomically)
+	busyReplies int64
+}
+
+// Proposals rejected as "server busy" are sent again after a delay that
+// doubles with each rejection, from busyMinDelay to busyMaxDelay
+const (
+	busyRing     = 1 << 16
+	busyMinDelay = 2 * time.Millisecond
+	busyMaxDelay = time.Second
+)
+
+// Replicas answer the handshake once they serve clients, which they do after
+// connecting to and probing their peers
+const handshakeTimeout = 30 * time.Second
+
+type sentProposal struct {
+	p    defs.Propose
+	busy int // times rejected
+	ok   bool
 }
 
 func NewClient(server, maddr string, mport int, fast, leaderless, verbose bool) *Client {
@@ -65,6 +121,7 @@ func NewClientLog(server, maddr string, mport int, fast, leaderless, verbose boo
 		ClientId:  int32(uuid.New().ID()),
 		LeaderId:  -1,
 		ClosestId: -1,
+		ReadId:    -1,
 
 		Fast:       fast,
 		Verbose:    verbose,
@@ -82,6 +139,35 @@ func NewClientLog(server, maddr string, mport int, fast, leaderless, verbose boo
 	}
 }
 
+// SetTransport sets the network used to connect to the master and the
+// replicas (nil = TCP). It must be called before Connect.
+func (c *Client) SetTransport(t transport.Transport) {
+	c.transport = t
+}
+
+// SetMasters sets the addresses of the masters, tried in turn until one
+// answers as primary. It must be called before Connect.
+func (c *Client) SetMasters(addrs []string) {
+	c.masterAddrs = addrs
+}
+
+// SetCluster sets the protocol and the cluster id checked by the replicas
+// when the client connects. It must be called before Connect.
+func (c *Client) SetCluster(protocol, cluster string) {
+	c.protocol = protocol
+	c.cluster = cluster
+}
+
+// SetClock sets the clock of the client's timers (nil = wall clock).
+func (c *Client) SetClock(clock sim.Clock) {
+	c.clock = clock
+}
+
+// Clock returns the clock of the client's timers.
+func (c *Client) Clock() sim.Clock {
+	return sim.OrReal(c.clock)
+}
+
 func (c *Client) Connect() error {
 	c.Println("dialing master...")
 	_, err := c.dialMaster()
@@ -96,14 +182,9 @@ func (c *Client) Connect() error {
 	}
 	masterReply := rl.(*defs.GetReplicaListReply)
 	c.replicas = masterReply.ReplicaList
-
-	c.Println("searching for the closest replica...")
-	err = c.findClosest(masterReply.AliveList)
-	if err != nil {
-		return err
-	}
-	c.Println("replicas", c.replicas)
-	c.Println("closest (alive)", c.ClosestId)
+	c.Members = masterReply.MemberList
+	c.Learners = masterReply.LearnerList
+	c.Witnesses = masterReply.WitnessList
 
 	c.dt = defs.NewLatencyTable(defs.LatencyConf, defs.IP(), -1, c.replicas)
 
@@ -142,10 +223,20 @@ func (c *Client) Connect() error {
 		c.writers[i] = bufio.NewWriter(c.servers[i])
 	}
 
+	c.Println("searching for the closest replica...")
+	err = c.findClosest(masterReply.AliveList)
+	if err != nil {
+		return err
+	}
+	c.ReadId = c.closestReader()
+	c.Println("replicas", c.replicas)
+	c.Println("closest (alive)", c.ClosestId, "closest with the log", c.ReadId)
+
 	return nil
 }
 
 func (c *Client) Disconnect() {
+	atomic.StoreInt32(&c.disconnected, 1)
 	for _, s := range c.servers {
 		if s != nil {
 			s.Close()
@@ -179,6 +270,16 @@ func (c *Client) Reconnect() error {
 }
 
 func (c *Client) SendProposal(cmd defs.Propose) {
+	c.sentMu.Lock()
+	s := &c.sent[uint32(cmd.CommandId)%busyRing]
+	if !s.ok || s.p.CommandId != cmd.CommandId {
+		*s = sentProposal{p: cmd, ok: true}
+	}
+	c.sentMu.Unlock()
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
 	d := c.LeaderId
 	if c.Leaderless {
 		d = c.ClosestId
@@ -186,15 +287,13 @@ func (c *Client) SendProposal(cmd defs.Propose) {
 
 	if !c.Fast {
 		c.Println("sending command", cmd.CommandId, "to", d)
-		c.writers[d].WriteByte(defs.PROPOSE)
-		cmd.Marshal(c.writers[d])
+		fastrpc.WriteFrame(c.writers[d], fastrpc.Code(defs.PROPOSE), &cmd)
 		c.writers[d].Flush()
 	} else {
 		c.Println("sending command", cmd.CommandId, "to everyone")
 		for rep := 0; rep < len(c.servers); rep++ {
 			if c.writers[rep] != nil {
-				c.writers[rep].WriteByte(defs.PROPOSE)
-				cmd.Marshal(c.writers[rep])
+				fastrpc.WriteFrame(c.writers[rep], fastrpc.Code(defs.PROPOSE), &cmd)
 				c.writers[rep].Flush()
 			}
 		}
@@ -292,32 +391,57 @@ func (c *Client) SendScan(key, count int64) int32 {
 
 func (c *Client) GetReplyFrom(rid int) (*defs.ProposeReplyTS, error) {
 	rep := &defs.ProposeReplyTS{}
-	err := rep.Unmarshal(c.readers[rid])
+	b, err := fastrpc.ReadFrame(c.readers[rid], nil)
+	if err != nil {
+		return rep, err
+	}
+	err = fastrpc.UnmarshalFrame(bytes.NewReader(b), rep)
 	return rep, err
 }
 
 func (c *Client) RegisterRPCTable(t *fastrpc.Table) {
+	// the messages of the client are a part of the replicas' ones
+	if c.messages != nil {
+		if err := fastrpc.CompareMessages(t.Messages(), c.messages, false); err != nil {
+			c.Fatal("the messages of the client do not match the replicas: ", err)
+		}
+	}
 	for i, reader := range c.readers {
 		if reader == nil {
 			continue
 		}
 		go func(i int, reader *bufio.Reader) {
+			frames := fastrpc.NewFrameReader(reader)
 			for {
-				var (
-					msgType uint8
-					err     error
-				)
-				if msgType, err = reader.ReadByte(); err != nil {
-					c.Println("reader goroutine for replica", i, "exiting: ReadByte:", err)
+				payload, err := frames.Next()
+				if err != nil {
+					c.Println("reader goroutine for replica", i, "exiting: ReadFrame:", err)
+					break
+				}
+				if b, _ := payload.ReadByte(); b == defs.BUSY {
+					// the code is the first byte of the reply
+					payload.UnreadByte()
+					rep := &defs.ProposeReplyTS{}
+					if err = fastrpc.UnmarshalFrame(payload, rep); err != nil {
+						c.Println("reader goroutine for replica", i, "exiting: Unmarshal:", err)
+						break
+					}
+					c.RetryBusy(rep.CommandId)
+					continue
+				}
+				payload.UnreadByte()
+				code, err := fastrpc.ReadCode(payload)
+				if err != nil {
+					c.Println("reader goroutine for replica", i, "exiting: ReadCode:", err)
 					break
 				}
-				p, exists := t.Get(msgType)
+				p, exists := t.Get(code)
 				if !exists {
-					c.Println("error: received unknown message from replica", i, ":", msgType)
+					c.Println("error: received unknown message from replica", i, ":", code)
 					continue
 				}
 				obj := p.Obj.New()
-				if err = obj.Unmarshal(reader); err != nil {
+				if err = fastrpc.UnmarshalFrame(payload, obj); err != nil {
 					c.Println("reader goroutine for replica", i, "exiting: Unmarshal:", err)
 					break
 				}
@@ -326,20 +450,66 @@ func (c *Client) RegisterRPCTable(t *fastrpc.Table) {
 					p.Chan <- obj
 				}(obj)
 			}
-			// Notify protocol client that this reader is dead
-			select {
-			case c.ReaderDead <- i:
-			default:
-			}
+			c.readerDead(i)
 		}(i, reader)
 	}
 }
 
+// readerDead records the loss of the connection to replica i and notifies
+// the protocol client through ReaderDead.
+func (c *Client) readerDead(i int) {
+	if atomic.LoadInt32(&c.disconnected) == 0 {
+		atomic.AddInt64(&c.connErrors, 1)
+	}
+	select {
+	case c.ReaderDead <- i:
+	default:
+	}
+}
+
+// ConnErrors returns the number of replica connections lost so far
+// (connections closed by Disconnect are not counted).
+func (c *Client) ConnErrors() int64 {
+	return atomic.LoadInt64(&c.connErrors)
+}
+
+// RefreshMembers gets the members and the leader from the master again,
+// after a membership change.
+func (c *Client) RefreshMembers() error {
+	rl, err := c.callMaster("GetReplicaList")
+	if err != nil {
+		return err
+	}
+	c.Members = rl.(*defs.GetReplicaListReply).MemberList
+	c.Learners = rl.(*defs.GetReplicaListReply).LearnerList
+	c.Witnesses = rl.(*defs.GetReplicaListReply).WitnessList
+	c.ReadId = c.closestReader()
+	if !c.Leaderless {
+		gl, err := c.callMaster("GetLeader")
+		if err != nil {
+			return err
+		}
+		c.LeaderId = gl.(*defs.GetLeaderReply).LeaderId
+	}
+	return nil
+}
+
 // NumReplicas returns the number of replicas.
 func (c *Client) NumReplicas() int {
 	return len(c.replicas)
 }
 
+// NumWitnesses returns the number of replicas that are only witnesses.
+func (c *Client) NumWitnesses() int {
+	n := 0
+	for _, w := range c.Witnesses {
+		if w {
+			n++
+		}
+	}
+	return n
+}
+
 // WaitDurationForReplica returns the simulated network delay for the given replica.
 func (c *Client) WaitDurationForReplica(rid int) time.Duration {
 	if c.dt == nil || rid < 0 || rid >= len(c.replicas) {
@@ -348,15 +518,46 @@ func (c *Client) WaitDurationForReplica(rid int) time.Duration {
 	return c.dt.WaitDuration(c.replicas[rid])
 }
 
+// RetryBusy sends again the proposal cmdId, which a replica rejected as
+// "server busy", after a backoff delay. It is called by the reply readers.
+func (c *Client) RetryBusy(cmdId int32) {
+	atomic.AddInt64(&c.busyReplies, 1)
+	c.sentMu.Lock()
+	s := &c.sent[uint32(cmdId)%busyRing]
+	if !s.ok || s.p.CommandId != cmdId {
+		c.sentMu.Unlock()
+		c.Println("busy reply for unknown command", cmdId)
+		return
+	}
+	delay := busyMaxDelay
+	if s.busy < 20 && busyMinDelay<<s.busy < busyMaxDelay {
+		delay = busyMinDelay << s.busy
+	}
+	s.busy++
+	p := s.p
+	c.sentMu.Unlock()
+
+	go func() {
+		c.Clock().Sleep(delay)
+		c.SendProposal(p)
+	}()
+}
+
+// BusyReplies returns the number of proposals rejected as "server busy".
+func (c *Client) BusyReplies() int64 {
+	return atomic.LoadInt64(&c.busyReplies)
+}
+
 // For custom client messages
-func (c *Client) SendMsg(rid int32, code uint8, msg fastrpc.Serializable) {
+func (c *Client) SendMsg(rid int32, code fastrpc.Code, msg fastrpc.Serializable) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
 	w := c.writers[rid]
 	if w == nil {
 		// TODO: return an error
 		return
 	}
-	w.WriteByte(code)
-	msg.Marshal(w)
+	fastrpc.WriteFrame(w, code, msg)
 	w.Flush()
 }
 
@@ -364,23 +565,34 @@ func (c *Client) dial(addr string, connect bool) (net.Conn, error) {
 	var (
 		err  error    = nil
 		conn net.Conn = nil
-		resp *http.Response
 	)
 
 	for try := 0; try < 3; try++ {
-		conn, err = net.DialTimeout("tcp", addr, 3*time.Second)
+		conn, err = transport.OrTCP(c.transport).Dial(addr, 3*time.Second)
 		if err == nil {
 			if connect {
-				io.WriteString(conn, "CONNECT "+rpc.DefaultRPCPath+" HTTP/1.0\n\n")
-				resp, err = http.ReadResponse(bufio.NewReader(conn),
-					&http.Request{
-						Method: "CONNECT",
-					})
-				if err == nil && resp != nil && resp.Status == "200 Connected to Go RPC" {
+				if err = transport.ConnectRPC(conn); err == nil {
 					return conn, nil
 				}
 			} else {
-				return conn, nil
+				var remote *defs.Hello
+				remote, err = defs.Handshake(conn, &defs.Hello{
+					Protocol: c.protocol,
+					Version:  defs.WireVersion,
+					Role:     defs.RoleClient,
+					Cluster:  c.cluster,
+					Id:       c.ClientId,
+				}, handshakeTimeout)
+				if err == nil {
+					if c.messages == nil {
+						c.messages = remote.Messages
+					}
+					return conn, nil
+				}
+				// retrying does not help
+				c.Println(addr, err)
+				conn.Close()
+				return nil, err
 			}
 		} else {
 			c.Println(addr, "connection error:", err)
@@ -393,23 +605,51 @@ func (c *Client) dial(addr string, connect bool) (net.Conn, error) {
 	return nil, errors.New("cannot connect")
 }
 
+// dialMaster connects to the current master, or else to the next ones.
 func (c *Client) dialMaster() (*rpc.Client, error) {
 	if c.master != nil {
 		c.master.Close()
+		c.master = nil
 	}
-	addr := fmt.Sprintf("%s:%d", c.masterAddr, c.masterPort)
-	conn, err := c.dial(addr, true)
-	if err != nil {
-		return nil, err
+	addrs := c.masterAddrs
+	if len(addrs) == 0 {
+		addrs = []string{fmt.Sprintf("%s:%d", c.masterAddr, c.masterPort)}
+	}
+	var err error
+	for i := 0; i < len(addrs); i++ {
+		m := (c.masterIdx + i) % len(addrs)
+		var conn net.Conn
+		if conn, err = c.dial(addrs[m], true); err == nil {
+			c.masterIdx = m
+			c.master = rpc.NewClient(conn)
+			return c.master, nil
+		}
 	}
-	c.master = rpc.NewClient(conn)
-	return c.master, nil
+	return nil, err
 }
 
+// nextMaster connects to the master after the current one, which failed.
+func (c *Client) nextMaster() {
+	if len(c.masterAddrs) < 2 {
+		return
+	}
+	c.masterIdx = (c.masterIdx + 1) % len(c.masterAddrs)
+	if _, err := c.dialMaster(); err != nil {
+		c.Println("cannot connect to a master:", err)
+	}
+}
+
+// findClosest measures the round trips to the live replicas in Ping, in
+// milliseconds (MaxFloat64 for the others), and sets ClosestId to the
+// replica of the same host as the client or else to the nearest one. It is
+// called once connected to the replicas, before their replies are read, and
+// only then: the protocol clients keep the replicas they bind to.
 func (c *Client) findClosest(alive []bool) error {
 	c.Println("pinging all replicas...")
 
+	c.Ping = make([]float64, len(c.replicas))
 	for i := 0; i < len(c.replicas); i++ {
+		c.Ping[i] = math.MaxFloat64
 		if !alive[i] {
 			continue
 		}
@@ -418,15 +658,15 @@ func (c *Client) findClosest(alive []bool) error {
 			addr = "127.0.0.1"
 		}
 
-		if addr == c.server {
+		// c.server is host:port, or only a host
+		if c.replicas[i] == c.server || addr == c.server {
 			c.ClosestId = i
 		}
 
-		out, err := exec.Command("ping", addr, "-c 3", "-q").Output()
+		latency, err := c.probe(i, probeCount)
 		if err == nil {
-			latency, _ := strconv.ParseFloat(strings.Split(string(out), "/")[4], 64)
 			c.Println(i, "->", latency)
-			c.Ping = append(c.Ping, latency)
+			c.Ping[i] = latency
 		} else {
 			c.Println(c.replicas[i], err)
 			return err
@@ -446,6 +686,63 @@ func (c *Client) findClosest(alive []bool) error {
 	return nil
 }
 
+// closestReader returns ClosestId if it is a member or a learner that is no
+// witness, or else the closest such replica (-1 if none is alive).
+func (c *Client) closestReader() int {
+	hasLog := func(i int) bool {
+		if c.Witnesses != nil && c.Witnesses[i] {
+			return false
+		}
+		return c.Members == nil || c.Members[i] || (c.Learners != nil && c.Learners[i])
+	}
+	if c.ClosestId >= 0 && hasLog(c.ClosestId) {
+		return c.ClosestId
+	}
+	id, min := -1, math.MaxFloat64
+	for i, l := range c.Ping {
+		if l < min && hasLog(i) {
+			id, min = i, l
+		}
+	}
+	return id
+}
+
+// probeCount is the number of beacons sent to each replica by findClosest.
+const probeCount = 3
+
+// probe returns the average round trip to replica i in milliseconds, over
+// count beacons sent on the connection to the replica.
+func (c *Client) probe(i, count int) (float64, error) {
+	conn, w := c.servers[i], c.writers[i]
+	frames := fastrpc.NewFrameReader(c.readers[i])
+	defer conn.SetReadDeadline(time.Time{})
+
+	total := time.Duration(0)
+	for k := 0; k < count; k++ {
+		start := c.Clock().Now()
+		beacon := &defs.Beacon{Timestamp: start.UnixNano()}
+		fastrpc.WriteFrame(w, fastrpc.Code(defs.GENERIC_SMR_BEACON), beacon)
+		if err := w.Flush(); err != nil {
+			return 0, err
+		}
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		for {
+			code, payload, err := frames.NextCode()
+			if err != nil {
+				return 0, err
+			}
+			reply := &defs.BeaconReply{}
+			if code != fastrpc.Code(defs.GENERIC_SMR_BEACON_REPLY) ||
+				fastrpc.UnmarshalFrame(payload, reply) != nil || reply.Timestamp != beacon.Timestamp {
+				continue
+			}
+			break
+		}
+		total += c.Clock().Now().Sub(start)
+	}
+	return float64(total) / float64(count*int(time.Millisecond)), nil
+}
+
 func (c *Client) call(r *rpc.Client, method string, args, reply interface{}) error {
 	errs := make(chan error, 1)
 	go func() {
@@ -473,6 +770,13 @@ func (c *Client) callMaster(method string) (interface{}, error) {
 	)
 
 	for i := 0; i < 100; i++ {
+		if c.master == nil {
+			// the masters are unreachable
+			if _, err := c.dialMaster(); err != nil {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+		}
 		if method == "GetReplicaList" {
 			rl = &defs.GetReplicaListReply{}
 			rlArgs = &defs.GetReplicaListArgs{}
@@ -480,6 +784,9 @@ func (c *Client) callMaster(method string) (interface{}, error) {
 			if err == nil && rl.Ready {
 				return rl, nil
 			}
+			if err != nil {
+				c.nextMaster()
+			}
 		} else if method == "GetLeader" {
 			gl = &defs.GetLeaderReply{}
 			glArgs = &defs.GetLeaderArgs{}
@@ -487,6 +794,7 @@ func (c *Client) callMaster(method string) (interface{}, error) {
 			if err == nil {
 				return gl, nil
 			}
+			c.nextMaster()
 		}
 	}
 
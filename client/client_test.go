@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *lockedBuffer) proposals(t *testing.T) []int32 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r := bytes.NewReader(b.b.Bytes())
+	var ids []int32
+	for r.Len() > 0 {
+		f, err := fastrpc.ReadFrame(r, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload := bytes.NewReader(f)
+		if code, _ := fastrpc.ReadCode(payload); code != fastrpc.Code(defs.PROPOSE) {
+			t.Fatalf("unexpected code %d", code)
+		}
+		p := &defs.Propose{}
+		if err := fastrpc.UnmarshalFrame(payload, p); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, p.CommandId)
+	}
+	return ids
+}
+
+// TestRetryBusy tests that rejected proposals are sent again
+func TestRetryBusy(t *testing.T) {
+	c := NewClient("", "", 0, false, false, false)
+	buf := &lockedBuffer{}
+	c.writers = []*bufio.Writer{bufio.NewWriter(buf)}
+	c.LeaderId = 0
+
+	c.SendWrite(1, state.NIL())
+	c.SendWrite(2, state.NIL())
+	c.RetryBusy(1)
+	// unknown commands are not sent
+	c.RetryBusy(7)
+
+	deadline := time.Now().Add(time.Second)
+	for len(buf.proposals(t)) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	ids := buf.proposals(t)
+	if len(ids) != 3 || ids[2] != 1 {
+		t.Errorf("sent %v, want [0 1 1]", ids)
+	}
+	if n := c.BusyReplies(); n != 2 {
+		t.Errorf("%d busy replies, want 2", n)
+	}
+}
+
+// TestFindClosest tests that the round trips to the replicas are measured
+// with beacons over the connections to them
+func TestFindClosest(t *testing.T) {
+	c := NewClient("10.0.0.9", "", 0, false, false, false)
+	c.replicas = []string{"10.0.0.1:7070", "10.0.0.2:7071", "10.0.0.3:7072"}
+	c.servers = make([]net.Conn, 3)
+	c.readers = make([]*bufio.Reader, 3)
+	c.writers = make([]*bufio.Writer, 3)
+	for i, delay := range []time.Duration{20 * time.Millisecond, time.Millisecond} {
+		conn, replica := net.Pipe()
+		defer conn.Close()
+		c.servers[i] = conn
+		c.readers[i] = bufio.NewReader(conn)
+		c.writers[i] = bufio.NewWriter(conn)
+		go func(delay time.Duration) {
+			frames := fastrpc.NewFrameReader(replica)
+			for {
+				_, payload, err := frames.NextCode()
+				if err != nil {
+					return
+				}
+				b := &defs.Beacon{}
+				fastrpc.UnmarshalFrame(payload, b)
+				time.Sleep(delay)
+				fastrpc.WriteFrame(replica, fastrpc.Code(defs.GENERIC_SMR_BEACON_REPLY), &defs.BeaconReply{Timestamp: b.Timestamp})
+			}
+		}(delay)
+	}
+
+	if err := c.findClosest([]bool{true, true, false}); err != nil {
+		t.Fatal(err)
+	}
+	if c.ClosestId != 1 {
+		t.Errorf("closest replica %d, want 1 (round trips %v)", c.ClosestId, c.Ping)
+	}
+	if len(c.Ping) != 3 || c.Ping[0] < 20 || c.Ping[2] != math.MaxFloat64 {
+		t.Errorf("round trips %v", c.Ping)
+	}
+}
+
+func TestClosestReader(t *testing.T) {
+	c := NewClient("10.0.0.9", "", 0, false, false, false)
+	c.Ping = []float64{30, 10, 20, math.MaxFloat64}
+	c.ClosestId = 1
+	if id := c.closestReader(); id != 1 {
+		t.Errorf("reader %d without members, want 1", id)
+	}
+
+	// replica 1 is a spare, replica 2 a learner
+	c.Members = []bool{true, false, false, true}
+	c.Learners = []bool{false, false, true, false}
+	if id := c.closestReader(); id != 2 {
+		t.Errorf("reader %d, want the learner 2", id)
+	}
+	c.Learners = nil
+	if id := c.closestReader(); id != 0 {
+		t.Errorf("reader %d without learners, want 0", id)
+	}
+
+	// witnesses have no state machine
+	c.Members = nil
+	c.Witnesses = []bool{false, true, false, false}
+	if id := c.closestReader(); id != 2 {
+		t.Errorf("reader %d, want 2 after the witness 1", id)
+	}
+	if n := c.NumWitnesses(); n != 1 {
+		t.Errorf("%d witnesses, want 1", n)
+	}
+}
@@ -0,0 +1,390 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// DefaultSigFigs is the number of significant decimal digits kept by the
+// per-run latency histograms (0.1% relative error).
+const DefaultSigFigs = 3
+
+// IntervalSigFigs is the precision of the per-interval histograms, which are
+// rotated every interval and only need p50/p99-level accuracy (1% error).
+const IntervalSigFigs = 2
+
+// Histogram is a high-dynamic-range histogram of non-negative integer values
+// (latencies in microseconds), following the HdrHistogram bucket layout:
+// values are grouped in power-of-two buckets, each split into linear
+// sub-buckets so that every value is recorded with sigFigs significant
+// digits. Memory depends on the largest recorded value, not on the number of
+// samples, and histograms with the same precision merge by adding counts.
+//
+// A Histogram is not safe for concurrent use.
+type Histogram struct {
+	sigFigs            int
+	subBucketHalfMag   uint
+	subBucketHalfCount int64
+	subBucketMask      int64
+
+	counts []int64 // grown lazily up to the highest recorded index
+	total  int64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+// NewHistogram creates an empty Histogram with the given number of
+// significant digits (1-5).
+func NewHistogram(sigFigs int) *Histogram {
+	if sigFigs < 1 {
+		sigFigs = 1
+	}
+	if sigFigs > 5 {
+		sigFigs = 5
+	}
+	largest := 2 * int64(math.Pow10(sigFigs))
+	subBucketMag := uint(math.Ceil(math.Log2(float64(largest))))
+	h := &Histogram{
+		sigFigs:            sigFigs,
+		subBucketHalfMag:   subBucketMag - 1,
+		subBucketHalfCount: 1 << (subBucketMag - 1),
+		subBucketMask:      1<<subBucketMag - 1,
+	}
+	h.Reset()
+	return h
+}
+
+// Reset removes all recorded values.
+func (h *Histogram) Reset() {
+	for i := range h.counts {
+		h.counts[i] = 0
+	}
+	h.total = 0
+	h.sum = 0
+	h.min = math.MaxInt64
+	h.max = 0
+}
+
+func (h *Histogram) bucketOf(v int64) int {
+	pow2Ceiling := 64 - bits.LeadingZeros64(uint64(v|h.subBucketMask))
+	return pow2Ceiling - int(h.subBucketHalfMag+1)
+}
+
+func (h *Histogram) indexOf(v int64) int {
+	bucket := h.bucketOf(v)
+	sub := v >> uint(bucket)
+	return (bucket+1)<<h.subBucketHalfMag + int(sub-h.subBucketHalfCount)
+}
+
+// valueAt returns the lowest value that maps to counts index i.
+func (h *Histogram) valueAt(i int) int64 {
+	bucket := (i >> h.subBucketHalfMag) - 1
+	sub := int64(i)&(h.subBucketHalfCount-1) + h.subBucketHalfCount
+	if bucket < 0 {
+		sub -= h.subBucketHalfCount
+		bucket = 0
+	}
+	return sub << uint(bucket)
+}
+
+// highestEquivalent returns the highest value that maps to counts index i.
+func (h *Histogram) highestEquivalent(i int) int64 {
+	v := h.valueAt(i)
+	return v + (int64(1) << uint(h.bucketOf(v))) - 1
+}
+
+// RecordN records count occurrences of value v.
+func (h *Histogram) RecordN(v, count int64) {
+	if v < 0 {
+		v = 0
+	}
+	i := h.indexOf(v)
+	if i >= len(h.counts) {
+		grown := make([]int64, i+1, 2*(i+1))
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	h.counts[i] += count
+	h.total += count
+	h.sum += v * count
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// Record records value v.
+func (h *Histogram) Record(v int64) {
+	h.RecordN(v, 1)
+}
+
+// RecordMs records a latency given in milliseconds (stored in microseconds).
+func (h *Histogram) RecordMs(ms float64) {
+	h.Record(int64(math.Round(ms * 1000)))
+}
+
+// Count returns the number of recorded values.
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+// Mean returns the exact mean of the recorded values.
+func (h *Histogram) Mean() float64 {
+	if h.total == 0 {
+		return 0
+	}
+	return float64(h.sum) / float64(h.total)
+}
+
+// Min returns the smallest recorded value.
+func (h *Histogram) Min() int64 {
+	if h.total == 0 {
+		return 0
+	}
+	return h.min
+}
+
+// Max returns the largest recorded value.
+func (h *Histogram) Max() int64 {
+	return h.max
+}
+
+// ValueAtQuantile returns the value below or at which a fraction q (0-1) of
+// the recorded values fall, within the histogram's precision.
+func (h *Histogram) ValueAtQuantile(q float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.min
+	}
+	target := int64(math.Ceil(q * float64(h.total)))
+	if target < 1 {
+		target = 1
+	}
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			v := h.highestEquivalent(i)
+			if v > h.max {
+				v = h.max
+			}
+			if v < h.min {
+				v = h.min
+			}
+			return v
+		}
+	}
+	return h.max
+}
+
+// Merge adds all values recorded in o to h. Histograms of different
+// precision are merged by re-recording o's bucket values.
+func (h *Histogram) Merge(o *Histogram) {
+	if o == nil || o.total == 0 {
+		return
+	}
+	if o.sigFigs == h.sigFigs {
+		if len(o.counts) > len(h.counts) {
+			grown := make([]int64, len(o.counts))
+			copy(grown, h.counts)
+			h.counts = grown
+		}
+		for i, c := range o.counts {
+			h.counts[i] += c
+		}
+		h.total += o.total
+		h.sum += o.sum
+	} else {
+		sum, min, max := h.sum, h.min, h.max
+		for i, c := range o.counts {
+			if c > 0 {
+				h.RecordN(o.valueAt(i), c)
+			}
+		}
+		h.sum, h.min, h.max = sum+o.sum, min, max
+	}
+	if o.min < h.min {
+		h.min = o.min
+	}
+	if o.max > h.max {
+		h.max = o.max
+	}
+}
+
+// Copy returns a deep copy of h.
+func (h *Histogram) Copy() *Histogram {
+	c := *h
+	c.counts = make([]int64, len(h.counts))
+	copy(c.counts, h.counts)
+	return &c
+}
+
+// Bucket is one non-empty histogram bucket: Count values are at most Value.
+type Bucket struct {
+	Value int64
+	Count int64
+}
+
+// Distribution returns the non-empty buckets in increasing value order.
+func (h *Histogram) Distribution() []Bucket {
+	var d []Bucket
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		v := h.highestEquivalent(i)
+		if v > h.max {
+			v = h.max
+		}
+		d = append(d, Bucket{Value: v, Count: c})
+	}
+	return d
+}
+
+// histogramJSON is the wire format of a Histogram: only non-empty counts
+// are stored, as [index, count] pairs.
+type histogramJSON struct {
+	SigFigs int        `json:"sig_figs"`
+	Total   int64      `json:"total"`
+	Sum     int64      `json:"sum"`
+	Min     int64      `json:"min"`
+	Max     int64      `json:"max"`
+	Counts  [][2]int64 `json:"counts"`
+}
+
+// MarshalJSON encodes the histogram in a compact sparse form, so that
+// histograms can be merged across processes.
+func (h *Histogram) MarshalJSON() ([]byte, error) {
+	hj := histogramJSON{
+		SigFigs: h.sigFigs,
+		Total:   h.total,
+		Sum:     h.sum,
+		Min:     h.Min(),
+		Max:     h.max,
+		Counts:  [][2]int64{},
+	}
+	for i, c := range h.counts {
+		if c > 0 {
+			hj.Counts = append(hj.Counts, [2]int64{int64(i), c})
+		}
+	}
+	return json.Marshal(hj)
+}
+
+// UnmarshalJSON decodes a histogram encoded by MarshalJSON.
+func (h *Histogram) UnmarshalJSON(b []byte) error {
+	var hj histogramJSON
+	if err := json.Unmarshal(b, &hj); err != nil {
+		return err
+	}
+	*h = *NewHistogram(hj.SigFigs)
+	var total int64
+	for _, ic := range hj.Counts {
+		i, c := int(ic[0]), ic[1]
+		if i < 0 || c < 0 || i > 1<<20 {
+			return fmt.Errorf("invalid histogram entry [%d, %d]", i, c)
+		}
+		if i >= len(h.counts) {
+			grown := make([]int64, i+1)
+			copy(grown, h.counts)
+			h.counts = grown
+		}
+		h.counts[i] += c
+		total += c
+	}
+	if total != hj.Total {
+		return fmt.Errorf("histogram total %d does not match counts (%d)", hj.Total, total)
+	}
+	h.total = hj.Total
+	h.sum = hj.Sum
+	if h.total > 0 {
+		h.min = hj.Min
+	}
+	h.max = hj.Max
+	return nil
+}
+
+// GobEncode lets histograms travel over net/rpc.
+func (h *Histogram) GobEncode() ([]byte, error) {
+	return h.MarshalJSON()
+}
+
+// GobDecode lets histograms travel over net/rpc.
+func (h *Histogram) GobDecode(b []byte) error {
+	return h.UnmarshalJSON(b)
+}
+
+// LatencySummary holds the statistics printed and exported for one
+// command type. All latencies are in milliseconds.
+type LatencySummary struct {
+	Count int64   `json:"count"`
+	Mean  float64 `json:"mean"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	P999  float64 `json:"p999"`
+}
+
+// Summary computes the LatencySummary of a histogram of microsecond latencies.
+func (h *Histogram) Summary() LatencySummary {
+	ms := func(us int64) float64 { return float64(us) / 1000 }
+	return LatencySummary{
+		Count: h.Count(),
+		Mean:  h.Mean() / 1000,
+		Min:   ms(h.Min()),
+		Max:   ms(h.Max()),
+		P50:   ms(h.ValueAtQuantile(0.5)),
+		P90:   ms(h.ValueAtQuantile(0.9)),
+		P99:   ms(h.ValueAtQuantile(0.99)),
+		P999:  ms(h.ValueAtQuantile(0.999)),
+	}
+}
+
+// IntervalRecorder records per-command-type latencies for the current
+// interval (e.g. one second). It is safe for concurrent use: the benchmark
+// loop records while a ticker goroutine rotates intervals.
+type IntervalRecorder struct {
+	mu    sync.Mutex
+	cur   [numCommandTypes]*Histogram
+	spare [numCommandTypes]*Histogram
+}
+
+// NewIntervalRecorder creates an IntervalRecorder with IntervalSigFigs precision.
+func NewIntervalRecorder() *IntervalRecorder {
+	r := &IntervalRecorder{}
+	for t := range r.cur {
+		r.cur[t] = NewHistogram(IntervalSigFigs)
+		r.spare[t] = NewHistogram(IntervalSigFigs)
+	}
+	return r
+}
+
+// Record records a latency in milliseconds for the given command type.
+func (r *IntervalRecorder) Record(cmdType CommandType, latencyMs float64) {
+	r.mu.Lock()
+	r.cur[cmdType].RecordMs(latencyMs)
+	r.mu.Unlock()
+}
+
+// Rotate ends the current interval and returns its histograms, indexed by
+// CommandType. The returned histograms are only valid until the next Rotate.
+func (r *IntervalRecorder) Rotate() [numCommandTypes]*Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for t := range r.spare {
+		r.spare[t].Reset()
+	}
+	done := r.cur
+	r.cur, r.spare = r.spare, done
+	return done
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// TestHistogramPrecision tests that every value is recorded within the
+// relative error implied by the number of significant digits
+func TestHistogramPrecision(t *testing.T) {
+	for _, sf := range []int{1, 2, 3} {
+		h := NewHistogram(sf)
+		maxErr := math.Pow10(-sf)
+		for _, v := range []int64{0, 1, 7, 999, 1000, 2047, 2048, 2049, 123456, 10000000, 3600000000} {
+			i := h.indexOf(v)
+			lo, hi := h.valueAt(i), h.highestEquivalent(i)
+			if v < lo || v > hi {
+				t.Fatalf("sf=%d: value %d not in its bucket [%d, %d]", sf, v, lo, hi)
+			}
+			if v > 0 && float64(hi-lo)/float64(v) > maxErr {
+				t.Errorf("sf=%d: bucket [%d, %d] too wide for %d", sf, lo, hi, v)
+			}
+		}
+	}
+}
+
+// TestHistogramIndexMonotonic tests that bucket indices grow with values
+func TestHistogramIndexMonotonic(t *testing.T) {
+	h := NewHistogram(DefaultSigFigs)
+	prev := -1
+	for v := int64(0); v < 100000; v++ {
+		i := h.indexOf(v)
+		if i < prev {
+			t.Fatalf("indexOf(%d) = %d < indexOf(%d) = %d", v, i, v-1, prev)
+		}
+		prev = i
+	}
+}
+
+// TestHistogramBoundedMemory tests that memory depends on the value range, not the sample count
+func TestHistogramBoundedMemory(t *testing.T) {
+	h := NewHistogram(DefaultSigFigs)
+	r := rand.New(rand.NewSource(42))
+	h.RecordMs(1000)
+	size := len(h.counts)
+	for i := 0; i < 1000000; i++ {
+		h.RecordMs(r.Float64() * 1000)
+	}
+	if len(h.counts) != size {
+		t.Errorf("counts grew from %d to %d with more samples in the same range", size, len(h.counts))
+	}
+	if h.Count() != 1000001 {
+		t.Errorf("Count = %d, want 1000001", h.Count())
+	}
+}
+
+// TestHistogramQuantilesMatchExact compares histogram percentiles with exact ones
+func TestHistogramQuantilesMatchExact(t *testing.T) {
+	h := NewHistogram(DefaultSigFigs)
+	r := rand.New(rand.NewSource(7))
+	values := make([]int64, 100000)
+	for i := range values {
+		values[i] = int64(r.ExpFloat64() * 5000)
+		h.Record(values[i])
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	for _, q := range []float64{0.5, 0.9, 0.99, 0.999} {
+		exact := values[int(math.Ceil(q*float64(len(values))))-1]
+		got := h.ValueAtQuantile(q)
+		if math.Abs(float64(got-exact)) > float64(exact)*0.001+1 {
+			t.Errorf("q=%v: got %d, exact %d", q, got, exact)
+		}
+	}
+	if h.ValueAtQuantile(0) != values[0] || h.ValueAtQuantile(1) != values[len(values)-1] {
+		t.Errorf("q=0/1: got %d/%d, want %d/%d", h.ValueAtQuantile(0), h.ValueAtQuantile(1), values[0], values[len(values)-1])
+	}
+}
+
+// TestHistogramMerge tests merging histograms of equal and different precision
+func TestHistogramMerge(t *testing.T) {
+	a := NewHistogram(3)
+	b := NewHistogram(3)
+	c := NewHistogram(2)
+	for v := int64(1); v <= 1000; v++ {
+		a.Record(v)
+		b.Record(v + 1000)
+		c.Record(v + 2000)
+	}
+
+	a.Merge(b)
+	if a.Count() != 2000 || a.Min() != 1 || a.Max() != 2000 || a.Mean() != 1000.5 {
+		t.Errorf("same precision merge: count=%d min=%d max=%d mean=%v", a.Count(), a.Min(), a.Max(), a.Mean())
+	}
+
+	a.Merge(c)
+	if a.Count() != 3000 || a.Min() != 1 || a.Max() != 3000 || a.Mean() != 1500.5 {
+		t.Errorf("mixed precision merge: count=%d min=%d max=%d mean=%v", a.Count(), a.Min(), a.Max(), a.Mean())
+	}
+	if p := a.ValueAtQuantile(0.9); math.Abs(float64(p-2700)) > 2700*0.01 {
+		t.Errorf("p90 after merge = %d, want ~2700", p)
+	}
+
+	a.Merge(nil)
+	a.Merge(NewHistogram(3))
+	if a.Count() != 3000 {
+		t.Errorf("merging empty histograms changed count to %d", a.Count())
+	}
+}
+
+// TestHistogramCopyAndReset tests that copies are independent
+func TestHistogramCopyAndReset(t *testing.T) {
+	h := NewHistogram(3)
+	h.Record(42)
+	c := h.Copy()
+	h.Reset()
+	if h.Count() != 0 || h.Max() != 0 || h.Min() != 0 {
+		t.Errorf("Reset left count=%d min=%d max=%d", h.Count(), h.Min(), h.Max())
+	}
+	if c.Count() != 1 || c.Max() != 42 {
+		t.Errorf("copy changed by Reset: count=%d max=%d", c.Count(), c.Max())
+	}
+}
+
+// TestHistogramJSONRoundTrip tests the sparse JSON encoding
+func TestHistogramJSONRoundTrip(t *testing.T) {
+	h := NewHistogram(3)
+	for _, v := range []int64{5, 5, 100, 2500, 1000000} {
+		h.Record(v)
+	}
+	b, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d Histogram
+	if err := json.Unmarshal(b, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Count() != h.Count() || d.Min() != h.Min() || d.Max() != h.Max() || d.Mean() != h.Mean() {
+		t.Errorf("decoded stats differ: %+v vs %+v", d.Summary(), h.Summary())
+	}
+	for _, q := range []float64{0.1, 0.5, 0.9, 1} {
+		if d.ValueAtQuantile(q) != h.ValueAtQuantile(q) {
+			t.Errorf("q=%v: decoded %d, original %d", q, d.ValueAtQuantile(q), h.ValueAtQuantile(q))
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"sig_figs":3,"total":5,"counts":[[1,2]]}`), &d); err == nil {
+		t.Error("expected error for inconsistent total")
+	}
+	if err := json.Unmarshal([]byte(`{"sig_figs":3,"total":1,"counts":[[-1,1]]}`), &d); err == nil {
+		t.Error("expected error for negative index")
+	}
+}
+
+// TestHistogramGobRoundTrip tests that histograms can be sent over net/rpc
+func TestHistogramGobRoundTrip(t *testing.T) {
+	m := NewHybridMetrics()
+	m.StrongWriteCount = 2
+	m.StrongWriteLatency.RecordMs(1.5)
+	m.StrongWriteLatency.RecordMs(3.0)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		t.Fatal(err)
+	}
+	var d HybridMetrics
+	if err := gob.NewDecoder(&buf).Decode(&d); err != nil {
+		t.Fatal(err)
+	}
+	if d.StrongWriteCount != 2 || d.StrongWriteLatency.Count() != 2 || d.StrongWriteLatency.Max() != 3000 {
+		t.Errorf("decoded metrics differ: %+v", d.StrongWriteLatency.Summary())
+	}
+}
+
+// TestIntervalRecorderRotate tests per-interval histograms
+func TestIntervalRecorderRotate(t *testing.T) {
+	r := NewIntervalRecorder()
+	r.Record(StrongWrite, 10)
+	r.Record(StrongWrite, 20)
+	r.Record(WeakRead, 1)
+
+	first := r.Rotate()
+	if first[StrongWrite].Count() != 2 || first[WeakRead].Count() != 1 || first[StrongRead].Count() != 0 {
+		t.Errorf("first interval counts: sw=%d wr=%d sr=%d",
+			first[StrongWrite].Count(), first[WeakRead].Count(), first[StrongRead].Count())
+	}
+
+	r.Record(StrongRead, 5)
+	second := r.Rotate()
+	if second[StrongWrite].Count() != 0 || second[StrongRead].Count() != 1 {
+		t.Errorf("second interval counts: sw=%d sr=%d", second[StrongWrite].Count(), second[StrongRead].Count())
+	}
+
+	if third := r.Rotate(); third[StrongRead].Count() != 0 {
+		t.Errorf("third interval should be empty, got %d", third[StrongRead].Count())
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"log"
 	"math"
 	"os"
-	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -118,28 +117,49 @@ type HybridClient interface {
 }
 
 // HybridMetrics tracks per-consistency-level metrics for the hybrid benchmark.
+// Latencies are kept in HDR histograms (microseconds), so memory does not grow
+// with the number of operations.
 type HybridMetrics struct {
 	// Strong command metrics
-	StrongWriteCount    int
-	StrongReadCount     int
-	StrongWriteLatency  []float64 // in milliseconds
-	StrongReadLatency   []float64 // in milliseconds
+	StrongWriteCount   int
+	StrongReadCount    int
+	StrongWriteLatency *Histogram
+	StrongReadLatency  *Histogram
 
 	// Weak command metrics
-	WeakWriteCount    int
-	WeakReadCount     int
-	WeakWriteLatency  []float64 // in milliseconds
-	WeakReadLatency   []float64 // in milliseconds
+	WeakWriteCount   int
+	WeakReadCount    int
+	WeakWriteLatency *Histogram
+	WeakReadLatency  *Histogram
+
+	// Payload bytes of completed writes and of values returned by reads
+	WriteBytes int64
+	ReadBytes  int64
 }
 
-// NewHybridMetrics creates a new HybridMetrics with pre-allocated slices.
-func NewHybridMetrics(expectedOps int) *HybridMetrics {
+// NewHybridMetrics creates a new HybridMetrics with empty histograms.
+func NewHybridMetrics() *HybridMetrics {
 	return &HybridMetrics{
-		StrongWriteLatency: make([]float64, 0, expectedOps/4),
-		StrongReadLatency:  make([]float64, 0, expectedOps/4),
-		WeakWriteLatency:   make([]float64, 0, expectedOps/4),
-		WeakReadLatency:    make([]float64, 0, expectedOps/4),
+		StrongWriteLatency: NewHistogram(DefaultSigFigs),
+		StrongReadLatency:  NewHistogram(DefaultSigFigs),
+		WeakWriteLatency:   NewHistogram(DefaultSigFigs),
+		WeakReadLatency:    NewHistogram(DefaultSigFigs),
+	}
+}
+
+// Latency returns the latency histogram of the given command type.
+func (m *HybridMetrics) Latency(cmdType CommandType) *Histogram {
+	switch cmdType {
+	case StrongWrite:
+		return m.StrongWriteLatency
+	case StrongRead:
+		return m.StrongReadLatency
+	case WeakWrite:
+		return m.WeakWriteLatency
+	case WeakRead:
+		return m.WeakReadLatency
 	}
+	return nil
 }
 
 // CommandType represents the type of command (for metrics tracking)
@@ -150,6 +170,8 @@ const (
 	StrongRead
 	WeakWrite
 	WeakRead
+
+	numCommandTypes = 4
 )
 
 // String returns the string representation of CommandType
@@ -187,8 +209,9 @@ type HybridBufferClient struct {
 	// HybridClient implementation (set by protocol-specific client)
 	hybrid HybridClient
 
-	// Metrics tracking
-	Metrics *HybridMetrics
+	// Metrics tracking (metricsMu guards Metrics while the loop runs)
+	Metrics   *HybridMetrics
+	metricsMu sync.Mutex
 
 	// Reply channel with command type information
 	HybridReply chan *HybridReqReply
@@ -198,6 +221,16 @@ type HybridBufferClient struct {
 
 	// Maximum time to wait for a single reply before declaring a hang.
 	replyTimeout time.Duration
+
+	// Per-interval latency histograms (rotated by the interval reporter)
+	intervals *IntervalRecorder
+
+	// Commands sent and completed (excluding warmup), and 1 once the loop
+	// stalls, after waiting replyTimeout for a reply, read by the time series
+	// while the loop runs (accessed atomically)
+	sent      int64
+	completed int64
+	stalled   int64
 }
 
 // NewHybridBufferClient creates a new HybridBufferClient wrapping an existing BufferClient.
@@ -211,9 +244,10 @@ func NewHybridBufferClient(bc *BufferClient, weakRatio, weakWrites, replyTimeout
 		BufferClient: bc,
 		weakRatio:    weakRatio,
 		weakWrites:   weakWrites,
-		Metrics:      NewHybridMetrics(bc.reqNum),
+		Metrics:      NewHybridMetrics(),
 		HybridReply:  make(chan *HybridReqReply, bc.reqNum+1),
 		replyTimeout: timeout,
+		intervals:    NewIntervalRecorder(),
 	}
 	return hbc
 }
@@ -318,11 +352,11 @@ func (c *HybridBufferClient) HybridLoop() {
 	}
 
 	getKey := c.genGetKey()
-	val := make([]byte, c.psize)
-	c.rand.Read(val)
+	getValue := c.genGetValue()
 
-	// Track command types for metrics
+	// Track command types and write payload sizes for metrics
 	cmdTypes := make([]CommandType, c.reqNum+1)
+	valSizes := make([]int, c.reqNum+1)
 
 	// Per-second throughput tracker
 	tput := newTputTracker(fmt.Sprintf("client%d", c.ClientId))
@@ -344,16 +378,19 @@ func (c *HybridBufferClient) HybridLoop() {
 					c.Metrics.WeakWriteCount, c.Metrics.WeakReadCount
 				log.Printf("REPLY TIMEOUT: waited %v for reply %d/%d (received: StrongW=%d StrongR=%d WeakW=%d WeakR=%d total=%d)",
 					c.replyTimeout, i, c.reqNum+1, sw, sr, ww, wr, sw+sr+ww+wr)
+				atomic.StoreInt64(&c.stalled, 1)
 				close(timedOut)
 				return
 			}
 			// Ignore first request (warmup)
 			if i != 0 {
 				tput.inc()
+				atomic.AddInt64(&c.completed, 1)
 				d := r.Time.Sub(c.reqTime[r.Seqnum])
 				latencyMs := float64(d.Nanoseconds()) / float64(time.Millisecond)
 				cmdType := cmdTypes[r.Seqnum]
 				c.recordLatency(cmdType, latencyMs)
+				c.recordBytes(cmdType, valSizes[r.Seqnum], len(r.Val))
 				c.Println("Returning:", r.Val.String())
 				c.Printf("latency %v (%s)\n", latencyMs, cmdType.String())
 			}
@@ -379,8 +416,6 @@ func (c *HybridBufferClient) HybridLoop() {
 
 	// Command generation loop
 	for i := 0; i <= c.reqNum; i++ {
-		key := getKey()
-
 		// First command (i=0, warmup) MUST be a strong command to set up ClientWriters.
 		// Weak commands use custom RPC messages that don't register ClientWriters,
 		// so we need at least one strong PROPOSE to establish the connection properly.
@@ -393,6 +428,7 @@ func (c *HybridBufferClient) HybridLoop() {
 		}
 		cmdType := GetCommandType(isWeak, isWrite)
 		cmdTypes[i] = cmdType
+		key := getKey(isWrite)
 
 		c.reqTime[i] = time.Now()
 
@@ -404,11 +440,15 @@ func (c *HybridBufferClient) HybridLoop() {
 		// Send command based on type
 		switch cmdType {
 		case StrongWrite:
-			c.hybrid.SendStrongWrite(key, state.Value(val))
+			val := getValue()
+			valSizes[i] = len(val)
+			c.hybrid.SendStrongWrite(key, val)
 		case StrongRead:
 			c.hybrid.SendStrongRead(key)
 		case WeakWrite:
-			c.hybrid.SendWeakWrite(key, state.Value(val))
+			val := getValue()
+			valSizes[i] = len(val)
+			c.hybrid.SendWeakWrite(key, val)
 		case WeakRead:
 			if c.scanRatio > 0 && c.scanCount > 0 && c.randomTrue(c.scanRatio) {
 				count := c.zipfScanCount()
@@ -417,6 +457,9 @@ func (c *HybridBufferClient) HybridLoop() {
 				c.hybrid.SendWeakRead(key)
 			}
 		}
+		if i != 0 {
+			atomic.AddInt64(&c.sent, 1)
+		}
 
 		// Pipelining window management
 		if c.window > 0 {
@@ -463,103 +506,49 @@ func (c *HybridBufferClient) HybridLoop() {
 
 // recordLatency records a latency measurement for the given command type.
 func (c *HybridBufferClient) recordLatency(cmdType CommandType, latencyMs float64) {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
 	switch cmdType {
 	case StrongWrite:
 		c.Metrics.StrongWriteCount++
-		c.Metrics.StrongWriteLatency = append(c.Metrics.StrongWriteLatency, latencyMs)
 	case StrongRead:
 		c.Metrics.StrongReadCount++
-		c.Metrics.StrongReadLatency = append(c.Metrics.StrongReadLatency, latencyMs)
 	case WeakWrite:
 		c.Metrics.WeakWriteCount++
-		c.Metrics.WeakWriteLatency = append(c.Metrics.WeakWriteLatency, latencyMs)
 	case WeakRead:
 		c.Metrics.WeakReadCount++
-		c.Metrics.WeakReadLatency = append(c.Metrics.WeakReadLatency, latencyMs)
-	}
-}
-
-// PrintMetrics outputs the hybrid benchmark metrics summary.
-func (c *HybridBufferClient) PrintMetrics(duration time.Duration) {
-	totalOps := c.reqNum // Exclude warmup request
-	throughput := float64(totalOps) / duration.Seconds()
-
-	strongOps := c.Metrics.StrongWriteCount + c.Metrics.StrongReadCount
-	weakOps := c.Metrics.WeakWriteCount + c.Metrics.WeakReadCount
-
-	c.Println("\n=== Hybrid Benchmark Results ===")
-	c.Printf("Total operations: %d\n", totalOps)
-	c.Printf("Duration: %.2fs\n", duration.Seconds())
-	c.Printf("Throughput: %.2f ops/sec\n", throughput)
-
-	if strongOps > 0 {
-		strongPct := float64(strongOps) * 100 / float64(totalOps)
-		c.Printf("\nStrong Operations: %d (%.1f%%)\n", strongOps, strongPct)
-		c.Printf("  Writes: %d | Reads: %d\n", c.Metrics.StrongWriteCount, c.Metrics.StrongReadCount)
-
-		allStrongLatencies := append(c.Metrics.StrongWriteLatency, c.Metrics.StrongReadLatency...)
-		if len(allStrongLatencies) > 0 {
-			avg, median, p99, p999 := computePercentiles(allStrongLatencies)
-			c.Printf("  Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
-		if len(c.Metrics.StrongWriteLatency) > 0 {
-			avg, median, p99, p999 := computePercentiles(c.Metrics.StrongWriteLatency)
-			c.Printf("  Strong Write: Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
-		if len(c.Metrics.StrongReadLatency) > 0 {
-			avg, median, p99, p999 := computePercentiles(c.Metrics.StrongReadLatency)
-			c.Printf("  Strong Read:  Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
 	}
-
-	if weakOps > 0 {
-		weakPct := float64(weakOps) * 100 / float64(totalOps)
-		c.Printf("\nWeak Operations: %d (%.1f%%)\n", weakOps, weakPct)
-		c.Printf("  Writes: %d | Reads: %d\n", c.Metrics.WeakWriteCount, c.Metrics.WeakReadCount)
-
-		allWeakLatencies := append(c.Metrics.WeakWriteLatency, c.Metrics.WeakReadLatency...)
-		if len(allWeakLatencies) > 0 {
-			avg, median, p99, p999 := computePercentiles(allWeakLatencies)
-			c.Printf("  Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
-		if len(c.Metrics.WeakWriteLatency) > 0 {
-			avg, median, p99, p999 := computePercentiles(c.Metrics.WeakWriteLatency)
-			c.Printf("  Weak Write:  Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
-		if len(c.Metrics.WeakReadLatency) > 0 {
-			avg, median, p99, p999 := computePercentiles(c.Metrics.WeakReadLatency)
-			c.Printf("  Weak Read:   Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
+	c.Metrics.Latency(cmdType).RecordMs(latencyMs)
+	if c.intervals != nil {
+		c.intervals.Record(cmdType, latencyMs)
 	}
-
-	c.Println("================================")
 }
 
-// computePercentiles computes median, P99, and P99.9 from a slice of latencies.
-func computePercentiles(latencies []float64) (avg, median, p99, p999 float64) {
-	if len(latencies) == 0 {
-		return 0, 0, 0, 0
+// recordBytes records the payload bytes of a completed command:
+// written bytes for writes, returned value bytes for reads.
+func (c *HybridBufferClient) recordBytes(cmdType CommandType, written, read int) {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	switch cmdType {
+	case StrongWrite, WeakWrite:
+		c.Metrics.WriteBytes += int64(written)
+	case StrongRead, WeakRead:
+		c.Metrics.ReadBytes += int64(read)
 	}
+}
 
-	sorted := make([]float64, len(latencies))
-	copy(sorted, latencies)
-	sort.Float64s(sorted)
-
-	n := len(sorted)
-	sum := 0.0
-	for _, v := range sorted {
-		sum += v
-	}
-	avg = sum / float64(n)
-	median = sorted[n/2]
-	p99 = sorted[int(float64(n)*0.99)]
-	p999Idx := int(float64(n) * 0.999)
-	if p999Idx >= n {
-		p999Idx = n - 1
-	}
-	p999 = sorted[p999Idx]
+// bandwidthString formats payload throughput in KB/sec.
+func bandwidthString(writeBytes, readBytes int64, duration time.Duration) string {
+	secs := duration.Seconds()
+	total := float64(writeBytes+readBytes) / 1024 / secs
+	w := float64(writeBytes) / 1024 / secs
+	r := float64(readBytes) / 1024 / secs
+	return fmt.Sprintf("%.2f KB/sec (writes: %.2f KB/sec, reads: %.2f KB/sec)", total, w, r)
+}
 
-	return avg, median, p99, p999
+// PrintMetrics outputs the hybrid benchmark metrics summary.
+func (c *HybridBufferClient) PrintMetrics(duration time.Duration) {
+	c.Metrics.print(c, c.reqNum, duration) // Exclude warmup request
 }
 
 // MetricsString returns a formatted string of the metrics (for logging/testing).
@@ -580,11 +569,11 @@ func (c *HybridBufferClient) HybridLoopWithOptions(printResults bool) {
 	}
 
 	getKey := c.genGetKey()
-	val := make([]byte, c.psize)
-	c.rand.Read(val)
+	getValue := c.genGetValue()
 
-	// Track command types for metrics
+	// Track command types and write payload sizes for metrics
 	cmdTypes := make([]CommandType, c.reqNum+1)
+	valSizes := make([]int, c.reqNum+1)
 
 	// Per-second throughput tracker
 	tput := newTputTracker(fmt.Sprintf("client%d", c.ClientId))
@@ -607,16 +596,19 @@ func (c *HybridBufferClient) HybridLoopWithOptions(printResults bool) {
 					c.Metrics.WeakWriteCount, c.Metrics.WeakReadCount
 				log.Printf("REPLY TIMEOUT: waited %v for reply %d/%d (received: StrongW=%d StrongR=%d WeakW=%d WeakR=%d total=%d)",
 					c.replyTimeout, i, c.reqNum+1, sw, sr, ww, wr, sw+sr+ww+wr)
+				atomic.StoreInt64(&c.stalled, 1)
 				close(timedOut)
 				return
 			}
 			// Ignore first request (warmup)
 			if i != 0 {
 				tput.inc()
+				atomic.AddInt64(&c.completed, 1)
 				d := r.Time.Sub(c.reqTime[r.Seqnum])
 				latencyMs := float64(d.Nanoseconds()) / float64(time.Millisecond)
 				cmdType := cmdTypes[r.Seqnum]
 				c.recordLatency(cmdType, latencyMs)
+				c.recordBytes(cmdType, valSizes[r.Seqnum], len(r.Val))
 				if printResults {
 					c.Println("Returning:", r.Val.String())
 					c.Printf("latency %v (%s)\n", latencyMs, cmdType.String())
@@ -644,8 +636,6 @@ func (c *HybridBufferClient) HybridLoopWithOptions(printResults bool) {
 
 	// Command generation loop
 	for i := 0; i <= c.reqNum; i++ {
-		key := getKey()
-
 		var isWeak, isWrite bool
 		if i == 0 {
 			isWeak = false // Force strong for warmup
@@ -655,6 +645,7 @@ func (c *HybridBufferClient) HybridLoopWithOptions(printResults bool) {
 		}
 		cmdType := GetCommandType(isWeak, isWrite)
 		cmdTypes[i] = cmdType
+		key := getKey(isWrite)
 
 		c.reqTime[i] = time.Now()
 
@@ -664,11 +655,15 @@ func (c *HybridBufferClient) HybridLoopWithOptions(printResults bool) {
 
 		switch cmdType {
 		case StrongWrite:
-			c.hybrid.SendStrongWrite(key, state.Value(val))
+			val := getValue()
+			valSizes[i] = len(val)
+			c.hybrid.SendStrongWrite(key, val)
 		case StrongRead:
 			c.hybrid.SendStrongRead(key)
 		case WeakWrite:
-			c.hybrid.SendWeakWrite(key, state.Value(val))
+			val := getValue()
+			valSizes[i] = len(val)
+			c.hybrid.SendWeakWrite(key, val)
 		case WeakRead:
 			if c.scanRatio > 0 && c.scanCount > 0 && c.randomTrue(c.scanRatio) {
 				count := c.zipfScanCount()
@@ -677,6 +672,9 @@ func (c *HybridBufferClient) HybridLoopWithOptions(printResults bool) {
 				c.hybrid.SendWeakRead(key)
 			}
 		}
+		if i != 0 {
+			atomic.AddInt64(&c.sent, 1)
+		}
 
 		if c.window > 0 {
 			cmdM.Lock()
@@ -727,19 +725,19 @@ func (c *HybridBufferClient) GetMetrics() *HybridMetrics {
 	return c.Metrics
 }
 
+// Intervals returns the recorder of per-interval latency histograms.
+func (c *HybridBufferClient) Intervals() *IntervalRecorder {
+	return c.intervals
+}
+
 // GetDuration returns the benchmark duration.
 func (c *HybridBufferClient) GetDuration() time.Duration {
 	return c.duration
 }
 
-// AggregateMetrics combines metrics from multiple threads into one.
+// AggregateMetrics combines metrics from multiple threads (or processes) into one.
 func AggregateMetrics(metrics []*HybridMetrics) *HybridMetrics {
-	result := &HybridMetrics{
-		StrongWriteLatency: make([]float64, 0),
-		StrongReadLatency:  make([]float64, 0),
-		WeakWriteLatency:   make([]float64, 0),
-		WeakReadLatency:    make([]float64, 0),
-	}
+	result := NewHybridMetrics()
 
 	for _, m := range metrics {
 		if m == nil {
@@ -749,28 +747,53 @@ func AggregateMetrics(metrics []*HybridMetrics) *HybridMetrics {
 		result.StrongReadCount += m.StrongReadCount
 		result.WeakWriteCount += m.WeakWriteCount
 		result.WeakReadCount += m.WeakReadCount
-		result.StrongWriteLatency = append(result.StrongWriteLatency, m.StrongWriteLatency...)
-		result.StrongReadLatency = append(result.StrongReadLatency, m.StrongReadLatency...)
-		result.WeakWriteLatency = append(result.WeakWriteLatency, m.WeakWriteLatency...)
-		result.WeakReadLatency = append(result.WeakReadLatency, m.WeakReadLatency...)
+		result.WriteBytes += m.WriteBytes
+		result.ReadBytes += m.ReadBytes
+		for t := CommandType(0); t < numCommandTypes; t++ {
+			result.Latency(t).Merge(m.Latency(t))
+		}
 	}
 
 	return result
 }
 
-// ExportLatencies writes all per-operation latency arrays to a JSON file for CDF plotting.
-// The output contains sorted latency arrays (in milliseconds) grouped by operation type.
+// LatencyExport is the exported latency data of one command type: summary
+// statistics, the full distribution as [latency_ms, cumulative_fraction]
+// points for CDF plots, and the histogram itself for merging.
+type LatencyExport struct {
+	LatencySummary
+	CDF       [][2]float64 `json:"cdf"`
+	Histogram *Histogram   `json:"histogram"`
+}
+
+// NewLatencyExport builds the LatencyExport of a histogram of microsecond latencies.
+func NewLatencyExport(h *Histogram) *LatencyExport {
+	e := &LatencyExport{
+		LatencySummary: h.Summary(),
+		CDF:            [][2]float64{},
+		Histogram:      h,
+	}
+	var seen int64
+	for _, b := range h.Distribution() {
+		seen += b.Count
+		e.CDF = append(e.CDF, [2]float64{float64(b.Value) / 1000, float64(seen) / float64(h.Count())})
+	}
+	return e
+}
+
+// ExportLatencies writes the per-operation-type latency distributions to a
+// JSON file for CDF plotting and cross-process merging.
 func (m *HybridMetrics) ExportLatencies(path string) error {
 	data := struct {
-		StrongWrite []float64 `json:"strong_write"`
-		StrongRead  []float64 `json:"strong_read"`
-		WeakWrite   []float64 `json:"weak_write"`
-		WeakRead    []float64 `json:"weak_read"`
+		StrongWrite *LatencyExport `json:"strong_write"`
+		StrongRead  *LatencyExport `json:"strong_read"`
+		WeakWrite   *LatencyExport `json:"weak_write"`
+		WeakRead    *LatencyExport `json:"weak_read"`
 	}{
-		StrongWrite: sortedCopy(m.StrongWriteLatency),
-		StrongRead:  sortedCopy(m.StrongReadLatency),
-		WeakWrite:   sortedCopy(m.WeakWriteLatency),
-		WeakRead:    sortedCopy(m.WeakReadLatency),
+		StrongWrite: NewLatencyExport(m.StrongWriteLatency),
+		StrongRead:  NewLatencyExport(m.StrongReadLatency),
+		WeakWrite:   NewLatencyExport(m.WeakWriteLatency),
+		WeakRead:    NewLatencyExport(m.WeakReadLatency),
 	}
 	f, err := os.Create(path)
 	if err != nil {
@@ -780,16 +803,6 @@ func (m *HybridMetrics) ExportLatencies(path string) error {
 	return json.NewEncoder(f).Encode(data)
 }
 
-func sortedCopy(s []float64) []float64 {
-	if len(s) == 0 {
-		return []float64{}
-	}
-	c := make([]float64, len(s))
-	copy(c, s)
-	sort.Float64s(c)
-	return c
-}
-
 // Printer is an interface for logging output.
 type Printer interface {
 	Println(v ...interface{})
@@ -798,55 +811,53 @@ type Printer interface {
 
 // Print outputs the aggregated metrics summary.
 func (m *HybridMetrics) Print(p Printer, totalOps int, duration time.Duration) {
+	actualTotalOps := m.StrongWriteCount + m.StrongReadCount + m.WeakWriteCount + m.WeakReadCount
+	m.print(p, actualTotalOps, duration)
+}
+
+func (m *HybridMetrics) print(p Printer, totalOps int, duration time.Duration) {
 	strongOps := m.StrongWriteCount + m.StrongReadCount
 	weakOps := m.WeakWriteCount + m.WeakReadCount
-	actualTotalOps := strongOps + weakOps
-	throughput := float64(actualTotalOps) / duration.Seconds()
+	throughput := float64(totalOps) / duration.Seconds()
 
 	p.Println("\n=== Hybrid Benchmark Results ===")
-	p.Printf("Total operations: %d\n", actualTotalOps)
+	p.Printf("Total operations: %d\n", totalOps)
 	p.Printf("Duration: %.2fs\n", duration.Seconds())
-	p.Printf("Throughput: %.2f ops/sec\n", throughput)
+	p.Printf("Throughput: %.2f ops/sec | %s\n", throughput,
+		bandwidthString(m.WriteBytes, m.ReadBytes, duration))
 
 	if strongOps > 0 {
-		strongPct := float64(strongOps) * 100 / float64(actualTotalOps)
+		strongPct := float64(strongOps) * 100 / float64(totalOps)
 		p.Printf("\nStrong Operations: %d (%.1f%%)\n", strongOps, strongPct)
 		p.Printf("  Writes: %d | Reads: %d\n", m.StrongWriteCount, m.StrongReadCount)
 
-		allStrongLatencies := append(m.StrongWriteLatency, m.StrongReadLatency...)
-		if len(allStrongLatencies) > 0 {
-			avg, median, p99, p999 := computePercentiles(allStrongLatencies)
-			p.Printf("  Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
-		if len(m.StrongWriteLatency) > 0 {
-			avg, median, p99, p999 := computePercentiles(m.StrongWriteLatency)
-			p.Printf("  Strong Write: Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
-		if len(m.StrongReadLatency) > 0 {
-			avg, median, p99, p999 := computePercentiles(m.StrongReadLatency)
-			p.Printf("  Strong Read:  Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
+		all := m.StrongWriteLatency.Copy()
+		all.Merge(m.StrongReadLatency)
+		printLatency(p, "", all)
+		printLatency(p, "Strong Write: ", m.StrongWriteLatency)
+		printLatency(p, "Strong Read:  ", m.StrongReadLatency)
 	}
 
 	if weakOps > 0 {
-		weakPct := float64(weakOps) * 100 / float64(actualTotalOps)
+		weakPct := float64(weakOps) * 100 / float64(totalOps)
 		p.Printf("\nWeak Operations: %d (%.1f%%)\n", weakOps, weakPct)
 		p.Printf("  Writes: %d | Reads: %d\n", m.WeakWriteCount, m.WeakReadCount)
 
-		allWeakLatencies := append(m.WeakWriteLatency, m.WeakReadLatency...)
-		if len(allWeakLatencies) > 0 {
-			avg, median, p99, p999 := computePercentiles(allWeakLatencies)
-			p.Printf("  Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
-		if len(m.WeakWriteLatency) > 0 {
-			avg, median, p99, p999 := computePercentiles(m.WeakWriteLatency)
-			p.Printf("  Weak Write:  Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
-		if len(m.WeakReadLatency) > 0 {
-			avg, median, p99, p999 := computePercentiles(m.WeakReadLatency)
-			p.Printf("  Weak Read:   Avg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", avg, median, p99, p999)
-		}
+		all := m.WeakWriteLatency.Copy()
+		all.Merge(m.WeakReadLatency)
+		printLatency(p, "", all)
+		printLatency(p, "Weak Write:  ", m.WeakWriteLatency)
+		printLatency(p, "Weak Read:   ", m.WeakReadLatency)
 	}
 
 	p.Println("================================")
 }
+
+// printLatency prints the summary line of a latency histogram, if not empty.
+func printLatency(p Printer, label string, h *Histogram) {
+	if h.Count() == 0 {
+		return
+	}
+	s := h.Summary()
+	p.Printf("  %sAvg: %.2fms | Median: %.2fms | P99: %.2fms | P99.9: %.2fms\n", label, s.Mean, s.P50, s.P99, s.P999)
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"math"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -69,7 +70,7 @@ func TestGetCommandType(t *testing.T) {
 
 // TestNewHybridMetrics tests HybridMetrics initialization
 func TestNewHybridMetrics(t *testing.T) {
-	m := NewHybridMetrics(1000)
+	m := NewHybridMetrics()
 
 	if m == nil {
 		t.Fatal("NewHybridMetrics returned nil")
@@ -80,51 +81,54 @@ func TestNewHybridMetrics(t *testing.T) {
 		t.Error("Counts should be initialized to 0")
 	}
 
-	if cap(m.StrongWriteLatency) < 250 || cap(m.StrongReadLatency) < 250 ||
-		cap(m.WeakWriteLatency) < 250 || cap(m.WeakReadLatency) < 250 {
-		t.Error("Latency slices should be pre-allocated")
+	for ct := CommandType(0); ct < numCommandTypes; ct++ {
+		if h := m.Latency(ct); h == nil || h.Count() != 0 {
+			t.Errorf("%v latency histogram should be allocated and empty", ct)
+		}
 	}
 }
 
-// TestComputePercentiles tests percentile computation
-func TestComputePercentiles(t *testing.T) {
-	// Empty slice
-	avg, median, p99, p999 := computePercentiles([]float64{})
-	if avg != 0 || median != 0 || p99 != 0 || p999 != 0 {
-		t.Error("Empty slice should return zeros")
+// TestHistogramPercentiles tests percentile computation
+func TestHistogramPercentiles(t *testing.T) {
+	// Empty histogram
+	s := NewHistogram(DefaultSigFigs).Summary()
+	if s.Mean != 0 || s.P50 != 0 || s.P99 != 0 || s.P999 != 0 {
+		t.Error("Empty histogram should return zeros")
 	}
 
 	// Single element
-	avg, median, p99, p999 = computePercentiles([]float64{5.0})
-	if avg != 5.0 || median != 5.0 || p99 != 5.0 || p999 != 5.0 {
-		t.Errorf("Single element: got avg=%v, median=%v, p99=%v, p999=%v", avg, median, p99, p999)
+	h := NewHistogram(DefaultSigFigs)
+	h.RecordMs(5.0)
+	s = h.Summary()
+	if s.Mean != 5.0 || s.P50 != 5.0 || s.P99 != 5.0 || s.P999 != 5.0 {
+		t.Errorf("Single element: got avg=%v, median=%v, p99=%v, p999=%v", s.Mean, s.P50, s.P99, s.P999)
 	}
 
 	// Known distribution
-	data := make([]float64, 100)
+	h = NewHistogram(DefaultSigFigs)
 	for i := 0; i < 100; i++ {
-		data[i] = float64(i + 1) // 1 to 100
+		h.RecordMs(float64(i + 1)) // 1 to 100
 	}
-	avg, median, p99, p999 = computePercentiles(data)
+	s = h.Summary()
 
 	// Avg should be 50.5
-	if avg < 50.4 || avg > 50.6 {
-		t.Errorf("Avg should be 50.5, got %v", avg)
+	if s.Mean < 50.4 || s.Mean > 50.6 {
+		t.Errorf("Avg should be 50.5, got %v", s.Mean)
 	}
 
 	// Median should be around 50-51
-	if median < 50 || median > 51 {
-		t.Errorf("Median should be around 50-51, got %v", median)
+	if s.P50 < 50 || s.P50 > 51 {
+		t.Errorf("Median should be around 50-51, got %v", s.P50)
 	}
 
 	// P99 should be around 99-100
-	if p99 < 99 || p99 > 100 {
-		t.Errorf("P99 should be around 99-100, got %v", p99)
+	if s.P99 < 99 || s.P99 > 100 {
+		t.Errorf("P99 should be around 99-100, got %v", s.P99)
 	}
 
 	// P999 should be around 99-100
-	if p999 < 99 || p999 > 100 {
-		t.Errorf("P999 should be around 99-100, got %v", p999)
+	if s.P999 < 99 || s.P999 > 100 {
+		t.Errorf("P999 should be around 99-100, got %v", s.P999)
 	}
 }
 
@@ -250,7 +254,7 @@ func TestRecordLatency(t *testing.T) {
 	bc := &BufferClient{}
 	hbc := &HybridBufferClient{
 		BufferClient: bc,
-		Metrics:      NewHybridMetrics(100),
+		Metrics:      NewHybridMetrics(),
 	}
 
 	// Record latencies for each type
@@ -276,10 +280,10 @@ func TestRecordLatency(t *testing.T) {
 	}
 
 	// Verify latencies recorded
-	if len(hbc.Metrics.StrongWriteLatency) != 2 {
-		t.Errorf("StrongWriteLatency length = %d, want 2", len(hbc.Metrics.StrongWriteLatency))
+	if hbc.Metrics.StrongWriteLatency.Count() != 2 {
+		t.Errorf("StrongWriteLatency count = %d, want 2", hbc.Metrics.StrongWriteLatency.Count())
 	}
-	if hbc.Metrics.StrongWriteLatency[0] != 10.0 || hbc.Metrics.StrongWriteLatency[1] != 20.0 {
+	if hbc.Metrics.StrongWriteLatency.Min() != 10000 || hbc.Metrics.StrongWriteLatency.Max() != 20000 {
 		t.Error("StrongWriteLatency values incorrect")
 	}
 }
@@ -289,7 +293,7 @@ func TestMetricsString(t *testing.T) {
 	bc := &BufferClient{}
 	hbc := &HybridBufferClient{
 		BufferClient: bc,
-		Metrics:      NewHybridMetrics(100),
+		Metrics:      NewHybridMetrics(),
 	}
 
 	hbc.Metrics.StrongWriteCount = 10
@@ -328,14 +332,16 @@ func TestSupportsHybrid(t *testing.T) {
 	}
 }
 
-// TestExportLatencies tests ExportLatencies writes correct sorted JSON
+// TestExportLatencies tests ExportLatencies writes summaries and CDFs
 func TestExportLatencies(t *testing.T) {
-	m := &HybridMetrics{
-		StrongWriteLatency: []float64{30.0, 10.0, 20.0},
-		StrongReadLatency:  []float64{5.0, 15.0},
-		WeakWriteLatency:   []float64{2.0},
-		WeakReadLatency:    []float64{},
+	m := NewHybridMetrics()
+	for _, l := range []float64{30.0, 10.0, 20.0} {
+		m.StrongWriteLatency.RecordMs(l)
+	}
+	for _, l := range []float64{5.0, 15.0} {
+		m.StrongReadLatency.RecordMs(l)
 	}
+	m.WeakWriteLatency.RecordMs(2.0)
 
 	path := filepath.Join(t.TempDir(), "latencies.json")
 	if err := m.ExportLatencies(path); err != nil {
@@ -348,47 +354,47 @@ func TestExportLatencies(t *testing.T) {
 	}
 
 	var result struct {
-		StrongWrite []float64 `json:"strong_write"`
-		StrongRead  []float64 `json:"strong_read"`
-		WeakWrite   []float64 `json:"weak_write"`
-		WeakRead    []float64 `json:"weak_read"`
+		StrongWrite LatencyExport `json:"strong_write"`
+		StrongRead  LatencyExport `json:"strong_read"`
+		WeakWrite   LatencyExport `json:"weak_write"`
+		WeakRead    LatencyExport `json:"weak_read"`
 	}
 	if err := json.Unmarshal(data, &result); err != nil {
 		t.Fatalf("Failed to unmarshal JSON: %v", err)
 	}
 
-	// Verify sorted
-	if len(result.StrongWrite) != 3 || result.StrongWrite[0] != 10.0 || result.StrongWrite[2] != 30.0 {
-		t.Errorf("StrongWrite not sorted correctly: %v", result.StrongWrite)
+	// Values are exact within the histogram precision (0.1%)
+	approx := func(got, want float64) bool {
+		return math.Abs(got-want) <= want*0.001
 	}
-	if len(result.StrongRead) != 2 || result.StrongRead[0] != 5.0 || result.StrongRead[1] != 15.0 {
-		t.Errorf("StrongRead not sorted correctly: %v", result.StrongRead)
+
+	// Verify sorted CDF
+	sw := result.StrongWrite
+	if sw.Count != 3 || len(sw.CDF) != 3 || !approx(sw.CDF[0][0], 10.0) || !approx(sw.CDF[2][0], 30.0) || sw.CDF[2][1] != 1.0 {
+		t.Errorf("StrongWrite CDF not sorted correctly: %+v", sw)
 	}
-	if len(result.WeakWrite) != 1 || result.WeakWrite[0] != 2.0 {
-		t.Errorf("WeakWrite incorrect: %v", result.WeakWrite)
+	if sw.Min != 10.0 || sw.Max != 30.0 || sw.Mean != 20.0 {
+		t.Errorf("StrongWrite summary incorrect: %+v", sw.LatencySummary)
 	}
-	if len(result.WeakRead) != 0 {
-		t.Errorf("WeakRead should be empty: %v", result.WeakRead)
+	if result.StrongRead.Count != 2 || !approx(result.StrongRead.CDF[0][0], 5.0) || !approx(result.StrongRead.CDF[1][0], 15.0) {
+		t.Errorf("StrongRead not sorted correctly: %+v", result.StrongRead)
 	}
-}
-
-// TestExportLatenciesDoesNotMutateOriginal verifies export doesn't sort the original slice
-func TestExportLatenciesDoesNotMutateOriginal(t *testing.T) {
-	m := &HybridMetrics{
-		StrongWriteLatency: []float64{30.0, 10.0, 20.0},
-		StrongReadLatency:  []float64{},
-		WeakWriteLatency:   []float64{},
-		WeakReadLatency:    []float64{},
+	if result.WeakWrite.Count != 1 || result.WeakWrite.P50 != 2.0 {
+		t.Errorf("WeakWrite incorrect: %+v", result.WeakWrite)
 	}
-
-	path := filepath.Join(t.TempDir(), "latencies.json")
-	if err := m.ExportLatencies(path); err != nil {
-		t.Fatalf("ExportLatencies failed: %v", err)
+	if result.WeakRead.Count != 0 || len(result.WeakRead.CDF) != 0 {
+		t.Errorf("WeakRead should be empty: %+v", result.WeakRead)
 	}
 
-	// Original should remain unsorted
-	if m.StrongWriteLatency[0] != 30.0 {
-		t.Errorf("Original slice was mutated: %v", m.StrongWriteLatency)
+	// The exported histogram can be merged back (e.g. across processes)
+	if result.StrongWrite.Histogram == nil || result.StrongWrite.Histogram.Count() != 3 {
+		t.Fatalf("StrongWrite histogram not exported: %+v", result.StrongWrite.Histogram)
+	}
+	merged := NewHybridMetrics()
+	merged.StrongWriteLatency.Merge(result.StrongWrite.Histogram)
+	merged.StrongWriteLatency.Merge(m.StrongWriteLatency)
+	if merged.StrongWriteLatency.Count() != 6 || merged.StrongWriteLatency.Mean() != 20000 {
+		t.Errorf("merged histogram: count=%d mean=%v", merged.StrongWriteLatency.Count(), merged.StrongWriteLatency.Mean())
 	}
 }
 
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// WriteKeyGenerator is implemented by generators whose write keys follow a
+// different rule than their read keys (e.g. LatestKeyGenerator, where writes
+// insert "new" keys and reads favour the most recently written ones).
+type WriteKeyGenerator interface {
+	KeyGenerator
+	// NextWriteKey returns the key to use for the next write operation.
+	NextWriteKey() int64
+}
+
+// HotspotKeyGenerator sends hotOps% of the operations to the first hotKeys%
+// of the key space (the hot set) and the rest uniformly to the cold keys.
+// Within each set keys are chosen uniformly.
+type HotspotKeyGenerator struct {
+	rand     *rand.Rand
+	keySpace int64
+	hotSize  int64
+	hotOps   int
+}
+
+// NewHotspotKeyGenerator creates a new HotspotKeyGenerator.
+// Parameters:
+//   - keySpace: total number of unique keys
+//   - hotKeys: percentage of the key space in the hot set (1-100)
+//   - hotOps: percentage of operations that target the hot set (0-100)
+//   - seed: random seed for reproducibility
+func NewHotspotKeyGenerator(keySpace int64, hotKeys, hotOps int, seed int64) *HotspotKeyGenerator {
+	hotSize := keySpace * int64(hotKeys) / 100
+	if hotSize < 1 {
+		hotSize = 1
+	}
+	if hotSize > keySpace {
+		hotSize = keySpace
+	}
+	return &HotspotKeyGenerator{
+		rand:     rand.New(rand.NewSource(seed)),
+		keySpace: keySpace,
+		hotSize:  hotSize,
+		hotOps:   hotOps,
+	}
+}
+
+// NextKey returns a key from the hot set with probability hotOps%,
+// otherwise a key from the cold set.
+func (g *HotspotKeyGenerator) NextKey() int64 {
+	if g.hotSize == g.keySpace || g.rand.Intn(100) < g.hotOps {
+		return g.rand.Int63n(g.hotSize)
+	}
+	return g.hotSize + g.rand.Int63n(g.keySpace-g.hotSize)
+}
+
+// HotSetSize returns the number of keys in the hot set.
+func (g *HotspotKeyGenerator) HotSetSize() int64 {
+	return g.hotSize
+}
+
+// LatestKeyGenerator implements the YCSB "latest" distribution.
+// Writes insert keys in increasing order (wrapping around the key space),
+// and reads are Zipf-skewed towards the most recently written keys:
+// rank 0 is the latest write, rank 1 the one before it, and so on.
+type LatestKeyGenerator struct {
+	ranks    *ZipfKeyGenerator
+	keySpace int64
+	latest   int64
+}
+
+// NewLatestKeyGenerator creates a new LatestKeyGenerator.
+// The recency ranks follow Zipf(skew); a skew below 0.01 defaults to 0.99,
+// the YCSB default.
+func NewLatestKeyGenerator(keySpace int64, skew float64, seed int64) *LatestKeyGenerator {
+	if skew < 0.01 {
+		skew = 0.99
+	}
+	return &LatestKeyGenerator{
+		ranks:    NewZipfKeyGenerator(keySpace, skew, seed),
+		keySpace: keySpace,
+		latest:   0,
+	}
+}
+
+// NextKey returns a read key skewed towards the most recently written keys.
+func (g *LatestKeyGenerator) NextKey() int64 {
+	rank := g.ranks.NextKey()
+	return ((g.latest-rank)%g.keySpace + g.keySpace) % g.keySpace
+}
+
+// NextWriteKey advances the latest key and returns it.
+func (g *LatestKeyGenerator) NextWriteKey() int64 {
+	g.latest = (g.latest + 1) % g.keySpace
+	return g.latest
+}
+
+// ShiftingKeyGenerator moves the hot set of an underlying generator over time.
+// Every interval the keys returned by the base generator are rotated by step
+// positions, so the keys that were hot in the previous interval become cold.
+// The rotation is derived from the wall clock, so clients in different
+// processes shift their hot sets at the same moment.
+type ShiftingKeyGenerator struct {
+	base     KeyGenerator
+	keySpace int64
+	step     int64
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewShiftingKeyGenerator wraps base so that its hot set moves by step keys
+// every interval.
+func NewShiftingKeyGenerator(base KeyGenerator, keySpace, step int64, interval time.Duration) *ShiftingKeyGenerator {
+	if step < 1 {
+		step = 1
+	}
+	return &ShiftingKeyGenerator{
+		base:     base,
+		keySpace: keySpace,
+		step:     step,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Offset returns the current rotation applied to the base generator's keys.
+func (g *ShiftingKeyGenerator) Offset() int64 {
+	if g.interval <= 0 {
+		return 0
+	}
+	epoch := g.now().UnixNano() / int64(g.interval)
+	return (epoch % g.keySpace) * (g.step % g.keySpace) % g.keySpace
+}
+
+// NextKey returns the base generator's key rotated by the current offset.
+func (g *ShiftingKeyGenerator) NextKey() int64 {
+	return (g.base.NextKey() + g.Offset()) % g.keySpace
+}
+
+// NextWriteKey rotates the base generator's write key if it has one.
+func (g *ShiftingKeyGenerator) NextWriteKey() int64 {
+	if wg, ok := g.base.(WriteKeyGenerator); ok {
+		return (wg.NextWriteKey() + g.Offset()) % g.keySpace
+	}
+	return g.NextKey()
+}
+
+// Key distribution names accepted by NewKeyGeneratorDist.
+const (
+	KeyDistUniform = "uniform"
+	KeyDistZipf    = "zipf"
+	KeyDistHotspot = "hotspot"
+	KeyDistLatest  = "latest"
+)
+
+// KeyDistConfig describes the key distribution used by a benchmark client.
+type KeyDistConfig struct {
+	// Dist is one of uniform, zipf, hotspot or latest. Empty selects
+	// uniform or zipf depending on Skew (as NewKeyGenerator does).
+	Dist     string
+	KeySpace int64
+	// Zipf skewness (zipf and latest)
+	Skew float64
+	// Percentage of keys in the hot set (hotspot), negative for the
+	// default 20
+	HotKeys int
+	// Percentage of operations that target the hot set (hotspot), negative
+	// for the default 80
+	HotOps int
+	// Interval after which the hot set moves (0 = static)
+	HotShift time.Duration
+}
+
+// NewKeyGeneratorDist creates a KeyGenerator for the given distribution.
+// If d.HotShift > 0 the generator is wrapped in a ShiftingKeyGenerator that
+// moves the hot set by its own size (hotspot) or by 10% of the key space
+// (other distributions) every HotShift.
+func NewKeyGeneratorDist(d KeyDistConfig, clientId int32) (KeyGenerator, error) {
+	if d.KeySpace <= 0 {
+		d.KeySpace = DefaultKeySpace
+	}
+	if d.HotKeys < 0 {
+		d.HotKeys = 20
+	}
+	if d.HotOps < 0 {
+		d.HotOps = 80
+	}
+	if d.HotKeys > 100 || d.HotOps > 100 {
+		return nil, fmt.Errorf("hot keys %d%% and hot operations %d%% must be at most 100%%", d.HotKeys, d.HotOps)
+	}
+	seed := time.Now().UnixNano() + int64(clientId)
+
+	var (
+		kg   KeyGenerator
+		step = d.KeySpace / 10
+	)
+	switch strings.ToLower(d.Dist) {
+	case "":
+		kg = NewKeyGenerator(d.KeySpace, d.Skew, clientId)
+	case KeyDistUniform:
+		kg = NewUniformKeyGenerator(d.KeySpace, seed)
+	case KeyDistZipf:
+		kg = NewZipfKeyGenerator(d.KeySpace, d.Skew, seed)
+	case KeyDistHotspot:
+		hg := NewHotspotKeyGenerator(d.KeySpace, d.HotKeys, d.HotOps, seed)
+		step = hg.HotSetSize()
+		kg = hg
+	case KeyDistLatest:
+		kg = NewLatestKeyGenerator(d.KeySpace, d.Skew, seed)
+	default:
+		return nil, fmt.Errorf("unknown key distribution %q", d.Dist)
+	}
+
+	if d.HotShift > 0 {
+		kg = NewShiftingKeyGenerator(kg, d.KeySpace, step, d.HotShift)
+	}
+	return kg, nil
+}
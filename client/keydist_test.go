@@ -0,0 +1,207 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestHotspotKeyGenerator tests that hotOps% of the keys fall into the hot set
+func TestHotspotKeyGenerator(t *testing.T) {
+	keySpace := int64(1000)
+	gen := NewHotspotKeyGenerator(keySpace, 10, 90, 42)
+
+	if gen.HotSetSize() != 100 {
+		t.Fatalf("HotSetSize = %d, want 100", gen.HotSetSize())
+	}
+
+	hot := 0
+	numSamples := 20000
+	for i := 0; i < numSamples; i++ {
+		key := gen.NextKey()
+		if key < 0 || key >= keySpace {
+			t.Fatalf("Key %d out of range [0, %d)", key, keySpace)
+		}
+		if key < gen.HotSetSize() {
+			hot++
+		}
+	}
+
+	ratio := float64(hot) / float64(numSamples)
+	if ratio < 0.87 || ratio > 0.93 {
+		t.Errorf("hot set ratio = %.3f, want ~0.90", ratio)
+	}
+}
+
+// TestHotspotKeyGeneratorWholeSpace tests hotKeys=100 (every key is hot)
+func TestHotspotKeyGeneratorWholeSpace(t *testing.T) {
+	gen := NewHotspotKeyGenerator(10, 100, 50, 42)
+	for i := 0; i < 1000; i++ {
+		if key := gen.NextKey(); key < 0 || key >= 10 {
+			t.Fatalf("Key %d out of range [0, 10)", key)
+		}
+	}
+}
+
+// TestLatestKeyGenerator tests that reads favour the most recent writes
+func TestLatestKeyGenerator(t *testing.T) {
+	keySpace := int64(1000)
+	gen := NewLatestKeyGenerator(keySpace, 0.99, 42)
+
+	for i := 0; i < 500; i++ {
+		gen.NextWriteKey()
+	}
+	if gen.latest != 500 {
+		t.Fatalf("latest = %d, want 500", gen.latest)
+	}
+
+	recent := 0
+	numSamples := 10000
+	for i := 0; i < numSamples; i++ {
+		key := gen.NextKey()
+		if key < 0 || key >= keySpace {
+			t.Fatalf("Key %d out of range [0, %d)", key, keySpace)
+		}
+		if key > 490 && key <= 500 {
+			recent++
+		}
+	}
+
+	// The 10 most recent keys out of 1000 should get a large share of reads
+	if recent < numSamples/4 {
+		t.Errorf("recent keys read %d times out of %d, want at least %d", recent, numSamples, numSamples/4)
+	}
+}
+
+// TestLatestKeyGeneratorWraps tests that write keys wrap around the key space
+func TestLatestKeyGeneratorWraps(t *testing.T) {
+	gen := NewLatestKeyGenerator(10, 0.99, 42)
+	for i := 0; i < 25; i++ {
+		key := gen.NextWriteKey()
+		if key < 0 || key >= 10 {
+			t.Fatalf("Write key %d out of range [0, 10)", key)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		if key := gen.NextKey(); key < 0 || key >= 10 {
+			t.Fatalf("Key %d out of range [0, 10)", key)
+		}
+	}
+}
+
+// TestShiftingKeyGenerator tests that the hot set moves every interval
+func TestShiftingKeyGenerator(t *testing.T) {
+	keySpace := int64(1000)
+	base := NewHotspotKeyGenerator(keySpace, 10, 100, 42)
+	gen := NewShiftingKeyGenerator(base, keySpace, base.HotSetSize(), time.Second)
+
+	now := time.Unix(0, 0)
+	gen.now = func() time.Time { return now }
+
+	inRange := func(lo, hi int64) {
+		for i := 0; i < 1000; i++ {
+			key := gen.NextKey()
+			if key < lo || key >= hi {
+				t.Fatalf("at %v: key %d not in hot set [%d, %d)", now, key, lo, hi)
+			}
+		}
+	}
+
+	inRange(0, 100)
+	now = now.Add(time.Second)
+	inRange(100, 200)
+	now = now.Add(3 * time.Second)
+	inRange(400, 500)
+	now = now.Add(6 * time.Second)
+	inRange(0, 100)
+}
+
+// TestShiftingKeyGeneratorStatic tests that interval=0 disables shifting
+func TestShiftingKeyGeneratorStatic(t *testing.T) {
+	base := NewHotspotKeyGenerator(1000, 10, 100, 42)
+	gen := NewShiftingKeyGenerator(base, 1000, 100, 0)
+	if gen.Offset() != 0 {
+		t.Errorf("Offset = %d, want 0", gen.Offset())
+	}
+}
+
+// TestShiftingKeyGeneratorWriteKey tests that write keys of the base generator are rotated too
+func TestShiftingKeyGeneratorWriteKey(t *testing.T) {
+	base := NewLatestKeyGenerator(1000, 0.99, 42)
+	gen := NewShiftingKeyGenerator(base, 1000, 100, time.Second)
+	gen.now = func() time.Time { return time.Unix(2, 0) }
+
+	if key := gen.NextWriteKey(); key != 201 {
+		t.Errorf("NextWriteKey = %d, want 201", key)
+	}
+}
+
+// TestNewKeyGeneratorDist tests distribution selection by name
+func TestNewKeyGeneratorDist(t *testing.T) {
+	tests := []struct {
+		name string
+		dist KeyDistConfig
+		want string
+	}{
+		{"default_uniform", KeyDistConfig{KeySpace: 100}, "*client.UniformKeyGenerator"},
+		{"default_zipf", KeyDistConfig{KeySpace: 100, Skew: 0.99}, "*client.ZipfKeyGenerator"},
+		{"uniform", KeyDistConfig{Dist: "uniform", KeySpace: 100}, "*client.UniformKeyGenerator"},
+		{"zipf", KeyDistConfig{Dist: "zipf", KeySpace: 100, Skew: 1.2}, "*client.ZipfKeyGenerator"},
+		{"hotspot", KeyDistConfig{Dist: "hotspot", KeySpace: 100}, "*client.HotspotKeyGenerator"},
+		{"latest", KeyDistConfig{Dist: "Latest", KeySpace: 100}, "*client.LatestKeyGenerator"},
+		{"shifting", KeyDistConfig{Dist: "hotspot", KeySpace: 100, HotShift: time.Second}, "*client.ShiftingKeyGenerator"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gen, err := NewKeyGeneratorDist(tc.dist, 1)
+			if err != nil {
+				t.Fatalf("NewKeyGeneratorDist failed: %v", err)
+			}
+			if got := fmt.Sprintf("%T", gen); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	// Hotspot defaults: 20% of keys receive 80% of operations
+	gen, _ := NewKeyGeneratorDist(KeyDistConfig{Dist: "hotspot", KeySpace: 100, HotKeys: -1, HotOps: -1}, 1)
+	if hg := gen.(*HotspotKeyGenerator); hg.HotSetSize() != 20 || hg.hotOps != 80 {
+		t.Errorf("hotspot defaults = (%d, %d), want (20, 80)", hg.HotSetSize(), hg.hotOps)
+	}
+	// no operation may target the hot set
+	gen, _ = NewKeyGeneratorDist(KeyDistConfig{Dist: "hotspot", KeySpace: 100, HotKeys: 10, HotOps: 0}, 1)
+	if hg := gen.(*HotspotKeyGenerator); hg.HotSetSize() != 10 || hg.hotOps != 0 {
+		t.Errorf("hotspot = (%d, %d), want (10, 0)", hg.HotSetSize(), hg.hotOps)
+	}
+	if _, err := NewKeyGeneratorDist(KeyDistConfig{Dist: "hotspot", KeySpace: 100, HotOps: 101}, 1); err == nil {
+		t.Error("expected error for 101% hot operations")
+	}
+	// A shifting hotspot moves by the size of its hot set
+	gen, _ = NewKeyGeneratorDist(KeyDistConfig{Dist: "hotspot", KeySpace: 100, HotKeys: -1, HotShift: time.Second}, 1)
+	if sg := gen.(*ShiftingKeyGenerator); sg.step != 20 {
+		t.Errorf("step = %d, want hot set size 20", sg.step)
+	}
+
+	if _, err := NewKeyGeneratorDist(KeyDistConfig{Dist: "gaussian", KeySpace: 100}, 1); err == nil {
+		t.Error("expected error for unknown distribution")
+	}
+}
+
+// TestGenGetKeyUsesWriteKeys tests that genGetKey routes writes to NextWriteKey
+func TestGenGetKeyUsesWriteKeys(t *testing.T) {
+	bc := NewBufferClientWithConns(nil, 0, 1)
+	bc.SetKeyGenerator(NewLatestKeyGenerator(1000, 0.99, 42))
+	getKey := bc.genGetKey()
+
+	for i := int64(1); i <= 5; i++ {
+		if key := getKey(true); key != i {
+			t.Errorf("write %d: key = %d, want %d", i, key, i)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if key := getKey(false); key < 0 || key >= 1000 {
+			t.Errorf("read key %d out of range [0, 1000)", key)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/dlog"
+	"github.com/imdea-software/swiftpaxos/transport"
+)
+
+// MetricsReport is sent by a client process to the master with the metrics
+// of all its threads. Periodic reports carry the metrics accumulated since
+// the start of the run; the last report of a run has Final set.
+type MetricsReport struct {
+	Alias    string
+	Protocol string
+	Threads  int
+	Final    bool
+	// Time the client has been running (for the final report, the
+	// benchmark duration used to compute its throughput)
+	Duration time.Duration
+	Metrics  *HybridMetrics
+}
+
+// MetricsReportReply is the reply to Master.ReportMetrics.
+type MetricsReportReply struct {
+	// Number of clients whose final report the master has received
+	Done int
+	// Number of clients the master expects a final report from
+	Expected int
+}
+
+// ReportMetrics sends a metrics report to the master.
+func (c *Client) ReportMetrics(r *MetricsReport) (*MetricsReportReply, error) {
+	if c.master == nil {
+		if _, err := c.dialMaster(); err != nil {
+			return nil, err
+		}
+	}
+	reply := &MetricsReportReply{}
+	if err := c.call(c.master, "Master.ReportMetrics", r, reply); err != nil {
+		// reconnect on the next report, to the next master if any
+		c.master.Close()
+		c.master = nil
+		if len(c.masterAddrs) > 1 {
+			c.masterIdx = (c.masterIdx + 1) % len(c.masterAddrs)
+		}
+		return nil, err
+	}
+	return reply, nil
+}
+
+// SnapshotMetrics returns a copy of the metrics recorded so far. Unlike
+// GetMetrics, it is safe to call while the benchmark loop is running.
+func (c *HybridBufferClient) SnapshotMetrics() *HybridMetrics {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	return c.Metrics.Copy()
+}
+
+// Copy returns a deep copy of m.
+func (m *HybridMetrics) Copy() *HybridMetrics {
+	c := *m
+	c.StrongWriteLatency = m.StrongWriteLatency.Copy()
+	c.StrongReadLatency = m.StrongReadLatency.Copy()
+	c.WeakWriteLatency = m.WeakWriteLatency.Copy()
+	c.WeakReadLatency = m.WeakReadLatency.Copy()
+	return &c
+}
+
+// MetricsReporter reports the metrics of the benchmark threads of a client
+// process to the master, periodically while they run and once at the end.
+type MetricsReporter struct {
+	cl *Client
+
+	alias    string
+	protocol string
+	threads  int
+	start    time.Time
+
+	mu      sync.Mutex
+	sources []*HybridBufferClient
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewMetricsReporter creates a MetricsReporter that uses its own connection
+// to the master at maddr:mport.
+func NewMetricsReporter(maddr string, mport int, alias, protocol string, threads int, logger *dlog.Logger) *MetricsReporter {
+	return &MetricsReporter{
+		cl:       NewClientLog("", maddr, mport, false, false, false, logger),
+		alias:    alias,
+		protocol: protocol,
+		threads:  threads,
+		start:    time.Now(),
+	}
+}
+
+// SetMasters sets the addresses of the masters (see Client.SetMasters).
+func (r *MetricsReporter) SetMasters(addrs []string) {
+	r.cl.SetMasters(addrs)
+}
+
+// SetTransport sets the network used to reach the master (nil = TCP).
+func (r *MetricsReporter) SetTransport(t transport.Transport) {
+	r.cl.SetTransport(t)
+}
+
+// Add registers a benchmark thread whose metrics are included in the
+// periodic reports. It does nothing on a nil MetricsReporter.
+func (r *MetricsReporter) Add(hbc *HybridBufferClient) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.sources = append(r.sources, hbc)
+	r.mu.Unlock()
+}
+
+// Snapshot aggregates the current metrics of all registered threads.
+func (r *MetricsReporter) Snapshot() *HybridMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ms := make([]*HybridMetrics, len(r.sources))
+	for i, hbc := range r.sources {
+		ms[i] = hbc.SnapshotMetrics()
+	}
+	return AggregateMetrics(ms)
+}
+
+// Start sends a report with the current metrics every interval until Final
+// is called. A non-positive interval disables periodic reports.
+func (r *MetricsReporter) Start(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-t.C:
+				err := r.send(&MetricsReport{
+					Duration: time.Since(r.start),
+					Metrics:  r.Snapshot(),
+				})
+				if err != nil {
+					r.cl.Println("periodic metrics report failed:", err)
+				}
+			}
+		}
+	}()
+}
+
+// Final stops the periodic reports and sends the final metrics of the run.
+func (r *MetricsReporter) Final(m *HybridMetrics, duration time.Duration) error {
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
+		r.stop = nil
+	}
+	if m == nil {
+		return errors.New("no metrics to report")
+	}
+	return r.send(&MetricsReport{
+		Final:    true,
+		Duration: duration,
+		Metrics:  m,
+	})
+}
+
+func (r *MetricsReporter) send(report *MetricsReport) error {
+	report.Alias = r.alias
+	report.Protocol = r.protocol
+	report.Threads = r.threads
+	reply, err := r.cl.ReportMetrics(report)
+	if err != nil {
+		return err
+	}
+	if report.Final {
+		r.cl.Printf("reported metrics to master (%d/%d clients done)", reply.Done, reply.Expected)
+	}
+	return nil
+}
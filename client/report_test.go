@@ -0,0 +1,110 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/rpc"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/dlog"
+)
+
+// fakeMaster records the metrics reports it receives.
+type fakeMaster struct {
+	mu      sync.Mutex
+	reports []*MetricsReport
+}
+
+func (m *fakeMaster) ReportMetrics(args *MetricsReport, reply *MetricsReportReply) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reports = append(m.reports, args)
+	if args.Final {
+		reply.Done = 1
+	}
+	reply.Expected = 1
+	return nil
+}
+
+func startFakeMaster(t *testing.T) (*fakeMaster, int) {
+	fm := &fakeMaster{}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("Master", fm); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	mux := http.NewServeMux()
+	mux.Handle(rpc.DefaultRPCPath, srv)
+	go http.Serve(l, mux)
+	return fm, l.Addr().(*net.TCPAddr).Port
+}
+
+// TestMetricsReporter tests periodic and final reports
+func TestMetricsReporter(t *testing.T) {
+	fm, port := startFakeMaster(t)
+
+	bc := NewBufferClientWithConns(nil, 0, 1)
+	hbc := NewHybridBufferClient(bc, 0, 0, 0)
+	hbc.recordLatency(StrongWrite, 2.0)
+
+	r := NewMetricsReporter("127.0.0.1", port, "client0", "curpht", 1, dlog.New("", false))
+	r.Add(hbc)
+	r.Start(20 * time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	hbc.recordLatency(StrongRead, 3.0)
+
+	if err := r.Final(hbc.GetMetrics(), time.Second); err != nil {
+		t.Fatalf("Final failed: %v", err)
+	}
+
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if len(fm.reports) < 2 {
+		t.Fatalf("got %d reports, want periodic reports and a final one", len(fm.reports))
+	}
+	first := fm.reports[0]
+	if first.Final || first.Alias != "client0" || first.Protocol != "curpht" || first.Metrics.StrongWriteCount != 1 {
+		t.Errorf("unexpected periodic report: %+v", first)
+	}
+	last := fm.reports[len(fm.reports)-1]
+	if !last.Final || last.Duration != time.Second {
+		t.Errorf("last report final=%v duration=%v, want final report of 1s", last.Final, last.Duration)
+	}
+	if last.Metrics.StrongReadCount != 1 || last.Metrics.StrongReadLatency.Max() != 3000 {
+		t.Errorf("final report lost metrics: %+v", last.Metrics.StrongReadLatency.Summary())
+	}
+	for _, rep := range fm.reports[:len(fm.reports)-1] {
+		if rep.Final {
+			t.Error("periodic report marked final")
+		}
+	}
+}
+
+// TestSnapshotMetricsIsCopy tests that snapshots do not alias the live metrics
+func TestSnapshotMetricsIsCopy(t *testing.T) {
+	bc := NewBufferClientWithConns(nil, 0, 1)
+	hbc := NewHybridBufferClient(bc, 0, 0, 0)
+	hbc.recordLatency(WeakWrite, 1.0)
+
+	snap := hbc.SnapshotMetrics()
+	hbc.recordLatency(WeakWrite, 1.0)
+
+	if snap.WeakWriteCount != 1 || snap.WeakWriteLatency.Count() != 1 {
+		t.Errorf("snapshot changed: count=%d hist=%d", snap.WeakWriteCount, snap.WeakWriteLatency.Count())
+	}
+	if hbc.Metrics.WeakWriteLatency.Count() != 2 {
+		t.Errorf("live histogram count = %d, want 2", hbc.Metrics.WeakWriteLatency.Count())
+	}
+}
+
+// TestMetricsReporterNil tests that Add is a no-op on a nil reporter
+func TestMetricsReporterNil(t *testing.T) {
+	var r *MetricsReporter
+	r.Add(nil)
+}
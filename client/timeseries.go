@@ -0,0 +1,202 @@
+package client
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TimeSeriesPoint holds the metrics of one client process over one interval.
+type TimeSeriesPoint struct {
+	// Wall-clock end of the interval
+	Time   time.Time
+	Client string
+	// Completed operations and latency percentiles (ms), by CommandType
+	Ops [numCommandTypes]int64
+	P50 [numCommandTypes]float64
+	P99 [numCommandTypes]float64
+	// Replica connections lost, and benchmark threads that stalled on a
+	// reply timeout, during the interval
+	Errors  int64
+	Stalled int64
+	// Commands sent but not completed at the end of the interval
+	InFlight int64
+}
+
+// TotalOps returns the number of operations of all types.
+func (p *TimeSeriesPoint) TotalOps() int64 {
+	var n int64
+	for _, ops := range p.Ops {
+		n += ops
+	}
+	return n
+}
+
+// timeSeriesHeader is the CSV header written by TimeSeries.
+var timeSeriesHeader = []string{
+	"timestamp_ms", "time", "client", "ops",
+	"strong_write_ops", "strong_read_ops", "weak_write_ops", "weak_read_ops",
+	"strong_write_p50", "strong_write_p99", "strong_read_p50", "strong_read_p99",
+	"weak_write_p50", "weak_write_p99", "weak_read_p50", "weak_read_p99",
+	"errors", "stalled", "inflight",
+}
+
+// record returns the CSV record of the point.
+func (p *TimeSeriesPoint) record() []string {
+	i64 := func(v int64) string { return strconv.FormatInt(v, 10) }
+	ms := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	r := []string{
+		i64(p.Time.UnixMilli()),
+		p.Time.UTC().Format(time.RFC3339Nano),
+		p.Client,
+		i64(p.TotalOps()),
+	}
+	for t := range p.Ops {
+		r = append(r, i64(p.Ops[t]))
+	}
+	for t := range p.Ops {
+		r = append(r, ms(p.P50[t]), ms(p.P99[t]))
+	}
+	return append(r, i64(p.Errors), i64(p.Stalled), i64(p.InFlight))
+}
+
+// TimeSeries writes the per-interval metrics of the benchmark threads of a
+// client process as CSV rows: operations, p50/p99 latency of each command
+// type, errors, stalled threads and in-flight commands, with wall-clock timestamps.
+// Intervals are aligned to the wall clock, so rows of different clients with
+// the same timestamp cover the same period.
+type TimeSeries struct {
+	client   string
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	w       *csv.Writer
+	sources []*HybridBufferClient
+	errors  int64 // connection errors of the sources at the previous sample
+	stalled int64
+	err     error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewTimeSeries creates a TimeSeries writing to w every interval.
+func NewTimeSeries(w io.Writer, client string, interval time.Duration) *TimeSeries {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &TimeSeries{
+		client:   client,
+		interval: interval,
+		now:      time.Now,
+		w:        csv.NewWriter(w),
+	}
+}
+
+// Add registers a benchmark thread. It does nothing on a nil TimeSeries.
+func (ts *TimeSeries) Add(hbc *HybridBufferClient) {
+	if ts == nil {
+		return
+	}
+	ts.mu.Lock()
+	ts.sources = append(ts.sources, hbc)
+	ts.mu.Unlock()
+}
+
+// Start writes the header and then one row every interval until Stop.
+func (ts *TimeSeries) Start() {
+	ts.mu.Lock()
+	ts.w.Write(timeSeriesHeader)
+	ts.w.Flush()
+	ts.mu.Unlock()
+
+	ts.stop = make(chan struct{})
+	ts.done = make(chan struct{})
+	go func() {
+		defer close(ts.done)
+		// align the first tick to the wall clock
+		now := ts.now()
+		wait := now.Truncate(ts.interval).Add(ts.interval).Sub(now)
+		select {
+		case <-time.After(wait):
+		case <-ts.stop:
+			ts.Write(ts.Sample())
+			return
+		}
+		ts.Write(ts.Sample())
+		ticker := time.NewTicker(ts.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ts.Write(ts.Sample())
+			case <-ts.stop:
+				// Emit the final partial interval
+				ts.Write(ts.Sample())
+				return
+			}
+		}
+	}()
+}
+
+// Stop writes the last (partial) interval and stops the time series.
+// It returns the first write error, if any.
+func (ts *TimeSeries) Stop() error {
+	if ts.stop != nil {
+		close(ts.stop)
+		<-ts.done
+		ts.stop = nil
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.err
+}
+
+// Sample ends the current interval of all registered threads and returns
+// its metrics.
+func (ts *TimeSeries) Sample() *TimeSeriesPoint {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	p := &TimeSeriesPoint{
+		Time:   ts.now(),
+		Client: ts.client,
+	}
+	var merged [numCommandTypes]*Histogram
+	for t := range merged {
+		merged[t] = NewHistogram(IntervalSigFigs)
+	}
+	var errors, stalled int64
+	for _, hbc := range ts.sources {
+		hs := hbc.intervals.Rotate()
+		for t := range merged {
+			merged[t].Merge(hs[t])
+		}
+		errors += hbc.ConnErrors()
+		stalled += atomic.LoadInt64(&hbc.stalled)
+		p.InFlight += atomic.LoadInt64(&hbc.sent) - atomic.LoadInt64(&hbc.completed)
+	}
+	for t, h := range merged {
+		p.Ops[t] = h.Count()
+		p.P50[t] = float64(h.ValueAtQuantile(0.5)) / 1000
+		p.P99[t] = float64(h.ValueAtQuantile(0.99)) / 1000
+	}
+	p.Errors, ts.errors = errors-ts.errors, errors
+	p.Stalled, ts.stalled = stalled-ts.stalled, stalled
+	return p
+}
+
+// Write writes one point as a CSV row.
+func (ts *TimeSeries) Write(p *TimeSeriesPoint) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.w.Write(p.record())
+	ts.w.Flush()
+	if err := ts.w.Error(); err != nil && ts.err == nil {
+		ts.err = err
+	}
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestHybridClient() *HybridBufferClient {
+	return NewHybridBufferClient(NewBufferClientWithConns(nil, 0, 1), 50, 50, 0)
+}
+
+// TestTimeSeriesSample tests per-interval ops, percentiles and counters
+func TestTimeSeriesSample(t *testing.T) {
+	a, b := newTestHybridClient(), newTestHybridClient()
+	ts := NewTimeSeries(&bytes.Buffer{}, "client0", time.Second)
+	ts.now = func() time.Time { return time.Unix(100, 0) }
+	ts.Add(a)
+	ts.Add(b)
+
+	for i := 1; i <= 100; i++ {
+		a.recordLatency(StrongWrite, float64(i))
+	}
+	b.recordLatency(WeakRead, 2)
+	atomic.AddInt64(&a.sent, 105)
+	atomic.AddInt64(&a.completed, 100)
+	atomic.StoreInt64(&b.stalled, 1)
+	b.readerDead(0)
+
+	p := ts.Sample()
+	if p.Ops[StrongWrite] != 100 || p.Ops[WeakRead] != 1 || p.TotalOps() != 101 {
+		t.Errorf("ops = %v, want 100 strong writes and 1 weak read", p.Ops)
+	}
+	if p.P50[StrongWrite] < 49.5 || p.P50[StrongWrite] > 50.5 {
+		t.Errorf("strong write p50 = %v, want ~50", p.P50[StrongWrite])
+	}
+	if p.P99[StrongWrite] < 98 || p.P99[StrongWrite] > 100 {
+		t.Errorf("strong write p99 = %v, want ~99", p.P99[StrongWrite])
+	}
+	if p.Errors != 1 || p.Stalled != 1 || p.InFlight != 5 {
+		t.Errorf("errors/stalled/inflight = %d/%d/%d, want 1/1/5", p.Errors, p.Stalled, p.InFlight)
+	}
+
+	// The next interval only reports what happened since the previous sample
+	a.recordLatency(StrongRead, 1)
+	p = ts.Sample()
+	if p.Ops[StrongWrite] != 0 || p.Ops[StrongRead] != 1 {
+		t.Errorf("second interval ops = %v", p.Ops)
+	}
+	if p.Errors != 0 || p.Stalled != 0 {
+		t.Errorf("second interval errors/stalled = %d/%d, want 0/0", p.Errors, p.Stalled)
+	}
+	// The run totals are not affected by rotating intervals
+	if a.Metrics.StrongWriteLatency.Count() != 100 {
+		t.Errorf("run histogram count = %d, want 100", a.Metrics.StrongWriteLatency.Count())
+	}
+}
+
+// TestTimeSeriesDisconnectNotAnError tests that closing connections at the end of a run is not counted
+func TestTimeSeriesDisconnectNotAnError(t *testing.T) {
+	c := newTestHybridClient()
+	c.Disconnect()
+	c.readerDead(0)
+	if c.ConnErrors() != 0 {
+		t.Errorf("ConnErrors = %d after Disconnect, want 0", c.ConnErrors())
+	}
+}
+
+// TestTimeSeriesCSV tests the CSV output of a running time series
+func TestTimeSeriesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	c := newTestHybridClient()
+	ts := NewTimeSeries(&buf, "client1", 20*time.Millisecond)
+	ts.Add(c)
+	ts.Start()
+	c.recordLatency(WeakWrite, 3)
+	time.Sleep(70 * time.Millisecond)
+	if err := ts.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) < 3 {
+		t.Fatalf("got %d rows, want header and at least 2 intervals", len(rows))
+	}
+	if len(rows[0]) != len(timeSeriesHeader) || rows[0][0] != "timestamp_ms" {
+		t.Errorf("unexpected header %v", rows[0])
+	}
+	var ops, prev int
+	for _, r := range rows[1:] {
+		if len(r) != len(timeSeriesHeader) || r[2] != "client1" {
+			t.Fatalf("unexpected row %v", r)
+		}
+		at, err := time.Parse(time.RFC3339Nano, r[1])
+		if err != nil {
+			t.Fatalf("bad time %q: %v", r[1], err)
+		}
+		if int(at.UnixMilli()) < prev {
+			t.Errorf("timestamps not increasing: %v", rows)
+		}
+		prev = int(at.UnixMilli())
+		if r[3] == "1" {
+			ops++
+		}
+	}
+	if ops != 1 {
+		t.Errorf("the weak write was reported in %d intervals, want 1", ops)
+	}
+}
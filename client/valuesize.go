@@ -0,0 +1,231 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValueSizeGenerator defines the interface for choosing the payload size of
+// benchmark writes.
+type ValueSizeGenerator interface {
+	// NextSize returns the size in bytes of the next write payload.
+	NextSize() int
+	// MaxSize returns the largest size NextSize can return.
+	MaxSize() int
+}
+
+// ConstantValueSize always returns the same size (the legacy commandSize behavior).
+type ConstantValueSize struct {
+	size int
+}
+
+// NewConstantValueSize creates a new ConstantValueSize.
+func NewConstantValueSize(size int) *ConstantValueSize {
+	return &ConstantValueSize{size: size}
+}
+
+// NextSize returns the constant size.
+func (g *ConstantValueSize) NextSize() int {
+	return g.size
+}
+
+// MaxSize returns the constant size.
+func (g *ConstantValueSize) MaxSize() int {
+	return g.size
+}
+
+// UniformValueSize draws sizes uniformly from [min, max].
+type UniformValueSize struct {
+	rand     *rand.Rand
+	min, max int
+}
+
+// NewUniformValueSize creates a new UniformValueSize.
+func NewUniformValueSize(min, max int, seed int64) *UniformValueSize {
+	return &UniformValueSize{
+		rand: rand.New(rand.NewSource(seed)),
+		min:  min,
+		max:  max,
+	}
+}
+
+// NextSize returns a uniformly random size in [min, max].
+func (g *UniformValueSize) NextSize() int {
+	return g.min + g.rand.Intn(g.max-g.min+1)
+}
+
+// MaxSize returns max.
+func (g *UniformValueSize) MaxSize() int {
+	return g.max
+}
+
+// ZipfValueSize draws sizes from [min, max] with Zipf-distributed offsets:
+// small values are common and large values are rare.
+type ZipfValueSize struct {
+	offsets  *ZipfKeyGenerator
+	min, max int
+}
+
+// NewZipfValueSize creates a new ZipfValueSize with the given skew.
+func NewZipfValueSize(min, max int, skew float64, seed int64) *ZipfValueSize {
+	return &ZipfValueSize{
+		offsets: NewZipfKeyGenerator(int64(max-min+1), skew, seed),
+		min:     min,
+		max:     max,
+	}
+}
+
+// NextSize returns min plus a Zipf-distributed offset.
+func (g *ZipfValueSize) NextSize() int {
+	return g.min + int(g.offsets.NextKey())
+}
+
+// MaxSize returns max.
+func (g *ZipfValueSize) MaxSize() int {
+	return g.max
+}
+
+// HistogramValueSize draws sizes from an empirical histogram.
+type HistogramValueSize struct {
+	rand  *rand.Rand
+	sizes []int
+	cdf   []float64
+	max   int
+}
+
+// NewHistogramValueSize creates a HistogramValueSize from (size, weight) pairs.
+func NewHistogramValueSize(sizes []int, weights []float64, seed int64) (*HistogramValueSize, error) {
+	if len(sizes) == 0 || len(sizes) != len(weights) {
+		return nil, fmt.Errorf("histogram needs the same non-zero number of sizes and weights")
+	}
+	g := &HistogramValueSize{
+		rand:  rand.New(rand.NewSource(seed)),
+		sizes: sizes,
+		cdf:   make([]float64, len(weights)),
+	}
+	sum := 0.0
+	for i, w := range weights {
+		if w < 0 || sizes[i] < 0 {
+			return nil, fmt.Errorf("histogram entry %d: negative size or weight", i)
+		}
+		sum += w
+		g.cdf[i] = sum
+		if sizes[i] > g.max {
+			g.max = sizes[i]
+		}
+	}
+	if sum == 0 {
+		return nil, fmt.Errorf("histogram weights sum to zero")
+	}
+	for i := range g.cdf {
+		g.cdf[i] /= sum
+	}
+	g.cdf[len(g.cdf)-1] = 1.0
+	return g, nil
+}
+
+// ReadHistogramValueSize reads a histogram file with one "size weight" pair
+// per line. Empty lines and lines starting with // or # are ignored.
+func ReadHistogramValueSize(path string, seed int64) (*HistogramValueSize, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		sizes   []int
+		weights []float64
+		line    = 0
+	)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line++
+		words := strings.Fields(s.Text())
+		if len(words) == 0 || strings.HasPrefix(words[0], "//") || strings.HasPrefix(words[0], "#") {
+			continue
+		}
+		if len(words) < 2 {
+			return nil, fmt.Errorf("%s:%d: expecting \"size weight\"", path, line)
+		}
+		size, err := strconv.Atoi(words[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid size: %v", path, line, err)
+		}
+		w, err := strconv.ParseFloat(words[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid weight: %v", path, line, err)
+		}
+		sizes = append(sizes, size)
+		weights = append(weights, w)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return NewHistogramValueSize(sizes, weights, seed)
+}
+
+// NextSize returns a size drawn from the histogram.
+func (g *HistogramValueSize) NextSize() int {
+	i := sort.SearchFloat64s(g.cdf, g.rand.Float64())
+	if i >= len(g.sizes) {
+		i = len(g.sizes) - 1
+	}
+	return g.sizes[i]
+}
+
+// MaxSize returns the largest size in the histogram.
+func (g *HistogramValueSize) MaxSize() int {
+	return g.max
+}
+
+// Value size distribution names accepted by NewValueSizeGenerator.
+const (
+	ValueDistConstant  = "constant"
+	ValueDistUniform   = "uniform"
+	ValueDistZipf      = "zipf"
+	ValueDistHistogram = "histogram"
+)
+
+// ValueDistConfig describes the payload size distribution of a benchmark client.
+type ValueDistConfig struct {
+	// Dist is one of constant, uniform, zipf or histogram (default: constant)
+	Dist string
+	// Payload size for constant, and default maximum for uniform and zipf
+	Size int
+	Min  int
+	Max  int
+	// Zipf skewness (zipf)
+	Skew float64
+	// Histogram file (histogram)
+	Hist string
+}
+
+// NewValueSizeGenerator creates a ValueSizeGenerator for the given distribution.
+func NewValueSizeGenerator(d ValueDistConfig, clientId int32) (ValueSizeGenerator, error) {
+	seed := time.Now().UnixNano() + int64(clientId)
+	if d.Max <= 0 {
+		d.Max = d.Size
+	}
+	if d.Min < 0 || d.Min > d.Max {
+		return nil, fmt.Errorf("invalid value size range [%d, %d]", d.Min, d.Max)
+	}
+
+	switch strings.ToLower(d.Dist) {
+	case "", ValueDistConstant:
+		return NewConstantValueSize(d.Size), nil
+	case ValueDistUniform:
+		return NewUniformValueSize(d.Min, d.Max, seed), nil
+	case ValueDistZipf:
+		return NewZipfValueSize(d.Min, d.Max, d.Skew, seed), nil
+	case ValueDistHistogram:
+		return ReadHistogramValueSize(d.Hist, seed)
+	}
+	return nil, fmt.Errorf("unknown value size distribution %q", d.Dist)
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestConstantValueSize tests that constant sizes never vary
+func TestConstantValueSize(t *testing.T) {
+	g := NewConstantValueSize(100)
+	for i := 0; i < 100; i++ {
+		if s := g.NextSize(); s != 100 {
+			t.Fatalf("NextSize = %d, want 100", s)
+		}
+	}
+	if g.MaxSize() != 100 {
+		t.Errorf("MaxSize = %d, want 100", g.MaxSize())
+	}
+}
+
+// TestUniformValueSize tests that uniform sizes stay within [min, max] and cover both ends
+func TestUniformValueSize(t *testing.T) {
+	g := NewUniformValueSize(10, 20, 42)
+	seen := make(map[int]bool)
+	for i := 0; i < 10000; i++ {
+		s := g.NextSize()
+		if s < 10 || s > 20 {
+			t.Fatalf("NextSize = %d, out of [10, 20]", s)
+		}
+		seen[s] = true
+	}
+	if len(seen) != 11 {
+		t.Errorf("saw %d distinct sizes, want 11", len(seen))
+	}
+}
+
+// TestZipfValueSize tests that small sizes dominate
+func TestZipfValueSize(t *testing.T) {
+	g := NewZipfValueSize(8, 4096, 1.2, 42)
+	small := 0
+	for i := 0; i < 10000; i++ {
+		s := g.NextSize()
+		if s < 8 || s > 4096 {
+			t.Fatalf("NextSize = %d, out of [8, 4096]", s)
+		}
+		if s < 64 {
+			small++
+		}
+	}
+	if small < 5000 {
+		t.Errorf("only %d of 10000 sizes below 64 bytes, expected most", small)
+	}
+}
+
+// TestReadHistogramValueSize tests parsing and sampling a histogram file
+func TestReadHistogramValueSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sizes.hist")
+	content := `// size weight
+64   3
+# large values
+1024 1
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := ReadHistogramValueSize(path, 42)
+	if err != nil {
+		t.Fatalf("ReadHistogramValueSize failed: %v", err)
+	}
+	if g.MaxSize() != 1024 {
+		t.Errorf("MaxSize = %d, want 1024", g.MaxSize())
+	}
+
+	counts := make(map[int]int)
+	for i := 0; i < 10000; i++ {
+		counts[g.NextSize()]++
+	}
+	if len(counts) != 2 {
+		t.Fatalf("unexpected sizes %v", counts)
+	}
+	ratio := float64(counts[64]) / 10000
+	if ratio < 0.72 || ratio > 0.78 {
+		t.Errorf("64-byte ratio = %.3f, want ~0.75", ratio)
+	}
+}
+
+// TestReadHistogramValueSizeErrors tests malformed histogram files
+func TestReadHistogramValueSizeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"missing_weight", "64\n", ":1:"},
+		{"bad_size", "abc 1\n", "invalid size"},
+		{"bad_weight", "64 x\n", "invalid weight"},
+		{"empty", "// nothing\n", "non-zero"},
+		{"zero_weights", "64 0\n128 0\n", "zero"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sizes.hist")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := ReadHistogramValueSize(path, 42)
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("err = %v, want containing %q", err, tc.errMsg)
+			}
+		})
+	}
+}
+
+// TestNewValueSizeGenerator tests distribution selection and defaults
+func TestNewValueSizeGenerator(t *testing.T) {
+	g, err := NewValueSizeGenerator(ValueDistConfig{Size: 100}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.(*ConstantValueSize); !ok || g.NextSize() != 100 {
+		t.Errorf("default: got %T(%d), want constant 100", g, g.NextSize())
+	}
+
+	g, err = NewValueSizeGenerator(ValueDistConfig{Dist: "uniform", Size: 100, Min: 10}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.MaxSize() != 100 {
+		t.Errorf("uniform MaxSize = %d, want commandSize 100", g.MaxSize())
+	}
+
+	if _, err := NewValueSizeGenerator(ValueDistConfig{Dist: "uniform", Min: 50, Max: 10}, 1); err == nil {
+		t.Error("expected error for min > max")
+	}
+	if _, err := NewValueSizeGenerator(ValueDistConfig{Dist: "pareto", Size: 10}, 1); err == nil {
+		t.Error("expected error for unknown distribution")
+	}
+}
+
+// TestGenGetValueVariableSize tests that payloads follow the size generator
+func TestGenGetValueVariableSize(t *testing.T) {
+	bc := NewBufferClientWithConns(nil, 0, 1)
+	bc.rand = rand.New(rand.NewSource(1))
+	bc.SetValueSizeGenerator(NewUniformValueSize(1, 64, 42))
+	getValue := bc.genGetValue()
+	for i := 0; i < 100; i++ {
+		if n := len(getValue()); n < 1 || n > 64 {
+			t.Fatalf("payload size %d out of [1, 64]", n)
+		}
+	}
+}
+
+// TestRecordBytes tests byte accounting and aggregation
+func TestRecordBytes(t *testing.T) {
+	hbc := &HybridBufferClient{
+		BufferClient: &BufferClient{},
+		Metrics:      NewHybridMetrics(),
+	}
+	hbc.recordBytes(StrongWrite, 100, 0)
+	hbc.recordBytes(WeakWrite, 50, 0)
+	hbc.recordBytes(StrongRead, 0, 30)
+	hbc.recordBytes(WeakRead, 0, 20)
+
+	if hbc.Metrics.WriteBytes != 150 {
+		t.Errorf("WriteBytes = %d, want 150", hbc.Metrics.WriteBytes)
+	}
+	if hbc.Metrics.ReadBytes != 50 {
+		t.Errorf("ReadBytes = %d, want 50", hbc.Metrics.ReadBytes)
+	}
+
+	agg := AggregateMetrics([]*HybridMetrics{hbc.Metrics, hbc.Metrics})
+	if agg.WriteBytes != 300 || agg.ReadBytes != 100 {
+		t.Errorf("aggregated bytes = (%d, %d), want (300, 100)", agg.WriteBytes, agg.ReadBytes)
+	}
+
+	got := bandwidthString(2048, 1024, 2*time.Second)
+	want := "1.50 KB/sec (writes: 1.00 KB/sec, reads: 0.50 KB/sec)"
+	if got != want {
+		t.Errorf("bandwidthString = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/client"
+	"github.com/imdea-software/swiftpaxos/config"
+	"github.com/imdea-software/swiftpaxos/dlog"
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/sim"
+	"github.com/imdea-software/swiftpaxos/state"
+	"github.com/imdea-software/swiftpaxos/transport"
+)
+
+var clusterProtocols = flag.String("cluster.protocols", "raft,curpht,epaxos,paxos",
+	"Comma-separated `protocols` run by TestClusterInMemory")
+
+const clusterConf = `-- Replicas --
+replica0 10.0.0.1
+replica1 10.0.0.2
+replica2 10.0.0.3
+
+-- Clients --
+client0 10.0.1.1
+
+-- Master --
+master0 10.0.2.1
+
+masterPort: 7087
+protocol: %s
+report: none
+fast: true
+
+reqs:        %d
+writes:      50
+commandSize: 16
+weakRatio:   50
+weakWrites:  50
+%s
+-- Proxy --
+server_alias replica0
+client0 (local)
+---
+`
+
+// clusterConfig reads the test cluster config for alias, with the
+// participant attached to host of the in-process network
+func clusterConfig(t *testing.T, path, alias, host string, n *transport.Network) *config.Config {
+	c, err := config.Read(path, alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Transport = n.Host(host)
+	return c
+}
+
+// runCluster runs a master, three replicas and a client of protocol in one
+// process and returns the metrics of the client (nil for protocols without
+// hybrid metrics).
+func runCluster(t *testing.T, protocol string, reqs int) *client.HybridMetrics {
+	n := transport.NewNetwork()
+	defer n.Close()
+	return runClusterOn(t, n, nil, protocol, reqs, "")
+}
+
+// runClusterOn runs the cluster of runCluster on n, with the clocks of s if
+// it is not nil and the extra lines of config.
+func runClusterOn(t *testing.T, n *transport.Network, s *sim.Simulator, protocol string, reqs int, extra string) *client.HybridMetrics {
+	return runClusterConf(t, n, s, fmt.Sprintf(clusterConf, protocol, reqs, extra))
+}
+
+// runClusterConf runs on n the master, the replicas and client0 of the
+// config conf, at the hosts of conf, and returns the metrics of the client.
+func runClusterConf(t *testing.T, n *transport.Network, s *sim.Simulator, conf string) *client.HybridMetrics {
+	clusterConfig := func(t *testing.T, path, alias, host string, n *transport.Network) *config.Config {
+		c := clusterConfig(t, path, alias, host, n)
+		if s != nil {
+			c.Clock = s.Clock(alias)
+		}
+		if err := useTLS(c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	path := filepath.Join(t.TempDir(), "cluster.conf")
+	if err := os.WriteFile(path, []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mc, err := config.Read(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clusterConfig(t, path, mc.MasterAlias, mc.MasterAddr, n)
+	go runMaster(c)
+	// clients do not retry to reach the master
+	waitListening(t, n, c.MasterList()[0])
+	for _, alias := range mc.ReplicaAliases {
+		c := clusterConfig(t, path, alias, mc.ReplicaAddrs[alias], n)
+		go runReplica(c, dlog.New("", false))
+	}
+
+	c = clusterConfig(t, path, "client0", mc.ClientAddrs["client0"], n)
+	done := make(chan *client.HybridMetrics, 1)
+	go func() {
+		m, _ := runSingleClient(c, 0, false, 1, func(*client.HybridBufferClient) {})
+		done <- m
+	}()
+	select {
+	case m := <-done:
+		return m
+	case <-time.After(60 * time.Second):
+		t.Fatalf("%s client did not complete %d requests", mc.Protocol, mc.Reqs)
+		return nil
+	}
+}
+
+// waitListening waits until addr accepts connections
+func waitListening(t *testing.T, n *transport.Network, addr string) {
+	for i := 0; i < 100; i++ {
+		if conn, err := n.Host("10.0.9.9").Dial(addr, time.Second); err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("nothing listening on %s", addr)
+}
+
+// TestClusterInMemory runs whole clusters over the in-process transport
+func TestClusterInMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starting clusters takes a few seconds")
+	}
+	const reqs = 100
+	for _, p := range strings.Split(*clusterProtocols, ",") {
+		p := p
+		t.Run(p, func(t *testing.T) {
+			t.Parallel()
+			m := runCluster(t, p, reqs)
+			if !isHybridProtocol(strings.ToLower(p)) {
+				return
+			}
+			if m == nil {
+				t.Fatal("no metrics")
+			}
+			ops := m.StrongWriteCount + m.StrongReadCount + m.WeakWriteCount + m.WeakReadCount
+			if ops != reqs {
+				t.Errorf("completed %d operations, want %d", ops, reqs)
+			}
+		})
+	}
+}
+
+// TestClusterSimulated runs a whole cluster with the simulator scheduling
+// its messages and replica timers
+func TestClusterSimulated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starting clusters takes a few seconds")
+	}
+	s := sim.New(1)
+	defer s.Stop()
+	s.Start()
+	const reqs = 50
+	m := runClusterOn(t, s.Network(), s, "raft", reqs, "")
+	if ops := m.StrongWriteCount + m.StrongReadCount + m.WeakWriteCount + m.WeakReadCount; ops != reqs {
+		t.Errorf("completed %d operations, want %d", ops, reqs)
+	}
+	t.Logf("%d events, virtual time %v", len(s.Trace()), s.Now().Sub(sim.Epoch))
+}
+
+// TestClusterTLS runs a whole cluster with mutual TLS between all
+// participants
+func TestClusterTLS(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starting clusters takes a few seconds")
+	}
+	dir := t.TempDir()
+	aliases := []string{"replica0", "replica1", "replica2", "client0", "master0"}
+	if err := transport.GenerateCerts(dir, aliases, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	n := transport.NewNetwork()
+	defer n.Close()
+	const reqs = 50
+	m := runClusterOn(t, n, nil, "raft", reqs, "tls: "+dir)
+	if ops := m.StrongWriteCount + m.StrongReadCount + m.WeakWriteCount + m.WeakReadCount; ops != reqs {
+		t.Errorf("completed %d operations, want %d", ops, reqs)
+	}
+}
+
+// TestClusterMembership starts a raft cluster with replica2 as a spare,
+// then replaces replica0, the leader, by replica2
+func TestClusterMembership(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starting clusters takes a few seconds")
+	}
+	n := transport.NewNetwork()
+	defer n.Close()
+	const reqs = 20
+	m := runClusterOn(t, n, nil, "raft", reqs, "members: replica0 replica1")
+	if ops := m.StrongWriteCount + m.StrongReadCount + m.WeakWriteCount + m.WeakReadCount; ops != reqs {
+		t.Errorf("completed %d operations, want %d", ops, reqs)
+	}
+
+	c := &config.Config{MasterAddr: "10.0.2.1", MasterPort: 7087, Transport: n.Host("10.0.9.8")}
+	reply, err := changeMembership(c, strings.Fields("replace 0 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(reply.Members) != "[1 2]" || (reply.Leader != 1 && reply.Leader != 2) {
+		t.Errorf("members %v, leader %d after replace 0 2", reply.Members, reply.Leader)
+	}
+}
+
+// TestClusterLeaderTransfer moves the leadership of running clusters
+// through the master
+func TestClusterLeaderTransfer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starting clusters takes a few seconds")
+	}
+	for i, p := range []string{"raft", "paxos"} {
+		i, p := i, p
+		t.Run(p, func(t *testing.T) {
+			t.Parallel()
+			n := transport.NewNetwork()
+			defer n.Close()
+			runClusterOn(t, n, nil, p, 20, "")
+
+			c := &config.Config{MasterAddr: "10.0.2.1", MasterPort: 7087, Transport: n.Host(fmt.Sprintf("10.0.9.%d", i+1))}
+			reply, err := transferLeader(c, []string{"2"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			gl := &defs.GetLeaderReply{}
+			if err := callMaster(c, "Master.GetLeader", &defs.GetLeaderArgs{}, gl); err != nil {
+				t.Fatal(err)
+			}
+			if reply.Leader != 2 || gl.LeaderId != 2 {
+				t.Errorf("leader %d, master leader %d after the transfer to 2", reply.Leader, gl.LeaderId)
+			}
+		})
+	}
+}
+
+func TestClusterAdmin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starting clusters takes a few seconds")
+	}
+	n := transport.NewNetwork()
+	defer n.Close()
+	dir := t.TempDir()
+	runClusterOn(t, n, nil, "raft", 20, "snapshotDir: "+dir+"\n")
+
+	c := &config.Config{MasterAddr: "10.0.2.1", MasterPort: 7087, Transport: n.Host("10.0.9.1")}
+	var out strings.Builder
+	if err := runAdmin(c, []string{"status"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "replica") != 3 || !strings.Contains(out.String(), " leader ") {
+		t.Errorf("status without the three replicas and a leader:\n%s", out.String())
+	}
+
+	gl := &defs.GetLeaderReply{}
+	if err := callMaster(c, "Master.GetLeader", &defs.GetLeaderArgs{}, gl); err != nil {
+		t.Fatal(err)
+	}
+	leader := fmt.Sprint(gl.LeaderId)
+	out.Reset()
+	if err := runAdmin(c, []string{"stats", leader}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "clientMsgDrops") {
+		t.Errorf("stats without the message drops:\n%s", out.String())
+	}
+
+	// the keys of the snapshot are in the state machine of the replica
+	path := filepath.Join(dir, "leader.snapshot")
+	out.Reset()
+	if err := runAdmin(c, []string{"snapshot", leader, "leader.snapshot"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	st := state.InitState()
+	if err := st.Load(f); err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Store) == 0 {
+		t.Fatalf("empty snapshot: %s", out.String())
+	}
+	for k, v := range st.Store {
+		out.Reset()
+		if err := runAdmin(c, []string{"get", leader, fmt.Sprint(k)}, &out); err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("%d: %s", k, v.String()); !strings.HasPrefix(out.String(), want) {
+			t.Errorf("get %d: %q, want %q", k, out.String(), want)
+		}
+		break
+	}
+
+	if err := runAdmin(c, []string{"get", "7", "1"}, &out); err == nil {
+		t.Error("get from a replica that does not exist")
+	}
+
+	// the status and the metrics of the replicas over HTTP
+	hc := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return c.Transport.Dial(addr, time.Second)
+		},
+	}}
+	get := func(url string) string {
+		resp, err := hc.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: %s %v", url, resp.Status, err)
+		}
+		return string(b)
+	}
+	if s := get("http://10.0.0.1:8070/status"); !strings.Contains(s, `"Alias": "replica0"`) {
+		t.Errorf("status of replica0:\n%s", s)
+	}
+	if m := get("http://10.0.0.2:8071/metrics"); !strings.Contains(m, `swiftpaxos_commit_index{replica="replica1"}`) {
+		t.Errorf("metrics of replica1:\n%s", m)
+	}
+}
+
+// TestClusterWitnesses runs CURP clusters whose replica2 is a witness
+func TestClusterWitnesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starting clusters takes a few seconds")
+	}
+	for _, p := range []string{"curp", "curpht", "curpho"} {
+		p := p
+		t.Run(p, func(t *testing.T) {
+			t.Parallel()
+			n := transport.NewNetwork()
+			defer n.Close()
+			const reqs = 50
+			m := runClusterOn(t, n, nil, p, reqs, "witnesses: replica2")
+			if ops := m.StrongWriteCount + m.StrongReadCount + m.WeakWriteCount + m.WeakReadCount; ops != reqs {
+				t.Errorf("completed %d operations, want %d", ops, reqs)
+			}
+		})
+	}
+}
+
+const explicitPortsConf = `-- Replicas --
+alpha 10.0.0.1:%[1]d0
+beta 10.0.0.1:%[1]d1 %[1]d9
+gamma 10.0.0.2:%[1]d2
+
+-- Clients --
+client0 10.0.1.1
+
+-- Master --
+master0 10.0.0.1:%[1]d5
+
+protocol: raft
+report: none
+reqs: 20
+
+-- Proxy --
+server_alias alpha
+client0 (local)
+---
+`
+
+// TestClusterExplicitPorts runs two clusters whose replicas have no numbers
+// in their aliases on the same hosts, at the ports of their config
+func TestClusterExplicitPorts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starting clusters takes a few seconds")
+	}
+	n := transport.NewNetwork()
+	defer n.Close()
+	done := make(chan *client.HybridMetrics, 2)
+	for _, ports := range []int{710, 720} {
+		conf := fmt.Sprintf(explicitPortsConf, ports)
+		go func() { done <- runClusterConf(t, n, nil, conf) }()
+	}
+	for i := 0; i < 2; i++ {
+		m := <-done
+		if ops := m.StrongWriteCount + m.StrongReadCount + m.WeakWriteCount + m.WeakReadCount; ops != 20 {
+			t.Errorf("completed %d operations, want 20", ops)
+		}
+	}
+	// the RPC endpoint of beta in the second cluster
+	s := &defs.StatusReply{}
+	c := &config.Config{Transport: n.Host("10.0.9.7")}
+	if err := callReplicaAt(c, "10.0.0.1:7209", "Replica.Status", &defs.StatusArgs{}, s); err != nil || s.Alias != "beta" {
+		t.Errorf("status of beta: %v %+v", err, s)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	c := &config.Config{Protocol: "CurpHT", ReplicaAddrs: map[string]string{"replica0": "10.0.0.1"}}
+	if err := validateConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []*config.Config{
+		{Protocol: "curpxx"},
+		{Protocol: "raft", Alias: "replica0"},
+		{Protocol: "raft", KeyDist: "pareto"},
+		{Protocol: "raft", ValueDist: "histogram", ValueHist: filepath.Join(t.TempDir(), "none")},
+	} {
+		if err := validateConfig(bad); err == nil {
+			t.Errorf("%+v accepted", bad)
+		}
+	}
+}
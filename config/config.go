@@ -2,19 +2,36 @@ package config
 
 import (
 	"bufio"
+	"fmt"
+	"math"
+	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/imdea-software/swiftpaxos/sim"
+	"github.com/imdea-software/swiftpaxos/transport"
 )
 
 type Error struct {
 	errs    []error
 	field   string
 	comment string
+	// line of the config file, 0 if none
+	line int
 }
 
 func (err *Error) Error() string {
+	s := err.body()
+	if err.line != 0 {
+		return fmt.Sprintf("line %d: %s", err.line, strings.TrimPrefix(s, "\t"))
+	}
+	return s
+}
+
+func (err *Error) body() string {
 	s := ""
 	if err.field != "" {
 		s = "field: " + err.field + " --"
@@ -44,6 +61,18 @@ func Err(field, comment string, errs ...error) *Error {
 	}
 }
 
+// atLine returns err as the error of line n, unless it has a line.
+func atLine(n int, err error) error {
+	e, ok := err.(*Error)
+	if !ok {
+		e = Err("", "", err)
+	}
+	if e.line == 0 {
+		e.line = n
+	}
+	return e
+}
+
 type Machine int
 
 const (
@@ -59,14 +88,29 @@ type Config struct {
 	MachineType Machine
 	//Port        int
 
-	// associates client/replica alias with the address
+	// associates client/replica alias with the address (the host, without
+	// the port)
 	ClientAddrs  map[string]string
 	ReplicaAddrs map[string]string
+	// replicas in the order of the Replicas section
+	ReplicaAliases []string
+	// ports given with the address of a replica, host:port, and after it,
+	// by alias; the others are derived from the alias (see ReplicaPort and
+	// ReplicaRPCPort)
+	ReplicaPorts    map[string]int
+	ReplicaRPCPorts map[string]int
 
 	// -- master info --
+	// first master of the Master section
 	MasterAlias string
 	MasterPort  int
 	MasterAddr  string
+	// all the masters, in the order of the Master section; the first
+	// alive one is the primary, the others are backups
+	MasterAliases []string
+	MasterAddrs   []string
+	// ports given with the address of a master, host:port (0 = MasterPort)
+	MasterPorts []int
 
 	// -- replica info --
 	// do not execute client commands
@@ -118,6 +162,29 @@ type Config struct {
 	// Zipf skewness parameter (default: 0 = uniform)
 	// Values: 0=uniform, 0.99=moderate skew, 1.5=high skew
 	ZipfSkew float64
+	// Key distribution: uniform, zipf, hotspot or latest
+	// (default: "" = uniform or zipf depending on ZipfSkew)
+	KeyDist string
+	// Percentage of the key space in the hot set
+	// (hotspot, default: -1 = 20)
+	HotKeys int
+	// Percentage of operations that target the hot set
+	// (hotspot, default: -1 = 80)
+	HotOps int
+	// Interval after which the hot set moves to other keys (default: 0 = static)
+	HotShift time.Duration
+
+	// Write payload size distribution: constant, uniform, zipf or histogram
+	// (default: "" = constant CommandSize bytes)
+	ValueDist string
+	// Smallest payload size in bytes (uniform, zipf; default: 0)
+	ValueMin int
+	// Largest payload size in bytes (uniform, zipf; default: CommandSize)
+	ValueMax int
+	// Zipf skewness of payload sizes (zipf)
+	ValueSkew float64
+	// File with "size weight" lines (histogram)
+	ValueHist string
 
 	// Maximum number of concurrent command descriptor goroutines per replica.
 	// Controls the threshold for switching between parallel goroutine processing
@@ -125,6 +192,11 @@ type Config struct {
 	// memory and scheduling overhead. 0 = use protocol default (10000).
 	MaxDescRoutines int
 
+	// Maximum number of replies queued for a client before the replica answers
+	// its proposals with "server busy" and delays its other messages
+	// (default: 0 = 16384, -1 = no limit)
+	MaxInFlight int
+
 	// Reply timeout in seconds for client benchmark loop.
 	// How long to wait for a single reply before declaring a hang.
 	// 0 = use default (10s).
@@ -144,11 +216,47 @@ type Config struct {
 	// Actual count per SCAN drawn from Zipf distribution over [1, ScanCount]
 	ScanCount int
 
+	// Interval at which clients report their metrics to the master
+	// (default: 0 = only at the end of the run)
+	ReportInterval time.Duration
+	// Path prefix of the cluster report written by the master
+	// (<report>.json and <report>.csv, default: cluster-report, none = disabled)
+	Report string
+	// Directory of the snapshots that the admin participant has the replicas
+	// write (default: "" = their working directory)
+	SnapshotDir string
+
+	// Directory with the certificates of mutual TLS: ca.pem, <alias>.pem and
+	// <alias>.key (default: "" = plaintext)
+	TLS string
+	// Id of the cluster, checked when replicas and clients connect, so that
+	// nodes of different deployments do not talk (default: "")
+	Cluster string
+	// Replicas that vote when a raft or raft-ht cluster starts; the other
+	// replicas are spares, added by membership changes
+	// (default: empty = all replicas but the learners)
+	Members []string
+	// Replicas that get the log of a raft or raft-ht cluster without voting,
+	// e.g. to serve weak reads near clients (default: none)
+	Learners []string
+	// Replicas of a curp, curp-ht or curp-ho cluster that are only
+	// witnesses: they hold the unsynced commands, with no log and no state
+	// machine, and count neither in the quorums of the backups nor in the
+	// elections (default: none)
+	Witnesses []string
+
 	// quorum config file
 	Quorum string
 
 	Proxy *ProxyInfo
 	//latency *LatencyTable
+
+	// Network used by replicas, clients and the master
+	// (not read from the file, default: nil = TCP)
+	Transport transport.Transport
+	// Time, timers and random numbers of the participant
+	// (not read from the file, default: nil = wall clock)
+	Clock sim.Clock
 }
 
 // GetNumClientThreads returns the number of client threads to spawn.
@@ -174,11 +282,95 @@ func (c *Config) GetClientOffset(sortedClients []string, alias string) int {
 	return 0
 }
 
+// Ports of the replicas whose address has no port: 7070 plus the index of
+// the replica, and RPCPortOffset more for RPC.
+const (
+	BasePort      = 7070
+	RPCPortOffset = 1000
+)
+
+// AliasIndex returns the index of a participant given by the number ending
+// its alias, e.g. 3 for replica3, and 0 if there is none.
+func AliasIndex(alias string) int {
+	for i, ch := range alias {
+		if ch >= '0' && ch <= '9' {
+			if idx, err := strconv.Atoi(alias[i:]); err == nil {
+				return idx
+			}
+			break
+		}
+	}
+	return 0
+}
+
+// ReplicaIndex returns the index of the replica of alias: the number ending
+// its alias, e.g. 3 for replica3, if the numbers of the replicas are
+// distinct and below their count, or else its rank in the Replicas section.
+func (c *Config) ReplicaIndex(alias string) int {
+	seen := make([]bool, len(c.ReplicaAliases))
+	for _, a := range c.ReplicaAliases {
+		i := AliasIndex(a)
+		if i >= len(seen) || seen[i] {
+			for j, a := range c.ReplicaAliases {
+				if a == alias {
+					return j
+				}
+			}
+			return 0
+		}
+		seen[i] = true
+	}
+	return AliasIndex(alias)
+}
+
+// ReplicaPort returns the port of the replica of alias: the one of its
+// address, or else BasePort plus its index.
+func (c *Config) ReplicaPort(alias string) int {
+	if port := c.ReplicaPorts[alias]; port != 0 {
+		return port
+	}
+	return BasePort + c.ReplicaIndex(alias)
+}
+
+// ReplicaRPCPort returns the RPC port of the replica of alias: the one
+// given after its address, or else its port plus RPCPortOffset.
+func (c *Config) ReplicaRPCPort(alias string) int {
+	if port := c.ReplicaRPCPorts[alias]; port != 0 {
+		return port
+	}
+	return c.ReplicaPort(alias) + RPCPortOffset
+}
+
+// Read reads the config of filename for the participant alias. The file is
+// in the format of aws.conf or, if its name ends with .json, in JSON (see
+// readJSON). Unknown keys and sections, invalid or out of range arguments
+// and references to undefined aliases are errors, given with their line.
 func Read(filename, alias string) (*Config, error) {
 	c := &Config{
-		ClientAddrs:  make(map[string]string),
-		ReplicaAddrs: make(map[string]string),
-		Alias:        alias,
+		ClientAddrs:     make(map[string]string),
+		ReplicaAddrs:    make(map[string]string),
+		ReplicaPorts:    make(map[string]int),
+		ReplicaRPCPorts: make(map[string]int),
+		Alias:           alias,
+		HotKeys:         -1,
+		HotOps:          -1,
+	}
+	r := &reader{
+		c:     c,
+		alias: alias,
+		apply: true,
+		lines: make(map[string]int),
+	}
+
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return c, err
+		}
+		if err := r.readJSON(data); err != nil {
+			return c, err
+		}
+		return c, r.finish()
 	}
 
 	f, err := os.Open(filename)
@@ -187,168 +379,479 @@ func Read(filename, alias string) (*Config, error) {
 	}
 	defer f.Close()
 
-	var (
-		apply           = true
-		readingMaster   = false
-		readingClients  = false
-		readingReplicas = false
-	)
-
 	s := bufio.NewScanner(f)
 	for s.Scan() {
-		txt := strings.ToLower(s.Text())
-		words := strings.Fields(txt)
-		if len(words) < 1 {
-			continue
+		r.line++
+		if err := r.read(strings.Fields(s.Text())); err != nil {
+			return c, atLine(r.line, err)
 		}
-		switch words[0] {
-		case "//":
-			continue
-		case "--":
-			if len(words) < 2 {
-				return c, Err("", "expecting [Replicas | Clients | Master | Apply | Proxy] after --")
-			}
-			apply = true
-			readingMaster = false
-			readingClients = false
-			readingReplicas = false
-			switch strings.ToLower(words[1]) {
-			case "master":
-				readingMaster = true
-			case "clients":
-				readingClients = true
-			case "replicas":
-				readingReplicas = true
-			case "apply":
-				if len(words) < 4 || words[2] != "to" {
-					return c, Err("-- Apply", "Missing argument")
-				}
-				if words[3] != alias {
-					apply = false
-				}
-			case "proxy":
-				c.Proxy = ReadProxyInfo(c, s, "---")
-			}
-		default:
-			if !apply {
-				continue
+	}
+	if err := s.Err(); err != nil {
+		return c, err
+	}
+	return c, r.finish()
+}
+
+// reader reads a config into c, line by line.
+type reader struct {
+	c     *Config
+	alias string
+
+	// line being read
+	line int
+	// section of the participants being read: master, clients or
+	// replicas, or the proxy section
+	section string
+	// whether the keys of the section are for alias (see Apply)
+	apply bool
+
+	// last line of each key, by its lowercase name
+	lines map[string]int
+	// participants of the Apply sections
+	applies []aliasLine
+	// lines of the Proxy section, and their server alias
+	proxies []proxyLine
+	server  string
+}
+
+type aliasLine struct {
+	alias string
+	line  int
+}
+
+// proxyLine is a line of the Proxy section: client is a proxy of server,
+// local to it if local, or none if the line only declares server.
+type proxyLine struct {
+	server string
+	client string
+	local  bool
+	line   int
+}
+
+// read reads the line of words ws of a config in the text format.
+func (r *reader) read(ws []string) error {
+	if len(ws) == 0 || isComment(ws[0]) {
+		return nil
+	}
+	if ws[0] == "--" {
+		return r.startSection(ws)
+	}
+	if r.section == "proxy" {
+		return r.readProxy(ws)
+	}
+	ok, err := r.set(ws)
+	if ok {
+		// a key ends the participants
+		r.section = ""
+		return err
+	}
+	// lines of participants have no key, key: is a key
+	if r.section == "" || strings.Contains(ws[0], ":") {
+		return Err("", "unknown key "+ws[0])
+	}
+	return r.participant(r.section, ws)
+}
+
+func (r *reader) startSection(ws []string) error {
+	if len(ws) < 2 {
+		return Err("", "expecting [Replicas | Clients | Master | Apply | Proxy] after --")
+	}
+	r.section = ""
+	r.apply = true
+	switch name := strings.ToLower(ws[1]); name {
+	case "master", "clients", "replicas":
+		r.section = name
+	case "apply":
+		if len(ws) < 4 || strings.ToLower(ws[2]) != "to" {
+			return Err("-- Apply", "Missing argument")
+		}
+		r.applies = append(r.applies, aliasLine{ws[3], r.line})
+		r.apply = ws[3] == r.alias
+	case "proxy":
+		r.section = name
+		r.server = ""
+		r.c.Proxy = newProxyInfo()
+	default:
+		return Err("", "unknown section "+ws[1])
+	}
+	return nil
+}
+
+// set sets the key ws[0] to its argument, and tells whether ws[0] is a
+// key. The keys of the Apply sections of other participants are checked,
+// but not set.
+func (r *reader) set(ws []string) (bool, error) {
+	if !r.apply {
+		return (&Config{}).set(ws)
+	}
+	ok, err := r.c.set(ws)
+	if ok {
+		r.lines[strings.ToLower(strings.Split(ws[0], ":")[0])] = r.line
+	}
+	return ok, err
+}
+
+// participant adds the participant of ws, alias and address, to section.
+func (r *reader) participant(section string, ws []string) error {
+	c := r.c
+	alias := ws[0]
+	if alias == "" {
+		return Err("", "missing alias")
+	}
+	addr, port, rpcPort, err := expectAddr(ws)
+	if err != nil {
+		return err
+	}
+	if rpcPort != 0 && section != "replicas" {
+		return Err(alias, "RPC port of a participant that is not a replica")
+	}
+	switch section {
+	case "master":
+		for _, a := range c.MasterAliases {
+			if a == alias {
+				return Err("", "duplicate master "+alias)
 			}
-			var (
-				ok  = false
-				err error
-			)
-			switch strings.Split(words[0], ":")[0] {
-			case "masterport":
-				c.MasterPort, err = expectInt(words)
-				ok = true
-			case "reqs":
-				c.Reqs, err = expectInt(words)
-				ok = true
-			case "writes":
-				c.Writes, err = expectInt(words)
-				ok = true
-			case "conflicts":
-				c.Conflicts, err = expectInt(words)
-				ok = true
-			case "commandSize":
-				c.CommandSize, err = expectInt(words)
-				ok = true
-			case "clones":
-				c.Clones, err = expectInt(words)
-				ok = true
-			case "protocol":
-				c.Protocol, err = expectString(words)
-				ok = true
-			case "runtime":
-				c.RunTime, err = expectDuration(words)
-				ok = true
-			case "noop":
-				c.Noop, err = expectBool(words)
-				ok = true
-			case "thrifty":
-				c.Thrifty, err = expectBool(words)
-				ok = true
-			case "optread":
-				c.Optread, err = expectBool(words)
-				ok = true
-			case "leaderless":
-				c.Leaderless, err = expectBool(words)
-				ok = true
-			case "fast":
-				c.Fast, err = expectBool(words)
-				ok = true
-			case "waitclosest":
-				c.WaitClosest, err = expectBool(words)
-				ok = true
-			case "pipeline":
-				c.Pipeline, err = expectBool(words)
-				ok = true
-			case "pendings":
-				c.Pendings, err = expectInt(words)
-				ok = true
-			case "key":
-				c.Key, err = expectInt(words)
-				ok = true
-			case "commandsize":
-				c.CommandSize, err = expectInt(words)
-				ok = true
-			case "weakratio":
-				c.WeakRatio, err = expectInt(words)
-				ok = true
-			case "weakwrites":
-				c.WeakWrites, err = expectInt(words)
-				ok = true
-			case "clientthreads":
-				c.ClientThreads, err = expectInt(words)
-				ok = true
-			case "keyspace":
-				c.KeySpace, err = expectInt64(words)
-				ok = true
-			case "zipfskew":
-				c.ZipfSkew, err = expectFloat64(words)
-				ok = true
-			case "maxdescroutines":
-				c.MaxDescRoutines, err = expectInt(words)
-				ok = true
-			case "batchdelayus":
-				c.BatchDelayUs, err = expectInt(words)
-				ok = true
-			case "replytimeout":
-				c.ReplyTimeout, err = expectInt(words)
-				ok = true
-			case "scanratio":
-				c.ScanRatio, err = expectInt(words)
-				ok = true
-			case "scancount":
-				c.ScanCount, err = expectInt(words)
-				ok = true
+		}
+		if len(c.MasterAliases) == 0 {
+			c.MasterAlias = alias
+			c.MasterAddr = addr
+		}
+		c.MasterAliases = append(c.MasterAliases, alias)
+		c.MasterAddrs = append(c.MasterAddrs, addr)
+		c.MasterPorts = append(c.MasterPorts, port)
+	case "replicas":
+		if _, exists := c.ReplicaAddrs[alias]; exists {
+			return Err("", "duplicate replica "+alias)
+		}
+		c.ReplicaAliases = append(c.ReplicaAliases, alias)
+		c.ReplicaAddrs[alias] = addr
+		c.ReplicaPorts[alias] = port
+		c.ReplicaRPCPorts[alias] = rpcPort
+	case "clients":
+		if _, exists := c.ClientAddrs[alias]; exists {
+			return Err("", "duplicate client "+alias)
+		}
+		// clients only dial, their port is not used
+		c.ClientAddrs[alias] = addr
+	}
+	return nil
+}
+
+// readProxy reads a line of the Proxy section, which is either
+// "server_alias <replica>", or "<client>" or "<client> (local)" for the
+// clients of the last server_alias, or the end of the section, ---.
+func (r *reader) readProxy(ws []string) error {
+	if ws[0] == "---" {
+		r.section = ""
+		return nil
+	}
+	if ws[0] == "server_alias" {
+		server, err := expectString(ws)
+		if err != nil {
+			return err
+		}
+		r.server = server
+		r.proxies = append(r.proxies, proxyLine{server: server, line: r.line})
+		return nil
+	}
+	if r.server == "" {
+		return Err("", "client "+ws[0]+" before any server_alias")
+	}
+	local := false
+	if len(ws) > 1 && !isComment(ws[1]) {
+		if !strings.Contains(strings.ToLower(ws[1]), "local") {
+			return Err(ws[0], "Unexpected argument "+ws[1])
+		}
+		local = true
+	}
+	r.proxies = append(r.proxies, proxyLine{r.server, ws[0], local, r.line})
+	return nil
+}
+
+// finish checks the aliases that the config refers to, once all its
+// participants are known.
+func (r *reader) finish() error {
+	c := r.c
+	for _, a := range r.applies {
+		if !c.IsParticipant(a.alias) {
+			return atLine(a.line, Err("-- Apply", "unknown participant "+a.alias))
+		}
+	}
+	for _, p := range r.proxies {
+		if _, exists := c.ReplicaAddrs[p.server]; !exists {
+			return atLine(p.line, Err("server_alias", "unknown replica "+p.server))
+		}
+		if p.client == "" {
+			c.Proxy.addServer(p.server)
+			continue
+		}
+		addr, exists := c.ClientAddrs[p.client]
+		if !exists {
+			return atLine(p.line, Err("", "unknown client "+p.client))
+		}
+		c.Proxy.add(p.server, addr, p.local)
+	}
+
+	for _, m := range c.Members {
+		if _, exists := c.ReplicaAddrs[m]; !exists {
+			return atLine(r.lines["members"], Err("members", "unknown replica "+m))
+		}
+	}
+	for _, l := range c.Learners {
+		if _, exists := c.ReplicaAddrs[l]; !exists {
+			return atLine(r.lines["learners"], Err("learners", "unknown replica "+l))
+		}
+		for _, m := range c.Members {
+			if m == l {
+				return atLine(r.lines["learners"], Err("learners", l+" is also a member"))
 			}
-			if ok {
-				readingMaster = false
-				readingClients = false
-				readingReplicas = false
-				if err != nil {
-					return c, err
-				}
-			} else if !strings.HasPrefix(words[0], "//") {
-				addr := ""
-				a, err := expectString(words)
-				if err == nil {
-					addr = a
-				}
-				if readingMaster {
-					c.MasterAlias = words[0]
-					c.MasterAddr = addr
-				} else if readingReplicas {
-					c.ReplicaAddrs[words[0]] = addr
-				} else if readingClients {
-					c.ClientAddrs[words[0]] = addr
-				}
+		}
+	}
+
+	for _, w := range c.Witnesses {
+		if _, exists := c.ReplicaAddrs[w]; !exists {
+			return atLine(r.lines["witnesses"], Err("witnesses", "unknown replica "+w))
+		}
+		// the clients of a proxy wait for its replies
+		for _, s := range c.Proxy.Servers() {
+			if s == w {
+				return atLine(r.lines["witnesses"], Err("witnesses", w+" is a proxy"))
 			}
 		}
 	}
+	if len(c.Witnesses) > 0 && len(c.Witnesses) >= len(c.ReplicaAddrs) {
+		return atLine(r.lines["witnesses"], Err("witnesses", "no backup"))
+	}
+
+	return nil
+}
+
+// IsParticipant reports whether alias is a replica, a client or a master.
+func (c *Config) IsParticipant(alias string) bool {
+	_, replica := c.ReplicaAddrs[alias]
+	_, client := c.ClientAddrs[alias]
+	if replica || client {
+		return true
+	}
+	for _, a := range c.MasterAliases {
+		if a == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// set sets the field of the key ws[0] to its argument, and tells whether
+// ws[0] is a key. Keys are not case-sensitive, nor are the arguments other
+// than aliases, paths and names.
+func (c *Config) set(rawWords []string) (bool, error) {
+	words := make([]string, len(rawWords))
+	for i, w := range rawWords {
+		words[i] = strings.ToLower(w)
+	}
+	var err error
+	switch strings.Split(words[0], ":")[0] {
+	case "masterport":
+		c.MasterPort, err = expectPort(words)
+	case "reqs":
+		c.Reqs, err = expectCount(words)
+	case "writes":
+		c.Writes, err = expectPercent(words)
+	case "conflicts":
+		c.Conflicts, err = expectPercent(words)
+	case "clones":
+		c.Clones, err = expectCount(words)
+	case "protocol":
+		c.Protocol, err = expectString(words)
+	case "runtime":
+		c.RunTime, err = expectDuration(words)
+	case "noop":
+		c.Noop, err = expectBool(words)
+	case "thrifty":
+		c.Thrifty, err = expectBool(words)
+	case "optread":
+		c.Optread, err = expectBool(words)
+	case "leaderless":
+		c.Leaderless, err = expectBool(words)
+	case "fast":
+		c.Fast, err = expectBool(words)
+	case "waitclosest":
+		c.WaitClosest, err = expectBool(words)
+	case "pipeline":
+		c.Pipeline, err = expectBool(words)
+	case "pendings":
+		c.Pendings, err = expectCount(words)
+	case "key":
+		c.Key, err = expectInt(words)
+	case "commandsize":
+		c.CommandSize, err = expectCount(words)
+	case "weakratio":
+		c.WeakRatio, err = expectPercent(words)
+	case "weakwrites":
+		c.WeakWrites, err = expectPercent(words)
+	case "clientthreads":
+		c.ClientThreads, err = expectCount(words)
+	case "keyspace":
+		c.KeySpace, err = expectInt64(words)
+		if err == nil && c.KeySpace < 0 {
+			err = Err(words[0], "Negative argument")
+		}
+	case "zipfskew":
+		c.ZipfSkew, err = expectSkew(words)
+	case "keydist":
+		c.KeyDist, err = expectString(words)
+	case "hotkeys":
+		c.HotKeys, err = expectPercent(words)
+	case "hotops":
+		c.HotOps, err = expectPercent(words)
+	case "hotshift":
+		c.HotShift, err = expectDuration(words)
+	case "valuedist":
+		c.ValueDist, err = expectString(words)
+	case "valuemin":
+		c.ValueMin, err = expectCount(words)
+	case "valuemax":
+		c.ValueMax, err = expectCount(words)
+	case "valueskew":
+		c.ValueSkew, err = expectSkew(words)
+	case "valuehist":
+		c.ValueHist, err = expectString(rawWords)
+	case "maxdescroutines":
+		c.MaxDescRoutines, err = expectCount(words)
+	case "batchdelayus":
+		c.BatchDelayUs, err = expectCount(words)
+	case "maxinflight":
+		c.MaxInFlight, err = expectRange(words, -1, math.MaxInt)
+	case "replytimeout":
+		c.ReplyTimeout, err = expectCount(words)
+	case "scanratio":
+		c.ScanRatio, err = expectPercent(words)
+	case "scancount":
+		c.ScanCount, err = expectCount(words)
+	case "reportinterval":
+		c.ReportInterval, err = expectDuration(words)
+	case "tls":
+		c.TLS, err = expectString(rawWords)
+	case "cluster":
+		c.Cluster, err = expectString(rawWords)
+	case "report":
+		c.Report, err = expectString(rawWords)
+	case "snapshotdir":
+		c.SnapshotDir, err = expectString(rawWords)
+	case "members":
+		c.Members, err = expectList(rawWords)
+	case "learners":
+		c.Learners, err = expectList(rawWords)
+	case "witnesses":
+		c.Witnesses, err = expectList(rawWords)
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// InitialMembers returns, for each of the n replicas, whether it is one of
+// the Members, or, without Members, whether it is not one of the Learners.
+func (c *Config) InitialMembers(n int) []bool {
+	learners := c.InitialLearners(n)
+	members := make([]bool, n)
+	for i := range members {
+		members[i] = len(c.Members) == 0 && !learners[i]
+	}
+	for _, m := range c.Members {
+		if i := c.ReplicaIndex(m); i < n {
+			members[i] = true
+		}
+	}
+	return members
+}
+
+// InitialLearners returns, for each of the n replicas, whether it is one of
+// the Learners.
+func (c *Config) InitialLearners(n int) []bool {
+	learners := make([]bool, n)
+	for _, l := range c.Learners {
+		if i := c.ReplicaIndex(l); i < n {
+			learners[i] = true
+		}
+	}
+	return learners
+}
+
+// WitnessList returns, for each of the n replicas, whether it is one of the
+// Witnesses.
+func (c *Config) WitnessList(n int) []bool {
+	witnesses := make([]bool, n)
+	for _, w := range c.Witnesses {
+		if i := c.ReplicaIndex(w); i < n {
+			witnesses[i] = true
+		}
+	}
+	return witnesses
+}
+
+// Voters returns how many of the n replicas vote: the initial members that
+// are not witnesses.
+func (c *Config) Voters(n int) int {
+	members, witnesses := c.InitialMembers(n), c.WitnessList(n)
+	voters := 0
+	for i := range members {
+		if members[i] && !witnesses[i] {
+			voters++
+		}
+	}
+	return voters
+}
+
+// IsWitness reports whether the replica of alias is one of the Witnesses.
+func (c *Config) IsWitness(alias string) bool {
+	for _, w := range c.Witnesses {
+		if w == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// MasterList returns the addresses of the masters, with their port.
+func (c *Config) MasterList() []string {
+	if len(c.MasterAddrs) == 0 {
+		return []string{fmt.Sprintf("%s:%d", c.MasterAddr, c.MasterPort)}
+	}
+	l := make([]string, len(c.MasterAddrs))
+	for i, addr := range c.MasterAddrs {
+		l[i] = fmt.Sprintf("%s:%d", addr, c.masterPort(i))
+	}
+	return l
+}
+
+// MasterPortOf returns the port of the master alias, or MasterPort if alias
+// is not a master.
+func (c *Config) MasterPortOf(alias string) int {
+	for i, a := range c.MasterAliases {
+		if a == alias {
+			return c.masterPort(i)
+		}
+	}
+	return c.MasterPort
+}
 
-	return c, nil
+func (c *Config) masterPort(i int) int {
+	if i < len(c.MasterPorts) && c.MasterPorts[i] != 0 {
+		return c.MasterPorts[i]
+	}
+	return c.MasterPort
+}
+
+// MasterIndex returns the index of the master alias in MasterList, or 0 if
+// alias is not a master.
+func (c *Config) MasterIndex(alias string) int {
+	for i, a := range c.MasterAliases {
+		if a == alias {
+			return i
+		}
+	}
+	return 0
 }
 
 func (c *Config) MapClientToIP(client, ip string) {
@@ -367,6 +870,33 @@ func expectInt(ws []string) (int, error) {
 	return expect(ws, strconv.Atoi, 0)
 }
 
+// expectRange returns the integer argument of ws, which must be between min
+// and max.
+func expectRange(ws []string, min, max int) (int, error) {
+	i, err := expectInt(ws)
+	if err == nil && (i < min || i > max) {
+		if max == math.MaxInt {
+			return i, Err(ws[0], fmt.Sprintf("%d is less than %d", i, min))
+		}
+		return i, Err(ws[0], fmt.Sprintf("%d out of range [%d, %d]", i, min, max))
+	}
+	return i, err
+}
+
+// expectPercent returns the argument of ws, a percentage.
+func expectPercent(ws []string) (int, error) {
+	return expectRange(ws, 0, 100)
+}
+
+// expectCount returns the argument of ws, a non-negative integer.
+func expectCount(ws []string) (int, error) {
+	return expectRange(ws, 0, math.MaxInt)
+}
+
+func expectPort(ws []string) (int, error) {
+	return expectRange(ws, 1, 65535)
+}
+
 func expectInt64(ws []string) (int64, error) {
 	return expect(ws, func(s string) (int64, error) {
 		return strconv.ParseInt(s, 10, 64)
@@ -379,6 +909,15 @@ func expectFloat64(ws []string) (float64, error) {
 	}, float64(0))
 }
 
+// expectSkew returns the argument of ws, a non-negative skewness.
+func expectSkew(ws []string) (float64, error) {
+	f, err := expectFloat64(ws)
+	if err == nil && f < 0 {
+		err = Err(ws[0], "Negative argument")
+	}
+	return f, err
+}
+
 func expectString(ws []string) (string, error) {
 	return expect(ws, func(s string) (string, error) {
 		return s, nil
@@ -389,15 +928,83 @@ func expectBool(ws []string) (bool, error) {
 	return expect(ws, strconv.ParseBool, false)
 }
 
+// expectAddr returns the address of the participant of ws, host or
+// host:port, and the RPC port that may follow it, with 0 for the ports that
+// are not given. A participant without address has the empty address.
+func expectAddr(ws []string) (host string, port, rpcPort int, err error) {
+	if len(ws) < 2 || isComment(ws[1]) {
+		return "", 0, 0, nil
+	}
+	host = ws[1]
+	// a bare IPv6 address has no port
+	if strings.Count(host, ":") == 1 || strings.HasPrefix(host, "[") {
+		h, p, err := net.SplitHostPort(host)
+		if err != nil {
+			return "", 0, 0, Err(ws[0], "Invalid address", err)
+		}
+		if host = h; p != "" {
+			if port, err = parsePort(p); err != nil {
+				return "", 0, 0, Err(ws[0], "Invalid port", err)
+			}
+		}
+	}
+	if len(ws) > 2 && !isComment(ws[2]) {
+		if rpcPort, err = parsePort(ws[2]); err != nil {
+			return "", 0, 0, Err(ws[0], "Invalid RPC port", err)
+		}
+		if len(ws) > 3 && !isComment(ws[3]) {
+			return "", 0, 0, Err(ws[0], "Unexpected argument "+ws[3])
+		}
+	}
+	return host, port, rpcPort, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err == nil && (port <= 0 || port > 65535) {
+		err = fmt.Errorf("port %d out of range", port)
+	}
+	return port, err
+}
+
+// expectList returns the arguments of ws, separated by spaces or commas.
+func expectList(ws []string) ([]string, error) {
+	var l []string
+	for _, w := range ws[1:] {
+		if isComment(w) {
+			break
+		}
+		for _, e := range strings.Split(w, ",") {
+			if e != "" {
+				l = append(l, e)
+			}
+		}
+	}
+	if len(l) == 0 {
+		return nil, Err(ws[0], "Missing argument")
+	}
+	return l, nil
+}
+
 func expectDuration(ws []string) (time.Duration, error) {
 	return expect(ws, func(s string) (time.Duration, error) {
 		if s == "none" {
 			return time.Duration(0), nil
 		}
-		return time.ParseDuration(s)
+		d, err := time.ParseDuration(s)
+		if err == nil && d < 0 {
+			err = fmt.Errorf("negative duration %v", d)
+		}
+		return d, err
 	}, time.Duration(0))
 }
 
+// isComment reports whether the word w starts a comment, which runs to the
+// end of the line.
+func isComment(w string) bool {
+	return strings.HasPrefix(w, "//") || strings.HasPrefix(w, "#")
+}
+
 type expectRet interface {
 	int | int64 | float64 | string | bool | time.Duration
 }
@@ -406,9 +1013,12 @@ func expect[R expectRet](ws []string, f func(string) (R, error), none R) (R, err
 	if ws == nil || len(ws) < 1 {
 		return none, Err("", "Missing field")
 	}
-	if len(ws) < 2 || strings.HasPrefix(ws[1], "//") {
+	if len(ws) < 2 || isComment(ws[1]) {
 		return none, Err(ws[0], "Missing argument")
 	}
+	if len(ws) > 2 && !isComment(ws[2]) {
+		return none, Err(ws[0], "Unexpected argument "+ws[2])
+	}
 	i, err := f(ws[1])
 	if err != nil {
 		return i, Err(ws[0], "Invalid argument", err)
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestWeakRatioConfig tests parsing of weakRatio configuration parameter
@@ -1210,3 +1213,471 @@ func TestExpTaoConfigFile(t *testing.T) {
 		t.Errorf("Pendings = %d, want 15", c.Pendings)
 	}
 }
+
+// TestKeyDistConfig tests parsing of key distribution parameters
+func TestKeyDistConfig(t *testing.T) {
+	content := `
+keySpace 100000
+keyDist  hotspot
+hotKeys  5
+hotOps   95
+hotShift 30s
+`
+	f, err := os.CreateTemp("", "test_config_*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	c, err := Read(f.Name(), "test")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if c.KeyDist != "hotspot" {
+		t.Errorf("KeyDist = %q, want hotspot", c.KeyDist)
+	}
+	if c.HotKeys != 5 {
+		t.Errorf("HotKeys = %d, want 5", c.HotKeys)
+	}
+	if c.HotOps != 95 {
+		t.Errorf("HotOps = %d, want 95", c.HotOps)
+	}
+	if c.HotShift != 30*time.Second {
+		t.Errorf("HotShift = %v, want 30s", c.HotShift)
+	}
+
+	// 0 is not the default
+	c, err = readTemp(t, ".conf", "keyDist hotspot\nhotOps 0\n", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.HotKeys != -1 || c.HotOps != 0 {
+		t.Errorf("HotKeys, HotOps = %d, %d, want -1 (default), 0", c.HotKeys, c.HotOps)
+	}
+}
+
+// TestValueDistConfig tests parsing of value size distribution parameters
+func TestValueDistConfig(t *testing.T) {
+	content := `
+commandSize 1024
+valueDist   histogram
+valueMin    16
+valueMax    4096
+valueSkew   0.9
+valueHist   /tmp/Sizes.hist
+`
+	f, err := os.CreateTemp("", "test_config_*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	c, err := Read(f.Name(), "test")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if c.ValueDist != "histogram" {
+		t.Errorf("ValueDist = %q, want histogram", c.ValueDist)
+	}
+	if c.ValueMin != 16 || c.ValueMax != 4096 {
+		t.Errorf("ValueMin/ValueMax = %d/%d, want 16/4096", c.ValueMin, c.ValueMax)
+	}
+	if c.ValueSkew != 0.9 {
+		t.Errorf("ValueSkew = %f, want 0.9", c.ValueSkew)
+	}
+	// File paths keep their case
+	if c.ValueHist != "/tmp/Sizes.hist" {
+		t.Errorf("ValueHist = %q, want /tmp/Sizes.hist", c.ValueHist)
+	}
+}
+
+func TestReportConfig(t *testing.T) {
+	content := `
+reportInterval 5s
+report         results/Cluster-Report
+`
+	f, err := os.CreateTemp("", "test_config_*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	c, err := Read(f.Name(), "test")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if c.ReportInterval != 5*time.Second {
+		t.Errorf("ReportInterval = %v, want 5s", c.ReportInterval)
+	}
+	if c.Report != "results/Cluster-Report" {
+		t.Errorf("Report = %q, want results/Cluster-Report", c.Report)
+	}
+}
+
+func TestMembersConfig(t *testing.T) {
+	content := `
+-- Replicas --
+replica0 127.0.0.1
+replica1 127.0.0.2
+replica2 127.0.0.3
+replica3 127.0.0.4
+
+-- Clients --
+members: replica0, replica1 replica2 // replica3 is a spare
+`
+	f, err := os.CreateTemp("", "test_config_*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	c, err := Read(f.Name(), "test")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	members := c.InitialMembers(4)
+	if !members[0] || !members[1] || !members[2] || members[3] {
+		t.Errorf("InitialMembers = %v, want replica3 to be a spare", members)
+	}
+	if m := (&Config{}).InitialMembers(2); !m[0] || !m[1] {
+		t.Errorf("InitialMembers without members = %v", m)
+	}
+
+	if err := os.WriteFile(f.Name(), []byte("-- Replicas --\nreplica0 127.0.0.1\nmembers: replica0 replica7\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(f.Name(), "test"); err == nil {
+		t.Error("unknown member accepted")
+	}
+}
+
+func TestLearners(t *testing.T) {
+	f, err := os.CreateTemp("", "test_config_*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	replicas := "-- Replicas --\nreplica0 127.0.0.1\nreplica1 127.0.0.2\nreplica2 127.0.0.3\nreplica3 127.0.0.4\n"
+	if err := os.WriteFile(f.Name(), []byte(replicas+"learners: replica3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Read(f.Name(), "test")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	members, learners := c.InitialMembers(4), c.InitialLearners(4)
+	if !members[0] || !members[1] || !members[2] || members[3] {
+		t.Errorf("InitialMembers = %v, want all the replicas but the learner", members)
+	}
+	if learners[0] || !learners[3] {
+		t.Errorf("InitialLearners = %v, want replica3", learners)
+	}
+	if v := c.Voters(4); v != 3 {
+		t.Errorf("Voters = %d, want 3", v)
+	}
+
+	for _, bad := range []string{"learners: replica7\n", "members: replica0 replica3\nlearners: replica3\n"} {
+		if err := os.WriteFile(f.Name(), []byte(replicas+bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Read(f.Name(), "test"); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestWitnesses(t *testing.T) {
+	f, err := os.CreateTemp("", "test_config_*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	replicas := "-- Replicas --\nreplica0 127.0.0.1\nreplica1 127.0.0.2\nreplica2 127.0.0.3\n"
+	if err := os.WriteFile(f.Name(), []byte(replicas+"witnesses: replica2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Read(f.Name(), "replica2")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if w := c.WitnessList(3); w[0] || w[1] || !w[2] {
+		t.Errorf("WitnessList = %v, want replica2", w)
+	}
+	if !c.IsWitness("replica2") || c.IsWitness("replica0") {
+		t.Error("IsWitness")
+	}
+	if v := c.Voters(3); v != 2 {
+		t.Errorf("Voters = %d, want 2", v)
+	}
+
+	for _, bad := range []string{
+		"witnesses: replica7\n",
+		"witnesses: replica0 replica1 replica2\n",
+		"witnesses: replica2\n-- Clients --\nclient0 127.0.1.1\n-- Proxy --\nserver_alias replica2\nclient0 (local)\n---\n",
+	} {
+		if err := os.WriteFile(f.Name(), []byte(replicas+bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Read(f.Name(), "test"); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestMultipleMasters(t *testing.T) {
+	content := `
+-- Replicas --
+replica0 127.0.0.1
+
+-- Master --
+master0 10.0.2.1
+master1 10.0.2.2
+master2 10.0.2.3
+
+masterPort: 7087
+`
+	f, err := os.CreateTemp("", "test_config_*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	c, err := Read(f.Name(), "master1")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if c.MasterAlias != "master0" || c.MasterAddr != "10.0.2.1" {
+		t.Errorf("first master = %s %s, want master0 10.0.2.1", c.MasterAlias, c.MasterAddr)
+	}
+	l := c.MasterList()
+	if len(l) != 3 || l[0] != "10.0.2.1:7087" || l[2] != "10.0.2.3:7087" {
+		t.Errorf("MasterList = %v", l)
+	}
+	if i := c.MasterIndex("master1"); i != 1 {
+		t.Errorf("MasterIndex(master1) = %d, want 1", i)
+	}
+	if i := c.MasterIndex("client0"); i != 0 {
+		t.Errorf("MasterIndex(client0) = %d, want 0", i)
+	}
+}
+
+func TestExplicitPorts(t *testing.T) {
+	content := `
+-- Replicas --
+alpha 10.0.0.1:7100 9100
+beta 10.0.0.1:7101
+gamma 10.0.0.2
+
+-- Clients --
+client0 10.0.1.1:6000
+
+-- Master --
+master0 10.0.2.1:7300
+master1 10.0.2.2
+
+masterPort: 7087
+`
+	f, err := os.CreateTemp("", "test_config_*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if err := os.WriteFile(f.Name(), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Read(f.Name(), "beta")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if c.ReplicaAddrs["alpha"] != "10.0.0.1" || c.ClientAddrs["client0"] != "10.0.1.1" {
+		t.Errorf("addresses %v %v, want the hosts", c.ReplicaAddrs, c.ClientAddrs)
+	}
+	// aliases without numbers are numbered in the order of the section
+	for i, alias := range []string{"alpha", "beta", "gamma"} {
+		if idx := c.ReplicaIndex(alias); idx != i {
+			t.Errorf("ReplicaIndex(%s) = %d, want %d", alias, idx, i)
+		}
+	}
+	for _, p := range []struct {
+		alias     string
+		port, rpc int
+	}{
+		{"alpha", 7100, 9100},
+		{"beta", 7101, 8101},
+		{"gamma", 7072, 8072},
+	} {
+		if port, rpc := c.ReplicaPort(p.alias), c.ReplicaRPCPort(p.alias); port != p.port || rpc != p.rpc {
+			t.Errorf("%s: ports %d %d, want %d %d", p.alias, port, rpc, p.port, p.rpc)
+		}
+	}
+	if l := c.MasterList(); len(l) != 2 || l[0] != "10.0.2.1:7300" || l[1] != "10.0.2.2:7087" {
+		t.Errorf("MasterList = %v", l)
+	}
+	if p := c.MasterPortOf("master0"); p != 7300 {
+		t.Errorf("MasterPortOf(master0) = %d, want 7300", p)
+	}
+
+	for _, bad := range []string{
+		"-- Replicas --\nreplica0 10.0.0.1:70000\n",
+		"-- Replicas --\nreplica0 10.0.0.1:7070 rpc\n",
+		"-- Master --\nmaster0 10.0.2.1:7087 8087\n",
+	} {
+		if err := os.WriteFile(f.Name(), []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Read(f.Name(), "test"); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestReplicaIndexFromAlias(t *testing.T) {
+	c := &Config{ReplicaAliases: []string{"replica2", "replica0", "replica1"}}
+	if i := c.ReplicaIndex("replica2"); i != 2 {
+		t.Errorf("ReplicaIndex(replica2) = %d, want 2", i)
+	}
+	if p := c.ReplicaPort("replica2"); p != 7072 {
+		t.Errorf("ReplicaPort(replica2) = %d, want 7072", p)
+	}
+	// numbers that do not index the replicas
+	c.ReplicaAliases = []string{"replica1", "replica5"}
+	if i := c.ReplicaIndex("replica5"); i != 1 {
+		t.Errorf("ReplicaIndex(replica5) = %d, want 1", i)
+	}
+}
+
+// readTemp reads the config content from a temporary file whose name ends
+// with ext.
+func readTemp(t *testing.T, ext, content, alias string) (*Config, error) {
+	f, err := os.CreateTemp("", "test_config_*"+ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	return Read(f.Name(), alias)
+}
+
+func TestStrictConfig(t *testing.T) {
+	c, err := readTemp(t, ".conf", `
+-- Replicas --
+Alpha 10.0.0.1 # the first replica
+-- Apply to Alpha --
+Protocol: CurpHT
+WaitClosest: True
+`, "Alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := c.ReplicaAddrs["Alpha"]; !exists || c.Protocol != "curpht" || !c.WaitClosest {
+		t.Errorf("replicas %v, protocol %q, waitClosest %v", c.ReplicaAddrs, c.Protocol, c.WaitClosest)
+	}
+
+	for _, bad := range []struct {
+		content string
+		line    int
+	}{
+		{"reqs 10\nreqz 20\n", 2},
+		{"-- Replicas --\nr0 10.0.0.1\nreqz: 20\n", 3},
+		{"-- Replica --\n", 1},
+		{"\nweakRatio 101\n", 2},
+		{"hotOps -1\n", 1},
+		{"reqs 10 20\n", 1},
+		{"runTime -5s\n", 1},
+		{"-- Replicas --\nr0 10.0.0.1\nr0 10.0.0.2\n", 3},
+		{"-- Replicas --\nr0 10.0.0.1\nmembers r0 r1\n", 3},
+		{"-- Apply to nobody --\nreqs 10\n", 1},
+		// the keys of other participants are checked too
+		{"-- Apply to c0 --\nreqz 10\n-- Clients --\nc0 10.0.1.1\n", 2},
+		{"-- Replicas --\nr0 10.0.0.1\n-- Proxy --\nserver_alias r0\nc0\n---\n", 5},
+		{"-- Proxy --\nserver_alias r0\n---\n", 2},
+		{"-- Proxy --\nc0\n", 2},
+		{"-- Replicas --\nr0 10.0.0.1\n-- Clients --\nc0 10.0.1.1\n-- Proxy --\nserver_alias r0\nc0 10.0.1.1\n---\n", 7},
+	} {
+		_, err := readTemp(t, ".conf", bad.content, "test")
+		if err == nil {
+			t.Errorf("%q accepted", bad.content)
+		} else if want := fmt.Sprintf("line %d: ", bad.line); !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%q: error %q, want it at line %d", bad.content, err, bad.line)
+		}
+	}
+}
+
+func TestJSONConfig(t *testing.T) {
+	c, err := readTemp(t, ".json", `{
+	"protocol": "Raft",
+	"reqs": 100,
+	"runTime": "10s",
+	"members": ["replica0", "replica1"],
+	"replicas": [
+		{"alias": "replica0", "addr": "10.0.0.1:7100", "rpcPort": 9100},
+		{"alias": "replica1", "addr": "10.0.0.2"},
+		{"alias": "replica2", "addr": "10.0.0.3"}
+	],
+	"clients": [{"alias": "client0", "addr": "10.0.1.1"}],
+	"master": [{"alias": "master0", "addr": "10.0.2.1:7087"}],
+	"proxy": [{"server": "replica1", "local": ["client0"]}],
+	"apply": {"client0": {"waitClosest": true}}
+}`, "client0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Protocol != "raft" || c.Reqs != 100 || c.RunTime != 10*time.Second || !c.WaitClosest {
+		t.Errorf("protocol %q, reqs %d, runTime %v, waitClosest %v", c.Protocol, c.Reqs, c.RunTime, c.WaitClosest)
+	}
+	if len(c.ReplicaAliases) != 3 || c.ReplicaPort("replica0") != 7100 || c.ReplicaRPCPort("replica0") != 9100 {
+		t.Errorf("replicas %v, ports %v %v", c.ReplicaAliases, c.ReplicaPorts, c.ReplicaRPCPorts)
+	}
+	if members := c.InitialMembers(3); !members[1] || members[2] {
+		t.Errorf("members %v", members)
+	}
+	if !c.Proxy.IsLocal("replica1", "10.0.1.1") || c.Proxy.ProxyOf("10.0.1.1") != "replica1" {
+		t.Errorf("client0 is not local to replica1")
+	}
+	if l := c.MasterList(); len(l) != 1 || l[0] != "10.0.2.1:7087" {
+		t.Errorf("MasterList = %v", l)
+	}
+
+	for _, bad := range []struct {
+		content string
+		line    int
+	}{
+		{"{\n\"reqs\": 1,\n\"reqz\": 2\n}", 3},
+		{"{\n\"writes\": 200\n}", 2},
+		{"{\n\"reqs\": 1,\n\"writes\" 2\n}", 3},
+		{"{\n\"replicas\": [\n{\"alias\": \"r0\", \"host\": \"10.0.0.1\"}\n]\n}", 2},
+		{"{\n\"members\": [\"r0\"]\n}", 2},
+		{"{\n\"apply\": {\"c0\": {\"reqs\": 1}}\n}", 2},
+	} {
+		_, err := readTemp(t, ".json", bad.content, "test")
+		if err == nil {
+			t.Errorf("%q accepted", bad.content)
+		} else if want := fmt.Sprintf("line %d: ", bad.line); !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%q: error %q, want it at line %d", bad.content, err, bad.line)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// readJSON reads a config in JSON: an object with the keys of the text
+// format, e.g. "reqs": 1000 or "members": ["replica0", "replica1"], and
+//
+//	"replicas": [{"alias": "replica0", "addr": "10.0.0.1:7070", "rpcPort": 8070}, ...],
+//	"clients":  [{"alias": "client0", "addr": "10.0.1.1"}, ...],
+//	"master":   [{"alias": "master0", "addr": "10.0.2.1:7087"}, ...],
+//	"proxy":    [{"server": "replica0", "clients": ["client1"], "local": ["client0"]}, ...],
+//	"apply":    {"client0": {"waitClosest": true}, ...}
+//
+// for the sections of the text format.
+func (r *reader) readJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	err := r.jsonObject(dec, data, func(key string) error {
+		switch section := strings.ToLower(key); section {
+		case "replicas", "clients", "master":
+			var ps []struct {
+				Alias   string
+				Addr    string
+				RPCPort int `json:"rpcPort"`
+			}
+			if err := dec.Decode(&ps); err != nil {
+				return err
+			}
+			for _, p := range ps {
+				ws := []string{p.Alias, p.Addr}
+				if p.RPCPort != 0 {
+					ws = append(ws, strconv.Itoa(p.RPCPort))
+				}
+				if err := r.participant(section, ws); err != nil {
+					return err
+				}
+			}
+			return nil
+		case "proxy":
+			var ps []struct {
+				Server  string
+				Clients []string
+				Local   []string
+			}
+			if err := dec.Decode(&ps); err != nil {
+				return err
+			}
+			r.c.Proxy = newProxyInfo()
+			for _, p := range ps {
+				r.proxies = append(r.proxies, proxyLine{server: p.Server, line: r.line})
+				for _, c := range p.Clients {
+					r.proxies = append(r.proxies, proxyLine{p.Server, c, false, r.line})
+				}
+				for _, c := range p.Local {
+					r.proxies = append(r.proxies, proxyLine{p.Server, c, true, r.line})
+				}
+			}
+			return nil
+		case "apply":
+			return r.jsonObject(dec, data, func(alias string) error {
+				r.applies = append(r.applies, aliasLine{alias, r.line})
+				r.apply = alias == r.alias
+				defer func() { r.apply = true }()
+				return r.jsonObject(dec, data, func(key string) error {
+					return r.setJSON(dec, key)
+				})
+			})
+		default:
+			return r.setJSON(dec, key)
+		}
+	})
+	if err != nil {
+		return r.jsonError(data, err)
+	}
+	if _, err := dec.Token(); err == nil {
+		return atLine(lineAt(data, dec.InputOffset()), Err("", "unexpected data after the config"))
+	}
+	return nil
+}
+
+// jsonObject reads an object from dec, calling f with each of its keys,
+// when the value of the key is next in dec.
+func (r *reader) jsonObject(dec *json.Decoder, data []byte, f func(key string) error) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if t != json.Delim('{') {
+		return atLine(lineAt(data, dec.InputOffset()), Err("", fmt.Sprintf("expecting an object, not %v", t)))
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		r.line = lineAt(data, dec.InputOffset())
+		if err := f(t.(string)); err != nil {
+			return r.jsonError(data, err)
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// setJSON sets the key to its value, read from dec: a string, a number, a
+// boolean or a list of them.
+func (r *reader) setJSON(dec *json.Decoder, key string) error {
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	ws := []string{key}
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			ws = append(ws, fmt.Sprint(e))
+		}
+	case string, json.Number, bool:
+		ws = append(ws, fmt.Sprint(v))
+	default:
+		return Err(key, "Invalid argument")
+	}
+	ok, err := r.set(ws)
+	if !ok {
+		return Err("", "unknown key "+key)
+	}
+	return err
+}
+
+// jsonError returns err as the error of the line of its offset in data, if
+// it is a syntax error, or else of the line being read.
+func (r *reader) jsonError(data []byte, err error) error {
+	var serr *json.SyntaxError
+	if errors.As(err, &serr) {
+		return atLine(lineAt(data, serr.Offset), err)
+	}
+	return atLine(r.line, err)
+}
+
+// lineAt returns the line of data at offset.
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -1,10 +1,5 @@
 package config
 
-import (
-	"bufio"
-	"strings"
-)
-
 type ProxyInfo struct {
 	// server alias -> client addrs
 	locals map[string]map[string]struct{}
@@ -16,38 +11,31 @@ type ProxyInfo struct {
 
 const REMOTE = "none"
 
-func ReadProxyInfo(c *Config, s *bufio.Scanner, end string) *ProxyInfo {
-	p := &ProxyInfo{
+func newProxyInfo() *ProxyInfo {
+	return &ProxyInfo{
 		locals:  make(map[string]map[string]struct{}),
 		proxies: make(map[string]map[string]struct{}),
 		servers: make(map[string]string),
 	}
+}
 
-	alias := ""
-	for s.Scan() {
-		fs := strings.Fields(s.Text())
-		if l := len(fs); l < 1 {
-			continue
-		} else if l > 1 {
-			if fs[0] == "server_alias" {
-				alias = fs[1]
-				p.locals[alias] = make(map[string]struct{})
-				p.proxies[alias] = make(map[string]struct{})
-			} else if strings.Contains(fs[1], "local") {
-				addr := c.ClientAddrs[fs[0]]
-				p.locals[alias][addr] = struct{}{}
-				p.proxies[alias][addr] = struct{}{}
-				p.servers[addr] = alias
-			}
-		} else if l == 1 {
-			if fs[0] == end {
-				return p
-			}
-			p.proxies[alias][c.ClientAddrs[fs[0]]] = struct{}{}
-		}
+// addServer adds the server alias, with no clients yet.
+func (p *ProxyInfo) addServer(alias string) {
+	if _, exists := p.proxies[alias]; !exists {
+		p.locals[alias] = make(map[string]struct{})
+		p.proxies[alias] = make(map[string]struct{})
 	}
+}
 
-	return p
+// add makes the server alias the proxy of the client of addr, local to it
+// if local.
+func (p *ProxyInfo) add(alias, addr string, local bool) {
+	p.addServer(alias)
+	if local {
+		p.locals[alias][addr] = struct{}{}
+		p.servers[addr] = alias
+	}
+	p.proxies[alias][addr] = struct{}{}
 }
 
 func (p *ProxyInfo) IsProxy(serverAlias, clientAddr string) bool {
@@ -20,7 +20,7 @@ type cacheEntry struct {
 
 // sendRequest represents a message to be sent asynchronously via a remoteSendQueue.
 type sendRequest struct {
-	code uint8
+	code fastrpc.Code
 	msg  fastrpc.Serializable
 }
 
@@ -106,7 +106,7 @@ func NewClient(b *client.BufferClient, repNum, reqNum, pclients int) *Client {
 		N:   repNum,
 		t:   NewTimer(),
 		Q:   replica.NewThreeQuartersOf(repNum),
-		M:   replica.NewMajorityOf(repNum),
+		M:   replica.NewMajorityOf(repNum - b.NumWitnesses()),
 		num: num,
 		val: nil,
 
@@ -124,8 +124,9 @@ func NewClient(b *client.BufferClient, repNum, reqNum, pclients int) *Client {
 		writeSet:    make(map[CommandId]struct{}),
 
 		// CURP-HO: Bind to closest replica for 1-RTT causal op completion.
-		// ClosestId is computed by base client during Connect() via ping latency measurement.
-		boundReplica: int32(b.ClosestId),
+		// ReadId is computed by base client during Connect() via ping latency measurement,
+		// and is ClosestId unless ClosestId is a witness, which cannot reply.
+		boundReplica: int32(b.ReadId),
 
 		// Client local cache
 		localCache:        make(map[int64]cacheEntry),
@@ -142,7 +143,7 @@ func NewClient(b *client.BufferClient, repNum, reqNum, pclients int) *Client {
 		SeqNum:   0,
 	}
 
-	t := fastrpc.NewTableId(defs.RPC_TABLE)
+	t := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	initCs(&c.cs, t)
 	c.RegisterRPCTable(t)
 
@@ -169,8 +170,7 @@ func NewClient(b *client.BufferClient, repNum, reqNum, pclients int) *Client {
 	go c.handleWeakMsgs()
 
 	// Start MSync retry timer: periodically retransmit MSync for pending
-	// strong commands whose replies may have been dropped by the non-blocking
-	// SendClientMsgFast on the replica side.
+	// strong commands whose replies may have been lost with a connection.
 	c.t.Start(2 * time.Second)
 
 	return c
@@ -212,8 +212,8 @@ func (c *Client) handleStrongMsgs() {
 			c.handleSyncReply(rep)
 
 		case <-c.t.c:
-			// Retry pending commands whose replies may have been dropped
-			// by the non-blocking SendClientMsgFast, or whose delivery
+			// Retry pending commands whose replies may have been lost
+			// with a connection, or whose delivery
 			// is stuck in slot ordering on the proxy.
 			c.mu.Lock()
 			var syncSeqnums []int32    // commands recoverable via MSync
@@ -529,8 +529,7 @@ func (c *Client) sendProposeSafe(rid int32, p *defs.Propose) {
 	c.writerMu[rid].Lock()
 	w := c.BufferClient.GetWriter(rid)
 	if w != nil {
-		w.WriteByte(defs.PROPOSE)
-		p.Marshal(w)
+		fastrpc.WriteFrame(w, fastrpc.Code(defs.PROPOSE), p)
 		w.Flush()
 	}
 	c.writerMu[rid].Unlock()
@@ -601,7 +600,7 @@ func (c *Client) SendWeakScan(key int64, count int64) int32 {
 	c.mu.Lock()
 	c.weakPending[seqnum] = struct{}{}
 	c.weakPendingKeys[seqnum] = key
-	closest := c.ClosestId
+	closest := c.ReadId
 	c.mu.Unlock()
 
 	msg := &MWeakRead{
@@ -659,7 +658,7 @@ func (c *Client) SendCausalRead(key int64) int32 {
 	c.mu.Lock()
 	c.weakPending[seqnum] = struct{}{}
 	c.weakPendingKeys[seqnum] = key
-	closest := c.ClosestId
+	closest := c.ReadId
 	c.mu.Unlock()
 
 	// Weak reads go to nearest replica only (not broadcast)
@@ -757,7 +756,7 @@ func (c *Client) remoteSender(rid int32) {
 // sendMsgSafe sends a message to a single replica under the per-replica writer mutex.
 // This prevents concurrent writes to the same bufio.Writer from different goroutines
 // (e.g., timer retry sends in handleStrongMsgs racing with main-thread sends).
-func (c *Client) sendMsgSafe(rid int32, code uint8, msg fastrpc.Serializable) {
+func (c *Client) sendMsgSafe(rid int32, code fastrpc.Code, msg fastrpc.Serializable) {
 	c.writerMu[rid].Lock()
 	c.SendMsg(rid, code, msg)
 	c.writerMu[rid].Unlock()
@@ -774,7 +773,7 @@ func (c *Client) sendMsgSafe(rid int32, code uint8, msg fastrpc.Serializable) {
 // strong commands because SendStrongWrite/Read acquire writerMu[leader], which
 // serializes against the remoteSender goroutine. The FIFO queue preserves
 // per-replica message ordering.
-func (c *Client) sendMsgToAll(code uint8, msg fastrpc.Serializable) {
+func (c *Client) sendMsgToAll(code fastrpc.Code, msg fastrpc.Serializable) {
 	c.sendMsgSafe(c.boundReplica, code, msg)
 	for i := 0; i < c.N; i++ {
 		if int32(i) != c.boundReplica {
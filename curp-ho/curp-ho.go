@@ -26,6 +26,8 @@ type Replica struct {
 	contactClients bool
 
 	Q replica.Majority
+	// witnesses are the replicas that are only witnesses (see witness.go)
+	witnesses []int32
 
 	isLeader    bool
 	lastCmdSlot int
@@ -124,6 +126,7 @@ type commandDesc struct {
 	seq    bool
 
 	accepted    bool
+	slowPath    bool // the leader told the client to wait for the commit
 	pendingCall func()
 
 	isWeak  bool // Mark if this is a weak command
@@ -197,7 +200,12 @@ func New(alias string, rid int, addrs []string, exec bool, pl, f int,
 		},
 	}
 
-	r.Q = replica.NewMajorityOf(r.N)
+	for i, w := range conf.WitnessList(r.N) {
+		if w {
+			r.witnesses = append(r.witnesses, int32(i))
+		}
+	}
+	r.Q = replica.NewMajorityOf(r.N - len(r.witnesses))
 	r.sender = replica.NewSender(r.Replica)
 	r.batcher = NewBatcher(r, 128) // Increased from 8 for better batching
 
@@ -229,6 +237,9 @@ func New(alias string, rid int, addrs []string, exec bool, pl, f int,
 
 	initCs(&r.cs, r.RPC)
 
+	// strong commands committed by the leader on the fast and the slow path
+	r.Stats.M["fast"], r.Stats.M["slow"] = 0, 0
+
 	hook.HookUser1(func() {
 		totalNum := 0
 		for i := 0; i < HISTORY_SIZE; i++ {
@@ -591,6 +602,9 @@ func getAcksHandler(r *Replica, desc *commandDesc) replica.MsgSetHandler {
 			CmdSlot: desc.cmdSlot,
 		}
 		if r.optimized {
+			if r.isLeader {
+				r.commitToWitnesses(commit)
+			}
 			r.handleCommit(commit, desc)
 		} else if r.isLeader {
 			r.sender.SendToAll(commit, r.cs.commitRPC)
@@ -599,6 +613,14 @@ func getAcksHandler(r *Replica, desc *commandDesc) replica.MsgSetHandler {
 	}
 }
 
+// commitToWitnesses sends commit to the witnesses, which then forget the
+// command; the backups of the optimized protocol commit on their own.
+func (r *Replica) commitToWitnesses(commit *MCommit) {
+	for _, w := range r.witnesses {
+		r.sender.SendTo(w, commit, r.cs.commitRPC)
+	}
+}
+
 func (r *Replica) handleCommit(msg *MCommit, desc *commandDesc) {
 	slotStr := strconv.Itoa(msg.CmdSlot)
 	if r.delivered.Has(slotStr) {
@@ -1012,6 +1034,7 @@ func (r *Replica) deliver(desc *commandDesc, slot int) {
 			} else {
 				rep.Ok = TRUE
 			}
+			desc.slowPath = rep.Ok == FALSE
 			// Always send reply to client so they can complete via macks quorum
 			// even when rep.Ok == FALSE (pending dependency). Without this,
 			// the client hangs waiting for a leader reply that never comes.
@@ -1052,6 +1075,15 @@ func (r *Replica) deliver(desc *commandDesc, slot int) {
 		}
 
 		if desc.phase == COMMIT {
+			if r.isLeader && desc.propose != nil && !desc.isWeak {
+				r.M.Lock()
+				if desc.slowPath {
+					r.Stats.M["slow"]++
+				} else {
+					r.Stats.M["fast"]++
+				}
+				r.M.Unlock()
+			}
 			// Sync reply is only for strong commands (which have desc.propose set)
 			// Weak commands are handled separately
 			if !r.contactClients && desc.propose != nil {
@@ -1147,6 +1179,7 @@ func (r *Replica) newDesc() *commandDesc {
 	desc.successorL = sync.Mutex{}
 	desc.accepted = false
 	desc.applied = false
+	desc.slowPath = false
 
 	desc.afterPayload = desc.afterPayload.ReinitCondF(func() bool {
 		// For weak commands on non-leaders, desc.cmd is set from the Accept message
@@ -3441,7 +3441,7 @@ func TestMRecordAckSerializationWithWeakDep(t *testing.T) {
 }
 
 func TestMRecordAckSerializationSizes(t *testing.T) {
-	// Without ReadDep, no CausalDeps: 20 bytes (17 fixed + 1 flag + 2 count)
+	// Without ReadDep, no CausalDeps: 19 bytes (17 fixed + 1 flag + 1 count)
 	noWeak := &MRecordAck{
 		Replica: 1,
 		Ballot:  1,
@@ -3451,11 +3451,11 @@ func TestMRecordAckSerializationSizes(t *testing.T) {
 	}
 	var buf1 bytes.Buffer
 	noWeak.Marshal(&buf1)
-	if buf1.Len() != 20 {
-		t.Errorf("Size without ReadDep = %d, want 20", buf1.Len())
+	if buf1.Len() != 19 {
+		t.Errorf("Size without ReadDep = %d, want 19", buf1.Len())
 	}
 
-	// With ReadDep, no CausalDeps: 28 bytes (17 fixed + 1 flag + 8 CommandId + 2 count)
+	// With ReadDep, no CausalDeps: 27 bytes (17 fixed + 1 flag + 8 CommandId + 1 count)
 	withWeak := &MRecordAck{
 		Replica: 1,
 		Ballot:  1,
@@ -3465,11 +3465,11 @@ func TestMRecordAckSerializationSizes(t *testing.T) {
 	}
 	var buf2 bytes.Buffer
 	withWeak.Marshal(&buf2)
-	if buf2.Len() != 28 {
-		t.Errorf("Size with ReadDep = %d, want 28", buf2.Len())
+	if buf2.Len() != 27 {
+		t.Errorf("Size with ReadDep = %d, want 27", buf2.Len())
 	}
 
-	// With ReadDep + 2 CausalDeps: 44 bytes (17 + 1 + 8 + 2 + 2*8)
+	// With ReadDep + 2 CausalDeps: 43 bytes (17 + 1 + 8 + 1 + 2*8)
 	withCausal := &MRecordAck{
 		Replica:    1,
 		Ballot:     1,
@@ -3480,8 +3480,8 @@ func TestMRecordAckSerializationSizes(t *testing.T) {
 	}
 	var buf3 bytes.Buffer
 	withCausal.Marshal(&buf3)
-	if buf3.Len() != 44 {
-		t.Errorf("Size with ReadDep + 2 CausalDeps = %d, want 44", buf3.Len())
+	if buf3.Len() != 43 {
+		t.Errorf("Size with ReadDep + 2 CausalDeps = %d, want 43", buf3.Len())
 	}
 }
 
@@ -4993,13 +4993,13 @@ func TestRemoteSendQueueFIFOOrdering(t *testing.T) {
 	// Enqueue 3 messages to replica 1's queue
 	for seq := int32(1); seq <= 3; seq++ {
 		msg := &MCausalPropose{CommandId: seq, ClientId: 100}
-		c.remoteSendQueues[1] <- sendRequest{code: uint8(seq), msg: msg}
+		c.remoteSendQueues[1] <- sendRequest{code: fastrpc.Code(seq), msg: msg}
 	}
 
 	// Dequeue and verify FIFO order
 	for seq := int32(1); seq <= 3; seq++ {
 		req := <-c.remoteSendQueues[1]
-		if req.code != uint8(seq) {
+		if req.code != fastrpc.Code(seq) {
 			t.Errorf("dequeued code = %d, want %d (FIFO violated)", req.code, seq)
 		}
 	}
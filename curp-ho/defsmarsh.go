@@ -0,0 +1,1089 @@
+// Code generated by marshalgen; DO NOT EDIT.
+
+package curpho
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+func (t *CommandId) New() fastrpc.Serializable {
+	return new(CommandId)
+}
+
+func (t *CommandId) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type CommandIdCache struct {
+	mu    sync.Mutex
+	cache []*CommandId
+}
+
+func NewCommandIdCache() *CommandIdCache {
+	c := &CommandIdCache{}
+	c.cache = make([]*CommandId, 0)
+	return c
+}
+
+func (p *CommandIdCache) Get() *CommandId {
+	var t *CommandId
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &CommandId{}
+	}
+	return t
+}
+
+func (p *CommandIdCache) Put(t *CommandId) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *CommandId) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.SeqNum))
+	wire.Write(b[:8])
+}
+
+func (t *CommandId) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *MReply) New() fastrpc.Serializable {
+	return new(MReply)
+}
+
+func (t *MReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MReplyCache struct {
+	mu    sync.Mutex
+	cache []*MReply
+}
+
+func NewMReplyCache() *MReplyCache {
+	c := &MReplyCache{}
+	c.cache = make([]*MReply, 0)
+	return c
+}
+
+func (p *MReplyCache) Get() *MReply {
+	var t *MReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MReply{}
+	}
+	return t
+}
+
+func (p *MReplyCache) Put(t *MReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MReply) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Rep)))])
+	wire.Write(t.Rep)
+	b[0] = byte(t.Ok)
+	wire.Write(b[:1])
+}
+
+func (t *MReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Rep = make([]byte, n1)
+	if _, err := io.ReadFull(wire, t.Rep); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:1]); err != nil {
+		return err
+	}
+	t.Ok = b[0]
+	return nil
+}
+
+func (t *MAccept) New() fastrpc.Serializable {
+	return new(MAccept)
+}
+
+func (t *MAccept) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MAcceptCache struct {
+	mu    sync.Mutex
+	cache []*MAccept
+}
+
+func NewMAcceptCache() *MAcceptCache {
+	c := &MAcceptCache{}
+	c.cache = make([]*MAccept, 0)
+	return c
+}
+
+func (p *MAcceptCache) Get() *MAccept {
+	var t *MAccept
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MAccept{}
+	}
+	return t
+}
+
+func (p *MAcceptCache) Put(t *MAccept) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MAccept) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+	t.Cmd.Marshal(wire)
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.CmdId.SeqNum))
+	binary.LittleEndian.PutUint64(b[8:], uint64(t.CmdSlot))
+	wire.Write(b[:16])
+}
+
+func (t *MAccept) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	if err := t.Cmd.Unmarshal(wire); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (t *MAcceptAck) New() fastrpc.Serializable {
+	return new(MAcceptAck)
+}
+
+func (t *MAcceptAck) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type MAcceptAckCache struct {
+	mu    sync.Mutex
+	cache []*MAcceptAck
+}
+
+func NewMAcceptAckCache() *MAcceptAckCache {
+	c := &MAcceptAckCache{}
+	c.cache = make([]*MAcceptAck, 0)
+	return c
+}
+
+func (p *MAcceptAckCache) Get() *MAcceptAck {
+	var t *MAcceptAck
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MAcceptAck{}
+	}
+	return t
+}
+
+func (p *MAcceptAckCache) Put(t *MAcceptAck) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MAcceptAck) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint64(b[8:], uint64(t.CmdSlot))
+	wire.Write(b[:16])
+}
+
+func (t *MAcceptAck) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (t *MAAcks) New() fastrpc.Serializable {
+	return new(MAAcks)
+}
+
+func (t *MAAcks) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MAAcksCache struct {
+	mu    sync.Mutex
+	cache []*MAAcks
+}
+
+func NewMAAcksCache() *MAAcksCache {
+	c := &MAAcksCache{}
+	c.cache = make([]*MAAcks, 0)
+	return c
+}
+
+func (p *MAAcksCache) Get() *MAAcks {
+	var t *MAAcks
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MAAcks{}
+	}
+	return t
+}
+
+func (p *MAAcksCache) Put(t *MAAcks) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MAAcks) Marshal(wire io.Writer) {
+	var b [16]byte
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Acks)))])
+	for i := range t.Acks {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Acks[i].Replica))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.Acks[i].Ballot))
+		binary.LittleEndian.PutUint64(b[8:], uint64(t.Acks[i].CmdSlot))
+		wire.Write(b[:16])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Accepts)))])
+	for i := range t.Accepts {
+		t.Accepts[i].Marshal(wire)
+	}
+}
+
+func (t *MAAcks) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Acks = make([]MAcceptAck, n1)
+	for i := range t.Acks {
+		if _, err := io.ReadFull(wire, b[:16]); err != nil {
+			return err
+		}
+		t.Acks[i].Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.Acks[i].Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+		t.Acks[i].CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Accepts = make([]MAccept, n2)
+	for i := range t.Accepts {
+		if err := t.Accepts[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *MRecordAck) New() fastrpc.Serializable {
+	return new(MRecordAck)
+}
+
+func (t *MRecordAck) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MRecordAckCache struct {
+	mu    sync.Mutex
+	cache []*MRecordAck
+}
+
+func NewMRecordAckCache() *MRecordAckCache {
+	c := &MRecordAckCache{}
+	c.cache = make([]*MRecordAck, 0)
+	return c
+}
+
+func (p *MRecordAckCache) Get() *MRecordAck {
+	var t *MRecordAck
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MRecordAck{}
+	}
+	return t
+}
+
+func (p *MRecordAckCache) Put(t *MRecordAck) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MRecordAck) Marshal(wire io.Writer) {
+	var b [17]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	b[16] = byte(t.Ok)
+	wire.Write(b[:17])
+	if t.ReadDep == nil {
+		b[0] = 0
+		wire.Write(b[:1])
+	} else {
+		b[0] = 1
+		wire.Write(b[:1])
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.ReadDep.ClientId))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.ReadDep.SeqNum))
+		wire.Write(b[:8])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.CausalDeps)))])
+	for i := range t.CausalDeps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.CausalDeps[i].ClientId))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.CausalDeps[i].SeqNum))
+		wire.Write(b[:8])
+	}
+}
+
+func (t *MRecordAck) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [17]byte
+	if _, err := io.ReadFull(wire, b[:17]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.Ok = b[16]
+	if _, err := io.ReadFull(wire, b[:1]); err != nil {
+		return err
+	}
+	if b[0] == 0 {
+		t.ReadDep = nil
+	} else {
+		t.ReadDep = new(CommandId)
+		if _, err := io.ReadFull(wire, b[:8]); err != nil {
+			return err
+		}
+		t.ReadDep.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.ReadDep.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	}
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.CausalDeps = make([]CommandId, n1)
+	for i := range t.CausalDeps {
+		if _, err := io.ReadFull(wire, b[:8]); err != nil {
+			return err
+		}
+		t.CausalDeps[i].ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.CausalDeps[i].SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	}
+	return nil
+}
+
+func (t *MCommit) New() fastrpc.Serializable {
+	return new(MCommit)
+}
+
+func (t *MCommit) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type MCommitCache struct {
+	mu    sync.Mutex
+	cache []*MCommit
+}
+
+func NewMCommitCache() *MCommitCache {
+	c := &MCommitCache{}
+	c.cache = make([]*MCommit, 0)
+	return c
+}
+
+func (p *MCommitCache) Get() *MCommit {
+	var t *MCommit
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MCommit{}
+	}
+	return t
+}
+
+func (p *MCommitCache) Put(t *MCommit) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MCommit) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint64(b[8:], uint64(t.CmdSlot))
+	wire.Write(b[:16])
+}
+
+func (t *MCommit) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (t *MSync) New() fastrpc.Serializable {
+	return new(MSync)
+}
+
+func (t *MSync) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type MSyncCache struct {
+	mu    sync.Mutex
+	cache []*MSync
+}
+
+func NewMSyncCache() *MSyncCache {
+	c := &MSyncCache{}
+	c.cache = make([]*MSync, 0)
+	return c
+}
+
+func (p *MSyncCache) Get() *MSync {
+	var t *MSync
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MSync{}
+	}
+	return t
+}
+
+func (p *MSyncCache) Put(t *MSync) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MSync) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:8])
+}
+
+func (t *MSync) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *MSyncReply) New() fastrpc.Serializable {
+	return new(MSyncReply)
+}
+
+func (t *MSyncReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MSyncReplyCache struct {
+	mu    sync.Mutex
+	cache []*MSyncReply
+}
+
+func NewMSyncReplyCache() *MSyncReplyCache {
+	c := &MSyncReplyCache{}
+	c.cache = make([]*MSyncReply, 0)
+	return c
+}
+
+func (p *MSyncReplyCache) Get() *MSyncReply {
+	var t *MSyncReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MSyncReply{}
+	}
+	return t
+}
+
+func (p *MSyncReplyCache) Put(t *MSyncReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MSyncReply) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Rep)))])
+	wire.Write(t.Rep)
+}
+
+func (t *MSyncReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Rep = make([]byte, n1)
+	if _, err := io.ReadFull(wire, t.Rep); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *MWeakPropose) New() fastrpc.Serializable {
+	return new(MWeakPropose)
+}
+
+func (t *MWeakPropose) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MWeakProposeCache struct {
+	mu    sync.Mutex
+	cache []*MWeakPropose
+}
+
+func NewMWeakProposeCache() *MWeakProposeCache {
+	c := &MWeakProposeCache{}
+	c.cache = make([]*MWeakPropose, 0)
+	return c
+}
+
+func (p *MWeakProposeCache) Get() *MWeakPropose {
+	var t *MWeakPropose
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MWeakPropose{}
+	}
+	return t
+}
+
+func (p *MWeakProposeCache) Put(t *MWeakPropose) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MWeakPropose) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CommandId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.ClientId))
+	wire.Write(b[:8])
+	t.Command.Marshal(wire)
+	binary.LittleEndian.PutUint64(b[0:], uint64(t.Timestamp))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CausalDep))
+	wire.Write(b[:12])
+}
+
+func (t *MWeakPropose) Unmarshal(wire io.Reader) error {
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.CommandId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.ClientId = int32(binary.LittleEndian.Uint32(b[4:]))
+	if err := t.Command.Unmarshal(wire); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.Timestamp = int64(binary.LittleEndian.Uint64(b[0:]))
+	t.CausalDep = int32(binary.LittleEndian.Uint32(b[8:]))
+	return nil
+}
+
+func (t *MWeakReply) New() fastrpc.Serializable {
+	return new(MWeakReply)
+}
+
+func (t *MWeakReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MWeakReplyCache struct {
+	mu    sync.Mutex
+	cache []*MWeakReply
+}
+
+func NewMWeakReplyCache() *MWeakReplyCache {
+	c := &MWeakReplyCache{}
+	c.cache = make([]*MWeakReply, 0)
+	return c
+}
+
+func (p *MWeakReplyCache) Get() *MWeakReply {
+	var t *MWeakReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MWeakReply{}
+	}
+	return t
+}
+
+func (p *MWeakReplyCache) Put(t *MWeakReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MWeakReply) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Rep)))])
+	wire.Write(t.Rep)
+}
+
+func (t *MWeakReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Rep = make([]byte, n1)
+	if _, err := io.ReadFull(wire, t.Rep); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *MCausalPropose) New() fastrpc.Serializable {
+	return new(MCausalPropose)
+}
+
+func (t *MCausalPropose) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MCausalProposeCache struct {
+	mu    sync.Mutex
+	cache []*MCausalPropose
+}
+
+func NewMCausalProposeCache() *MCausalProposeCache {
+	c := &MCausalProposeCache{}
+	c.cache = make([]*MCausalPropose, 0)
+	return c
+}
+
+func (p *MCausalProposeCache) Get() *MCausalPropose {
+	var t *MCausalPropose
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MCausalPropose{}
+	}
+	return t
+}
+
+func (p *MCausalProposeCache) Put(t *MCausalPropose) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MCausalPropose) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CommandId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.ClientId))
+	wire.Write(b[:8])
+	t.Command.Marshal(wire)
+	binary.LittleEndian.PutUint64(b[0:], uint64(t.Timestamp))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CausalDep))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.BoundReplica))
+	wire.Write(b[:16])
+}
+
+func (t *MCausalPropose) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.CommandId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.ClientId = int32(binary.LittleEndian.Uint32(b[4:]))
+	if err := t.Command.Unmarshal(wire); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Timestamp = int64(binary.LittleEndian.Uint64(b[0:]))
+	t.CausalDep = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.BoundReplica = int32(binary.LittleEndian.Uint32(b[12:]))
+	return nil
+}
+
+func (t *MCausalReply) New() fastrpc.Serializable {
+	return new(MCausalReply)
+}
+
+func (t *MCausalReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MCausalReplyCache struct {
+	mu    sync.Mutex
+	cache []*MCausalReply
+}
+
+func NewMCausalReplyCache() *MCausalReplyCache {
+	c := &MCausalReplyCache{}
+	c.cache = make([]*MCausalReply, 0)
+	return c
+}
+
+func (p *MCausalReplyCache) Get() *MCausalReply {
+	var t *MCausalReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MCausalReply{}
+	}
+	return t
+}
+
+func (p *MCausalReplyCache) Put(t *MCausalReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MCausalReply) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:12])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Rep)))])
+	wire.Write(t.Rep)
+}
+
+func (t *MCausalReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[8:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Rep = make([]byte, n1)
+	if _, err := io.ReadFull(wire, t.Rep); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *MWeakRead) New() fastrpc.Serializable {
+	return new(MWeakRead)
+}
+
+func (t *MWeakRead) BinarySize() (nbytes int, sizeKnown bool) {
+	return 25, true
+}
+
+type MWeakReadCache struct {
+	mu    sync.Mutex
+	cache []*MWeakRead
+}
+
+func NewMWeakReadCache() *MWeakReadCache {
+	c := &MWeakReadCache{}
+	c.cache = make([]*MWeakRead, 0)
+	return c
+}
+
+func (p *MWeakReadCache) Get() *MWeakRead {
+	var t *MWeakRead
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MWeakRead{}
+	}
+	return t
+}
+
+func (p *MWeakReadCache) Put(t *MWeakRead) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MWeakRead) Marshal(wire io.Writer) {
+	var b [25]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CommandId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.ClientId))
+	binary.LittleEndian.PutUint64(b[8:], uint64(t.Key))
+	b[16] = byte(t.Op)
+	binary.LittleEndian.PutUint64(b[17:], uint64(t.Count))
+	wire.Write(b[:25])
+}
+
+func (t *MWeakRead) Unmarshal(wire io.Reader) error {
+	var b [25]byte
+	if _, err := io.ReadFull(wire, b[:25]); err != nil {
+		return err
+	}
+	t.CommandId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.ClientId = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Key = state.Key(binary.LittleEndian.Uint64(b[8:]))
+	t.Op = b[16]
+	t.Count = int64(binary.LittleEndian.Uint64(b[17:]))
+	return nil
+}
+
+func (t *MWeakReadReply) New() fastrpc.Serializable {
+	return new(MWeakReadReply)
+}
+
+func (t *MWeakReadReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MWeakReadReplyCache struct {
+	mu    sync.Mutex
+	cache []*MWeakReadReply
+}
+
+func NewMWeakReadReplyCache() *MWeakReadReplyCache {
+	c := &MWeakReadReplyCache{}
+	c.cache = make([]*MWeakReadReply, 0)
+	return c
+}
+
+func (p *MWeakReadReplyCache) Get() *MWeakReadReply {
+	var t *MWeakReadReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MWeakReadReply{}
+	}
+	return t
+}
+
+func (p *MWeakReadReplyCache) Put(t *MWeakReadReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MWeakReadReply) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Rep)))])
+	wire.Write(t.Rep)
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Version))
+	wire.Write(b[:4])
+}
+
+func (t *MWeakReadReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Rep = make([]byte, n1)
+	if _, err := io.ReadFull(wire, t.Rep); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
+		return err
+	}
+	t.Version = int32(binary.LittleEndian.Uint32(b[0:]))
+	return nil
+}
+
+type byteReader interface {
+	io.Reader
+	ReadByte() (c byte, err error)
+}
@@ -0,0 +1,102 @@
+package curpho
+
+import (
+	"testing"
+
+	"github.com/imdea-software/swiftpaxos/replica"
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+func newTestWitness() *Witness {
+	w := &Witness{
+		Replica: &replica.Replica{Id: 2},
+		sender:  make(replica.Sender, 16),
+	}
+	w.initPool()
+	return w
+}
+
+func testPropose(clientId, seqNum int32, op state.Operation, k state.Key) *defs.GPropose {
+	return &defs.GPropose{
+		Propose: &defs.Propose{
+			ClientId:  clientId,
+			CommandId: seqNum,
+			Command:   state.Command{Op: op, K: k},
+		},
+	}
+}
+
+// recordAck returns the next record ack sent by w, or nil if none
+func recordAck(w *Witness) *MRecordAck {
+	select {
+	case arg := <-w.sender:
+		return arg.Msg().(*MRecordAck)
+	default:
+		return nil
+	}
+}
+
+func TestWitnessPool(t *testing.T) {
+	w := newTestWitness()
+
+	w.handlePropose(testPropose(1, 1, state.PUT, 7))
+	if ack := recordAck(w); ack == nil || ack.Ok != TRUE {
+		t.Fatalf("first command: %+v, want TRUE", ack)
+	}
+	w.handlePropose(testPropose(2, 1, state.PUT, 7))
+	if ack := recordAck(w); ack == nil || ack.Ok != FALSE {
+		t.Fatalf("conflicting command: %+v, want FALSE", ack)
+	}
+
+	// the commits of the leader empty the pool, in any order
+	w.handleAccept(&MAccept{CmdId: CommandId{1, 1}, CmdSlot: 0})
+	w.handleCommit(&MCommit{CmdSlot: 0})
+	w.handleCommit(&MCommit{CmdSlot: 1})
+	w.handleAccept(&MAccept{CmdId: CommandId{2, 1}, CmdSlot: 1})
+	if len(w.cmds) != 0 || len(w.slots) != 0 || len(w.committed) != 0 {
+		t.Fatalf("pool %v, slots %v, committed %v after the commits", w.cmds, w.slots, w.committed)
+	}
+	w.handlePropose(testPropose(3, 1, state.PUT, 7))
+	if ack := recordAck(w); ack == nil || ack.Ok != TRUE {
+		t.Errorf("command after the commits: %+v, want TRUE", ack)
+	}
+
+	// the accepts of other ballots are ignored
+	w.handleAccept(&MAccept{Ballot: 1, CmdId: CommandId{3, 1}, CmdSlot: 2})
+	if len(w.slots) != 0 {
+		t.Errorf("accept of ballot 1 taken")
+	}
+}
+
+func TestWitnessCausalDeps(t *testing.T) {
+	w := newTestWitness()
+
+	w.handleCausalPropose(&MCausalPropose{ClientId: 1, CommandId: 1,
+		Command: state.Command{Op: state.PUT, K: 7}})
+	w.handlePropose(testPropose(1, 2, state.GET, 7))
+	ack := recordAck(w)
+	if ack == nil || ack.Ok != TRUE {
+		t.Fatalf("read after a causal write: %+v, want TRUE", ack)
+	}
+	if ack.ReadDep == nil || *ack.ReadDep != (CommandId{1, 1}) {
+		t.Errorf("ReadDep = %v, want the causal write", ack.ReadDep)
+	}
+	if len(ack.CausalDeps) != 1 || ack.CausalDeps[0] != (CommandId{1, 1}) {
+		t.Errorf("CausalDeps = %v, want the causal write", ack.CausalDeps)
+	}
+
+	// the committed causal write is no dependency
+	w.handleAccept(&MAccept{CmdId: CommandId{1, 1}, CmdSlot: 0})
+	w.handleCommit(&MCommit{CmdSlot: 0})
+	w.handlePropose(testPropose(1, 3, state.GET, 8))
+	if ack := recordAck(w); ack == nil || len(ack.CausalDeps) != 0 {
+		t.Errorf("CausalDeps after the commit: %+v", ack)
+	}
+	// nor recorded again
+	w.handleCausalPropose(&MCausalPropose{ClientId: 1, CommandId: 1,
+		Command: state.Command{Op: state.PUT, K: 7}})
+	if _, exists := w.cmds[CommandId{1, 1}]; exists {
+		t.Error("committed causal write recorded")
+	}
+}
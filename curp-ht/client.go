@@ -89,9 +89,9 @@ func NewClient(b *client.BufferClient, repNum, reqNum, pclients int) *Client {
 		BufferClient: b,
 
 		N:   repNum,
-		t:   NewTimer(),
+		t:   NewClockTimer(b.Clock()),
 		Q:   replica.NewThreeQuartersOf(repNum),
-		M:   replica.NewMajorityOf(repNum),
+		M:   replica.NewMajorityOf(repNum - b.NumWitnesses()),
 		num: num,
 		val: nil,
 
@@ -123,7 +123,7 @@ func NewClient(b *client.BufferClient, repNum, reqNum, pclients int) *Client {
 
 	c.writerMu = make([]sync.Mutex, repNum)
 
-	t := fastrpc.NewTableId(defs.RPC_TABLE)
+	t := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	initCs(&c.cs, t)
 	c.RegisterRPCTable(t)
 
@@ -141,8 +141,7 @@ func NewClient(b *client.BufferClient, repNum, reqNum, pclients int) *Client {
 	go c.handleWeakMsgs()
 
 	// Start MSync retry timer: periodically retransmit MSync for pending
-	// strong commands whose replies may have been dropped by the non-blocking
-	// SendClientMsgFast on the replica side.
+	// strong commands whose replies may have been lost with a connection.
 	c.t.Start(2 * time.Second)
 
 	return c
@@ -187,8 +186,8 @@ func (c *Client) handleStrongMsgs() {
 			c.handleReaderDead(int32(deadReplica))
 
 		case <-c.t.c:
-			// Retry pending commands whose replies may have been dropped
-			// by the non-blocking SendClientMsgFast, or whose delivery
+			// Retry pending commands whose replies may have been lost
+			// with a connection, or whose delivery
 			// is stuck in slot ordering on the proxy.
 			c.mu.Lock()
 			var syncSeqnums []int32
@@ -500,7 +499,7 @@ func (c *Client) SendWeakRead(key int64) int32 {
 	c.mu.Lock()
 	c.weakPending[seqnum] = struct{}{}
 	c.weakPendingKeys[seqnum] = key
-	closest := c.ClosestId
+	closest := c.ReadId
 	c.mu.Unlock()
 
 	msg := &MWeakRead{
@@ -522,7 +521,7 @@ func (c *Client) SendWeakScan(key int64, count int64) int32 {
 	c.mu.Lock()
 	c.weakPending[seqnum] = struct{}{}
 	c.weakPendingKeys[seqnum] = key
-	closest := c.ClosestId
+	closest := c.ReadId
 	c.mu.Unlock()
 
 	msg := &MWeakRead{
@@ -584,8 +583,7 @@ func (c *Client) sendProposeSafe(p defs.Propose) {
 		if conn := c.GetConn(rep); conn != nil {
 			conn.SetWriteDeadline(time.Now().Add(500 * time.Millisecond))
 		}
-		w.WriteByte(defs.PROPOSE)
-		p.Marshal(w)
+		fastrpc.WriteFrame(w, fastrpc.Code(defs.PROPOSE), &p)
 		if err := w.Flush(); err != nil {
 			// Write failed — mark replica as dead and nil out the writer.
 			c.mu.Lock()
@@ -746,12 +744,16 @@ func (c *Client) handleReaderDead(deadReplica int32) {
 }
 
 // rotateLeader returns the next alive replica ID after the given one.
-// Skips replicas known to be dead. Falls back to (current+1)%N if all are dead.
+// Skips replicas known to be dead and witnesses. Falls back to (current+1)%N if all are dead.
 // Must be called with c.mu held.
 func (c *Client) rotateLeader(current int32) int32 {
+	var witnesses []bool
+	if c.BufferClient != nil && c.BufferClient.Client != nil {
+		witnesses = c.Witnesses
+	}
 	for i := int32(1); i < c.numReplicas; i++ {
 		next := (current + i) % c.numReplicas
-		if !c.deadReplicas[next] {
+		if !c.deadReplicas[next] && (witnesses == nil || !witnesses[next]) {
 			return next
 		}
 	}
@@ -772,8 +774,7 @@ func (c *Client) resendPropose(rid int32, cmd *defs.Propose) {
 	if conn := c.GetConn(rid); conn != nil {
 		conn.SetWriteDeadline(time.Now().Add(500 * time.Millisecond))
 	}
-	w.WriteByte(defs.PROPOSE)
-	cmd.Marshal(w)
+	fastrpc.WriteFrame(w, fastrpc.Code(defs.PROPOSE), cmd)
 	if err := w.Flush(); err != nil {
 		c.mu.Lock()
 		c.deadReplicas[rid] = true
@@ -791,7 +792,7 @@ func (c *Client) resendPropose(rid int32, cmd *defs.Propose) {
 // sendMsgSafe sends a message to a single replica under the per-replica writer mutex.
 // This prevents concurrent writes to the same bufio.Writer from different goroutines
 // (e.g., timer retry sends in handleMsgs racing with benchmark Send calls).
-func (c *Client) sendMsgSafe(rid int32, code uint8, msg fastrpc.Serializable) {
+func (c *Client) sendMsgSafe(rid int32, code fastrpc.Code, msg fastrpc.Serializable) {
 	if c.BufferClient == nil || c.BufferClient.Client == nil {
 		return
 	}
@@ -3,7 +3,6 @@ package curpht
 import (
 	"encoding/binary"
 	"log"
-	"math/rand"
 	"strconv"
 	"sync"
 	"time"
@@ -13,6 +12,7 @@ import (
 	"github.com/imdea-software/swiftpaxos/hook"
 	"github.com/imdea-software/swiftpaxos/replica"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/sim"
 	"github.com/imdea-software/swiftpaxos/state"
 	"github.com/orcaman/concurrent-map"
 )
@@ -30,6 +30,8 @@ type Replica struct {
 	contactClients bool
 
 	Q replica.Majority
+	// witnesses are the replicas that are only witnesses (see witness.go)
+	witnesses []int32
 
 	lastCmdSlot int
 
@@ -83,8 +85,8 @@ type Replica struct {
 	weakDepMu     sync.Mutex
 
 	// Election state (Phase 128)
-	electionTimer  *time.Timer   // Fires when election timeout expires
-	heartbeatTimer *time.Ticker  // Leader sends periodic heartbeats
+	electionTimer  *sim.Timer    // Fires when election timeout expires
+	heartbeatTimer *sim.Ticker   // Leader sends periodic heartbeats
 	votesReceived  int           // Votes received in current election
 	lastCommitted  int32         // Highest committed slot (for log comparison in votes)
 
@@ -99,6 +101,15 @@ type Replica struct {
 	// Leader tracking for proposal forwarding (Phase 128.4)
 	currentLeader int32 // Replica ID of the current leader (set from heartbeats)
 
+	// Leadership transfer (see transfer.go)
+	transferChan       chan *transfer
+	transfer           *transfer    // of the former leader, in progress
+	timeoutNow         *MTimeoutNow // of the leader, to run once caught up
+	timeoutNowDeadline time.Time
+
+	// Status calls of the admin participant, answered by the event loop
+	statusChan chan *statusQuery
+
 	// Recovery proposal buffer (Phase 128.9)
 	// Proposals arriving while leader is RECOVERING are buffered here
 	// and replayed after recovery completes (status → NORMAL).
@@ -128,6 +139,7 @@ type commandDesc struct {
 	seq    bool
 
 	accepted    bool
+	slowPath    bool // the leader told the client to wait for the commit
 	pendingCall func()
 
 	isWeak  bool // Mark if this is a weak command
@@ -187,6 +199,9 @@ func New(alias string, rid int, addrs []string, exec bool, pl, f int,
 
 		deliverChan: make(chan int, defs.CHAN_BUFFER_SIZE),
 
+		transferChan: make(chan *transfer),
+		statusChan:   make(chan *statusQuery),
+
 		poolLevel:    pl,
 		routineCount: 0,
 
@@ -203,7 +218,12 @@ func New(alias string, rid int, addrs []string, exec bool, pl, f int,
 		},
 	}
 
-	r.Q = replica.NewMajorityOf(r.N)
+	for i, w := range conf.WitnessList(r.N) {
+		if w {
+			r.witnesses = append(r.witnesses, int32(i))
+		}
+	}
+	r.Q = replica.NewMajorityOf(r.backups())
 	r.sender = replica.NewSender(r.Replica)
 	r.batcher = NewBatcher(r, 128) // Increased from 8 for better batching
 
@@ -242,6 +262,9 @@ func New(alias string, rid int, addrs []string, exec bool, pl, f int,
 
 	initCs(&r.cs, r.RPC)
 
+	// strong commands committed by the leader on the fast and the slow path
+	r.Stats.M["fast"], r.Stats.M["slow"] = 0, 0
+
 	hook.HookUser1(func() {
 		totalNum := 0
 		for i := 0; i < HISTORY_SIZE; i++ {
@@ -267,6 +290,39 @@ func (r *Replica) BeTheLeader(args *defs.BeTheLeaderArgs, reply *defs.BeTheLeade
 	return nil
 }
 
+// roleNames are the roles reported by Status.
+var roleNames = [...]string{FOLLOWER: "follower", CANDIDATE: "candidate", LEADER: "leader"}
+
+// statusQuery is a Status call, answered by the event loop, which owns the
+// role, the term and the slots.
+type statusQuery struct {
+	reply *defs.StatusReply
+	done  chan struct{}
+}
+
+// Status is called via RPC by the admin participant. The commit position is
+// the last committed slot.
+func (r *Replica) Status(args *defs.StatusArgs, reply *defs.StatusReply) error {
+	r.Replica.Status(args, reply)
+	q := &statusQuery{reply: reply, done: make(chan struct{})}
+	r.statusChan <- q
+	<-q.done
+	return nil
+}
+
+// reportStatus fills reply with the state of the protocol.
+func (r *Replica) reportStatus(reply *defs.StatusReply) {
+	reply.Role = roleNames[r.role]
+	if r.role == LEADER && r.status == RECOVERING {
+		reply.Role = "recovering"
+	}
+	reply.Term = r.currentTerm
+	if r.role == LEADER {
+		reply.Log = int32(r.lastCmdSlot) - 1
+	}
+	reply.Commit = r.lastCommitted
+}
+
 // becomeFollower transitions to FOLLOWER role for the given term.
 // Resets votedFor if the term is newer than the current term.
 func (r *Replica) becomeFollower(term int32) {
@@ -292,6 +348,22 @@ func (r *Replica) becomeLeader() {
 	r.currentLeader = r.Id
 }
 
+// backups returns the number of replicas that are not witnesses, which
+// alone vote and recover the log.
+func (r *Replica) backups() int {
+	return r.N - len(r.witnesses)
+}
+
+// isWitness reports whether replica id is a witness.
+func (r *Replica) isWitness(id int32) bool {
+	for _, w := range r.witnesses {
+		if w == id {
+			return true
+		}
+	}
+	return false
+}
+
 // IsLeader returns true if this replica is currently the leader.
 func (r *Replica) IsLeader() bool {
 	return r.role == LEADER
@@ -306,10 +378,10 @@ const (
 )
 
 // randomElectionTimeout returns a random duration between ElectionTimeoutMin and ElectionTimeoutMax.
-func randomElectionTimeout() time.Duration {
+func randomElectionTimeout(c sim.Clock) time.Duration {
 	// Use large random spread to avoid split vote when all followers lose heartbeat simultaneously
 	spread := ElectionTimeoutMax - ElectionTimeoutMin
-	return ElectionTimeoutMin + time.Duration(rand.Int63n(int64(spread)))
+	return ElectionTimeoutMin + time.Duration(c.Int63n(int64(spread)))
 }
 
 // randomBackoffTimeout returns a longer random timeout for retrying after a failed election.
@@ -318,13 +390,22 @@ func (r *Replica) randomBackoffTimeout() time.Duration {
 	// Base offset proportional to replica ID (0-400ms spread across 5 replicas)
 	idOffset := time.Duration(r.Id) * 100 * time.Millisecond
 	spread := ElectionTimeoutMax - ElectionTimeoutMin
-	return ElectionTimeoutMin + idOffset + time.Duration(rand.Int63n(int64(spread)))
+	return ElectionTimeoutMin + idOffset + time.Duration(r.clock().Int63n(int64(spread)))
+}
+
+// clock returns the clock of the base replica, or the wall clock if there
+// is none.
+func (r *Replica) clock() sim.Clock {
+	if r.Replica == nil {
+		return sim.Real
+	}
+	return sim.OrReal(r.Replica.Clock)
 }
 
 // resetElectionTimer resets the election timer with a new random timeout.
 func (r *Replica) resetElectionTimer() {
 	if r.electionTimer != nil {
-		r.electionTimer.Reset(randomElectionTimeout())
+		r.electionTimer.Reset(randomElectionTimeout(r.clock()))
 	}
 }
 
@@ -340,7 +421,7 @@ func (r *Replica) startHeartbeat() {
 	if r.heartbeatTimer != nil {
 		r.heartbeatTimer.Stop()
 	}
-	r.heartbeatTimer = time.NewTicker(HeartbeatInterval)
+	r.heartbeatTimer = r.clock().NewTicker(HeartbeatInterval)
 }
 
 // stopHeartbeat stops the heartbeat ticker (when stepping down from leader).
@@ -441,9 +522,10 @@ func (r *Replica) handleRequestVoteReply(msg *MRequestVoteReply) {
 	if msg.VoteGranted == TRUE {
 		r.votesReceived++
 		// Check if we have majority
-		if r.votesReceived > r.N/2 {
+		if r.votesReceived > r.backups()/2 {
 			r.Printf("Won election for term %d with %d votes\n", r.currentTerm, r.votesReceived)
 			r.becomeLeader()
+			r.transferred(r.Id)
 			r.stopElectionTimer()
 			r.startHeartbeat()
 			r.sendHeartbeat() // Immediately announce leadership
@@ -468,6 +550,10 @@ func (r *Replica) handleHeartbeat(msg *MHeartbeat) {
 
 	// Track who the leader is (for proposal forwarding)
 	r.currentLeader = msg.Replica
+	r.transferred(msg.Replica)
+	if r.timeoutNow != nil && msg.Term > r.timeoutNow.Term {
+		r.timeoutNow = nil
+	}
 
 	r.resetElectionTimer()
 }
@@ -553,7 +639,7 @@ func (r *Replica) handleLogSyncReply(msg *MLogSyncReply) {
 
 	// Check if we have enough replies (majority = (N-1)/2 peers + self = N/2+1 total)
 	// We need (N/2) peer replies since we already count ourselves
-	if len(r.logSyncReplies) >= r.N/2 {
+	if len(r.logSyncReplies) >= r.backups()/2 {
 		r.mergeAndRecoverLog()
 	}
 }
@@ -714,7 +800,7 @@ func (r *Replica) handleSlotSyncReply(msg *MSlotSyncReply) {
 	r.slotSyncReplies = append(r.slotSyncReplies, *msg)
 
 	// Need majority of peers: (N/2) replies (self already counted)
-	if len(r.slotSyncReplies) >= r.N/2 {
+	if len(r.slotSyncReplies) >= r.backups()/2 {
 		r.completeSlotSync()
 	}
 }
@@ -804,11 +890,11 @@ func (r *Replica) run() {
 	// Followers use a long initial delay to let the designated leader finish
 	// ConnectToPeers and send its first heartbeat before any follower starts an election.
 	if r.IsLeader() {
-		r.electionTimer = time.NewTimer(InitialElectionDelay)
+		r.electionTimer = r.clock().NewTimer(InitialElectionDelay)
 		r.electionTimer.Stop() // Leader doesn't need election timer
 		r.startHeartbeat()
 	} else {
-		r.electionTimer = time.NewTimer(InitialElectionDelay)
+		r.electionTimer = r.clock().NewTimer(InitialElectionDelay)
 	}
 
 	go r.WaitForClientConnections()
@@ -949,10 +1035,24 @@ func (r *Replica) run() {
 			r.handleWeakRead(weakRead)
 
 		case <-r.electionTimer.C:
-			if r.role != LEADER {
+			r.checkTransfer()
+			if r.transfer != nil {
+				// the former leader leaves the target time to run
+				r.resetElectionTimer()
+			} else if r.role != LEADER && !r.awaitTimeoutNow() {
 				r.startElection()
 			}
 
+		case t := <-r.transferChan:
+			r.handleTransfer(t)
+
+		case q := <-r.statusChan:
+			r.reportStatus(q.reply)
+			close(q.done)
+
+		case m := <-r.cs.timeoutNowChan:
+			r.handleTimeoutNow(m.(*MTimeoutNow))
+
 		case m := <-r.cs.requestVoteChan:
 			rv := m.(*MRequestVote)
 			r.handleRequestVote(rv)
@@ -1156,6 +1256,9 @@ func getAcksHandler(r *Replica, desc *commandDesc) replica.MsgSetHandler {
 			CmdSlot: desc.cmdSlot,
 		}
 		if r.optimized {
+			if r.IsLeader() {
+				r.commitToWitnesses(commit)
+			}
 			r.handleCommit(commit, desc)
 		} else if r.IsLeader() {
 			r.sender.SendToAll(commit, r.cs.commitRPC)
@@ -1164,6 +1267,14 @@ func getAcksHandler(r *Replica, desc *commandDesc) replica.MsgSetHandler {
 	}
 }
 
+// commitToWitnesses sends commit to the witnesses, which then forget the
+// command; the backups of the optimized protocol commit on their own.
+func (r *Replica) commitToWitnesses(commit *MCommit) {
+	for _, w := range r.witnesses {
+		r.sender.SendTo(w, commit, r.cs.commitRPC)
+	}
+}
+
 func (r *Replica) handleCommit(msg *MCommit, desc *commandDesc) {
 	if msg.Ballot > r.currentTerm {
 		r.becomeFollower(msg.Ballot)
@@ -1331,6 +1442,7 @@ func (r *Replica) deliver(desc *commandDesc, slot int) {
 			} else {
 				rep.Ok = TRUE
 			}
+			desc.slowPath = rep.Ok == FALSE
 			// Always send reply to client so they can complete via macks quorum
 			// even when rep.Ok == FALSE (pending dependency). Without this,
 			// the client hangs waiting for a leader reply that never comes.
@@ -1359,6 +1471,15 @@ func (r *Replica) deliver(desc *commandDesc, slot int) {
 		}
 
 		if desc.phase == COMMIT {
+			if r.IsLeader() && desc.propose != nil && !desc.isWeak {
+				r.M.Lock()
+				if desc.slowPath {
+					r.Stats.M["slow"]++
+				} else {
+					r.Stats.M["fast"]++
+				}
+				r.M.Unlock()
+			}
 			// Sync reply is only for strong commands (which have desc.propose set)
 			// Weak commands are handled separately
 			if !r.contactClients && desc.propose != nil {
@@ -1460,6 +1581,7 @@ func (r *Replica) newDesc() *commandDesc {
 	desc.successorL = sync.Mutex{}
 	desc.accepted = false
 	desc.applied = false
+	desc.slowPath = false
 
 	desc.afterPayload = desc.afterPayload.ReinitCondF(func() bool {
 		// For weak commands on non-leaders, desc.cmd is set from the Accept message
@@ -1658,7 +1780,7 @@ func (r *Replica) asyncReplicateWeak(desc *commandDesc, slot int, clientId int32
 	select {
 	case <-commitCh:
 		// Committed
-	case <-time.After(1 * time.Second):
+	case <-r.clock().After(1 * time.Second):
 		// Timeout - proceed anyway to avoid deadlock
 	}
 
@@ -1669,7 +1791,7 @@ func (r *Replica) asyncReplicateWeak(desc *commandDesc, slot int, clientId int32
 		select {
 		case <-executeCh:
 			// Slot-1 executed
-		case <-time.After(1 * time.Second):
+		case <-r.clock().After(1 * time.Second):
 			// Timeout - proceed anyway to avoid deadlock
 		}
 	}
@@ -1730,7 +1852,7 @@ func (r *Replica) waitForWeakDep(clientId int32, depSeqNum int32) {
 		select {
 		case <-ch:
 			// Notification received — re-check condition in next loop iteration
-		case <-time.After(5 * time.Second):
+		case <-r.clock().After(5 * time.Second):
 			// Timeout: proceed to avoid deadlock
 			return
 		}
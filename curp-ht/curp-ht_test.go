@@ -17,6 +17,7 @@ import (
 	"github.com/imdea-software/swiftpaxos/hook"
 	"github.com/imdea-software/swiftpaxos/replica"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/sim"
 	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
 	"github.com/imdea-software/swiftpaxos/state"
 )
@@ -1772,7 +1773,7 @@ func TestSplitMsgGoroutines(t *testing.T) {
 	// Verify the channel structure: strong channels should be separate from weak channels.
 	// CommunicationSupply has distinct channels for each message type.
 	var cs CommunicationSupply
-	tbl := fastrpc.NewTableId(defs.RPC_TABLE)
+	tbl := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	initCs(&cs, tbl)
 
 	// Strong channels should be non-nil and distinct
@@ -2246,7 +2247,7 @@ func TestStartElection(t *testing.T) {
 	r.Replica = newTestBaseReplica(5)
 	r.Id = 2
 	r.sender = newTestSender() // No-op sender for tests
-	r.electionTimer = time.NewTimer(time.Hour) // Won't fire
+	r.electionTimer = sim.Real.NewTimer(time.Hour) // Won't fire
 
 	r.startElection()
 
@@ -2275,7 +2276,7 @@ func TestHandleRequestVoteGranted(t *testing.T) {
 	r.Replica = newTestBaseReplica(5)
 	r.Id = 1
 	r.sender = newTestSender()
-	r.electionTimer = time.NewTimer(time.Hour)
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
 
 	msg := &MRequestVote{
 		Replica:          2,
@@ -2351,7 +2352,7 @@ func TestHandleRequestVoteHigherTerm(t *testing.T) {
 	r.Replica = newTestBaseReplica(5)
 	r.Id = 1
 	r.sender = newTestSender()
-	r.electionTimer = time.NewTimer(time.Hour)
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
 
 	msg := &MRequestVote{
 		Replica:          2,
@@ -2409,7 +2410,7 @@ func TestHandleVoteReplyWinElection(t *testing.T) {
 	r.Replica = newTestBaseReplica(5)
 	r.Id = 1
 	r.sender = newTestSender()
-	r.electionTimer = time.NewTimer(time.Hour)
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
 
 	// Receive 2 more votes (need 3 total = majority of 5)
 	r.handleRequestVoteReply(&MRequestVoteReply{Replica: 2, Term: 5, VoteGranted: TRUE})
@@ -2473,7 +2474,7 @@ func TestHandleHeartbeatResetTimer(t *testing.T) {
 		votedFor:    -1,
 	}
 	r.Replica = newTestBaseReplica(5)
-	r.electionTimer = time.NewTimer(time.Hour)
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
 
 	msg := &MHeartbeat{Replica: 0, Term: 3}
 	r.handleHeartbeat(msg)
@@ -2492,7 +2493,7 @@ func TestHandleHeartbeatHigherTerm(t *testing.T) {
 		votedFor:    1,
 	}
 	r.Replica = newTestBaseReplica(5)
-	r.electionTimer = time.NewTimer(time.Hour)
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
 
 	msg := &MHeartbeat{Replica: 0, Term: 5}
 	r.handleHeartbeat(msg)
@@ -2530,7 +2531,7 @@ func TestHandleHeartbeatCandidateStepDown(t *testing.T) {
 		votedFor:    1,
 	}
 	r.Replica = newTestBaseReplica(5)
-	r.electionTimer = time.NewTimer(time.Hour)
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
 
 	// Same-term heartbeat from another leader
 	msg := &MHeartbeat{Replica: 0, Term: 3}
@@ -2544,7 +2545,7 @@ func TestHandleHeartbeatCandidateStepDown(t *testing.T) {
 // TestRandomElectionTimeout verifies timeout is in expected range.
 func TestRandomElectionTimeout(t *testing.T) {
 	for i := 0; i < 100; i++ {
-		d := randomElectionTimeout()
+		d := randomElectionTimeout(sim.Real)
 		if d < ElectionTimeoutMin || d > ElectionTimeoutMax {
 			t.Errorf("timeout %v outside range [%v, %v]", d, ElectionTimeoutMin, ElectionTimeoutMax)
 		}
@@ -2564,7 +2565,7 @@ func TestElectionConstants(t *testing.T) {
 // TestElectionChannelRegistration verifies election channels are registered.
 func TestElectionChannelRegistration(t *testing.T) {
 	var cs CommunicationSupply
-	tbl := fastrpc.NewTableId(defs.RPC_TABLE)
+	tbl := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	initCs(&cs, tbl)
 
 	if cs.requestVoteChan == nil {
@@ -2600,7 +2601,7 @@ func newTestReplicaForRecovery(id int32, n int) *Replica {
 	base.State = state.InitState()
 
 	var cs CommunicationSupply
-	tbl := fastrpc.NewTableId(defs.RPC_TABLE)
+	tbl := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	initCs(&cs, tbl)
 
 	r := &Replica{
@@ -2799,7 +2800,7 @@ func TestMLogSyncReplyCache(t *testing.T) {
 // TestLogSyncChannelRegistration verifies log sync channels are registered.
 func TestLogSyncChannelRegistration(t *testing.T) {
 	var cs CommunicationSupply
-	tbl := fastrpc.NewTableId(defs.RPC_TABLE)
+	tbl := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	initCs(&cs, tbl)
 
 	if cs.logSyncChan == nil {
@@ -4666,7 +4667,7 @@ func TestCurrentLeaderUpdatedByHeartbeat(t *testing.T) {
 	r.currentLeader = 0
 	r.role = FOLLOWER
 	r.currentTerm = 1
-	r.electionTimer = time.NewTimer(10 * time.Second)
+	r.electionTimer = sim.Real.NewTimer(10 * time.Second)
 
 	// Receive heartbeat from replica 2 with term 2
 	hb := &MHeartbeat{Replica: 2, Term: 2}
@@ -6298,3 +6299,67 @@ func TestSendProposeSafeWriteDeadlineTimeout(t *testing.T) {
 		t.Fatal("sendProposeSafe blocked — write deadline should have triggered")
 	}
 }
+
+// TestTransferLeaderStepsDown verifies the leader steps down for the target
+// and that the transfer ends with the heartbeat of the target.
+func TestTransferLeaderStepsDown(t *testing.T) {
+	r := &Replica{
+		role:          LEADER,
+		status:        NORMAL,
+		currentTerm:   3,
+		votedFor:      0,
+		lastCommitted: 7,
+	}
+	r.Replica = newTestBaseReplica(3)
+	r.Id = 0
+	r.sender = newTestSender()
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
+	r.startHeartbeat()
+
+	tr := &transfer{target: 2, reply: &defs.TransferLeaderReply{}, done: make(chan error, 1)}
+	r.handleTransfer(tr)
+	if r.role != FOLLOWER || r.heartbeatTimer != nil || r.currentLeader != 2 || r.transfer != tr {
+		t.Fatalf("role %d, heartbeat %v, leader %d after the transfer started", r.role, r.heartbeatTimer, r.currentLeader)
+	}
+
+	r.handleHeartbeat(&MHeartbeat{Replica: 2, Term: 4})
+	if err := <-tr.done; err != nil || tr.reply.Leader != 2 {
+		t.Errorf("transfer: %v, leader %d", err, tr.reply.Leader)
+	}
+
+	// followers refuse transfers
+	tr = &transfer{target: 1, reply: &defs.TransferLeaderReply{}, done: make(chan error, 1)}
+	r.handleTransfer(tr)
+	if err := <-tr.done; err == nil {
+		t.Error("transfer accepted by a follower")
+	}
+}
+
+// TestTimeoutNowWaitsForCommits verifies the target runs only once it has
+// committed up to the last committed slot of the leader.
+func TestTimeoutNowWaitsForCommits(t *testing.T) {
+	r := &Replica{
+		role:          FOLLOWER,
+		currentTerm:   3,
+		votedFor:      -1,
+		lastCommitted: 5,
+	}
+	r.Replica = newTestBaseReplica(3)
+	r.Id = 2
+	r.sender = newTestSender()
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
+
+	r.handleTimeoutNow(&MTimeoutNow{Replica: 0, Term: 3, LastCommitted: 7})
+	if r.role != FOLLOWER || r.timeoutNow == nil {
+		t.Fatalf("role %d: ran before catching up", r.role)
+	}
+	r.lastCommitted = 7
+	if r.awaitTimeoutNow() || r.timeoutNow != nil {
+		t.Error("still waiting once caught up")
+	}
+
+	r.handleTimeoutNow(&MTimeoutNow{Replica: 0, Term: 3, LastCommitted: 7})
+	if r.role != CANDIDATE || r.currentTerm != 4 {
+		t.Errorf("role %d, term %d after an up-to-date TimeoutNow", r.role, r.currentTerm)
+	}
+}
@@ -3,6 +3,8 @@ package curpht
 import (
 	"sync"
 	"time"
+
+	"github.com/imdea-software/swiftpaxos/sim"
 )
 
 type Timer struct {
@@ -10,13 +12,20 @@ type Timer struct {
 	s       chan int
 	wg      sync.WaitGroup
 	version int
+	clock   sim.Clock
 }
 
 func NewTimer() *Timer {
+	return NewClockTimer(sim.Real)
+}
+
+// NewClockTimer returns a timer that waits on the given clock.
+func NewClockTimer(clock sim.Clock) *Timer {
 	return &Timer{
 		c:       make(chan bool, 1),
 		s:       make(chan int, 1),
 		version: 0,
+		clock:   clock,
 	}
 }
 
@@ -32,7 +41,7 @@ func (t *Timer) Start(wait time.Duration) {
 			select {
 			case <-s:
 				return
-			case <-time.After(wait):
+			case <-t.clock.After(wait):
 				stop := (len(s) != 0)
 				if stop {
 					return
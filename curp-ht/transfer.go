@@ -0,0 +1,143 @@
+package curpht
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+)
+
+// Leadership transfer
+//
+// The master moves the leadership to a replica, the target, through the
+// leader. The leader steps down, stops its heartbeats and sends the target
+// an MTimeoutNow with its last committed slot. The target starts an election
+// once it has committed up to that slot, so that the votes, which compare
+// the last committed slots, go to it, and recovers the log as after any
+// election. The transfer ends when the former leader hears a heartbeat of
+// the target, and fails after transferTimeout or if another replica is
+// elected.
+
+const transferTimeout = 2 * time.Second
+
+// transfer is a request of the master, handled by the event loop.
+type transfer struct {
+	target   int32
+	reply    *defs.TransferLeaderReply
+	deadline time.Time
+	done     chan error
+}
+
+// TransferLeader is called by the master via RPC to make args.Target the
+// leader. It returns once the target is the leader.
+func (r *Replica) TransferLeader(args *defs.TransferLeaderArgs, reply *defs.TransferLeaderReply) error {
+	t := &transfer{
+		target: args.Target,
+		reply:  reply,
+		done:   make(chan error, 1),
+	}
+	r.transferChan <- t
+	return <-t.done
+}
+
+// handleTransfer starts the transfer t: the leader steps down and asks the
+// target to run.
+func (r *Replica) handleTransfer(t *transfer) {
+	if t.target < 0 || t.target >= int32(r.N) {
+		t.done <- fmt.Errorf("no replica %d", t.target)
+		return
+	}
+	if r.isWitness(t.target) {
+		t.done <- fmt.Errorf("replica %d is a witness", t.target)
+		return
+	}
+	if r.role != LEADER {
+		t.done <- fmt.Errorf("replica %d is not the leader (leader: %d)", r.Id, r.currentLeader)
+		return
+	}
+	if t.target == r.Id {
+		t.reply.Leader = r.Id
+		t.done <- nil
+		return
+	}
+	if r.status != NORMAL {
+		t.done <- errors.New("the leader is recovering")
+		return
+	}
+	t.deadline = time.Now().Add(transferTimeout)
+	r.transfer = t
+
+	r.Printf("Transferring the leadership to %d at term %d (lastCommitted=%d)\n",
+		t.target, r.currentTerm, r.lastCommitted)
+	r.becomeFollower(r.currentTerm)
+	r.stopHeartbeat()
+	r.currentLeader = t.target
+	r.resetElectionTimer()
+	tn := &MTimeoutNow{
+		Replica:       r.Id,
+		Term:          r.currentTerm,
+		LastCommitted: r.lastCommitted,
+	}
+	r.sender.SendTo(t.target, tn, r.cs.timeoutNowRPC)
+}
+
+// handleTimeoutNow makes the replica run at the request of the leader, at
+// once if it has committed as much as the leader, or else on the next
+// heartbeat interval after it has (see awaitTimeoutNow).
+func (r *Replica) handleTimeoutNow(msg *MTimeoutNow) {
+	if msg.Term < r.currentTerm || r.role == LEADER {
+		return
+	}
+	r.Printf("Leadership transferred by %d at term %d\n", msg.Replica, msg.Term)
+	r.timeoutNow = msg
+	r.timeoutNowDeadline = time.Now().Add(transferTimeout)
+	if !r.awaitTimeoutNow() {
+		r.startElection()
+	}
+}
+
+// awaitTimeoutNow tells whether the replica waits to commit up to the slot
+// of the MTimeoutNow it got before it runs, and then sets the election
+// timer to check again.
+func (r *Replica) awaitTimeoutNow() bool {
+	tn := r.timeoutNow
+	if tn == nil {
+		return false
+	}
+	if r.lastCommitted < tn.LastCommitted && time.Now().Before(r.timeoutNowDeadline) {
+		if r.electionTimer != nil {
+			r.electionTimer.Reset(HeartbeatInterval)
+		}
+		return true
+	}
+	r.timeoutNow = nil
+	return false
+}
+
+// transferred ends the transfer in progress once leader is known to lead.
+func (r *Replica) transferred(leader int32) {
+	t := r.transfer
+	if t == nil {
+		return
+	}
+	r.transfer = nil
+	if leader != t.target {
+		r.Printf("Leader transfer to %d failed: replica %d was elected\n", t.target, leader)
+		t.done <- fmt.Errorf("replica %d was elected instead of %d", leader, t.target)
+		return
+	}
+	r.Printf("Leadership transferred to %d\n", leader)
+	t.reply.Leader = leader
+	t.done <- nil
+}
+
+// checkTransfer fails the transfer in progress after transferTimeout.
+func (r *Replica) checkTransfer() {
+	t := r.transfer
+	if t != nil && time.Now().After(t.deadline) {
+		r.transfer = nil
+		r.Printf("Leader transfer to %d timed out\n", t.target)
+		t.done <- fmt.Errorf("replica %d did not become the leader in %v", t.target, transferTimeout)
+	}
+}
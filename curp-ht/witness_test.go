@@ -0,0 +1,120 @@
+package curpht
+
+import (
+	"testing"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/replica"
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/sim"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+func newTestWitness(term int32) *Witness {
+	w := &Witness{
+		Replica: newTestBaseReplica(3),
+		term:    term,
+		sender:  make(replica.Sender, 16),
+	}
+	w.Id = 2
+	w.initPool()
+	return w
+}
+
+func testPropose(clientId, seqNum int32, k state.Key) *defs.GPropose {
+	return &defs.GPropose{
+		Propose: &defs.Propose{
+			ClientId:  clientId,
+			CommandId: seqNum,
+			Command:   state.Command{Op: state.PUT, K: k},
+		},
+	}
+}
+
+// recordAck returns the Ok of the next record ack sent by w, or -1 if none
+func recordAck(w *Witness) int {
+	select {
+	case arg := <-w.sender:
+		return int(arg.Msg().(*MRecordAck).Ok)
+	default:
+		return -1
+	}
+}
+
+func TestWitnessPool(t *testing.T) {
+	w := newTestWitness(1)
+
+	w.handlePropose(testPropose(1, 1, 7))
+	if ok := recordAck(w); ok != int(TRUE) {
+		t.Fatalf("first command: ok %d, want TRUE", ok)
+	}
+	w.handlePropose(testPropose(2, 1, 7))
+	if ok := recordAck(w); ok != int(FALSE) {
+		t.Fatalf("conflicting command: ok %d, want FALSE", ok)
+	}
+
+	// the commits of the leader empty the pool, in any order
+	w.handleAccept(&MAccept{Ballot: 1, CmdId: CommandId{1, 1}, CmdSlot: 0})
+	w.handleCommit(&MCommit{Ballot: 1, CmdSlot: 0})
+	w.handleCommit(&MCommit{Ballot: 1, CmdSlot: 1})
+	w.handleAccept(&MAccept{Ballot: 1, CmdId: CommandId{2, 1}, CmdSlot: 1})
+	if len(w.cmds) != 0 || len(w.slots) != 0 || len(w.committed) != 0 {
+		t.Fatalf("pool %v, slots %v, committed %v after the commits", w.cmds, w.slots, w.committed)
+	}
+	w.handlePropose(testPropose(3, 1, 7))
+	if ok := recordAck(w); ok != int(TRUE) {
+		t.Errorf("command after the commits: ok %d, want TRUE", ok)
+	}
+
+	// the accepts of former terms are ignored
+	w.handleAccept(&MAccept{Ballot: 0, CmdId: CommandId{3, 1}, CmdSlot: 2})
+	if len(w.slots) != 0 {
+		t.Errorf("accept of term 0 taken")
+	}
+}
+
+func TestWitnessNewTerm(t *testing.T) {
+	w := newTestWitness(1)
+	w.handlePropose(testPropose(1, 1, 7))
+	recordAck(w)
+	w.handleAccept(&MAccept{Ballot: 1, CmdId: CommandId{1, 1}, CmdSlot: 0})
+
+	// the pool of the former leader is dropped with its term
+	w.checkTerm(2)
+	if w.term != 2 || len(w.cmds) != 0 || len(w.slots) != 0 {
+		t.Fatalf("term %d, pool %v, slots %v", w.term, w.cmds, w.slots)
+	}
+	w.handlePropose(testPropose(2, 1, 7))
+	if ok := recordAck(w); ok != int(TRUE) {
+		t.Errorf("ok %d after the new term, want TRUE", ok)
+	}
+	if w.checkTerm(1) {
+		t.Error("message of term 1 taken at term 2")
+	}
+}
+
+func TestWitnessesDoNotVote(t *testing.T) {
+	r := &Replica{
+		role:          CANDIDATE,
+		currentTerm:   5,
+		votedFor:      0,
+		votesReceived: 1,
+		witnesses:     []int32{3, 4},
+	}
+	r.Replica = newTestBaseReplica(5)
+	r.sender = newTestSender()
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
+	r.transferChan = make(chan *transfer)
+
+	// a majority of the 3 backups
+	r.handleRequestVoteReply(&MRequestVoteReply{Replica: 1, Term: 5, VoteGranted: TRUE})
+	if r.role != LEADER {
+		t.Errorf("not leader with 2 votes of 3 backups, role %d", r.role)
+	}
+
+	tr := &transfer{target: 4, reply: &defs.TransferLeaderReply{}, done: make(chan error, 1)}
+	r.handleTransfer(tr)
+	if err := <-tr.done; err == nil || r.role != LEADER {
+		t.Errorf("leadership transferred to a witness: %v", err)
+	}
+}
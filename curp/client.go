@@ -57,7 +57,7 @@ func NewClient(b *client.BufferClient, repNum, reqNum, pclients int) *Client {
 		N:   repNum,
 		t:   NewTimer(),
 		Q:   replica.NewThreeQuartersOf(repNum),
-		M:   replica.NewMajorityOf(repNum),
+		M:   replica.NewMajorityOf(repNum - b.NumWitnesses()),
 		num: num,
 		val: nil,
 
@@ -80,7 +80,7 @@ func NewClient(b *client.BufferClient, repNum, reqNum, pclients int) *Client {
 		SeqNum:   0,
 	}
 
-	t := fastrpc.NewTableId(defs.RPC_TABLE)
+	t := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	initCs(&c.cs, t)
 	c.RegisterRPCTable(t)
 
@@ -97,7 +97,7 @@ func NewClient(b *client.BufferClient, repNum, reqNum, pclients int) *Client {
 	go c.handleMsgs()
 
 	// Start MSync retry timer: periodically retransmit MSync for pending
-	// commands whose replies may have been dropped by SendClientMsgFast.
+	// commands whose replies may have been lost with a connection.
 	c.t.Start(2 * time.Second)
 
 	return c
@@ -137,8 +137,8 @@ func (c *Client) handleMsgs() {
 			c.handleSyncReply(rep)
 
 		case <-c.t.c:
-			// Retry pending commands whose replies may have been dropped
-			// by the non-blocking SendClientMsgFast.
+			// Retry pending commands whose replies may have been lost
+			// with a connection.
 			c.mu.Lock()
 			var syncSeqnums []int32
 			for seqnum := range c.pending {
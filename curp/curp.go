@@ -25,6 +25,8 @@ type Replica struct {
 	contactClients bool
 
 	Q replica.Majority
+	// witnesses are the replicas that are only witnesses (see witness.go)
+	witnesses []int32
 
 	isLeader    bool
 	lastCmdSlot int
@@ -78,6 +80,7 @@ type commandDesc struct {
 	seq    bool
 
 	accepted    bool
+	slowPath    bool // the leader told the client to wait for the commit
 	applied     bool
 	pendingCall func()
 }
@@ -131,7 +134,12 @@ func New(alias string, rid int, addrs []string, exec bool, pl, f int,
 	r.closedChan = make(chan struct{})
 	close(r.closedChan)
 
-	r.Q = replica.NewMajorityOf(r.N)
+	for i, w := range conf.WitnessList(r.N) {
+		if w {
+			r.witnesses = append(r.witnesses, int32(i))
+		}
+	}
+	r.Q = replica.NewMajorityOf(r.N - len(r.witnesses))
 	r.sender = replica.NewSender(r.Replica)
 	r.batcher = NewBatcher(r, 128)
 
@@ -153,6 +161,9 @@ func New(alias string, rid int, addrs []string, exec bool, pl, f int,
 
 	initCs(&r.cs, r.RPC)
 
+	// strong commands committed by the leader on the fast and the slow path
+	r.Stats.M["fast"], r.Stats.M["slow"] = 0, 0
+
 	hook.HookUser1(func() {
 		totalNum := 0
 		for i := 0; i < HISTORY_SIZE; i++ {
@@ -379,6 +390,9 @@ func getAcksHandler(r *Replica, desc *commandDesc) replica.MsgSetHandler {
 			CmdSlot: desc.cmdSlot,
 		}
 		if r.optimized {
+			if r.isLeader {
+				r.commitToWitnesses(commit)
+			}
 			r.handleCommit(commit, desc)
 		} else if r.isLeader {
 			r.sender.SendToAll(commit, r.cs.commitRPC)
@@ -387,6 +401,14 @@ func getAcksHandler(r *Replica, desc *commandDesc) replica.MsgSetHandler {
 	}
 }
 
+// commitToWitnesses sends commit to the witnesses, which then forget the
+// command; the backups of the optimized protocol commit on their own.
+func (r *Replica) commitToWitnesses(commit *MCommit) {
+	for _, w := range r.witnesses {
+		r.sender.SendTo(w, commit, r.cs.commitRPC)
+	}
+}
+
 func (r *Replica) handleCommit(msg *MCommit, desc *commandDesc) {
 	if r.delivered.Has(desc.slotStr) {
 		return
@@ -534,6 +556,7 @@ func (r *Replica) deliver(desc *commandDesc, slot int) {
 			} else {
 				rep.Ok = TRUE
 			}
+			desc.slowPath = rep.Ok == FALSE
 			// Always send reply to client so they can complete via macks quorum
 			// even when rep.Ok == FALSE (pending dependency). Without this,
 			// the client hangs waiting for a leader reply that never comes.
@@ -557,6 +580,15 @@ func (r *Replica) deliver(desc *commandDesc, slot int) {
 		}
 
 		if desc.phase == COMMIT {
+			if r.isLeader && desc.propose != nil {
+				r.M.Lock()
+				if desc.slowPath {
+					r.Stats.M["slow"]++
+				} else {
+					r.Stats.M["fast"]++
+				}
+				r.M.Unlock()
+			}
 			if !r.contactClients {
 				if (r.optimized && desc.propose.Proxy) ||
 					(!r.optimized && r.isLeader) {
@@ -646,6 +678,7 @@ func (r *Replica) newDesc() *commandDesc {
 	desc.successorL = sync.Mutex{}
 	desc.accepted = false
 	desc.applied = false
+	desc.slowPath = false
 
 	desc.afterPayload = desc.afterPayload.ReinitCondF(func() bool {
 		return (desc.propose != nil || r.proposes.Has(desc.cmdId.String()))
@@ -221,6 +221,7 @@ func newTestReplica() *Replica {
 	baseRep := &replica.Replica{
 		Exec:  true,
 		State: state.InitState(),
+		Stats: &defs.Stats{M: make(map[string]int)},
 	}
 	r := &Replica{
 		Replica:     baseRep,
@@ -360,6 +361,44 @@ func TestD8AlwaysSendMReply(t *testing.T) {
 	}
 }
 
+// TestCommitCountsSlowPath verifies that the leader counts a command in the
+// slow path stats once it told the client to wait for the commit.
+func TestCommitCountsSlowPath(t *testing.T) {
+	r := newTestReplica()
+	r.history = make([]commandStaticDesc, HISTORY_SIZE)
+
+	cmdId := CommandId{ClientId: 1, SeqNum: 0}
+	propose := &defs.GPropose{
+		Propose: &defs.Propose{
+			ClientId: 1,
+			Command:  state.Command{Op: state.PUT, K: state.Key(1), V: state.Value([]byte("v"))},
+		},
+	}
+	r.proposes.Set(cmdId.String(), propose)
+
+	desc := &commandDesc{
+		cmdId:        cmdId,
+		cmd:          propose.Command,
+		phase:        ACCEPT,
+		cmdSlot:      0,
+		slotStr:      "0",
+		dep:          5, // dependency not committed: Ok=FALSE
+		afterPayload: hook.NewOptCondF(func() bool { return true }),
+		msgs:         make(chan interface{}, 128),
+		seq:          true,
+	}
+	r.deliver(desc, 0)
+	if !desc.slowPath || r.Stats.M["slow"] != 0 {
+		t.Fatalf("slowPath %v, slow %d before the commit", desc.slowPath, r.Stats.M["slow"])
+	}
+
+	desc.phase = COMMIT
+	r.deliver(desc, 0)
+	if r.Stats.M["slow"] != 1 || r.Stats.M["fast"] != 0 {
+		t.Errorf("stats = %v, want one slow path command", r.Stats.M)
+	}
+}
+
 // TestAppliedPreventsDoubleExecution verifies that the applied flag prevents
 // Execute from being called twice on the same descriptor.
 func TestAppliedPreventsDoubleExecution(t *testing.T) {
@@ -0,0 +1,617 @@
+// Code generated by marshalgen; DO NOT EDIT.
+
+package curp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+)
+
+func (t *CommandId) New() fastrpc.Serializable {
+	return new(CommandId)
+}
+
+func (t *CommandId) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type CommandIdCache struct {
+	mu    sync.Mutex
+	cache []*CommandId
+}
+
+func NewCommandIdCache() *CommandIdCache {
+	c := &CommandIdCache{}
+	c.cache = make([]*CommandId, 0)
+	return c
+}
+
+func (p *CommandIdCache) Get() *CommandId {
+	var t *CommandId
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &CommandId{}
+	}
+	return t
+}
+
+func (p *CommandIdCache) Put(t *CommandId) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *CommandId) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.SeqNum))
+	wire.Write(b[:8])
+}
+
+func (t *CommandId) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *MReply) New() fastrpc.Serializable {
+	return new(MReply)
+}
+
+func (t *MReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MReplyCache struct {
+	mu    sync.Mutex
+	cache []*MReply
+}
+
+func NewMReplyCache() *MReplyCache {
+	c := &MReplyCache{}
+	c.cache = make([]*MReply, 0)
+	return c
+}
+
+func (p *MReplyCache) Get() *MReply {
+	var t *MReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MReply{}
+	}
+	return t
+}
+
+func (p *MReplyCache) Put(t *MReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MReply) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Rep)))])
+	wire.Write(t.Rep)
+	b[0] = byte(t.Ok)
+	wire.Write(b[:1])
+}
+
+func (t *MReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Rep = make([]byte, n1)
+	if _, err := io.ReadFull(wire, t.Rep); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:1]); err != nil {
+		return err
+	}
+	t.Ok = b[0]
+	return nil
+}
+
+func (t *MAccept) New() fastrpc.Serializable {
+	return new(MAccept)
+}
+
+func (t *MAccept) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MAcceptCache struct {
+	mu    sync.Mutex
+	cache []*MAccept
+}
+
+func NewMAcceptCache() *MAcceptCache {
+	c := &MAcceptCache{}
+	c.cache = make([]*MAccept, 0)
+	return c
+}
+
+func (p *MAcceptCache) Get() *MAccept {
+	var t *MAccept
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MAccept{}
+	}
+	return t
+}
+
+func (p *MAcceptCache) Put(t *MAccept) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MAccept) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+	t.Cmd.Marshal(wire)
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.CmdId.SeqNum))
+	binary.LittleEndian.PutUint64(b[8:], uint64(t.CmdSlot))
+	wire.Write(b[:16])
+}
+
+func (t *MAccept) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	if err := t.Cmd.Unmarshal(wire); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (t *MAcceptAck) New() fastrpc.Serializable {
+	return new(MAcceptAck)
+}
+
+func (t *MAcceptAck) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type MAcceptAckCache struct {
+	mu    sync.Mutex
+	cache []*MAcceptAck
+}
+
+func NewMAcceptAckCache() *MAcceptAckCache {
+	c := &MAcceptAckCache{}
+	c.cache = make([]*MAcceptAck, 0)
+	return c
+}
+
+func (p *MAcceptAckCache) Get() *MAcceptAck {
+	var t *MAcceptAck
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MAcceptAck{}
+	}
+	return t
+}
+
+func (p *MAcceptAckCache) Put(t *MAcceptAck) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MAcceptAck) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint64(b[8:], uint64(t.CmdSlot))
+	wire.Write(b[:16])
+}
+
+func (t *MAcceptAck) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (t *MAAcks) New() fastrpc.Serializable {
+	return new(MAAcks)
+}
+
+func (t *MAAcks) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MAAcksCache struct {
+	mu    sync.Mutex
+	cache []*MAAcks
+}
+
+func NewMAAcksCache() *MAAcksCache {
+	c := &MAAcksCache{}
+	c.cache = make([]*MAAcks, 0)
+	return c
+}
+
+func (p *MAAcksCache) Get() *MAAcks {
+	var t *MAAcks
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MAAcks{}
+	}
+	return t
+}
+
+func (p *MAAcksCache) Put(t *MAAcks) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MAAcks) Marshal(wire io.Writer) {
+	var b [16]byte
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Acks)))])
+	for i := range t.Acks {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Acks[i].Replica))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.Acks[i].Ballot))
+		binary.LittleEndian.PutUint64(b[8:], uint64(t.Acks[i].CmdSlot))
+		wire.Write(b[:16])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Accepts)))])
+	for i := range t.Accepts {
+		t.Accepts[i].Marshal(wire)
+	}
+}
+
+func (t *MAAcks) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Acks = make([]MAcceptAck, n1)
+	for i := range t.Acks {
+		if _, err := io.ReadFull(wire, b[:16]); err != nil {
+			return err
+		}
+		t.Acks[i].Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.Acks[i].Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+		t.Acks[i].CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Accepts = make([]MAccept, n2)
+	for i := range t.Accepts {
+		if err := t.Accepts[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *MRecordAck) New() fastrpc.Serializable {
+	return new(MRecordAck)
+}
+
+func (t *MRecordAck) BinarySize() (nbytes int, sizeKnown bool) {
+	return 17, true
+}
+
+type MRecordAckCache struct {
+	mu    sync.Mutex
+	cache []*MRecordAck
+}
+
+func NewMRecordAckCache() *MRecordAckCache {
+	c := &MRecordAckCache{}
+	c.cache = make([]*MRecordAck, 0)
+	return c
+}
+
+func (p *MRecordAckCache) Get() *MRecordAck {
+	var t *MRecordAck
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MRecordAck{}
+	}
+	return t
+}
+
+func (p *MRecordAckCache) Put(t *MRecordAck) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MRecordAck) Marshal(wire io.Writer) {
+	var b [17]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	b[16] = byte(t.Ok)
+	wire.Write(b[:17])
+}
+
+func (t *MRecordAck) Unmarshal(wire io.Reader) error {
+	var b [17]byte
+	if _, err := io.ReadFull(wire, b[:17]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.Ok = b[16]
+	return nil
+}
+
+func (t *MCommit) New() fastrpc.Serializable {
+	return new(MCommit)
+}
+
+func (t *MCommit) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type MCommitCache struct {
+	mu    sync.Mutex
+	cache []*MCommit
+}
+
+func NewMCommitCache() *MCommitCache {
+	c := &MCommitCache{}
+	c.cache = make([]*MCommit, 0)
+	return c
+}
+
+func (p *MCommitCache) Get() *MCommit {
+	var t *MCommit
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MCommit{}
+	}
+	return t
+}
+
+func (p *MCommitCache) Put(t *MCommit) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MCommit) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint64(b[8:], uint64(t.CmdSlot))
+	wire.Write(b[:16])
+}
+
+func (t *MCommit) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (t *MSync) New() fastrpc.Serializable {
+	return new(MSync)
+}
+
+func (t *MSync) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type MSyncCache struct {
+	mu    sync.Mutex
+	cache []*MSync
+}
+
+func NewMSyncCache() *MSyncCache {
+	c := &MSyncCache{}
+	c.cache = make([]*MSync, 0)
+	return c
+}
+
+func (p *MSyncCache) Get() *MSync {
+	var t *MSync
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MSync{}
+	}
+	return t
+}
+
+func (p *MSyncCache) Put(t *MSync) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MSync) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:8])
+}
+
+func (t *MSync) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *MSyncReply) New() fastrpc.Serializable {
+	return new(MSyncReply)
+}
+
+func (t *MSyncReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MSyncReplyCache struct {
+	mu    sync.Mutex
+	cache []*MSyncReply
+}
+
+func NewMSyncReplyCache() *MSyncReplyCache {
+	c := &MSyncReplyCache{}
+	c.cache = make([]*MSyncReply, 0)
+	return c
+}
+
+func (p *MSyncReplyCache) Get() *MSyncReply {
+	var t *MSyncReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MSyncReply{}
+	}
+	return t
+}
+
+func (p *MSyncReplyCache) Put(t *MSyncReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MSyncReply) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Rep)))])
+	wire.Write(t.Rep)
+}
+
+func (t *MSyncReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Rep = make([]byte, n1)
+	if _, err := io.ReadFull(wire, t.Rep); err != nil {
+		return err
+	}
+	return nil
+}
+
+type byteReader interface {
+	io.Reader
+	ReadByte() (c byte, err error)
+}
@@ -0,0 +1,167 @@
+package curp
+
+import (
+	"github.com/imdea-software/swiftpaxos/config"
+	"github.com/imdea-software/swiftpaxos/dlog"
+	"github.com/imdea-software/swiftpaxos/replica"
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/state"
+	"github.com/orcaman/concurrent-map"
+)
+
+// Witnesses
+//
+// A witness is a replica of the config that only records the commands
+// proposed by the clients (see config.Config.Witnesses): like a backup, it
+// acknowledges the commands that commute with the unsynced commands it
+// holds, so that clients complete them on the fast path, but it has no log
+// and no state machine and does not acknowledge accepts. It learns the
+// slots of the commands from the accepts of the leader, and forgets a
+// command when the leader commits it. The backups count only the backups in
+// their quorums.
+
+// Witness is a witness of a CURP cluster.
+type Witness struct {
+	*replica.Replica
+
+	ballot int32
+
+	// pool is the witness pool of a backup, the only part of a replica
+	// that a witness has
+	pool      *Replica
+	cmds      map[CommandId]state.Command // unsynced commands
+	slots     map[int]CommandId           // of the accepts not committed yet
+	committed map[int]bool                // committed slots whose accept is late
+
+	sender replica.Sender
+	cs     CommunicationSupply
+}
+
+func NewWitness(alias string, rid int, addrs []string, f int,
+	conf *config.Config, logger *dlog.Logger) *Witness {
+	w := &Witness{
+		Replica: replica.New(alias, rid, f, addrs, false, false, false, conf, logger),
+	}
+	w.initPool()
+	w.sender = replica.NewSender(w.Replica)
+
+	_, leaderIds, err := replica.NewQuorumsFromFile(conf.Quorum, w.Replica)
+	if err == nil && len(leaderIds) != 0 {
+		w.ballot = leaderIds[0]
+	} else if err != replica.NO_QUORUM_FILE {
+		w.Fatal(err)
+	}
+	if w.ballot == w.Id {
+		w.Fatal("witness", w.Id, "cannot be the leader")
+	}
+
+	initCs(&w.cs, w.RPC)
+	w.Discard(w.cs.acceptChan, w.cs.aacksChan, w.cs.commitChan)
+
+	go w.run()
+
+	return w
+}
+
+func (w *Witness) initPool() {
+	w.pool = &Replica{
+		unsynced: cmap.New(),
+		synced:   cmap.New(),
+	}
+	w.cmds = make(map[CommandId]state.Command)
+	w.slots = make(map[int]CommandId)
+	w.committed = make(map[int]bool)
+}
+
+// Status is called via RPC by the admin participant.
+func (w *Witness) Status(args *defs.StatusArgs, reply *defs.StatusReply) error {
+	w.Replica.Status(args, reply)
+	reply.Role = "witness"
+	reply.Term = w.ballot
+	return nil
+}
+
+func (w *Witness) run() {
+	w.ConnectToPeers()
+	w.ComputeClosestPeers()
+
+	go w.WaitForClientConnections()
+
+	for !w.Shutdown {
+		select {
+		case propose := <-w.ProposeChan:
+			w.handlePropose(propose)
+
+		case m := <-w.cs.acceptChan:
+			w.handleAccept(m.(*MAccept))
+
+		case m := <-w.cs.aacksChan:
+			aacks := m.(*MAAcks)
+			for i := range aacks.Accepts {
+				w.handleAccept(&aacks.Accepts[i])
+			}
+
+		case m := <-w.cs.commitChan:
+			w.handleCommit(m.(*MCommit))
+		}
+	}
+}
+
+// handlePropose records the command of propose, unless it is already
+// committed, and tells the client whether it commutes with the unsynced
+// commands.
+func (w *Witness) handlePropose(propose *defs.GPropose) {
+	cmdId := CommandId{
+		ClientId: propose.ClientId,
+		SeqNum:   propose.CommandId,
+	}
+	if _, exists := w.cmds[cmdId]; exists || w.pool.synced.Has(cmdId.String()) {
+		return
+	}
+	recAck := &MRecordAck{
+		Replica: w.Id,
+		Ballot:  w.ballot,
+		CmdId:   cmdId,
+		Ok:      w.pool.ok(propose.Command),
+	}
+	w.sender.SendToClient(propose.ClientId, recAck, w.cs.recordAckRPC)
+	w.pool.unsync(propose.Command)
+	w.cmds[cmdId] = propose.Command
+}
+
+func (w *Witness) handleAccept(acc *MAccept) {
+	if acc.Ballot != w.ballot {
+		return
+	}
+	if w.committed[acc.CmdSlot] {
+		delete(w.committed, acc.CmdSlot)
+		w.sync(acc.CmdId)
+		return
+	}
+	w.slots[acc.CmdSlot] = acc.CmdId
+}
+
+func (w *Witness) handleCommit(commit *MCommit) {
+	if commit.Ballot != w.ballot {
+		return
+	}
+	cmdId, exists := w.slots[commit.CmdSlot]
+	if !exists {
+		w.committed[commit.CmdSlot] = true
+		return
+	}
+	delete(w.slots, commit.CmdSlot)
+	w.sync(cmdId)
+}
+
+// sync forgets the committed command cmdId, or its proposal if it comes
+// later.
+func (w *Witness) sync(cmdId CommandId) {
+	cmd, exists := w.cmds[cmdId]
+	if !exists {
+		w.pool.synced.Set(cmdId.String(), struct{}{})
+		return
+	}
+	delete(w.cmds, cmdId)
+	w.pool.sync(cmdId, cmd)
+}
@@ -0,0 +1,99 @@
+package curp
+
+import (
+	"testing"
+
+	"github.com/imdea-software/swiftpaxos/replica"
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+func newTestWitness() *Witness {
+	w := &Witness{
+		Replica: &replica.Replica{Id: 2},
+		sender:  make(replica.Sender, 16),
+	}
+	w.initPool()
+	return w
+}
+
+func testPropose(clientId, seqNum int32, k state.Key) *defs.GPropose {
+	return &defs.GPropose{
+		Propose: &defs.Propose{
+			ClientId:  clientId,
+			CommandId: seqNum,
+			Command:   state.Command{Op: state.PUT, K: k},
+		},
+	}
+}
+
+// recordAck returns the Ok of the next record ack sent by w, or -1 if none
+func recordAck(w *Witness) int {
+	select {
+	case arg := <-w.sender:
+		return int(arg.Msg().(*MRecordAck).Ok)
+	default:
+		return -1
+	}
+}
+
+func TestWitnessPool(t *testing.T) {
+	w := newTestWitness()
+
+	w.handlePropose(testPropose(1, 1, 7))
+	if ok := recordAck(w); ok != int(TRUE) {
+		t.Fatalf("first command: ok %d, want TRUE", ok)
+	}
+	w.handlePropose(testPropose(2, 1, 7))
+	if ok := recordAck(w); ok != int(FALSE) {
+		t.Fatalf("conflicting command: ok %d, want FALSE", ok)
+	}
+	w.handlePropose(testPropose(3, 1, 8))
+	if ok := recordAck(w); ok != int(TRUE) {
+		t.Fatalf("commuting command: ok %d, want TRUE", ok)
+	}
+
+	// the commits of the leader empty the pool
+	w.handleAccept(&MAccept{CmdId: CommandId{1, 1}, CmdSlot: 0})
+	w.handleAccept(&MAccept{CmdId: CommandId{2, 1}, CmdSlot: 1})
+	w.handleCommit(&MCommit{CmdSlot: 0})
+	w.handleCommit(&MCommit{CmdSlot: 1})
+	if len(w.cmds) != 1 || len(w.slots) != 0 {
+		t.Fatalf("pool %v, slots %v after the commits", w.cmds, w.slots)
+	}
+	w.handlePropose(testPropose(4, 1, 7))
+	if ok := recordAck(w); ok != int(TRUE) {
+		t.Errorf("command after the commits: ok %d, want TRUE", ok)
+	}
+
+	// the accepts of other ballots are ignored
+	w.handleAccept(&MAccept{Ballot: 1, CmdId: CommandId{4, 1}, CmdSlot: 2})
+	if len(w.slots) != 0 {
+		t.Errorf("accept of ballot 1 taken")
+	}
+}
+
+func TestWitnessLateMessages(t *testing.T) {
+	w := newTestWitness()
+
+	// commit before the accept
+	w.handlePropose(testPropose(1, 1, 7))
+	recordAck(w)
+	w.handleCommit(&MCommit{CmdSlot: 0})
+	w.handleAccept(&MAccept{CmdId: CommandId{1, 1}, CmdSlot: 0})
+	if len(w.cmds) != 0 || len(w.committed) != 0 {
+		t.Fatalf("pool %v, committed %v", w.cmds, w.committed)
+	}
+
+	// commit before the proposal
+	w.handleAccept(&MAccept{CmdId: CommandId{2, 1}, CmdSlot: 1})
+	w.handleCommit(&MCommit{CmdSlot: 1})
+	w.handlePropose(testPropose(2, 1, 7))
+	if ok := recordAck(w); ok != -1 || len(w.cmds) != 0 {
+		t.Errorf("committed command recorded: ok %d, pool %v", ok, w.cmds)
+	}
+	w.handlePropose(testPropose(3, 1, 7))
+	if ok := recordAck(w); ok != int(TRUE) {
+		t.Errorf("ok %d, want TRUE", ok)
+	}
+}
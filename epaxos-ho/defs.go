@@ -4,6 +4,8 @@ import (
 	"github.com/imdea-software/swiftpaxos/state"
 )
 
+//go:generate go run github.com/imdea-software/swiftpaxos/marshalgen -type Prepare,PrepareReply,PreAccept,PreAcceptReply,PreAcceptOK,Accept,AcceptReply,Commit,CausalCommit,CommitShort,TryPreAccept,TryPreAcceptReply
+
 // Instance status constants for EPaxos-HO.
 const (
 	NONE int8 = iota
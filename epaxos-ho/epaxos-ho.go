@@ -56,18 +56,18 @@ type Replica struct {
 	outstandingStrong int64
 
 	// RPC type identifiers
-	prepareRPC            uint8
-	prepareReplyRPC       uint8
-	preAcceptRPC          uint8
-	preAcceptReplyRPC     uint8
-	preAcceptOKRPC        uint8
-	acceptRPC             uint8
-	acceptReplyRPC        uint8
-	commitRPC             uint8
-	commitShortRPC        uint8
-	tryPreAcceptRPC       uint8
-	tryPreAcceptReplyRPC  uint8
-	causalCommitRPC       uint8
+	prepareRPC            fastrpc.Code
+	prepareReplyRPC       fastrpc.Code
+	preAcceptRPC          fastrpc.Code
+	preAcceptReplyRPC     fastrpc.Code
+	preAcceptOKRPC        fastrpc.Code
+	acceptRPC             fastrpc.Code
+	acceptReplyRPC        fastrpc.Code
+	commitRPC             fastrpc.Code
+	commitShortRPC        fastrpc.Code
+	tryPreAcceptRPC       fastrpc.Code
+	tryPreAcceptReplyRPC  fastrpc.Code
+	causalCommitRPC       fastrpc.Code
 
 	// Instance management
 	InstanceSpace [][]*Instance // the space of all instances (used and not yet used)
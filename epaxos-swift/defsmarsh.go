@@ -0,0 +1,848 @@
+// Code generated by marshalgen; DO NOT EDIT.
+
+package epaxosswift
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+func (t *Prepare) New() fastrpc.Serializable {
+	return new(Prepare)
+}
+
+func (t *Prepare) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type PrepareCache struct {
+	mu    sync.Mutex
+	cache []*Prepare
+}
+
+func NewPrepareCache() *PrepareCache {
+	c := &PrepareCache{}
+	c.cache = make([]*Prepare, 0)
+	return c
+}
+
+func (p *PrepareCache) Get() *Prepare {
+	var t *Prepare
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Prepare{}
+	}
+	return t
+}
+
+func (p *PrepareCache) Put(t *Prepare) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Prepare) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	wire.Write(b[:16])
+}
+
+func (t *Prepare) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	return nil
+}
+
+func (t *PrepareReply) New() fastrpc.Serializable {
+	return new(PrepareReply)
+}
+
+func (t *PrepareReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type PrepareReplyCache struct {
+	mu    sync.Mutex
+	cache []*PrepareReply
+}
+
+func NewPrepareReplyCache() *PrepareReplyCache {
+	c := &PrepareReplyCache{}
+	c.cache = make([]*PrepareReply, 0)
+	return c
+}
+
+func (p *PrepareReplyCache) Get() *PrepareReply {
+	var t *PrepareReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &PrepareReply{}
+	}
+	return t
+}
+
+func (p *PrepareReplyCache) Put(t *PrepareReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *PrepareReply) Marshal(wire io.Writer) {
+	var b [21]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.AcceptorId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.VBallot))
+	b[20] = byte(t.Status)
+	wire.Write(b[:21])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
+		t.Command[i].Marshal(wire)
+	}
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Seq))
+	wire.Write(b[:4])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
+	}
+}
+
+func (t *PrepareReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [21]byte
+	if _, err := io.ReadFull(wire, b[:21]); err != nil {
+		return err
+	}
+	t.AcceptorId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.VBallot = int32(binary.LittleEndian.Uint32(b[16:]))
+	t.Status = int8(b[20])
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
+		return err
+	}
+	t.Seq = int32(binary.LittleEndian.Uint32(b[0:]))
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Deps = make([]int32, n2)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
+			return err
+		}
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
+	}
+	return nil
+}
+
+func (t *PreAccept) New() fastrpc.Serializable {
+	return new(PreAccept)
+}
+
+func (t *PreAccept) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type PreAcceptCache struct {
+	mu    sync.Mutex
+	cache []*PreAccept
+}
+
+func NewPreAcceptCache() *PreAcceptCache {
+	c := &PreAcceptCache{}
+	c.cache = make([]*PreAccept, 0)
+	return c
+}
+
+func (p *PreAcceptCache) Get() *PreAccept {
+	var t *PreAccept
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &PreAccept{}
+	}
+	return t
+}
+
+func (p *PreAcceptCache) Put(t *PreAccept) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *PreAccept) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
+		t.Command[i].Marshal(wire)
+	}
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Seq))
+	wire.Write(b[:4])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
+	}
+}
+
+func (t *PreAccept) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
+		return err
+	}
+	t.Seq = int32(binary.LittleEndian.Uint32(b[0:]))
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Deps = make([]int32, n2)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
+			return err
+		}
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
+	}
+	return nil
+}
+
+func (t *PreAcceptReply) New() fastrpc.Serializable {
+	return new(PreAcceptReply)
+}
+
+func (t *PreAcceptReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type PreAcceptReplyCache struct {
+	mu    sync.Mutex
+	cache []*PreAcceptReply
+}
+
+func NewPreAcceptReplyCache() *PreAcceptReplyCache {
+	c := &PreAcceptReplyCache{}
+	c.cache = make([]*PreAcceptReply, 0)
+	return c
+}
+
+func (p *PreAcceptReplyCache) Get() *PreAcceptReply {
+	var t *PreAcceptReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &PreAcceptReply{}
+	}
+	return t
+}
+
+func (p *PreAcceptReplyCache) Put(t *PreAcceptReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *PreAcceptReply) Marshal(wire io.Writer) {
+	var b [20]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.VBallot))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.Seq))
+	wire.Write(b[:20])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.CommittedDeps)))])
+	for i := range t.CommittedDeps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.CommittedDeps[i]))
+		wire.Write(b[:4])
+	}
+	b[0] = byte(t.Status)
+	wire.Write(b[:1])
+}
+
+func (t *PreAcceptReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [20]byte
+	if _, err := io.ReadFull(wire, b[:20]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.VBallot = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.Seq = int32(binary.LittleEndian.Uint32(b[16:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Deps = make([]int32, n1)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
+			return err
+		}
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.CommittedDeps = make([]int32, n2)
+	for i := range t.CommittedDeps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
+			return err
+		}
+		t.CommittedDeps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
+	}
+	if _, err := io.ReadFull(wire, b[:1]); err != nil {
+		return err
+	}
+	t.Status = int8(b[0])
+	return nil
+}
+
+func (t *PreAcceptOK) New() fastrpc.Serializable {
+	return new(PreAcceptOK)
+}
+
+func (t *PreAcceptOK) BinarySize() (nbytes int, sizeKnown bool) {
+	return 4, true
+}
+
+type PreAcceptOKCache struct {
+	mu    sync.Mutex
+	cache []*PreAcceptOK
+}
+
+func NewPreAcceptOKCache() *PreAcceptOKCache {
+	c := &PreAcceptOKCache{}
+	c.cache = make([]*PreAcceptOK, 0)
+	return c
+}
+
+func (p *PreAcceptOKCache) Get() *PreAcceptOK {
+	var t *PreAcceptOK
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &PreAcceptOK{}
+	}
+	return t
+}
+
+func (p *PreAcceptOKCache) Put(t *PreAcceptOK) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *PreAcceptOK) Marshal(wire io.Writer) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Instance))
+	wire.Write(b[:4])
+}
+
+func (t *PreAcceptOK) Unmarshal(wire io.Reader) error {
+	var b [4]byte
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
+		return err
+	}
+	t.Instance = int32(binary.LittleEndian.Uint32(b[0:]))
+	return nil
+}
+
+func (t *Accept) New() fastrpc.Serializable {
+	return new(Accept)
+}
+
+func (t *Accept) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type AcceptCache struct {
+	mu    sync.Mutex
+	cache []*Accept
+}
+
+func NewAcceptCache() *AcceptCache {
+	c := &AcceptCache{}
+	c.cache = make([]*Accept, 0)
+	return c
+}
+
+func (p *AcceptCache) Get() *Accept {
+	var t *Accept
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Accept{}
+	}
+	return t
+}
+
+func (p *AcceptCache) Put(t *Accept) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Accept) Marshal(wire io.Writer) {
+	var b [20]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.Seq))
+	wire.Write(b[:20])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
+	}
+}
+
+func (t *Accept) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [20]byte
+	if _, err := io.ReadFull(wire, b[:20]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.Seq = int32(binary.LittleEndian.Uint32(b[16:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Deps = make([]int32, n1)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
+			return err
+		}
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
+	}
+	return nil
+}
+
+func (t *AcceptReply) New() fastrpc.Serializable {
+	return new(AcceptReply)
+}
+
+func (t *AcceptReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 12, true
+}
+
+type AcceptReplyCache struct {
+	mu    sync.Mutex
+	cache []*AcceptReply
+}
+
+func NewAcceptReplyCache() *AcceptReplyCache {
+	c := &AcceptReplyCache{}
+	c.cache = make([]*AcceptReply, 0)
+	return c
+}
+
+func (p *AcceptReplyCache) Get() *AcceptReply {
+	var t *AcceptReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &AcceptReply{}
+	}
+	return t
+}
+
+func (p *AcceptReplyCache) Put(t *AcceptReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *AcceptReply) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Ballot))
+	wire.Write(b[:12])
+}
+
+func (t *AcceptReply) Unmarshal(wire io.Reader) error {
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[8:]))
+	return nil
+}
+
+func (t *Commit) New() fastrpc.Serializable {
+	return new(Commit)
+}
+
+func (t *Commit) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type CommitCache struct {
+	mu    sync.Mutex
+	cache []*Commit
+}
+
+func NewCommitCache() *CommitCache {
+	c := &CommitCache{}
+	c.cache = make([]*Commit, 0)
+	return c
+}
+
+func (p *CommitCache) Get() *Commit {
+	var t *Commit
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Commit{}
+	}
+	return t
+}
+
+func (p *CommitCache) Put(t *Commit) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Commit) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
+		t.Command[i].Marshal(wire)
+	}
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Seq))
+	wire.Write(b[:4])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
+	}
+}
+
+func (t *Commit) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
+		return err
+	}
+	t.Seq = int32(binary.LittleEndian.Uint32(b[0:]))
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Deps = make([]int32, n2)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
+			return err
+		}
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
+	}
+	return nil
+}
+
+func (t *TryPreAccept) New() fastrpc.Serializable {
+	return new(TryPreAccept)
+}
+
+func (t *TryPreAccept) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type TryPreAcceptCache struct {
+	mu    sync.Mutex
+	cache []*TryPreAccept
+}
+
+func NewTryPreAcceptCache() *TryPreAcceptCache {
+	c := &TryPreAcceptCache{}
+	c.cache = make([]*TryPreAccept, 0)
+	return c
+}
+
+func (p *TryPreAcceptCache) Get() *TryPreAccept {
+	var t *TryPreAccept
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &TryPreAccept{}
+	}
+	return t
+}
+
+func (p *TryPreAcceptCache) Put(t *TryPreAccept) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *TryPreAccept) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
+		t.Command[i].Marshal(wire)
+	}
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Seq))
+	wire.Write(b[:4])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
+	}
+}
+
+func (t *TryPreAccept) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
+		return err
+	}
+	t.Seq = int32(binary.LittleEndian.Uint32(b[0:]))
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Deps = make([]int32, n2)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
+			return err
+		}
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
+	}
+	return nil
+}
+
+func (t *TryPreAcceptReply) New() fastrpc.Serializable {
+	return new(TryPreAcceptReply)
+}
+
+func (t *TryPreAcceptReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 29, true
+}
+
+type TryPreAcceptReplyCache struct {
+	mu    sync.Mutex
+	cache []*TryPreAcceptReply
+}
+
+func NewTryPreAcceptReplyCache() *TryPreAcceptReplyCache {
+	c := &TryPreAcceptReplyCache{}
+	c.cache = make([]*TryPreAcceptReply, 0)
+	return c
+}
+
+func (p *TryPreAcceptReplyCache) Get() *TryPreAcceptReply {
+	var t *TryPreAcceptReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &TryPreAcceptReply{}
+	}
+	return t
+}
+
+func (p *TryPreAcceptReplyCache) Put(t *TryPreAcceptReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *TryPreAcceptReply) Marshal(wire io.Writer) {
+	var b [29]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.AcceptorId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.VBallot))
+	binary.LittleEndian.PutUint32(b[20:], uint32(t.ConflictReplica))
+	binary.LittleEndian.PutUint32(b[24:], uint32(t.ConflictInstance))
+	b[28] = byte(t.ConflictStatus)
+	wire.Write(b[:29])
+}
+
+func (t *TryPreAcceptReply) Unmarshal(wire io.Reader) error {
+	var b [29]byte
+	if _, err := io.ReadFull(wire, b[:29]); err != nil {
+		return err
+	}
+	t.AcceptorId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.VBallot = int32(binary.LittleEndian.Uint32(b[16:]))
+	t.ConflictReplica = int32(binary.LittleEndian.Uint32(b[20:]))
+	t.ConflictInstance = int32(binary.LittleEndian.Uint32(b[24:]))
+	t.ConflictStatus = int8(b[28])
+	return nil
+}
+
+type byteReader interface {
+	io.Reader
+	ReadByte() (c byte, err error)
+}
@@ -51,15 +51,15 @@ type Replica struct {
 	acceptReplyChan       chan fastrpc.Serializable
 	tryPreAcceptChan      chan fastrpc.Serializable
 	tryPreAcceptReplyChan chan fastrpc.Serializable
-	prepareRPC            uint8
-	prepareReplyRPC       uint8
-	preAcceptRPC          uint8
-	preAcceptReplyRPC     uint8
-	acceptRPC             uint8
-	acceptReplyRPC        uint8
-	commitRPC             uint8
-	tryPreAcceptRPC       uint8
-	tryPreAcceptReplyRPC  uint8
+	prepareRPC            fastrpc.Code
+	prepareReplyRPC       fastrpc.Code
+	preAcceptRPC          fastrpc.Code
+	preAcceptReplyRPC     fastrpc.Code
+	acceptRPC             fastrpc.Code
+	acceptReplyRPC        fastrpc.Code
+	commitRPC             fastrpc.Code
+	tryPreAcceptRPC       fastrpc.Code
+	tryPreAcceptReplyRPC  fastrpc.Code
 	// the space of all instances (used and not yet used)
 	InstanceSpace [][]*Instance
 	// highest active instance numbers that this replica knows about
@@ -1,11 +1,11 @@
 package epaxos
 
 import (
-	"io"
-
 	"github.com/imdea-software/swiftpaxos/state"
 )
 
+//go:generate go run github.com/imdea-software/swiftpaxos/marshalgen -type Prepare,PrepareReply,PreAccept,PreAcceptReply,PreAcceptOK,Accept,AcceptReply,Commit,TryPreAccept,TryPreAcceptReply
+
 type Prepare struct {
 	LeaderId int32
 	Replica  int32
@@ -104,8 +104,3 @@ const (
 	COMMITTED
 	EXECUTED
 )
-
-type byteReader interface {
-	io.Reader
-	ReadByte() (c byte, err error)
-}
@@ -334,8 +334,8 @@ func TestBinarySize(t *testing.T) {
 	}{
 		{"Prepare", 16, true},
 		{"PreAcceptOK", 4, true},
-		{"AcceptReply", 13, true},
-		{"TryPreAcceptReply", 26, true},
+		{"AcceptReply", 12, true},
+		{"TryPreAcceptReply", 29, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1,3 +1,5 @@
+// Code generated by marshalgen; DO NOT EDIT.
+
 package epaxos
 
 import (
@@ -10,9 +12,70 @@ import (
 	"github.com/imdea-software/swiftpaxos/state"
 )
 
+func (t *Prepare) New() fastrpc.Serializable {
+	return new(Prepare)
+}
+
+func (t *Prepare) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type PrepareCache struct {
+	mu    sync.Mutex
+	cache []*Prepare
+}
+
+func NewPrepareCache() *PrepareCache {
+	c := &PrepareCache{}
+	c.cache = make([]*Prepare, 0)
+	return c
+}
+
+func (p *PrepareCache) Get() *Prepare {
+	var t *Prepare
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Prepare{}
+	}
+	return t
+}
+
+func (p *PrepareCache) Put(t *Prepare) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Prepare) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	wire.Write(b[:16])
+}
+
+func (t *Prepare) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	return nil
+}
+
 func (t *PrepareReply) New() fastrpc.Serializable {
 	return new(PrepareReply)
 }
+
 func (t *PrepareReply) BinarySize() (nbytes int, sizeKnown bool) {
 	return 0, false
 }
@@ -41,108 +104,74 @@ func (p *PrepareReplyCache) Get() *PrepareReply {
 	}
 	return t
 }
+
 func (p *PrepareReplyCache) Put(t *PrepareReply) {
 	p.mu.Lock()
 	p.cache = append(p.cache, t)
 	p.mu.Unlock()
 }
+
 func (t *PrepareReply) Marshal(wire io.Writer) {
-	var b [18]byte
-	var bs []byte
-	bs = b[:17]
-	tmp32 := t.AcceptorId
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	tmp32 = t.Replica
-	bs[4] = byte(tmp32)
-	bs[5] = byte(tmp32 >> 8)
-	bs[6] = byte(tmp32 >> 16)
-	bs[7] = byte(tmp32 >> 24)
-	tmp32 = t.Instance
-	bs[8] = byte(tmp32)
-	bs[9] = byte(tmp32 >> 8)
-	bs[10] = byte(tmp32 >> 16)
-	bs[11] = byte(tmp32 >> 24)
-	tmp32 = t.Ballot
-	bs[12] = byte(tmp32)
-	bs[13] = byte(tmp32 >> 8)
-	bs[14] = byte(tmp32 >> 16)
-	bs[15] = byte(tmp32 >> 24)
-	bs[16] = byte(t.Status)
-	wire.Write(bs)
-	bs = b[:]
-	alen1 := int64(len(t.Command))
-	if wlen := binary.PutVarint(bs, alen1); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen1; i++ {
+	var b [21]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.AcceptorId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.VBallot))
+	b[20] = byte(t.Status)
+	wire.Write(b[:21])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
 		t.Command[i].Marshal(wire)
 	}
-	bs = b[:4]
-	tmp32 = t.Seq
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	wire.Write(bs)
-	bs = b[:]
-	alen2 := int64(len(t.Deps))
-	if wlen := binary.PutVarint(bs, alen2); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen2; i++ {
-		bs = b[:4]
-		tmp32 = t.Deps[i]
-		bs[0] = byte(tmp32)
-		bs[1] = byte(tmp32 >> 8)
-		bs[2] = byte(tmp32 >> 16)
-		bs[3] = byte(tmp32 >> 24)
-		wire.Write(bs)
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Seq))
+	wire.Write(b[:4])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
 	}
 }
 
 func (t *PrepareReply) Unmarshal(rr io.Reader) error {
-	var wire byteReader
-	var ok bool
-	if wire, ok = rr.(byteReader); !ok {
+	wire, ok := rr.(byteReader)
+	if !ok {
 		wire = bufio.NewReader(rr)
 	}
-	var b [17]byte
-	var bs []byte
-	bs = b[:17]
-	if _, err := io.ReadAtLeast(wire, bs, 17); err != nil {
+	var b [21]byte
+	if _, err := io.ReadFull(wire, b[:21]); err != nil {
 		return err
 	}
-	t.AcceptorId = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	t.Replica = int32((uint32(bs[4]) | (uint32(bs[5]) << 8) | (uint32(bs[6]) << 16) | (uint32(bs[7]) << 24)))
-	t.Instance = int32((uint32(bs[8]) | (uint32(bs[9]) << 8) | (uint32(bs[10]) << 16) | (uint32(bs[11]) << 24)))
-	t.Ballot = int32((uint32(bs[12]) | (uint32(bs[13]) << 8) | (uint32(bs[14]) << 16) | (uint32(bs[15]) << 24)))
-	t.Status = int8(bs[16])
-	alen1, err := binary.ReadVarint(wire)
+	t.AcceptorId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.VBallot = int32(binary.LittleEndian.Uint32(b[16:]))
+	t.Status = int8(b[20])
+	n1, err := binary.ReadVarint(wire)
 	if err != nil {
 		return err
 	}
-	t.Command = make([]state.Command, alen1)
-	for i := int64(0); i < alen1; i++ {
-		t.Command[i].Unmarshal(wire)
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
+			return err
+		}
 	}
-	bs = b[:4]
-	if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
 		return err
 	}
-	t.Seq = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	alen2, err := binary.ReadVarint(wire)
+	t.Seq = int32(binary.LittleEndian.Uint32(b[0:]))
+	n2, err := binary.ReadVarint(wire)
 	if err != nil {
 		return err
 	}
-	t.Deps = make([]int32, alen2)
-	for i := int64(0); i < alen2; i++ {
-		if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	t.Deps = make([]int32, n2)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
 			return err
 		}
-		t.Deps[i] = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
 	}
 	return nil
 }
@@ -150,6 +179,7 @@ func (t *PrepareReply) Unmarshal(rr io.Reader) error {
 func (t *PreAccept) New() fastrpc.Serializable {
 	return new(PreAccept)
 }
+
 func (t *PreAccept) BinarySize() (nbytes int, sizeKnown bool) {
 	return 0, false
 }
@@ -178,130 +208,95 @@ func (p *PreAcceptCache) Get() *PreAccept {
 	}
 	return t
 }
+
 func (p *PreAcceptCache) Put(t *PreAccept) {
 	p.mu.Lock()
 	p.cache = append(p.cache, t)
 	p.mu.Unlock()
 }
+
 func (t *PreAccept) Marshal(wire io.Writer) {
 	var b [16]byte
-	var bs []byte
-	bs = b[:16]
-	tmp32 := t.LeaderId
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	tmp32 = t.Replica
-	bs[4] = byte(tmp32)
-	bs[5] = byte(tmp32 >> 8)
-	bs[6] = byte(tmp32 >> 16)
-	bs[7] = byte(tmp32 >> 24)
-	tmp32 = t.Instance
-	bs[8] = byte(tmp32)
-	bs[9] = byte(tmp32 >> 8)
-	bs[10] = byte(tmp32 >> 16)
-	bs[11] = byte(tmp32 >> 24)
-	tmp32 = t.Ballot
-	bs[12] = byte(tmp32)
-	bs[13] = byte(tmp32 >> 8)
-	bs[14] = byte(tmp32 >> 16)
-	bs[15] = byte(tmp32 >> 24)
-	wire.Write(bs)
-	bs = b[:]
-	alen1 := int64(len(t.Command))
-	if wlen := binary.PutVarint(bs, alen1); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen1; i++ {
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
 		t.Command[i].Marshal(wire)
 	}
-	bs = b[:4]
-	tmp32 = t.Seq
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	wire.Write(bs)
-	bs = b[:]
-	alen2 := int64(len(t.Deps))
-	if wlen := binary.PutVarint(bs, alen2); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen2; i++ {
-		bs = b[:4]
-		tmp32 = t.Deps[i]
-		bs[0] = byte(tmp32)
-		bs[1] = byte(tmp32 >> 8)
-		bs[2] = byte(tmp32 >> 16)
-		bs[3] = byte(tmp32 >> 24)
-		wire.Write(bs)
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Seq))
+	wire.Write(b[:4])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
 	}
 }
 
 func (t *PreAccept) Unmarshal(rr io.Reader) error {
-	var wire byteReader
-	var ok bool
-	if wire, ok = rr.(byteReader); !ok {
+	wire, ok := rr.(byteReader)
+	if !ok {
 		wire = bufio.NewReader(rr)
 	}
 	var b [16]byte
-	var bs []byte
-	bs = b[:16]
-	if _, err := io.ReadAtLeast(wire, bs, 16); err != nil {
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
 		return err
 	}
-	t.LeaderId = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	t.Replica = int32((uint32(bs[4]) | (uint32(bs[5]) << 8) | (uint32(bs[6]) << 16) | (uint32(bs[7]) << 24)))
-	t.Instance = int32((uint32(bs[8]) | (uint32(bs[9]) << 8) | (uint32(bs[10]) << 16) | (uint32(bs[11]) << 24)))
-	t.Ballot = int32((uint32(bs[12]) | (uint32(bs[13]) << 8) | (uint32(bs[14]) << 16) | (uint32(bs[15]) << 24)))
-	alen1, err := binary.ReadVarint(wire)
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
 	if err != nil {
 		return err
 	}
-	t.Command = make([]state.Command, alen1)
-	for i := int64(0); i < alen1; i++ {
-		t.Command[i].Unmarshal(wire)
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
+			return err
+		}
 	}
-	bs = b[:4]
-	if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
 		return err
 	}
-	t.Seq = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	alen2, err := binary.ReadVarint(wire)
+	t.Seq = int32(binary.LittleEndian.Uint32(b[0:]))
+	n2, err := binary.ReadVarint(wire)
 	if err != nil {
 		return err
 	}
-	t.Deps = make([]int32, alen2)
-	for i := int64(0); i < alen2; i++ {
-		if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	t.Deps = make([]int32, n2)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
 			return err
 		}
-		t.Deps[i] = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
 	}
 	return nil
 }
 
-func (t *Accept) New() fastrpc.Serializable {
-	return new(Accept)
+func (t *PreAcceptReply) New() fastrpc.Serializable {
+	return new(PreAcceptReply)
 }
-func (t *Accept) BinarySize() (nbytes int, sizeKnown bool) {
+
+func (t *PreAcceptReply) BinarySize() (nbytes int, sizeKnown bool) {
 	return 0, false
 }
 
-type AcceptCache struct {
+type PreAcceptReplyCache struct {
 	mu    sync.Mutex
-	cache []*Accept
+	cache []*PreAcceptReply
 }
 
-func NewAcceptCache() *AcceptCache {
-	c := &AcceptCache{}
-	c.cache = make([]*Accept, 0)
+func NewPreAcceptReplyCache() *PreAcceptReplyCache {
+	c := &PreAcceptReplyCache{}
+	c.cache = make([]*PreAcceptReply, 0)
 	return c
 }
 
-func (p *AcceptCache) Get() *Accept {
-	var t *Accept
+func (p *PreAcceptReplyCache) Get() *PreAcceptReply {
+	var t *PreAcceptReply
 	p.mu.Lock()
 	if len(p.cache) > 0 {
 		t = p.cache[len(p.cache)-1]
@@ -309,113 +304,103 @@ func (p *AcceptCache) Get() *Accept {
 	}
 	p.mu.Unlock()
 	if t == nil {
-		t = &Accept{}
+		t = &PreAcceptReply{}
 	}
 	return t
 }
-func (p *AcceptCache) Put(t *Accept) {
+
+func (p *PreAcceptReplyCache) Put(t *PreAcceptReply) {
 	p.mu.Lock()
 	p.cache = append(p.cache, t)
 	p.mu.Unlock()
 }
-func (t *Accept) Marshal(wire io.Writer) {
+
+func (t *PreAcceptReply) Marshal(wire io.Writer) {
 	var b [20]byte
-	var bs []byte
-	bs = b[:20]
-	tmp32 := t.LeaderId
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	tmp32 = t.Replica
-	bs[4] = byte(tmp32)
-	bs[5] = byte(tmp32 >> 8)
-	bs[6] = byte(tmp32 >> 16)
-	bs[7] = byte(tmp32 >> 24)
-	tmp32 = t.Instance
-	bs[8] = byte(tmp32)
-	bs[9] = byte(tmp32 >> 8)
-	bs[10] = byte(tmp32 >> 16)
-	bs[11] = byte(tmp32 >> 24)
-	tmp32 = t.Ballot
-	bs[12] = byte(tmp32)
-	bs[13] = byte(tmp32 >> 8)
-	bs[14] = byte(tmp32 >> 16)
-	bs[15] = byte(tmp32 >> 24)
-	tmp32 = t.Seq
-	bs[16] = byte(tmp32)
-	bs[17] = byte(tmp32 >> 8)
-	bs[18] = byte(tmp32 >> 16)
-	bs[19] = byte(tmp32 >> 24)
-	wire.Write(bs)
-	bs = b[:]
-	alen1 := int64(len(t.Deps))
-	if wlen := binary.PutVarint(bs, alen1); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen1; i++ {
-		bs = b[:4]
-		tmp32 = t.Deps[i]
-		bs[0] = byte(tmp32)
-		bs[1] = byte(tmp32 >> 8)
-		bs[2] = byte(tmp32 >> 16)
-		bs[3] = byte(tmp32 >> 24)
-		wire.Write(bs)
-	}
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.VBallot))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.Seq))
+	wire.Write(b[:20])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.CommittedDeps)))])
+	for i := range t.CommittedDeps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.CommittedDeps[i]))
+		wire.Write(b[:4])
+	}
+	b[0] = byte(t.Status)
+	wire.Write(b[:1])
 }
 
-func (t *Accept) Unmarshal(rr io.Reader) error {
-	var wire byteReader
-	var ok bool
-	if wire, ok = rr.(byteReader); !ok {
+func (t *PreAcceptReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
 		wire = bufio.NewReader(rr)
 	}
 	var b [20]byte
-	var bs []byte
-	bs = b[:20]
-	if _, err := io.ReadAtLeast(wire, bs, 20); err != nil {
+	if _, err := io.ReadFull(wire, b[:20]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.VBallot = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.Seq = int32(binary.LittleEndian.Uint32(b[16:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
 		return err
 	}
-	t.LeaderId = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	t.Replica = int32((uint32(bs[4]) | (uint32(bs[5]) << 8) | (uint32(bs[6]) << 16) | (uint32(bs[7]) << 24)))
-	t.Instance = int32((uint32(bs[8]) | (uint32(bs[9]) << 8) | (uint32(bs[10]) << 16) | (uint32(bs[11]) << 24)))
-	t.Ballot = int32((uint32(bs[12]) | (uint32(bs[13]) << 8) | (uint32(bs[14]) << 16) | (uint32(bs[15]) << 24)))
-	t.Seq = int32((uint32(bs[16]) | (uint32(bs[17]) << 8) | (uint32(bs[18]) << 16) | (uint32(bs[19]) << 24)))
-	alen1, err := binary.ReadVarint(wire)
+	t.Deps = make([]int32, n1)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
+			return err
+		}
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
+	}
+	n2, err := binary.ReadVarint(wire)
 	if err != nil {
 		return err
 	}
-	t.Deps = make([]int32, alen1)
-	for i := int64(0); i < alen1; i++ {
-		bs = b[:4]
-		if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	t.CommittedDeps = make([]int32, n2)
+	for i := range t.CommittedDeps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
 			return err
 		}
-		t.Deps[i] = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
+		t.CommittedDeps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
 	}
+	if _, err := io.ReadFull(wire, b[:1]); err != nil {
+		return err
+	}
+	t.Status = int8(b[0])
 	return nil
 }
 
-func (t *AcceptReply) New() fastrpc.Serializable {
-	return new(AcceptReply)
+func (t *PreAcceptOK) New() fastrpc.Serializable {
+	return new(PreAcceptOK)
 }
-func (t *AcceptReply) BinarySize() (nbytes int, sizeKnown bool) {
-	return 13, true
+
+func (t *PreAcceptOK) BinarySize() (nbytes int, sizeKnown bool) {
+	return 4, true
 }
 
-type AcceptReplyCache struct {
+type PreAcceptOKCache struct {
 	mu    sync.Mutex
-	cache []*AcceptReply
+	cache []*PreAcceptOK
 }
 
-func NewAcceptReplyCache() *AcceptReplyCache {
-	c := &AcceptReplyCache{}
-	c.cache = make([]*AcceptReply, 0)
+func NewPreAcceptOKCache() *PreAcceptOKCache {
+	c := &PreAcceptOKCache{}
+	c.cache = make([]*PreAcceptOK, 0)
 	return c
 }
 
-func (p *AcceptReplyCache) Get() *AcceptReply {
-	var t *AcceptReply
+func (p *PreAcceptOKCache) Get() *PreAcceptOK {
+	var t *PreAcceptOK
 	p.mu.Lock()
 	if len(p.cache) > 0 {
 		t = p.cache[len(p.cache)-1]
@@ -423,70 +408,53 @@ func (p *AcceptReplyCache) Get() *AcceptReply {
 	}
 	p.mu.Unlock()
 	if t == nil {
-		t = &AcceptReply{}
+		t = &PreAcceptOK{}
 	}
 	return t
 }
-func (p *AcceptReplyCache) Put(t *AcceptReply) {
+
+func (p *PreAcceptOKCache) Put(t *PreAcceptOK) {
 	p.mu.Lock()
 	p.cache = append(p.cache, t)
 	p.mu.Unlock()
 }
-func (t *AcceptReply) Marshal(wire io.Writer) {
-	var b [12]byte
-	var bs []byte
-	bs = b[:12]
-	tmp32 := t.Replica
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	tmp32 = t.Instance
-	bs[4] = byte(tmp32)
-	bs[5] = byte(tmp32 >> 8)
-	bs[6] = byte(tmp32 >> 16)
-	bs[7] = byte(tmp32 >> 24)
-	tmp32 = t.Ballot
-	bs[8] = byte(tmp32)
-	bs[9] = byte(tmp32 >> 8)
-	bs[10] = byte(tmp32 >> 16)
-	bs[11] = byte(tmp32 >> 24)
-	wire.Write(bs)
+
+func (t *PreAcceptOK) Marshal(wire io.Writer) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Instance))
+	wire.Write(b[:4])
 }
 
-func (t *AcceptReply) Unmarshal(wire io.Reader) error {
-	var b [12]byte
-	var bs []byte
-	bs = b[:12]
-	if _, err := io.ReadAtLeast(wire, bs, 12); err != nil {
+func (t *PreAcceptOK) Unmarshal(wire io.Reader) error {
+	var b [4]byte
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
 		return err
 	}
-	t.Replica = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	t.Instance = int32((uint32(bs[4]) | (uint32(bs[5]) << 8) | (uint32(bs[6]) << 16) | (uint32(bs[7]) << 24)))
-	t.Ballot = int32((uint32(bs[8]) | (uint32(bs[9]) << 8) | (uint32(bs[10]) << 16) | (uint32(bs[11]) << 24)))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[0:]))
 	return nil
 }
 
-func (t *TryPreAccept) New() fastrpc.Serializable {
-	return new(TryPreAccept)
+func (t *Accept) New() fastrpc.Serializable {
+	return new(Accept)
 }
-func (t *TryPreAccept) BinarySize() (nbytes int, sizeKnown bool) {
+
+func (t *Accept) BinarySize() (nbytes int, sizeKnown bool) {
 	return 0, false
 }
 
-type TryPreAcceptCache struct {
+type AcceptCache struct {
 	mu    sync.Mutex
-	cache []*TryPreAccept
+	cache []*Accept
 }
 
-func NewTryPreAcceptCache() *TryPreAcceptCache {
-	c := &TryPreAcceptCache{}
-	c.cache = make([]*TryPreAccept, 0)
+func NewAcceptCache() *AcceptCache {
+	c := &AcceptCache{}
+	c.cache = make([]*Accept, 0)
 	return c
 }
 
-func (p *TryPreAcceptCache) Get() *TryPreAccept {
-	var t *TryPreAccept
+func (p *AcceptCache) Get() *Accept {
+	var t *Accept
 	p.mu.Lock()
 	if len(p.cache) > 0 {
 		t = p.cache[len(p.cache)-1]
@@ -494,134 +462,81 @@ func (p *TryPreAcceptCache) Get() *TryPreAccept {
 	}
 	p.mu.Unlock()
 	if t == nil {
-		t = &TryPreAccept{}
+		t = &Accept{}
 	}
 	return t
 }
-func (p *TryPreAcceptCache) Put(t *TryPreAccept) {
+
+func (p *AcceptCache) Put(t *Accept) {
 	p.mu.Lock()
 	p.cache = append(p.cache, t)
 	p.mu.Unlock()
 }
-func (t *TryPreAccept) Marshal(wire io.Writer) {
-	var b [16]byte
-	var bs []byte
-	bs = b[:16]
-	tmp32 := t.LeaderId
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	tmp32 = t.Replica
-	bs[4] = byte(tmp32)
-	bs[5] = byte(tmp32 >> 8)
-	bs[6] = byte(tmp32 >> 16)
-	bs[7] = byte(tmp32 >> 24)
-	tmp32 = t.Instance
-	bs[8] = byte(tmp32)
-	bs[9] = byte(tmp32 >> 8)
-	bs[10] = byte(tmp32 >> 16)
-	bs[11] = byte(tmp32 >> 24)
-	tmp32 = t.Ballot
-	bs[12] = byte(tmp32)
-	bs[13] = byte(tmp32 >> 8)
-	bs[14] = byte(tmp32 >> 16)
-	bs[15] = byte(tmp32 >> 24)
-	wire.Write(bs)
-	bs = b[:]
-	alen1 := int64(len(t.Command))
-	if wlen := binary.PutVarint(bs, alen1); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen1; i++ {
-		t.Command[i].Marshal(wire)
-	}
-	bs = b[:4]
-	tmp32 = t.Seq
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	wire.Write(bs)
-	bs = b[:]
-	alen2 := int64(len(t.Deps))
-	if wlen := binary.PutVarint(bs, alen2); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen2; i++ {
-		bs = b[:4]
-		tmp32 = t.Deps[i]
-		bs[0] = byte(tmp32)
-		bs[1] = byte(tmp32 >> 8)
-		bs[2] = byte(tmp32 >> 16)
-		bs[3] = byte(tmp32 >> 24)
-		wire.Write(bs)
+
+func (t *Accept) Marshal(wire io.Writer) {
+	var b [20]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.Seq))
+	wire.Write(b[:20])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
 	}
 }
 
-func (t *TryPreAccept) Unmarshal(rr io.Reader) error {
-	var wire byteReader
-	var ok bool
-	if wire, ok = rr.(byteReader); !ok {
+func (t *Accept) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
 		wire = bufio.NewReader(rr)
 	}
-	var b [16]byte
-	var bs []byte
-	bs = b[:16]
-	if _, err := io.ReadAtLeast(wire, bs, 16); err != nil {
-		return err
-	}
-	t.LeaderId = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	t.Replica = int32((uint32(bs[4]) | (uint32(bs[5]) << 8) | (uint32(bs[6]) << 16) | (uint32(bs[7]) << 24)))
-	t.Instance = int32((uint32(bs[8]) | (uint32(bs[9]) << 8) | (uint32(bs[10]) << 16) | (uint32(bs[11]) << 24)))
-	t.Ballot = int32((uint32(bs[12]) | (uint32(bs[13]) << 8) | (uint32(bs[14]) << 16) | (uint32(bs[15]) << 24)))
-	alen1, err := binary.ReadVarint(wire)
-	if err != nil {
-		return err
-	}
-	t.Command = make([]state.Command, alen1)
-	for i := int64(0); i < alen1; i++ {
-		t.Command[i].Unmarshal(wire)
-	}
-	bs = b[:4]
-	if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	var b [20]byte
+	if _, err := io.ReadFull(wire, b[:20]); err != nil {
 		return err
 	}
-	t.Seq = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	alen2, err := binary.ReadVarint(wire)
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.Seq = int32(binary.LittleEndian.Uint32(b[16:]))
+	n1, err := binary.ReadVarint(wire)
 	if err != nil {
 		return err
 	}
-	t.Deps = make([]int32, alen2)
-	for i := int64(0); i < alen2; i++ {
-		if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	t.Deps = make([]int32, n1)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
 			return err
 		}
-		t.Deps[i] = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
 	}
 	return nil
 }
 
-func (t *Prepare) New() fastrpc.Serializable {
-	return new(Prepare)
+func (t *AcceptReply) New() fastrpc.Serializable {
+	return new(AcceptReply)
 }
-func (t *Prepare) BinarySize() (nbytes int, sizeKnown bool) {
-	return 16, true
+
+func (t *AcceptReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 12, true
 }
 
-type PrepareCache struct {
+type AcceptReplyCache struct {
 	mu    sync.Mutex
-	cache []*Prepare
+	cache []*AcceptReply
 }
 
-func NewPrepareCache() *PrepareCache {
-	c := &PrepareCache{}
-	c.cache = make([]*Prepare, 0)
+func NewAcceptReplyCache() *AcceptReplyCache {
+	c := &AcceptReplyCache{}
+	c.cache = make([]*AcceptReply, 0)
 	return c
 }
 
-func (p *PrepareCache) Get() *Prepare {
-	var t *Prepare
+func (p *AcceptReplyCache) Get() *AcceptReply {
+	var t *AcceptReply
 	p.mu.Lock()
 	if len(p.cache) > 0 {
 		t = p.cache[len(p.cache)-1]
@@ -629,76 +544,57 @@ func (p *PrepareCache) Get() *Prepare {
 	}
 	p.mu.Unlock()
 	if t == nil {
-		t = &Prepare{}
+		t = &AcceptReply{}
 	}
 	return t
 }
-func (p *PrepareCache) Put(t *Prepare) {
+
+func (p *AcceptReplyCache) Put(t *AcceptReply) {
 	p.mu.Lock()
 	p.cache = append(p.cache, t)
 	p.mu.Unlock()
 }
-func (t *Prepare) Marshal(wire io.Writer) {
-	var b [16]byte
-	var bs []byte
-	bs = b[:16]
-	tmp32 := t.LeaderId
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	tmp32 = t.Replica
-	bs[4] = byte(tmp32)
-	bs[5] = byte(tmp32 >> 8)
-	bs[6] = byte(tmp32 >> 16)
-	bs[7] = byte(tmp32 >> 24)
-	tmp32 = t.Instance
-	bs[8] = byte(tmp32)
-	bs[9] = byte(tmp32 >> 8)
-	bs[10] = byte(tmp32 >> 16)
-	bs[11] = byte(tmp32 >> 24)
-	tmp32 = t.Ballot
-	bs[12] = byte(tmp32)
-	bs[13] = byte(tmp32 >> 8)
-	bs[14] = byte(tmp32 >> 16)
-	bs[15] = byte(tmp32 >> 24)
-	wire.Write(bs)
+
+func (t *AcceptReply) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Ballot))
+	wire.Write(b[:12])
 }
 
-func (t *Prepare) Unmarshal(wire io.Reader) error {
-	var b [16]byte
-	var bs []byte
-	bs = b[:16]
-	if _, err := io.ReadAtLeast(wire, bs, 16); err != nil {
+func (t *AcceptReply) Unmarshal(wire io.Reader) error {
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
 		return err
 	}
-	t.LeaderId = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	t.Replica = int32((uint32(bs[4]) | (uint32(bs[5]) << 8) | (uint32(bs[6]) << 16) | (uint32(bs[7]) << 24)))
-	t.Instance = int32((uint32(bs[8]) | (uint32(bs[9]) << 8) | (uint32(bs[10]) << 16) | (uint32(bs[11]) << 24)))
-	t.Ballot = int32((uint32(bs[12]) | (uint32(bs[13]) << 8) | (uint32(bs[14]) << 16) | (uint32(bs[15]) << 24)))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[8:]))
 	return nil
 }
 
-func (t *PreAcceptReply) New() fastrpc.Serializable {
-	return new(PreAcceptReply)
+func (t *Commit) New() fastrpc.Serializable {
+	return new(Commit)
 }
-func (t *PreAcceptReply) BinarySize() (nbytes int, sizeKnown bool) {
+
+func (t *Commit) BinarySize() (nbytes int, sizeKnown bool) {
 	return 0, false
 }
 
-type PreAcceptReplyCache struct {
+type CommitCache struct {
 	mu    sync.Mutex
-	cache []*PreAcceptReply
+	cache []*Commit
 }
 
-func NewPreAcceptReplyCache() *PreAcceptReplyCache {
-	c := &PreAcceptReplyCache{}
-	c.cache = make([]*PreAcceptReply, 0)
+func NewCommitCache() *CommitCache {
+	c := &CommitCache{}
+	c.cache = make([]*Commit, 0)
 	return c
 }
 
-func (p *PreAcceptReplyCache) Get() *PreAcceptReply {
-	var t *PreAcceptReply
+func (p *CommitCache) Get() *Commit {
+	var t *Commit
 	p.mu.Lock()
 	if len(p.cache) > 0 {
 		t = p.cache[len(p.cache)-1]
@@ -706,199 +602,99 @@ func (p *PreAcceptReplyCache) Get() *PreAcceptReply {
 	}
 	p.mu.Unlock()
 	if t == nil {
-		t = &PreAcceptReply{}
+		t = &Commit{}
 	}
 	return t
 }
-func (p *PreAcceptReplyCache) Put(t *PreAcceptReply) {
+
+func (p *CommitCache) Put(t *Commit) {
 	p.mu.Lock()
 	p.cache = append(p.cache, t)
 	p.mu.Unlock()
 }
-func (t *PreAcceptReply) Marshal(wire io.Writer) {
-	var b [21]byte
-	var bs []byte
-	bs = b[:21]
-	tmp32 := t.Replica
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	tmp32 = t.Instance
-	bs[4] = byte(tmp32)
-	bs[5] = byte(tmp32 >> 8)
-	bs[6] = byte(tmp32 >> 16)
-	bs[7] = byte(tmp32 >> 24)
-	tmp32 = t.Ballot
-	bs[8] = byte(tmp32)
-	bs[9] = byte(tmp32 >> 8)
-	bs[10] = byte(tmp32 >> 16)
-	bs[11] = byte(tmp32 >> 24)
-	tmp32 = t.VBallot
-	bs[12] = byte(tmp32)
-	bs[13] = byte(tmp32 >> 8)
-	bs[14] = byte(tmp32 >> 16)
-	bs[15] = byte(tmp32 >> 24)
-	tmp32 = t.Seq
-	bs[16] = byte(tmp32)
-	bs[17] = byte(tmp32 >> 8)
-	bs[18] = byte(tmp32 >> 16)
-	bs[19] = byte(tmp32 >> 24)
-	bs[20] = byte(t.Status)
-	wire.Write(bs)
-	bs = b[:]
-	alen1 := int64(len(t.Deps))
-	if wlen := binary.PutVarint(bs, alen1); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen1; i++ {
-		bs = b[:4]
-		tmp32 = t.Deps[i]
-		bs[0] = byte(tmp32)
-		bs[1] = byte(tmp32 >> 8)
-		bs[2] = byte(tmp32 >> 16)
-		bs[3] = byte(tmp32 >> 24)
-		wire.Write(bs)
-	}
-	bs = b[:]
-	alen2 := int64(len(t.CommittedDeps))
-	if wlen := binary.PutVarint(bs, alen2); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen2; i++ {
-		bs = b[:4]
-		tmp32 = t.CommittedDeps[i]
-		bs[0] = byte(tmp32)
-		bs[1] = byte(tmp32 >> 8)
-		bs[2] = byte(tmp32 >> 16)
-		bs[3] = byte(tmp32 >> 24)
-		wire.Write(bs)
+
+func (t *Commit) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
+		t.Command[i].Marshal(wire)
+	}
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Seq))
+	wire.Write(b[:4])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
 	}
 }
 
-func (t *PreAcceptReply) Unmarshal(rr io.Reader) error {
-	var wire byteReader
-	var ok bool
-	if wire, ok = rr.(byteReader); !ok {
+func (t *Commit) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
 		wire = bufio.NewReader(rr)
 	}
-	var b [21]byte
-	var bs []byte
-	bs = b[:21]
-	if _, err := io.ReadAtLeast(wire, bs, 21); err != nil {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
 		return err
 	}
-	t.Replica = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	t.Instance = int32((uint32(bs[4]) | (uint32(bs[5]) << 8) | (uint32(bs[6]) << 16) | (uint32(bs[7]) << 24)))
-	t.Ballot = int32((uint32(bs[8]) | (uint32(bs[9]) << 8) | (uint32(bs[10]) << 16) | (uint32(bs[11]) << 24)))
-	t.VBallot = int32((uint32(bs[12]) | (uint32(bs[13]) << 8) | (uint32(bs[14]) << 16) | (uint32(bs[15]) << 24)))
-	t.Seq = int32((uint32(bs[16]) | (uint32(bs[17]) << 8) | (uint32(bs[18]) << 16) | (uint32(bs[19]) << 24)))
-	t.Status = int8(bs[20])
-	alen1, err := binary.ReadVarint(wire)
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
 	if err != nil {
 		return err
 	}
-	t.Deps = make([]int32, alen1)
-	for i := int64(0); i < alen1; i++ {
-		bs = b[:4]
-		if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
 			return err
 		}
-		t.Deps[i] = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
 	}
-	alen2, err := binary.ReadVarint(wire)
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
+		return err
+	}
+	t.Seq = int32(binary.LittleEndian.Uint32(b[0:]))
+	n2, err := binary.ReadVarint(wire)
 	if err != nil {
 		return err
 	}
-	t.CommittedDeps = make([]int32, alen2)
-	for i := int64(0); i < alen2; i++ {
-		if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	t.Deps = make([]int32, n2)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
 			return err
 		}
-		t.CommittedDeps[i] = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
 	}
 	return nil
 }
 
-func (t *PreAcceptOK) New() fastrpc.Serializable {
-	return new(PreAcceptOK)
-}
-func (t *PreAcceptOK) BinarySize() (nbytes int, sizeKnown bool) {
-	return 4, true
-}
-
-type PreAcceptOKCache struct {
-	mu    sync.Mutex
-	cache []*PreAcceptOK
-}
-
-func NewPreAcceptOKCache() *PreAcceptOKCache {
-	c := &PreAcceptOKCache{}
-	c.cache = make([]*PreAcceptOK, 0)
-	return c
-}
-
-func (p *PreAcceptOKCache) Get() *PreAcceptOK {
-	var t *PreAcceptOK
-	p.mu.Lock()
-	if len(p.cache) > 0 {
-		t = p.cache[len(p.cache)-1]
-		p.cache = p.cache[0:(len(p.cache) - 1)]
-	}
-	p.mu.Unlock()
-	if t == nil {
-		t = &PreAcceptOK{}
-	}
-	return t
-}
-func (p *PreAcceptOKCache) Put(t *PreAcceptOK) {
-	p.mu.Lock()
-	p.cache = append(p.cache, t)
-	p.mu.Unlock()
-}
-func (t *PreAcceptOK) Marshal(wire io.Writer) {
-	var b [4]byte
-	var bs []byte
-	bs = b[:4]
-	tmp32 := t.Instance
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	wire.Write(bs)
-}
-
-func (t *PreAcceptOK) Unmarshal(wire io.Reader) error {
-	var b [4]byte
-	var bs []byte
-	bs = b[:4]
-	if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
-		return err
-	}
-	t.Instance = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	return nil
+func (t *TryPreAccept) New() fastrpc.Serializable {
+	return new(TryPreAccept)
 }
 
-func (t *Commit) New() fastrpc.Serializable {
-	return new(Commit)
-}
-func (t *Commit) BinarySize() (nbytes int, sizeKnown bool) {
+func (t *TryPreAccept) BinarySize() (nbytes int, sizeKnown bool) {
 	return 0, false
 }
 
-type CommitCache struct {
+type TryPreAcceptCache struct {
 	mu    sync.Mutex
-	cache []*Commit
+	cache []*TryPreAccept
 }
 
-func NewCommitCache() *CommitCache {
-	c := &CommitCache{}
-	c.cache = make([]*Commit, 0)
+func NewTryPreAcceptCache() *TryPreAcceptCache {
+	c := &TryPreAcceptCache{}
+	c.cache = make([]*TryPreAccept, 0)
 	return c
 }
 
-func (p *CommitCache) Get() *Commit {
-	var t *Commit
+func (p *TryPreAcceptCache) Get() *TryPreAccept {
+	var t *TryPreAccept
 	p.mu.Lock()
 	if len(p.cache) > 0 {
 		t = p.cache[len(p.cache)-1]
@@ -906,110 +702,74 @@ func (p *CommitCache) Get() *Commit {
 	}
 	p.mu.Unlock()
 	if t == nil {
-		t = &Commit{}
+		t = &TryPreAccept{}
 	}
 	return t
 }
-func (p *CommitCache) Put(t *Commit) {
+
+func (p *TryPreAcceptCache) Put(t *TryPreAccept) {
 	p.mu.Lock()
 	p.cache = append(p.cache, t)
 	p.mu.Unlock()
 }
-func (t *Commit) Marshal(wire io.Writer) {
+
+func (t *TryPreAccept) Marshal(wire io.Writer) {
 	var b [16]byte
-	var bs []byte
-	bs = b[:16]
-	tmp32 := t.LeaderId
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	tmp32 = t.Replica
-	bs[4] = byte(tmp32)
-	bs[5] = byte(tmp32 >> 8)
-	bs[6] = byte(tmp32 >> 16)
-	bs[7] = byte(tmp32 >> 24)
-	tmp32 = t.Instance
-	bs[8] = byte(tmp32)
-	bs[9] = byte(tmp32 >> 8)
-	bs[10] = byte(tmp32 >> 16)
-	bs[11] = byte(tmp32 >> 24)
-	tmp32 = t.Ballot
-	bs[12] = byte(tmp32)
-	bs[13] = byte(tmp32 >> 8)
-	bs[14] = byte(tmp32 >> 16)
-	bs[15] = byte(tmp32 >> 24)
-	wire.Write(bs)
-	bs = b[:]
-	alen1 := int64(len(t.Command))
-	if wlen := binary.PutVarint(bs, alen1); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen1; i++ {
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
 		t.Command[i].Marshal(wire)
 	}
-	bs = b[:4]
-	tmp32 = t.Seq
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	wire.Write(bs)
-	bs = b[:]
-	alen2 := int64(len(t.Deps))
-	if wlen := binary.PutVarint(bs, alen2); wlen >= 0 {
-		wire.Write(b[0:wlen])
-	}
-	for i := int64(0); i < alen2; i++ {
-		bs = b[:4]
-		tmp32 = t.Deps[i]
-		bs[0] = byte(tmp32)
-		bs[1] = byte(tmp32 >> 8)
-		bs[2] = byte(tmp32 >> 16)
-		bs[3] = byte(tmp32 >> 24)
-		wire.Write(bs)
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Seq))
+	wire.Write(b[:4])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Deps[i]))
+		wire.Write(b[:4])
 	}
 }
 
-func (t *Commit) Unmarshal(rr io.Reader) error {
-	var wire byteReader
-	var ok bool
-	if wire, ok = rr.(byteReader); !ok {
+func (t *TryPreAccept) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
 		wire = bufio.NewReader(rr)
 	}
 	var b [16]byte
-	var bs []byte
-	bs = b[:16]
-	if _, err := io.ReadAtLeast(wire, bs, 16); err != nil {
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
 		return err
 	}
-	t.LeaderId = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	t.Replica = int32((uint32(bs[4]) | (uint32(bs[5]) << 8) | (uint32(bs[6]) << 16) | (uint32(bs[7]) << 24)))
-	t.Instance = int32((uint32(bs[8]) | (uint32(bs[9]) << 8) | (uint32(bs[10]) << 16) | (uint32(bs[11]) << 24)))
-	t.Ballot = int32((uint32(bs[12]) | (uint32(bs[13]) << 8) | (uint32(bs[14]) << 16) | (uint32(bs[15]) << 24)))
-	alen1, err := binary.ReadVarint(wire)
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
 	if err != nil {
 		return err
 	}
-	t.Command = make([]state.Command, alen1)
-	for i := int64(0); i < alen1; i++ {
-		t.Command[i].Unmarshal(wire)
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
+			return err
+		}
 	}
-	bs = b[:4]
-	if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
 		return err
 	}
-	t.Seq = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	alen2, err := binary.ReadVarint(wire)
+	t.Seq = int32(binary.LittleEndian.Uint32(b[0:]))
+	n2, err := binary.ReadVarint(wire)
 	if err != nil {
 		return err
 	}
-	t.Deps = make([]int32, alen2)
-	for i := int64(0); i < alen2; i++ {
-		if _, err := io.ReadAtLeast(wire, bs, 4); err != nil {
+	t.Deps = make([]int32, n2)
+	for i := range t.Deps {
+		if _, err := io.ReadFull(wire, b[:4]); err != nil {
 			return err
 		}
-		t.Deps[i] = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
+		t.Deps[i] = int32(binary.LittleEndian.Uint32(b[0:]))
 	}
 	return nil
 }
@@ -1017,8 +777,9 @@ func (t *Commit) Unmarshal(rr io.Reader) error {
 func (t *TryPreAcceptReply) New() fastrpc.Serializable {
 	return new(TryPreAcceptReply)
 }
+
 func (t *TryPreAcceptReply) BinarySize() (nbytes int, sizeKnown bool) {
-	return 26, true
+	return 29, true
 }
 
 type TryPreAcceptReplyCache struct {
@@ -1045,68 +806,43 @@ func (p *TryPreAcceptReplyCache) Get() *TryPreAcceptReply {
 	}
 	return t
 }
+
 func (p *TryPreAcceptReplyCache) Put(t *TryPreAcceptReply) {
 	p.mu.Lock()
 	p.cache = append(p.cache, t)
 	p.mu.Unlock()
 }
+
 func (t *TryPreAcceptReply) Marshal(wire io.Writer) {
 	var b [29]byte
-	var bs []byte
-	bs = b[:29]
-	tmp32 := t.AcceptorId
-	bs[0] = byte(tmp32)
-	bs[1] = byte(tmp32 >> 8)
-	bs[2] = byte(tmp32 >> 16)
-	bs[3] = byte(tmp32 >> 24)
-	tmp32 = t.Replica
-	bs[4] = byte(tmp32)
-	bs[5] = byte(tmp32 >> 8)
-	bs[6] = byte(tmp32 >> 16)
-	bs[7] = byte(tmp32 >> 24)
-	tmp32 = t.Instance
-	bs[8] = byte(tmp32)
-	bs[9] = byte(tmp32 >> 8)
-	bs[10] = byte(tmp32 >> 16)
-	bs[11] = byte(tmp32 >> 24)
-	tmp32 = t.Ballot
-	bs[12] = byte(tmp32)
-	bs[13] = byte(tmp32 >> 8)
-	bs[14] = byte(tmp32 >> 16)
-	bs[15] = byte(tmp32 >> 24)
-	tmp32 = t.VBallot
-	bs[16] = byte(tmp32)
-	bs[17] = byte(tmp32 >> 8)
-	bs[18] = byte(tmp32 >> 16)
-	bs[19] = byte(tmp32 >> 24)
-	tmp32 = t.ConflictReplica
-	bs[20] = byte(tmp32)
-	bs[21] = byte(tmp32 >> 8)
-	bs[22] = byte(tmp32 >> 16)
-	bs[23] = byte(tmp32 >> 24)
-	tmp32 = t.ConflictInstance
-	bs[24] = byte(tmp32)
-	bs[25] = byte(tmp32 >> 8)
-	bs[26] = byte(tmp32 >> 16)
-	bs[27] = byte(tmp32 >> 24)
-	bs[28] = byte(t.ConflictStatus)
-	wire.Write(bs)
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.AcceptorId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.VBallot))
+	binary.LittleEndian.PutUint32(b[20:], uint32(t.ConflictReplica))
+	binary.LittleEndian.PutUint32(b[24:], uint32(t.ConflictInstance))
+	b[28] = byte(t.ConflictStatus)
+	wire.Write(b[:29])
 }
 
 func (t *TryPreAcceptReply) Unmarshal(wire io.Reader) error {
 	var b [29]byte
-	var bs []byte
-	bs = b[:29]
-	if _, err := io.ReadAtLeast(wire, bs, 29); err != nil {
+	if _, err := io.ReadFull(wire, b[:29]); err != nil {
 		return err
 	}
-	t.AcceptorId = int32((uint32(bs[0]) | (uint32(bs[1]) << 8) | (uint32(bs[2]) << 16) | (uint32(bs[3]) << 24)))
-	t.Replica = int32((uint32(bs[4]) | (uint32(bs[5]) << 8) | (uint32(bs[6]) << 16) | (uint32(bs[7]) << 24)))
-	t.Instance = int32((uint32(bs[8]) | (uint32(bs[9]) << 8) | (uint32(bs[10]) << 16) | (uint32(bs[11]) << 24)))
-	t.Ballot = int32((uint32(bs[12]) | (uint32(bs[13]) << 8) | (uint32(bs[14]) << 16) | (uint32(bs[15]) << 24)))
-	t.VBallot = int32((uint32(bs[16]) | (uint32(bs[17]) << 8) | (uint32(bs[18]) << 16) | (uint32(bs[19]) << 24)))
-	t.ConflictReplica = int32((uint32(bs[20]) | (uint32(bs[21]) << 8) | (uint32(bs[22]) << 16) | (uint32(bs[23]) << 24)))
-	t.ConflictInstance = int32((uint32(bs[24]) | (uint32(bs[25]) << 8) | (uint32(bs[26]) << 16) | (uint32(bs[27]) << 24)))
-	t.ConflictStatus = int8(bs[28])
+	t.AcceptorId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.VBallot = int32(binary.LittleEndian.Uint32(b[16:]))
+	t.ConflictReplica = int32(binary.LittleEndian.Uint32(b[20:]))
+	t.ConflictInstance = int32(binary.LittleEndian.Uint32(b[24:]))
+	t.ConflictStatus = int8(b[28])
 	return nil
 }
+
+type byteReader interface {
+	io.Reader
+	ReadByte() (c byte, err error)
+}
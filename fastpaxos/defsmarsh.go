@@ -0,0 +1,131 @@
+// Code generated by marshalgen; DO NOT EDIT.
+
+package fastpaxos
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+)
+
+func (t *CommandId) New() fastrpc.Serializable {
+	return new(CommandId)
+}
+
+func (t *CommandId) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type CommandIdCache struct {
+	mu    sync.Mutex
+	cache []*CommandId
+}
+
+func NewCommandIdCache() *CommandIdCache {
+	c := &CommandIdCache{}
+	c.cache = make([]*CommandId, 0)
+	return c
+}
+
+func (p *CommandIdCache) Get() *CommandId {
+	var t *CommandId
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &CommandId{}
+	}
+	return t
+}
+
+func (p *CommandIdCache) Put(t *CommandId) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *CommandId) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.SeqNum))
+	wire.Write(b[:8])
+}
+
+func (t *CommandId) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *M2B) New() fastrpc.Serializable {
+	return new(M2B)
+}
+
+func (t *M2B) BinarySize() (nbytes int, sizeKnown bool) {
+	return 28, true
+}
+
+type M2BCache struct {
+	mu    sync.Mutex
+	cache []*M2B
+}
+
+func NewM2BCache() *M2BCache {
+	c := &M2BCache{}
+	c.cache = make([]*M2B, 0)
+	return c
+}
+
+func (p *M2BCache) Get() *M2B {
+	var t *M2B
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &M2B{}
+	}
+	return t
+}
+
+func (p *M2BCache) Put(t *M2B) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *M2B) Marshal(wire io.Writer) {
+	var b [28]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.CmdId.SeqNum))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Cballot))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.Replica))
+	binary.LittleEndian.PutUint64(b[20:], uint64(t.InstanceId))
+	wire.Write(b[:28])
+}
+
+func (t *M2B) Unmarshal(wire io.Reader) error {
+	var b [28]byte
+	if _, err := io.ReadFull(wire, b[:28]); err != nil {
+		return err
+	}
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Cballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.Replica = int32(binary.LittleEndian.Uint32(b[16:]))
+	t.InstanceId = int(binary.LittleEndian.Uint64(b[20:]))
+	return nil
+}
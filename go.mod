@@ -3,6 +3,6 @@ module github.com/imdea-software/swiftpaxos
 go 1.20
 
 require (
-	github.com/google/uuid v1.3.1 // indirect
-	github.com/orcaman/concurrent-map v1.0.0 // indirect
+	github.com/google/uuid v1.3.1
+	github.com/orcaman/concurrent-map v1.0.0
 )
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/rpc"
 	"os"
 	"sort"
 	"strings"
@@ -27,6 +28,7 @@ import (
 	raftht "github.com/imdea-software/swiftpaxos/raft-ht"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
 	"github.com/imdea-software/swiftpaxos/swift"
+	"github.com/imdea-software/swiftpaxos/transport"
 )
 
 var (
@@ -34,7 +36,7 @@ var (
 	latency      = flag.String("latency", "", "Latency config `file`")
 	logFile      = flag.String("log", "", "Path to the log `file`")
 	machineAlias = flag.String("alias", "", "An `alias` of this participant")
-	machineType  = flag.String("run", "server", "Run a `participant`, which is either a server (or replica), a client or a master")
+	machineType  = flag.String("run", "server", "Run a `participant`, which is either a server (or replica), a client or a master, administer (admin), inject faults in, change the members of or move the leader of a running cluster, generate the TLS certificates of the cluster (certs), or check the config without starting anything (validate)")
 	protocol     = flag.String("protocol", "", "Protocol to run. Overwrites `protocol` field of the config file")
 	quorum       = flag.String("quorum", "", "Quorum config `file`")
 )
@@ -56,6 +58,26 @@ func main() {
 	if *protocol != "" {
 		c.Protocol = *protocol
 	}
+	if *machineType == "validate" {
+		if err := validateConfig(c); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s: protocol %s, replicas %d, clients %d, masters %d\n", *confs,
+			c.Protocol, len(c.ReplicaAddrs), len(c.ClientAddrs), len(c.MasterAliases))
+		return
+	}
+	if *machineType == "certs" {
+		if err := generateCerts(c, flag.Args()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := useTLS(c); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	defs.LatencyConf = *latency
 
 	switch *machineType {
@@ -67,6 +89,38 @@ func main() {
 		c.MachineType = config.ClientMachine
 	case "master":
 		c.MachineType = config.MasterMachine
+	case "faults":
+		faults, err := injectFaults(c, flag.Args())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for _, f := range faults {
+			fmt.Println(f)
+		}
+		return
+	case "members":
+		reply, err := changeMembership(c, flag.Args())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("members", reply.Members, "learners", reply.Learners, "leader", reply.Leader)
+		return
+	case "leader":
+		reply, err := transferLeader(c, flag.Args())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("leader", reply.Leader)
+		return
+	case "admin":
+		if err := runAdmin(c, flag.Args(), os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
 	default:
 		fmt.Println("Unknown participant type")
 		flag.Usage()
@@ -90,10 +144,170 @@ func run(c *config.Config) {
 }
 
 func runMaster(c *config.Config) {
-	m := master.New(len(c.ReplicaAddrs), c.MasterPort, dlog.New(*logFile, true))
+	m := master.New(len(c.ReplicaAddrs), c.MasterPortOf(c.Alias), dlog.New(*logFile, true))
+	if len(c.Members)+len(c.Learners) > 0 {
+		m.SetMembers(c.InitialMembers(len(c.ReplicaAddrs)))
+	}
+	if len(c.Learners) > 0 {
+		m.SetLearners(c.InitialLearners(len(c.ReplicaAddrs)))
+	}
+	if len(c.Witnesses) > 0 {
+		m.SetWitnesses(c.WitnessList(len(c.ReplicaAddrs)))
+	}
+	reportPath := c.Report
+	if reportPath == "" {
+		reportPath = "cluster-report"
+	} else if strings.EqualFold(reportPath, "none") {
+		reportPath = ""
+	}
+	m.SetReport(len(c.ClientAddrs), reportPath)
+	m.SetTransport(c.Transport)
+	m.SetMasters(c.MasterIndex(c.Alias), c.MasterList())
 	m.Run()
 }
 
+// useTLS runs the connections of the participant over mutual TLS if the
+// config has a certificate directory.
+func useTLS(c *config.Config) error {
+	if c.TLS == "" {
+		return nil
+	}
+	tc, err := transport.LoadTLS(c.TLS, c.Alias)
+	if err != nil {
+		return fmt.Errorf("tls: %v", err)
+	}
+	c.Transport = transport.TLS(c.Transport, tc)
+	return nil
+}
+
+// generateCerts creates the TLS certificates of every participant of the
+// config in the directory given by args, or else by the tls field.
+func generateCerts(c *config.Config, args []string) error {
+	dir := c.TLS
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if dir == "" {
+		return fmt.Errorf("certs: missing directory")
+	}
+	var aliases []string
+	for alias := range c.ReplicaAddrs {
+		aliases = append(aliases, alias)
+	}
+	for alias := range c.ClientAddrs {
+		aliases = append(aliases, alias)
+	}
+	if c.MasterAlias != "" {
+		aliases = append(aliases, c.MasterAlias)
+	}
+	return transport.GenerateCerts(dir, aliases, 10*365*24*time.Hour)
+}
+
+// validateConfig checks what config.Read cannot: that the protocol of c is
+// known, that its alias, if any, is a participant, and that its clients can
+// generate its key and payload size distributions.
+func validateConfig(c *config.Config) error {
+	known := false
+	for _, p := range protocols {
+		known = known || strings.ToLower(c.Protocol) == p
+	}
+	if !known {
+		return fmt.Errorf("protocol: unknown protocol %q", c.Protocol)
+	}
+	if c.Alias != "" && !c.IsParticipant(c.Alias) {
+		return fmt.Errorf("alias: unknown participant %s", c.Alias)
+	}
+	if c.KeySpace > 0 || c.KeyDist != "" {
+		_, err := client.NewKeyGeneratorDist(client.KeyDistConfig{
+			Dist:     c.KeyDist,
+			KeySpace: c.KeySpace,
+			Skew:     c.ZipfSkew,
+			HotKeys:  c.HotKeys,
+			HotOps:   c.HotOps,
+			HotShift: c.HotShift,
+		}, 0)
+		if err != nil {
+			return fmt.Errorf("keyDist: %v", err)
+		}
+	}
+	if c.ValueDist != "" {
+		_, err := client.NewValueSizeGenerator(client.ValueDistConfig{
+			Dist: c.ValueDist,
+			Size: c.CommandSize,
+			Min:  c.ValueMin,
+			Max:  c.ValueMax,
+			Skew: c.ValueSkew,
+			Hist: c.ValueHist,
+		}, 0)
+		if err != nil {
+			return fmt.Errorf("valueDist: %v", err)
+		}
+	}
+	return nil
+}
+
+// injectFaults asks the master to inject the faults described by args (see
+// defs.ParseFaults) and returns the faults in place.
+func injectFaults(c *config.Config, args []string) ([]defs.LinkFault, error) {
+	fa, err := defs.ParseFaults(args)
+	if err != nil {
+		return nil, err
+	}
+	reply := &defs.FaultReply{}
+	if err := callMaster(c, "Master.InjectFaults", fa, reply); err != nil {
+		return nil, err
+	}
+	return reply.Links, nil
+}
+
+// changeMembership asks the master to change the members of a raft or
+// raft-ht cluster as described by args (see defs.ParseMembership).
+func changeMembership(c *config.Config, args []string) (*defs.MembershipReply, error) {
+	ma, err := defs.ParseMembership(args)
+	if err != nil {
+		return nil, err
+	}
+	reply := &defs.MembershipReply{}
+	if err := callMaster(c, "Master.ChangeMembership", ma, reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// transferLeader asks the master to make the replica of args the leader
+// (see defs.ParseTransferLeader).
+func transferLeader(c *config.Config, args []string) (*defs.TransferLeaderReply, error) {
+	ta, err := defs.ParseTransferLeader(args)
+	if err != nil {
+		return nil, err
+	}
+	reply := &defs.TransferLeaderReply{}
+	if err := callMaster(c, "Master.TransferLeader", ta, reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// callMaster calls method on the primary master, trying the masters in
+// turn.
+func callMaster(c *config.Config, method string, args, reply interface{}) error {
+	err := fmt.Errorf("no master")
+	for _, addr := range c.MasterList() {
+		var m *rpc.Client
+		m, err = transport.DialHTTP(transport.OrTCP(c.Transport), addr, 10*time.Second)
+		if err != nil {
+			err = fmt.Errorf("cannot connect to the master: %v", err)
+			continue
+		}
+		err = m.Call(method, args, reply)
+		m.Close()
+		if err == nil || err.Error() != master.ErrNotPrimary.Error() {
+			return err
+		}
+	}
+	return err
+}
+
 func runClient(c *config.Config, verbose bool) {
 	// Set protocol-specific config flags BEFORE spawning goroutines
 	// to avoid data races on shared *config.Config.
@@ -131,6 +345,33 @@ func runClient(c *config.Config, verbose bool) {
 
 	numThreads := c.GetNumClientThreads()
 
+	p := strings.ToLower(c.Protocol)
+
+	// Report metrics to the master (periodically if reportInterval is set)
+	reporter := client.NewMetricsReporter(c.MasterAddr, c.MasterPort, c.Alias,
+		c.Protocol, numThreads, dlog.New(*logFile, verbose))
+	reporter.SetTransport(c.Transport)
+	reporter.SetMasters(c.MasterList())
+	reporter.Start(c.ReportInterval)
+
+	// Write per-second metrics for time-series analysis
+	var ts *client.TimeSeries
+	if isHybridProtocol(p) {
+		tsPath := fmt.Sprintf("timeseries-%s.csv", c.Alias)
+		f, err := os.Create(tsPath)
+		if err != nil {
+			log.Printf("Warning: cannot create %s: %v", tsPath, err)
+		} else {
+			defer f.Close()
+			ts = client.NewTimeSeries(f, c.Alias, time.Second)
+			ts.Start()
+		}
+	}
+	register := func(hbc *client.HybridBufferClient) {
+		reporter.Add(hbc)
+		ts.Add(hbc)
+	}
+
 	// Collect metrics and durations from all threads
 	allMetrics := make([]*client.HybridMetrics, numThreads)
 	allDurations := make([]time.Duration, numThreads)
@@ -140,7 +381,7 @@ func runClient(c *config.Config, verbose bool) {
 	for i := 0; i < numThreads; i++ {
 		wg.Add(1)
 		go func(i int) {
-			metrics, duration := runSingleClient(c, i, verbose, numThreads)
+			metrics, duration := runSingleClient(c, i, verbose, numThreads, register)
 			metricsLock.Lock()
 			allMetrics[i] = metrics
 			allDurations[i] = duration
@@ -158,9 +399,14 @@ func runClient(c *config.Config, verbose bool) {
 		}
 	}
 
+	if ts != nil {
+		if err := ts.Stop(); err != nil {
+			log.Printf("Warning: failed to write time series: %v", err)
+		}
+	}
+
 	// Aggregate and print/export metrics
-	p := strings.ToLower(c.Protocol)
-	if p == "curp" || p == "curpht" || p == "curpho" || p == "raft" || p == "raftht" || p == "epaxos" || p == "epaxosswift" || p == "epaxosho" || p == "mongotunable" || p == "pileus" || p == "pileusht" {
+	if isHybridProtocol(p) {
 		aggregated := client.AggregateMetrics(allMetrics)
 		if numThreads > 1 {
 			l := dlog.New(*logFile, verbose)
@@ -173,10 +419,24 @@ func runClient(c *config.Config, verbose bool) {
 		if err := aggregated.ExportLatencies(latPath); err != nil {
 			log.Printf("Warning: failed to export latencies to %s: %v", latPath, err)
 		}
+
+		if err := reporter.Final(aggregated, maxDuration); err != nil {
+			log.Printf("Warning: failed to report metrics to master: %v", err)
+		}
 	}
 }
 
-func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads int) (*client.HybridMetrics, time.Duration) {
+// isHybridProtocol tells whether the clients of protocol p run the hybrid
+// benchmark loop and collect HybridMetrics.
+func isHybridProtocol(p string) bool {
+	switch p {
+	case "curp", "curpht", "curpho", "raft", "raftht", "epaxos", "epaxosswift", "epaxosho", "mongotunable", "pileus", "pileusht":
+		return true
+	}
+	return false
+}
+
+func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads int, register func(*client.HybridBufferClient)) (*client.HybridMetrics, time.Duration) {
 	// Thread 0 uses the main log file, other threads use /dev/null to avoid clutter
 	// All operational output goes through thread 0
 	var l *dlog.Logger
@@ -187,17 +447,51 @@ func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads i
 	}
 
 	server := c.Proxy.ProxyOf(c.ClientAddrs[c.Alias])
-	server = c.ReplicaAddrs[server]
+	if addr, exists := c.ReplicaAddrs[server]; exists {
+		// replicas may share a host
+		server = fmt.Sprintf("%s:%d", addr, c.ReplicaPort(server))
+	} else {
+		server = ""
+	}
 	cl := client.NewClientLog(server, c.MasterAddr, c.MasterPort, c.Fast, c.Leaderless, verbose, l)
+	cl.SetTransport(c.Transport)
+	cl.SetMasters(c.MasterList())
+	cl.SetClock(c.Clock)
+	cl.SetCluster(c.Protocol, c.Cluster)
 	b := client.NewBufferClient(cl, c.Reqs, c.CommandSize, c.Conflicts, c.Writes, int64(c.Key))
 	if c.Pipeline {
 		b.Pipeline(c.Syncs, int32(c.Pendings))
 	}
-	// Configure KeyGenerator for Zipf/Uniform key distribution
-	if c.KeySpace > 0 {
-		keyGen := client.NewKeyGenerator(c.KeySpace, c.ZipfSkew, cl.ClientId)
+	// Configure KeyGenerator for the key distribution
+	if c.KeySpace > 0 || c.KeyDist != "" {
+		keyGen, err := client.NewKeyGeneratorDist(client.KeyDistConfig{
+			Dist:     c.KeyDist,
+			KeySpace: c.KeySpace,
+			Skew:     c.ZipfSkew,
+			HotKeys:  c.HotKeys,
+			HotOps:   c.HotOps,
+			HotShift: c.HotShift,
+		}, cl.ClientId)
+		if err != nil {
+			log.Fatal(err)
+		}
 		b.SetKeyGenerator(keyGen)
 	}
+	// Configure ValueSizeGenerator for write payload sizes
+	if c.ValueDist != "" {
+		valSize, err := client.NewValueSizeGenerator(client.ValueDistConfig{
+			Dist: c.ValueDist,
+			Size: c.CommandSize,
+			Min:  c.ValueMin,
+			Max:  c.ValueMax,
+			Skew: c.ValueSkew,
+			Hist: c.ValueHist,
+		}, cl.ClientId)
+		if err != nil {
+			log.Fatal(err)
+		}
+		b.SetValueSizeGenerator(valSize)
+	}
 	if err := b.Connect(); err != nil {
 		log.Fatal(err)
 	}
@@ -226,9 +520,7 @@ func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads i
 		hbc := client.NewHybridBufferClient(b, 0, 0, c.ReplyTimeout) // weakRatio=0, weakWrites=0
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(cl)
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else if p == "curpht" {
 		cls := []string{}
 		for a := range c.ClientAddrs {
@@ -252,10 +544,7 @@ func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads i
 		hbc := client.NewHybridBufferClient(b, c.WeakRatio, weakWrites, c.ReplyTimeout)
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(cl)
-		// For single thread, run with printing. For multiple threads, collect metrics.
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else if p == "curpho" {
 		cls := []string{}
 		for a := range c.ClientAddrs {
@@ -277,17 +566,13 @@ func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads i
 		hbc := client.NewHybridBufferClient(b, c.WeakRatio, weakWrites, c.ReplyTimeout)
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(cl)
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else if p == "raft" {
 		raftCl := raft.NewClient(b)
 		hbc := client.NewHybridBufferClient(b, 0, 0, c.ReplyTimeout) // weakRatio=0: all strong
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(raftCl)
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else if p == "raftht" {
 		rafthtCl := raftht.NewClient(b)
 		weakWrites := c.WeakWrites
@@ -297,33 +582,25 @@ func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads i
 		hbc := client.NewHybridBufferClient(b, c.WeakRatio, weakWrites, c.ReplyTimeout)
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(rafthtCl)
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else if p == "epaxos" {
 		epaxosCl := epaxos.NewClient(b)
 		hbc := client.NewHybridBufferClient(b, 0, 0, c.ReplyTimeout) // weakRatio=0: all strong
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(epaxosCl)
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else if p == "epaxosswift" {
 		epaxosswiftCl := epaxosswift.NewClient(b)
 		hbc := client.NewHybridBufferClient(b, 0, 0, c.ReplyTimeout) // weakRatio=0: all strong
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(epaxosswiftCl)
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else if p == "epaxosho" {
 		epaxoshoCl := epaxosho.NewClient(b)
 		hbc := client.NewHybridBufferClient(b, c.WeakRatio, c.WeakWrites, c.ReplyTimeout)
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(epaxoshoCl)
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else if p == "mongotunable" {
 		mtCl := mongotunable.NewClient(b)
 		weakWrites := c.WeakWrites
@@ -333,9 +610,7 @@ func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads i
 		hbc := client.NewHybridBufferClient(b, c.WeakRatio, weakWrites, c.ReplyTimeout)
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(mtCl)
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else if p == "pileus" {
 		plCl := pileus.NewClient(b)
 		weakWrites := c.WeakWrites
@@ -345,9 +620,7 @@ func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads i
 		hbc := client.NewHybridBufferClient(b, c.WeakRatio, weakWrites, c.ReplyTimeout)
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(plCl)
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else if p == "pileusht" {
 		phtCl := pileusht.NewClient(b)
 		weakWrites := c.WeakWrites
@@ -357,9 +630,7 @@ func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads i
 		hbc := client.NewHybridBufferClient(b, c.WeakRatio, weakWrites, c.ReplyTimeout)
 		hbc.SetScanParams(c.ScanRatio, c.ScanCount)
 		hbc.SetHybridClient(phtCl)
-		printResults := (numThreads == 1)
-		hbc.HybridLoopWithOptions(printResults)
-		return hbc.GetMetrics(), hbc.GetDuration()
+		return runHybridLoop(hbc, numThreads, register)
 	} else {
 		waitFrom := b.LeaderId
 		if b.Fast || b.Leaderless || c.WaitClosest {
@@ -370,3 +641,13 @@ func runSingleClient(c *config.Config, threadIdx int, verbose bool, numThreads i
 		return nil, 0
 	}
 }
+
+// runHybridLoop registers the thread with the metrics collectors, runs its
+// hybrid benchmark loop and returns its metrics. Results are printed here
+// only for single-threaded clients; otherwise runClient prints the
+// aggregated metrics.
+func runHybridLoop(hbc *client.HybridBufferClient, numThreads int, register func(*client.HybridBufferClient)) (*client.HybridMetrics, time.Duration) {
+	register(hbc)
+	hbc.HybridLoopWithOptions(numThreads == 1)
+	return hbc.GetMetrics(), hbc.GetDuration()
+}
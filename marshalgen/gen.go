@@ -0,0 +1,582 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const rpcPath = "github.com/imdea-software/swiftpaxos/rpc"
+
+// Generate returns the file output of the package in dir with the methods
+// and caches of the given types. The current content of output is ignored.
+func Generate(dir, output string, typeNames []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return fi.Name() != output && !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%d packages in %s", len(pkgs), dir)
+	}
+	var files []*ast.File
+	for _, p := range pkgs {
+		for _, f := range p.Files {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return fset.Position(files[i].Pos()).Filename < fset.Position(files[j].Pos()).Filename
+	})
+
+	// the methods generated before are missing, so errors are expected
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, nil)
+
+	g := &gen{
+		pkg:      pkg,
+		names:    make(map[string]string),
+		imports:  make(map[string]bool),
+		messages: make(map[*types.TypeName]bool),
+		hand:     make(map[string]bool),
+	}
+	g.scan(files)
+	var msgs []*types.TypeName
+	for _, name := range typeNames {
+		name = strings.TrimSpace(name)
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("no type %s in %s", name, dir)
+		}
+		if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+			return nil, fmt.Errorf("%s is not a struct type", name)
+		}
+		g.messages[obj] = true
+		msgs = append(msgs, obj)
+	}
+	for _, obj := range msgs {
+		if err := g.check(obj.Type().Underlying(), obj.Name(), 0); err != nil {
+			return nil, err
+		}
+	}
+	var body bytes.Buffer
+	for _, obj := range msgs {
+		g.message(&body, obj)
+	}
+	if g.byteReader && !g.hand["byteReader"] {
+		g.imports["io"] = true
+		body.WriteString("type byteReader interface {\nio.Reader\nReadByte() (c byte, err error)\n}\n")
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by marshalgen; DO NOT EDIT.\n\npackage %s\n\n", pkg.Name())
+	g.writeImports(&src)
+	src.Write(body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+type gen struct {
+	pkg      *types.Package
+	names    map[string]string // import path -> name in the package files
+	imports  map[string]bool   // imports of the generated file
+	messages map[*types.TypeName]bool
+	// declarations of the package: types and functions by name, methods
+	// as Type.Method
+	hand       map[string]bool
+	byteReader bool
+}
+
+func (g *gen) scan(files []*ast.File) {
+	for _, f := range files {
+		for _, imp := range f.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+			if imp.Name != nil && g.names[path] == "" {
+				g.names[path] = imp.Name.Name
+			}
+		}
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.GenDecl:
+				for _, s := range d.Specs {
+					if ts, ok := s.(*ast.TypeSpec); ok {
+						g.hand[ts.Name.Name] = true
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					g.hand[d.Name.Name] = true
+					continue
+				}
+				t := d.Recv.List[0].Type
+				if s, ok := t.(*ast.StarExpr); ok {
+					t = s.X
+				}
+				if id, ok := t.(*ast.Ident); ok {
+					g.hand[id.Name+"."+d.Name.Name] = true
+				}
+			}
+		}
+	}
+}
+
+// qualifier names the packages in the generated code as the package files
+// do, and records the imports of the generated file.
+func (g *gen) qualifier(p *types.Package) string {
+	if p == g.pkg {
+		return ""
+	}
+	g.imports[p.Path()] = true
+	if name, ok := g.names[p.Path()]; ok {
+		return name
+	}
+	return p.Name()
+}
+
+func (g *gen) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+func (g *gen) writeImports(w *bytes.Buffer) {
+	var std, other []string
+	for path := range g.imports {
+		spec := strconv.Quote(path)
+		name, ok := g.names[path]
+		if path == rpcPath && !ok {
+			name, ok = "fastrpc", true
+		}
+		if ok {
+			spec = name + " " + spec
+		}
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	w.WriteString("import (\n")
+	for _, s := range std {
+		w.WriteString(s + "\n")
+	}
+	if len(std) > 0 && len(other) > 0 {
+		w.WriteString("\n")
+	}
+	for _, s := range other {
+		w.WriteString(s + "\n")
+	}
+	w.WriteString(")\n\n")
+}
+
+// check returns an error if t, the type of path, cannot be encoded.
+func (g *gen) check(t types.Type, path string, depth int) error {
+	if depth > 32 {
+		return fmt.Errorf("%s: recursive type %s", path, t)
+	}
+	if g.custom(t) {
+		return nil
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if u.Kind() == types.String || basicSize(u) > 0 {
+			return nil
+		}
+	case *types.Struct:
+		named, _ := t.(*types.Named)
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if f.Name() == "_" {
+				continue
+			}
+			if !f.Exported() && named != nil && named.Obj().Pkg() != g.pkg {
+				return fmt.Errorf("%s: unexported field %s of %s", path, f.Name(), t)
+			}
+			if err := g.check(f.Type(), path+"."+f.Name(), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *types.Array:
+		return g.check(u.Elem(), path+"[]", depth+1)
+	case *types.Slice:
+		return g.check(u.Elem(), path+"[]", depth+1)
+	case *types.Pointer:
+		return g.check(u.Elem(), "*"+path, depth+1)
+	}
+	return fmt.Errorf("%s: unsupported type %s", path, g.typeString(t))
+}
+
+func basicSize(b *types.Basic) int {
+	switch b.Kind() {
+	case types.Bool, types.Int8, types.Uint8:
+		return 1
+	case types.Int16, types.Uint16:
+		return 2
+	case types.Int32, types.Uint32:
+		return 4
+	case types.Int, types.Uint, types.Int64, types.Uint64:
+		return 8
+	}
+	return 0
+}
+
+// custom reports whether the values of t are encoded by their Marshal and
+// Unmarshal methods rather than field by field.
+func (g *gen) custom(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	if _, ok := named.Underlying().(*types.Basic); ok {
+		return false
+	}
+	if g.messages[named.Obj()] {
+		if g.hand[named.Obj().Name()+".Marshal"] {
+			return true
+		}
+		_, fixed := g.size(named.Underlying())
+		return !fixed
+	}
+	ms := types.NewMethodSet(types.NewPointer(named))
+	return ms.Lookup(named.Obj().Pkg(), "Marshal") != nil && ms.Lookup(named.Obj().Pkg(), "Unmarshal") != nil
+}
+
+// size returns the size of the encoding of t, if it is the same for all
+// values.
+func (g *gen) size(t types.Type) (int, bool) {
+	if g.custom(t) {
+		return 0, false
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		n := basicSize(u)
+		return n, n > 0
+	case *types.Struct:
+		size := 0
+		for i := 0; i < u.NumFields(); i++ {
+			if u.Field(i).Name() == "_" {
+				continue
+			}
+			n, fixed := g.size(u.Field(i).Type())
+			if !fixed {
+				return 0, false
+			}
+			size += n
+		}
+		return size, true
+	case *types.Array:
+		n, fixed := g.size(u.Elem())
+		return n * int(u.Len()), fixed
+	}
+	return 0, false
+}
+
+func isByte(t types.Type) bool {
+	b, ok := t.(*types.Basic)
+	return ok && b.Kind() == types.Uint8
+}
+
+// message writes the methods and the cache of the message obj to w.
+func (g *gen) message(w *bytes.Buffer, obj *types.TypeName) {
+	name := obj.Name()
+	st := obj.Type().Underlying().(*types.Struct)
+
+	if !g.hand[name+".New"] {
+		g.imports[rpcPath] = true
+		fmt.Fprintf(w, "func (t *%s) New() %s.Serializable {\nreturn new(%s)\n}\n\n", name, g.rpcName(), name)
+	}
+	if !g.hand[name+".BinarySize"] {
+		size, fixed := g.size(st)
+		if g.hand[name+".Marshal"] {
+			size, fixed = 0, false
+		}
+		fmt.Fprintf(w, "func (t *%s) BinarySize() (nbytes int, sizeKnown bool) {\nreturn %d, %v\n}\n\n", name, size, fixed)
+	}
+	if !g.hand[name+"Cache"] && !g.hand["New"+name+"Cache"] {
+		g.imports["sync"] = true
+		fmt.Fprintf(w, cacheTemplate, name)
+	}
+	if g.hand[name+".Marshal"] || g.hand[name+".Unmarshal"] {
+		return
+	}
+	g.imports["io"] = true
+
+	m := &coder{g: g}
+	m.fields("t", st)
+	m.flush()
+	fmt.Fprintf(w, "func (t *%s) Marshal(wire io.Writer) {\n", name)
+	if m.bufSize > 0 {
+		fmt.Fprintf(w, "var b [%d]byte\n", m.bufSize)
+	}
+	w.Write(m.body.Bytes())
+	w.WriteString("}\n\n")
+
+	u := &coder{g: g, unmarshal: true}
+	u.fields("t", st)
+	u.flush()
+	if u.varint {
+		g.byteReader = true
+		g.imports["bufio"] = true
+		fmt.Fprintf(w, "func (t *%s) Unmarshal(rr io.Reader) error {\n", name)
+		w.WriteString("wire, ok := rr.(byteReader)\nif !ok {\nwire = bufio.NewReader(rr)\n}\n")
+	} else {
+		fmt.Fprintf(w, "func (t *%s) Unmarshal(wire io.Reader) error {\n", name)
+	}
+	if u.bufSize > 0 {
+		fmt.Fprintf(w, "var b [%d]byte\n", u.bufSize)
+	}
+	w.Write(u.body.Bytes())
+	w.WriteString("return nil\n}\n\n")
+}
+
+func (g *gen) rpcName() string {
+	if name, ok := g.names[rpcPath]; ok {
+		return name
+	}
+	return "fastrpc"
+}
+
+const cacheTemplate = `type %[1]sCache struct {
+	mu    sync.Mutex
+	cache []*%[1]s
+}
+
+func New%[1]sCache() *%[1]sCache {
+	c := &%[1]sCache{}
+	c.cache = make([]*%[1]s, 0)
+	return c
+}
+
+func (p *%[1]sCache) Get() *%[1]s {
+	var t *%[1]s
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &%[1]s{}
+	}
+	return t
+}
+
+func (p *%[1]sCache) Put(t *%[1]s) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+`
+
+// coder writes the body of a Marshal or Unmarshal method. Consecutive
+// fields of fixed size are written or read at once through the buffer b.
+type coder struct {
+	g         *gen
+	unmarshal bool
+	body      bytes.Buffer
+	off       int      // size of the pending fields
+	pending   []string // statements that fill b or read from it
+	bufSize   int
+	depth     int // depth of the loops over slices
+	lens      int // number of the slice lengths read
+	varint    bool
+}
+
+func (c *coder) line(format string, args ...interface{}) {
+	fmt.Fprintf(&c.body, format+"\n", args...)
+}
+
+func (c *coder) need(n int) {
+	if n > c.bufSize {
+		c.bufSize = n
+	}
+}
+
+// add adds a field of n bytes at the current offset to the pending fields.
+func (c *coder) add(n int, stmt string) {
+	c.pending = append(c.pending, stmt)
+	c.off += n
+	c.need(c.off)
+}
+
+func (c *coder) flush() {
+	if c.off == 0 {
+		return
+	}
+	if c.unmarshal {
+		c.line("if _, err := io.ReadFull(wire, b[:%d]); err != nil {\nreturn err\n}", c.off)
+	}
+	for _, s := range c.pending {
+		c.line("%s", s)
+	}
+	if !c.unmarshal {
+		c.line("wire.Write(b[:%d])", c.off)
+	}
+	c.off, c.pending = 0, nil
+}
+
+func (c *coder) fields(expr string, st *types.Struct) {
+	for i := 0; i < st.NumFields(); i++ {
+		if f := st.Field(i); f.Name() != "_" {
+			c.value(expr+"."+f.Name(), f.Type())
+		}
+	}
+}
+
+// conv returns the conversion of v, an expression of type natural, to t.
+func (c *coder) conv(t types.Type, natural types.BasicKind, v string) string {
+	if b, ok := t.(*types.Basic); ok && b.Kind() == natural {
+		return v
+	}
+	return c.g.typeString(t) + "(" + v + ")"
+}
+
+func (c *coder) value(expr string, t types.Type) {
+	if c.g.custom(t) {
+		c.flush()
+		if c.unmarshal {
+			c.line("if err := %s.Unmarshal(wire); err != nil {\nreturn err\n}", expr)
+		} else {
+			c.line("%s.Marshal(wire)", expr)
+		}
+		return
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		c.basic(expr, t, u)
+	case *types.Struct:
+		c.fields(expr, u)
+	case *types.Array:
+		if isByte(u.Elem()) {
+			n := int(u.Len())
+			if c.unmarshal {
+				c.add(n, fmt.Sprintf("copy(%s[:], b[%d:%d])", expr, c.off, c.off+n))
+			} else {
+				c.add(n, fmt.Sprintf("copy(b[%d:%d], %s[:])", c.off, c.off+n, expr))
+			}
+			return
+		}
+		for i := 0; i < int(u.Len()); i++ {
+			c.value(fmt.Sprintf("%s[%d]", expr, i), u.Elem())
+		}
+	case *types.Slice:
+		c.slice(expr, t, u)
+	case *types.Pointer:
+		c.pointer(expr, t, u)
+	}
+}
+
+func (c *coder) basic(expr string, t types.Type, b *types.Basic) {
+	off := c.off
+	switch n := basicSize(b); {
+	case b.Kind() == types.String:
+		c.flush()
+		if c.unmarshal {
+			l := c.readLen()
+			c.line("s%s := make([]byte, %s)", l, l)
+			c.line("if _, err := io.ReadFull(wire, s%s); err != nil {\nreturn err\n}", l)
+			c.line("%s = %s", expr, c.conv(t, types.String, "string(s"+l+")"))
+		} else {
+			c.writeLen(expr)
+			c.line("io.WriteString(wire, string(%s))", expr)
+		}
+	case b.Kind() == types.Bool:
+		if c.unmarshal {
+			c.add(n, fmt.Sprintf("%s = %s", expr, c.conv(t, types.Bool, fmt.Sprintf("b[%d] != 0", off))))
+		} else {
+			c.add(n, fmt.Sprintf("b[%d] = 0\nif %s {\nb[%d] = 1\n}", off, expr, off))
+		}
+	case n == 1:
+		if c.unmarshal {
+			c.add(n, fmt.Sprintf("%s = %s", expr, c.conv(t, types.Uint8, fmt.Sprintf("b[%d]", off))))
+		} else {
+			c.add(n, fmt.Sprintf("b[%d] = byte(%s)", off, expr))
+		}
+	default:
+		bits := strconv.Itoa(8 * n)
+		natural := map[int]types.BasicKind{2: types.Uint16, 4: types.Uint32, 8: types.Uint64}[n]
+		c.g.imports["encoding/binary"] = true
+		if c.unmarshal {
+			c.add(n, fmt.Sprintf("%s = %s", expr, c.conv(t, natural, fmt.Sprintf("binary.LittleEndian.Uint%s(b[%d:])", bits, off))))
+		} else {
+			c.add(n, fmt.Sprintf("binary.LittleEndian.PutUint%s(b[%d:], uint%s(%s))", bits, off, bits, expr))
+		}
+	}
+}
+
+func (c *coder) writeLen(expr string) {
+	c.g.imports["encoding/binary"] = true
+	c.need(binary.MaxVarintLen64)
+	c.line("wire.Write(b[:binary.PutVarint(b[:], int64(len(%s)))])", expr)
+}
+
+// readLen reads a length and returns the name of its variable.
+func (c *coder) readLen() string {
+	c.g.imports["encoding/binary"] = true
+	c.varint = true
+	c.lens++
+	l := "n" + strconv.Itoa(c.lens)
+	c.line("%s, err := binary.ReadVarint(wire)\nif err != nil {\nreturn err\n}", l)
+	return l
+}
+
+func (c *coder) slice(expr string, t types.Type, s *types.Slice) {
+	c.flush()
+	if c.unmarshal {
+		l := c.readLen()
+		c.line("%s = make(%s, %s)", expr, c.g.typeString(t), l)
+		if isByte(s.Elem()) {
+			c.line("if _, err := io.ReadFull(wire, %s); err != nil {\nreturn err\n}", expr)
+			return
+		}
+	} else {
+		c.writeLen(expr)
+		if isByte(s.Elem()) {
+			c.line("wire.Write(%s)", expr)
+			return
+		}
+	}
+	i := string(rune('i' + c.depth))
+	c.line("for %s := range %s {", i, expr)
+	c.depth++
+	c.value(expr+"["+i+"]", s.Elem())
+	c.flush()
+	c.depth--
+	c.line("}")
+}
+
+func (c *coder) pointer(expr string, t types.Type, p *types.Pointer) {
+	c.flush()
+	elem := "*" + expr
+	if _, ok := p.Elem().Underlying().(*types.Struct); ok || c.g.custom(p.Elem()) {
+		elem = expr
+	}
+	c.need(1)
+	if c.unmarshal {
+		c.line("if _, err := io.ReadFull(wire, b[:1]); err != nil {\nreturn err\n}")
+		c.line("if b[0] == 0 {\n%s = nil\n} else {", expr)
+		c.line("%s = new(%s)", expr, c.g.typeString(p.Elem()))
+	} else {
+		c.line("if %s == nil {\nb[0] = 0\nwire.Write(b[:1])\n} else {", expr)
+		c.line("b[0] = 1\nwire.Write(b[:1])")
+	}
+	c.value(elem, p.Elem())
+	c.flush()
+	c.line("}")
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const directive = "//go:generate go run github.com/imdea-software/swiftpaxos/marshalgen "
+
+// TestGeneratedFiles tests that the generated files of the repository are
+// up to date with their message types.
+func TestGeneratedFiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("type-checks all protocol packages")
+	}
+	n := 0
+	err := filepath.Walk("..", func(path string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || !strings.HasSuffix(path, ".go") {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			if !strings.HasPrefix(s.Text(), directive) {
+				continue
+			}
+			n++
+			args := strings.Fields(strings.TrimPrefix(s.Text(), directive))
+			if len(args) != 2 || args[0] != "-type" {
+				t.Errorf("%s: unexpected arguments %v", path, args)
+				continue
+			}
+			dir, file := filepath.Split(path)
+			output := strings.TrimSuffix(file, ".go") + "marsh.go"
+			src, err := Generate(dir, output, strings.Split(args[1], ","))
+			if err != nil {
+				t.Errorf("%s: %v", path, err)
+				continue
+			}
+			old, err := os.ReadFile(filepath.Join(dir, output))
+			if err != nil || !bytes.Equal(old, src) {
+				t.Errorf("%s is out of date, run go generate in %s", filepath.Join(dir, output), dir)
+			}
+		}
+		return s.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n == 0 {
+		t.Error("no go:generate directive found")
+	}
+}
+
+func generate(t *testing.T, src string, types ...string) (string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "defs.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// an old output is ignored
+	if err := os.WriteFile(filepath.Join(dir, "defsmarsh.go"), []byte("package p\n\nfunc (t *A) New() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := Generate(dir, "defsmarsh.go", types)
+	return string(out), err
+}
+
+func TestGenerate(t *testing.T) {
+	out, err := generate(t, `package p
+
+type Id struct {
+	Client int32
+	Seq    int32
+}
+
+type A struct {
+	Ok    bool
+	Id    Id
+	Count int
+	Hash  [4]byte
+	Rep   []byte
+	Name  string
+	Dep   *Id
+	Ids   []Id
+	Bs    []B
+}
+
+type B struct {
+	Keys []int64
+}
+
+func (t *B) New() *B { return new(B) }
+`, "A", "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Code generated by marshalgen; DO NOT EDIT.",
+		"func (t *A) New() fastrpc.Serializable",
+		"func (t *A) BinarySize() (nbytes int, sizeKnown bool) {\n\treturn 0, false",
+		"type ACache struct",
+		"func NewBCache() *BCache",
+		"wire.Write(b[:21])",
+		"binary.LittleEndian.PutUint32(b[1:], uint32(t.Id.Client))",
+		"copy(b[17:21], t.Hash[:])",
+		"wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Rep)))])",
+		"if t.Dep == nil {",
+		"t.Bs[i].Marshal(wire)",
+		"if err := t.Bs[i].Unmarshal(wire); err != nil {",
+		"t.Ok = b[0] != 0",
+		"t.Ids = make([]Id, n3)",
+		"type byteReader interface",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	// B declares its own New
+	if strings.Contains(out, "func (t *B) New()") {
+		t.Error("New of B generated")
+	}
+}
+
+func TestGenerateFixedSize(t *testing.T) {
+	out, err := generate(t, `package p
+
+type Id struct {
+	Client int32
+	Seq    int32
+}
+
+type A struct {
+	Id     Id
+	Status int8
+	Slot   int
+}
+`, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "return 17, true") || strings.Contains(out, "bufio") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, c := range []struct {
+		src, typ, want string
+	}{
+		{"package p\n\ntype A struct{ M map[int]int }\n", "A", "A.M: unsupported type map[int]int"},
+		{"package p\n\ntype A struct{ F float64 }\n", "A", "unsupported type float64"},
+		{"package p\n\ntype A []int\n", "A", "not a struct"},
+		{"package p\n", "A", "no type A"},
+	} {
+		if _, err := generate(t, c.src, c.typ); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%q: got %v, want %q", c.src, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Marshalgen generates the Marshal, Unmarshal, BinarySize and New methods
+// of the messages of a package, and the pooled caches of these messages
+// (MsgCache, NewMsgCache). It is run by go generate, e.g.
+//
+//	//go:generate go run github.com/imdea-software/swiftpaxos/marshalgen -type MReply,MAccept
+//
+// writes the methods of MReply and MAccept to defsmarsh.go if the directive
+// is in defs.go. Methods and caches declared by hand in the package are not
+// generated.
+//
+// Fields are encoded in the order of their declaration, in little endian:
+// bool, int8 and uint8 in one byte, int16 and uint16 in two, int32 and
+// uint32 in four, int, int64 and uint64 in eight. Arrays and structs are
+// encoded field by field. Slices and strings are prefixed by their length
+// as a varint, pointers by a byte set to 1 if they are not nil. Fields whose
+// type has its own Marshal and Unmarshal methods (e.g. state.Command) are
+// encoded by these methods.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("marshalgen: ")
+	typeNames := flag.String("type", "", "comma-separated list of message types")
+	output := flag.String("o", "", "output file (default <file>marsh.go for <file>.go, the file of the directive)")
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		gofile := os.Getenv("GOFILE")
+		if gofile == "" {
+			log.Fatal("-o is required outside go generate")
+		}
+		*output = strings.TrimSuffix(gofile, ".go") + "marsh.go"
+	}
+	src, err := Generate(".", filepath.Base(*output), strings.Split(*typeNames, ","))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
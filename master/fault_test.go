@@ -0,0 +1,79 @@
+package master
+
+import (
+	"fmt"
+	"net/rpc"
+	"testing"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/transport"
+)
+
+// Replica records the faults set by the master
+type Replica struct {
+	links []defs.LinkFault
+}
+
+func (r *Replica) SetFaults(args *defs.FaultArgs, reply *defs.FaultReply) error {
+	r.links = args.Links
+	return nil
+}
+
+// TestInjectFaults verifies that the master expands partitions, accumulates
+// faults until they are healed and installs them in every replica.
+func TestInjectFaults(t *testing.T) {
+	m := newTestMaster(3)
+	if err := m.InjectFaults(&defs.FaultArgs{Heal: true}, &defs.FaultReply{}); err == nil {
+		t.Error("expected an error before the replicas are connected")
+	}
+
+	n := transport.NewNetwork()
+	defer n.Close()
+	replicas := make([]*Replica, 3)
+	for i := range replicas {
+		replicas[i] = &Replica{}
+		srv := rpc.NewServer()
+		srv.Register(replicas[i])
+		addr := fmt.Sprintf("10.0.0.%d:8070", i+1)
+		l, err := n.Host(fmt.Sprintf("10.0.0.%d", i+1)).Listen(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go transport.ServeRPC(l, srv)
+		if m.nodes[i], err = transport.DialHTTP(n.Host("10.0.2.1"), addr, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.finishInit = true
+
+	reply := &defs.FaultReply{}
+	if err := m.InjectFaults(&defs.FaultArgs{Partition: [][]int32{{0}}}, reply); err != nil {
+		t.Fatal(err)
+	}
+	// 0 is cut from 1 and 2, both ways
+	if len(reply.Links) != 4 {
+		t.Errorf("partition: got %v", reply.Links)
+	}
+	delay := defs.LinkFault{From: 1, To: defs.FaultClients, Drop: 0.5}
+	if err := m.InjectFaults(&defs.FaultArgs{Links: []defs.LinkFault{delay}}, reply); err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range replicas {
+		if len(r.links) != 5 || r.links[4] != delay {
+			t.Errorf("replica %d has faults %v", i, r.links)
+		}
+	}
+
+	if err := m.InjectFaults(&defs.FaultArgs{Heal: true}, reply); err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range replicas {
+		if len(r.links) != 0 {
+			t.Errorf("replica %d still has faults %v after heal", i, r.links)
+		}
+	}
+
+	if err := m.InjectFaults(&defs.FaultArgs{Partition: [][]int32{{3}}}, reply); err == nil {
+		t.Error("expected an error for an unknown replica")
+	}
+}
@@ -1,21 +1,17 @@
 package master
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"net"
-	"net/http"
 	"net/rpc"
-	"os/exec"
-	"strconv"
-	"strings"
 	"sync"
-	"syscall"
 	"time"
 
+	"github.com/imdea-software/swiftpaxos/client"
 	"github.com/imdea-software/swiftpaxos/dlog"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/transport"
 )
 
 type Master struct {
@@ -26,6 +22,7 @@ type Master struct {
 	nodeList     []string
 	addrList     []string
 	portList     []int
+	rpcList      []string // RPC endpoints, host:port
 	registered   []bool // tracks which replica IDs have registered
 	numRegistered int
 	lock         *sync.Mutex
@@ -36,6 +33,29 @@ type Master struct {
 	finishInit   bool
 	initCond     *sync.Cond
 	nextLeader   int
+	transport    transport.Transport
+	faults       []defs.LinkFault // injected in the replicas
+	members      []bool           // voting replicas (raft), nil = all
+	learners     []bool           // non-voting replicas (raft), nil = none
+	witnesses    []bool           // witnesses (curp), nil = none
+	confMu       sync.Mutex       // serializes membership changes
+
+	// replication (see replication.go)
+	id      int      // index among the masters
+	masters []string // addresses of the masters
+	primary bool
+	epoch   int
+	// the primary acts until leaseUntil, and the master votes for no
+	// other master until promised
+	leaseUntil time.Time
+	promised   time.Time
+	stopped  bool
+	listener net.Listener
+
+	// client metrics reports, by client alias
+	reports    map[string]*client.MetricsReport
+	numClients int
+	reportPath string
 }
 
 func New(N, port int, logger *dlog.Logger) *Master {
@@ -47,6 +67,7 @@ func New(N, port int, logger *dlog.Logger) *Master {
 		nodeList:      make([]string, N),
 		addrList:      make([]string, N),
 		portList:      make([]int, N),
+		rpcList:       make([]string, N),
 		registered:    make([]bool, N),
 		numRegistered: 0,
 		lock:          new(sync.Mutex),
@@ -56,6 +77,9 @@ func New(N, port int, logger *dlog.Logger) *Master {
 		latencies:     make([]float64, N),
 		finishInit:    false,
 		nextLeader:    -1,
+		transport:     transport.TCP,
+		reports:       make(map[string]*client.MetricsReport),
+		primary:       true,
 	}
 	master.initCond = sync.NewCond(master.lock)
 	return master
@@ -65,24 +89,36 @@ func (master *Master) Run() {
 	master.Printf("master starting on port %d", master.port)
 	master.Printf("waiting for %d replicas", master.N)
 
-	rpc.Register(master)
-	rpc.HandleHTTP()
-	lc := net.ListenConfig{
-		Control: func(network, address string, c syscall.RawConn) error {
-			return c.Control(func(fd uintptr) {
-				syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
-			})
-		},
-	}
-	l, err := lc.Listen(context.Background(), "tcp", fmt.Sprintf(":%d", master.port))
+	srv := rpc.NewServer()
+	srv.Register(master)
+	l, err := master.transport.Listen(fmt.Sprintf(":%d", master.port))
 	if err != nil {
 		master.Fatal("master listen error:", err)
 	}
-	go master.run()
-	http.Serve(l, nil)
+	master.lock.Lock()
+	master.listener = l
+	master.lock.Unlock()
+	if len(master.masters) > 1 {
+		go master.replicate()
+	} else {
+		go master.run()
+	}
+	transport.ServeRPC(l, srv)
 }
 
+// SetTransport sets the network used by the master (nil = TCP). It must be
+// called before Run.
+func (master *Master) SetTransport(t transport.Transport) {
+	master.transport = transport.OrTCP(t)
+}
+
+// run does the work of the primary master, until it is no longer the
+// primary.
 func (master *Master) run() {
+	master.lock.Lock()
+	epoch := master.epoch
+	master.lock.Unlock()
+
 	for {
 		master.lock.Lock()
 		if master.numRegistered == master.N {
@@ -90,20 +126,34 @@ func (master *Master) run() {
 			break
 		}
 		master.lock.Unlock()
+		if !master.leading(epoch) {
+			return
+		}
 		time.Sleep(time.Second)
 	}
 	time.Sleep(2 * time.Second)
 
+	// a backup that takes over finds the leaders already designated
+	master.lock.Lock()
+	takeover := master.finishInit
+	master.lock.Unlock()
+
 	for i := 0; i < master.N; {
+		if !master.leading(epoch) {
+			return
+		}
 		var err error
-		addr := fmt.Sprintf("%s:%d", master.addrList[i], master.portList[i]+1000)
-		master.nodes[i], err = rpc.DialHTTP("tcp", addr)
+		if master.nodes[i] != nil {
+			master.nodes[i].Close()
+		}
+		addr := master.rpcList[i]
+		master.nodes[i], err = transport.DialHTTP(master.transport, addr, 0)
 		if err != nil {
 			master.Printf("error connecting to replica %d (%v), retrying...", i, addr)
 			time.Sleep(time.Second)
 		} else {
 			btlReply := defs.NewBeTheLeaderReply()
-			if master.leader[i] {
+			if master.leader[i] && !takeover {
 				err = master.nodes[i].Call("Replica.BeTheLeader", &defs.BeTheLeaderArgs{}, btlReply)
 				if err != nil {
 					master.Fatal("Not today Zurg!")
@@ -120,8 +170,11 @@ func (master *Master) run() {
 	}
 
 	var new_leader bool
-	pingNode := func(i int, node *rpc.Client) {
+	// pingNode returns the round trip of the ping, if replica i answers
+	pingNode := func(i int, node *rpc.Client) (time.Duration, bool) {
+		start := time.Now()
 		err := node.Call("Replica.Ping", &defs.PingArgs{}, &defs.PingReply{})
+		rtt := time.Since(start)
 		if err != nil {
 			master.alive[i] = false
 			if master.leader[i] {
@@ -131,10 +184,13 @@ func (master *Master) run() {
 		} else {
 			master.alive[i] = true
 		}
+		return rtt, err == nil
 	}
 	master.lock.Lock()
 	for i, node := range master.nodes {
-		pingNode(i, node)
+		if rtt, ok := pingNode(i, node); ok {
+			master.recordLatency(i, rtt)
+		}
 	}
 	// initialization is finished
 	// (i.e., `alive` has been computed)
@@ -143,7 +199,10 @@ func (master *Master) run() {
 	master.lock.Unlock()
 
 	beTheLeader := func(i int) error {
-		if master.alive[i] {
+		if !master.leading(epoch) {
+			return ErrNotPrimary
+		}
+		if master.alive[i] && master.isMember(i) {
 			btlReply := defs.NewBeTheLeaderReply()
 			err := master.nodes[i].Call("Replica.BeTheLeader", &defs.BeTheLeaderArgs{}, btlReply)
 			if err == nil {
@@ -164,9 +223,16 @@ func (master *Master) run() {
 
 	for {
 		time.Sleep(3 * time.Second)
+		if !master.leading(epoch) {
+			return
+		}
 		new_leader = false
 		for i, node := range master.nodes {
-			pingNode(i, node)
+			if rtt, ok := pingNode(i, node); ok {
+				master.lock.Lock()
+				master.recordLatency(i, rtt)
+				master.lock.Unlock()
+			}
 		}
 
 		if !new_leader {
@@ -185,7 +251,23 @@ func (master *Master) run() {
 	}
 }
 
+// recordLatency adds the round trip rtt of a Replica.Ping to replica i to
+// the moving average of its latency, in milliseconds, which starts at the
+// first round trip. master.lock must be held.
+func (master *Master) recordLatency(i int, rtt time.Duration) {
+	ms := float64(rtt) / float64(time.Millisecond)
+	if master.latencies[i] == 0 {
+		master.latencies[i] = ms
+		master.Printf("node %v [%v] -> %v", i, master.nodeList[i], ms)
+	} else {
+		master.latencies[i] = 0.8*master.latencies[i] + 0.2*ms
+	}
+}
+
 func (master *Master) Register(args *defs.RegisterArgs, reply *defs.RegisterReply) error {
+	if err := master.checkPrimary(); err != nil {
+		return err
+	}
 	master.lock.Lock()
 	defer master.lock.Unlock()
 
@@ -200,23 +282,15 @@ func (master *Master) Register(args *defs.RegisterArgs, reply *defs.RegisterRepl
 		master.nodeList[index] = addrPort
 		master.addrList[index] = args.Addr
 		master.portList[index] = args.Port
+		rpcPort := args.RPCPort
+		if rpcPort == 0 {
+			rpcPort = args.Port + 1000
+		}
+		master.rpcList[index] = fmt.Sprintf("%s:%d", args.Addr, rpcPort)
 		master.registered[index] = true
 		master.leader[index] = false
 		master.numRegistered++
-
-		addr := args.Addr
-		if addr == "" {
-			addr = "127.0.0.1"
-		}
-		out, err := exec.Command("ping", addr, "-c 2", "-q").Output()
-		if err == nil {
-			master.latencies[index], _ =
-				strconv.ParseFloat(strings.Split(string(out), "/")[4], 64)
-			master.Printf("node %v [%v] -> %v", index,
-				master.nodeList[index], master.latencies[index])
-		} else {
-			master.Fatal("cannot connect to " + addr)
-		}
+		master.Printf("node %v [%v] registered", index, master.nodeList[index])
 	}
 
 	if master.numRegistered == master.N {
@@ -224,13 +298,14 @@ func (master *Master) Register(args *defs.RegisterArgs, reply *defs.RegisterRepl
 		reply.ReplicaId = index
 		reply.NodeList = master.nodeList
 
-		// Always use replica 0 as the initial leader.
-		// Set leader[0] once when all replicas are registered.
-		if !master.leader[0] {
-			master.leader[0] = true
-			master.Printf("replica 0 is the new leader")
+		// Always use the first member (replica 0 by default) as the
+		// initial leader. Set it once when all replicas are registered.
+		first := master.firstMember()
+		if !master.leader[first] {
+			master.leader[first] = true
+			master.Printf("replica %d is the new leader", first)
 		}
-		reply.IsLeader = (index == 0)
+		reply.IsLeader = (index == first)
 	} else {
 		reply.Ready = false
 	}
@@ -238,7 +313,51 @@ func (master *Master) Register(args *defs.RegisterArgs, reply *defs.RegisterRepl
 	return nil
 }
 
+// InjectFaults adds faults to the links between replicas and between
+// replicas and clients, or removes them, and installs the resulting faults
+// in every replica. It fails if the replicas are not connected yet, or if
+// some of them could not be updated.
+func (master *Master) InjectFaults(args *defs.FaultArgs, reply *defs.FaultReply) error {
+	if err := master.checkPrimary(); err != nil {
+		return err
+	}
+	master.lock.Lock()
+	if !master.finishInit {
+		master.lock.Unlock()
+		return errors.New("replicas are not connected yet")
+	}
+	partition, err := defs.PartitionFaults(args.Partition, master.N)
+	if err != nil {
+		master.lock.Unlock()
+		return err
+	}
+	if args.Heal {
+		master.faults = nil
+	}
+	master.faults = append(master.faults, partition...)
+	master.faults = append(master.faults, args.Links...)
+	links := append([]defs.LinkFault(nil), master.faults...)
+	nodes := append([]*rpc.Client(nil), master.nodes...)
+	master.lock.Unlock()
+
+	master.Printf("injecting faults %v", links)
+	reply.Links = links
+	var failed []int
+	for i, node := range nodes {
+		if err := node.Call("Replica.SetFaults", &defs.FaultArgs{Links: links}, &defs.FaultReply{}); err != nil {
+			failed = append(failed, i)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("cannot set the faults of replicas %v", failed)
+	}
+	return nil
+}
+
 func (master *Master) GetLeader(args *defs.GetLeaderArgs, reply *defs.GetLeaderReply) error {
+	if err := master.checkPrimary(); err != nil {
+		return err
+	}
 	master.lock.Lock()
 	defer master.lock.Unlock()
 
@@ -254,6 +373,9 @@ func (master *Master) GetLeader(args *defs.GetLeaderArgs, reply *defs.GetLeaderR
 }
 
 func (master *Master) GetReplicaList(args *defs.GetReplicaListArgs, reply *defs.GetReplicaListReply) error {
+	if err := master.checkPrimary(); err != nil {
+		return err
+	}
 	master.lock.Lock()
 
 	for !master.finishInit {
@@ -268,6 +390,16 @@ func (master *Master) GetReplicaList(args *defs.GetReplicaListArgs, reply *defs.
 
 	reply.ReplicaList = make([]string, 0)
 	reply.AliveList = make([]bool, 0)
+	if master.members != nil {
+		reply.MemberList = append([]bool(nil), master.members...)
+	}
+	if master.learners != nil {
+		reply.LearnerList = append([]bool(nil), master.learners...)
+	}
+	if master.witnesses != nil {
+		reply.WitnessList = append([]bool(nil), master.witnesses...)
+	}
+	reply.RPCList = append([]string(nil), master.rpcList...)
 	for i, node := range master.nodeList {
 		reply.ReplicaList = append(reply.ReplicaList, node)
 		reply.AliveList = append(reply.AliveList, master.alive[i])
@@ -2,6 +2,7 @@ package master
 
 import (
 	"testing"
+	"time"
 
 	"github.com/imdea-software/swiftpaxos/dlog"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
@@ -154,3 +155,17 @@ func TestRegister_ReadyOnlyWhenAllRegistered(t *testing.T) {
 		}
 	}
 }
+
+// TestRecordLatency verifies that the round trips of Replica.Ping start
+// and then update the latencies of the replicas, in milliseconds.
+func TestRecordLatency(t *testing.T) {
+	m := newTestMaster(2)
+	m.recordLatency(1, 10*time.Millisecond)
+	if m.latencies[1] != 10 {
+		t.Errorf("latency %v after a first round trip of 10ms", m.latencies[1])
+	}
+	m.recordLatency(1, 20*time.Millisecond)
+	if m.latencies[1] != 12 || m.latencies[0] != 0 {
+		t.Errorf("latencies %v", m.latencies)
+	}
+}
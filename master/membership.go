@@ -0,0 +1,161 @@
+package master
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+)
+
+// SetMembers sets the replicas that vote when the cluster starts (nil: all
+// the replicas). It must be called before Run.
+func (master *Master) SetMembers(members []bool) {
+	master.members = members
+}
+
+// SetLearners sets the replicas that get the log without voting when the
+// cluster starts (nil: none). It must be called before Run.
+func (master *Master) SetLearners(learners []bool) {
+	master.learners = learners
+}
+
+// SetWitnesses sets the replicas that are only witnesses (curp, curp-ht
+// and curp-ho), which are never the leader. It must be called before Run.
+func (master *Master) SetWitnesses(witnesses []bool) {
+	master.witnesses = witnesses
+}
+
+// isMember tells whether replica i may be the leader.
+func (master *Master) isMember(i int) bool {
+	return (master.members == nil || master.members[i]) &&
+		(master.witnesses == nil || !master.witnesses[i])
+}
+
+func (master *Master) firstMember() int {
+	for i := 0; i < master.N; i++ {
+		if master.isMember(i) {
+			return i
+		}
+	}
+	return 0
+}
+
+func (master *Master) leaderId() int {
+	for i, l := range master.leader {
+		if l {
+			return i
+		}
+	}
+	return -1
+}
+
+// ChangeMembership makes learners of the replicas of args.Learn, adds the
+// replicas of args.Add to the members of a raft or raft-ht cluster, then
+// removes the ones of args.Remove, one at a time, through the leader.
+// Adding before removing keeps the number of failures tolerated during the
+// replacement of a replica. Without changes, it returns the members known by
+// the leader.
+func (master *Master) ChangeMembership(args *defs.MembershipArgs, reply *defs.MembershipReply) error {
+	if err := master.checkPrimary(); err != nil {
+		return err
+	}
+	master.confMu.Lock()
+	defer master.confMu.Unlock()
+
+	master.lock.Lock()
+	ready := master.finishInit
+	master.lock.Unlock()
+	if !ready {
+		return errors.New("replicas are not connected yet")
+	}
+
+	var steps []*defs.MembershipArgs
+	for _, id := range args.Learn {
+		steps = append(steps, &defs.MembershipArgs{Learn: []int32{id}})
+	}
+	for _, id := range args.Add {
+		steps = append(steps, &defs.MembershipArgs{Add: []int32{id}})
+	}
+	for _, id := range args.Remove {
+		steps = append(steps, &defs.MembershipArgs{Remove: []int32{id}})
+	}
+	if len(steps) == 0 {
+		steps = append(steps, &defs.MembershipArgs{})
+	}
+	for _, step := range steps {
+		if err := master.changeMembership(step, reply); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changeMembership runs a change on the leader and records the members and
+// the leader after it.
+func (master *Master) changeMembership(args *defs.MembershipArgs, reply *defs.MembershipReply) error {
+	master.lock.Lock()
+	leader := master.leaderId()
+	master.lock.Unlock()
+	if leader < 0 {
+		return errors.New("no leader")
+	}
+	for _, id := range append(append(args.Add, args.Remove...), args.Learn...) {
+		if id < 0 || int(id) >= master.N {
+			return fmt.Errorf("no replica %d", id)
+		}
+	}
+
+	*reply = defs.MembershipReply{}
+	if err := master.nodes[leader].Call("Replica.ChangeMembership", args, reply); err != nil {
+		return fmt.Errorf("replica %d: %v", leader, err)
+	}
+	master.lock.Lock()
+	master.members = make([]bool, master.N)
+	for _, id := range reply.Members {
+		master.members[id] = true
+	}
+	master.learners = make([]bool, master.N)
+	for _, id := range reply.Learners {
+		master.learners[id] = true
+	}
+	if reply.Leader != int32(leader) {
+		master.leader[leader] = false
+	}
+	master.lock.Unlock()
+
+	if reply.Leader < 0 {
+		l, err := master.waitLeader()
+		if err != nil {
+			return err
+		}
+		reply.Leader = int32(l)
+	}
+	master.Printf("members %v, learners %v, leader %d", reply.Members, reply.Learners, reply.Leader)
+	return nil
+}
+
+// waitLeader waits for the members to elect a new leader, once the previous
+// one is removed.
+func (master *Master) waitLeader() (int, error) {
+	for try := 0; try < 50; try++ {
+		time.Sleep(100 * time.Millisecond)
+		for i := 0; i < master.N; i++ {
+			master.lock.Lock()
+			member := master.isMember(i)
+			master.lock.Unlock()
+			if !member {
+				continue
+			}
+			q := &defs.MembershipReply{}
+			if master.nodes[i].Call("Replica.ChangeMembership", &defs.MembershipArgs{}, q) == nil && q.Leader == int32(i) {
+				master.lock.Lock()
+				master.leader[i] = true
+				master.lock.Unlock()
+				master.Printf("replica %d is the new leader", i)
+				return i, nil
+			}
+		}
+	}
+	return -1, errors.New("no leader elected after the removal of the leader")
+}
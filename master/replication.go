@@ -0,0 +1,385 @@
+package master
+
+import (
+	"errors"
+	"net/rpc"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/client"
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/transport"
+)
+
+// Replication of the master
+//
+// Several masters can run for one cluster, listed in the Master section of
+// the config. One of them, the primary, does the work of the master: it
+// registers and monitors the replicas, elects leaders and answers clients.
+// The others, the backups, copy the state of the primary every pollInterval
+// and refuse the RPCs of replicas and clients, which then try the next
+// master.
+//
+// The primary acts only while it holds a lease: every pollInterval, it polls
+// the other masters, which acknowledge it unless they know of a greater
+// epoch, and a majority of acknowledgements, its own included, extends its
+// lease to leaseDuration after the start of the poll. A primary whose lease
+// expires becomes a backup. A backup becomes a candidate once, for
+// failoverPolls polls in a row, no master answers as primary and no master
+// before it in the config answers at all: it asks the masters to vote for it
+// in an epoch greater than the epochs it knows of, and becomes the primary
+// of that epoch with the votes of a majority. A master votes once per epoch,
+// and neither votes nor acknowledges a primary of a lower epoch afterwards;
+// once it has acknowledged a primary or voted, it votes for no other master
+// for leaseDuration. A new primary thus starts once the lease of the former
+// one has expired, and masters cut off from a majority stop acting as
+// primary, at the cost of a master majority being needed to make progress.
+
+const (
+	pollInterval  = 500 * time.Millisecond
+	failoverPolls = 3
+	leaseDuration = failoverPolls * pollInterval
+)
+
+var (
+	// ErrNotPrimary is returned by the RPCs of backup masters
+	ErrNotPrimary = errors.New("not the primary master")
+	errStopped    = errors.New("master stopped")
+)
+
+// State is the state of a master copied by its backups.
+type State struct {
+	Id         int
+	Primary    bool
+	Epoch      int
+	NodeList   []string
+	AddrList   []string
+	PortList   []int
+	RPCList    []string
+	Registered []bool
+	Leader     []bool
+	Alive      []bool
+	Latencies  []float64
+	FinishInit bool
+	NextLeader int
+	Faults     []defs.LinkFault
+	Members    []bool
+	Learners   []bool
+	Reports    map[string]*client.MetricsReport
+	// Granted tells whether the master acknowledged the primary, or voted
+	// for the candidate, that polled it
+	Granted bool
+}
+
+// GetStateArgs are the arguments of Master.GetState: the master that polls,
+// with its epoch if it is the primary, or the epoch it asks votes for if it
+// is a candidate.
+type GetStateArgs struct {
+	Id        int
+	Epoch     int
+	Primary   bool
+	Candidate bool
+}
+
+// SetMasters sets the addresses of all the masters of the cluster and the
+// index of this one among them. With more than one master, the master
+// starts as a backup. It must be called before Run.
+func (master *Master) SetMasters(id int, addrs []string) {
+	master.id = id
+	master.masters = addrs
+	master.primary = len(addrs) <= 1
+}
+
+// IsPrimary tells whether the master is the primary, with a lease.
+func (master *Master) IsPrimary() bool {
+	master.lock.Lock()
+	defer master.lock.Unlock()
+	return master.primary && master.hasLease() && !master.stopped
+}
+
+// leading tells whether the master is still the primary of epoch, with a
+// lease.
+func (master *Master) leading(epoch int) bool {
+	master.lock.Lock()
+	defer master.lock.Unlock()
+	return master.primary && master.hasLease() && !master.stopped && master.epoch == epoch
+}
+
+// hasLease tells whether a majority of the masters acknowledged the master
+// as primary less than leaseDuration ago (lock held). A single master
+// always has one.
+func (master *Master) hasLease() bool {
+	return len(master.masters) <= 1 || time.Now().Before(master.leaseUntil)
+}
+
+// Stop makes the master stop monitoring the replicas and refuse every RPC,
+// as if it had crashed.
+func (master *Master) Stop() {
+	master.lock.Lock()
+	master.stopped = true
+	l := master.listener
+	master.lock.Unlock()
+	if l != nil {
+		l.Close()
+	}
+}
+
+// GetState returns the state of the master, called by the other masters.
+func (master *Master) GetState(args *GetStateArgs, reply *State) error {
+	master.lock.Lock()
+	defer master.lock.Unlock()
+	if master.stopped {
+		return errStopped
+	}
+	granted := master.grant(args)
+	*reply = *master.state()
+	reply.Granted = granted
+	return nil
+}
+
+// grant tells whether the master acknowledges the primary, or votes for
+// the candidate, of args, and then takes its epoch and promises to vote
+// for no other master for leaseDuration (lock held).
+func (master *Master) grant(args *GetStateArgs) bool {
+	now := time.Now()
+	switch {
+	case args.Primary:
+		if args.Epoch < master.epoch || args.Epoch == master.epoch && master.primary {
+			return false
+		}
+	case args.Candidate:
+		if args.Epoch <= master.epoch || now.Before(master.promised) {
+			return false
+		}
+	default:
+		return false
+	}
+	if master.primary && args.Epoch > master.epoch {
+		master.Printf("master %d is the %s of epoch %d, becoming a backup", args.Id, role(args), args.Epoch)
+		master.primary = false
+	}
+	master.epoch = args.Epoch
+	master.promised = now.Add(leaseDuration)
+	return true
+}
+
+// role returns the role of the master that polls with args.
+func role(args *GetStateArgs) string {
+	if args.Primary {
+		return "primary"
+	}
+	return "candidate"
+}
+
+// checkPrimary returns an error unless the master is the primary.
+func (master *Master) checkPrimary() error {
+	master.lock.Lock()
+	defer master.lock.Unlock()
+	if master.stopped {
+		return errStopped
+	}
+	if !master.primary || !master.hasLease() {
+		return ErrNotPrimary
+	}
+	return nil
+}
+
+// state returns a copy of the state of the master (lock held).
+func (master *Master) state() *State {
+	s := &State{
+		Id:         master.id,
+		Primary:    master.primary && master.hasLease(),
+		Epoch:      master.epoch,
+		NodeList:   append([]string(nil), master.nodeList...),
+		AddrList:   append([]string(nil), master.addrList...),
+		PortList:   append([]int(nil), master.portList...),
+		RPCList:    append([]string(nil), master.rpcList...),
+		Registered: append([]bool(nil), master.registered...),
+		Leader:     append([]bool(nil), master.leader...),
+		Alive:      append([]bool(nil), master.alive...),
+		Latencies:  append([]float64(nil), master.latencies...),
+		FinishInit: master.finishInit,
+		NextLeader: master.nextLeader,
+		Faults:     append([]defs.LinkFault(nil), master.faults...),
+		Reports:    make(map[string]*client.MetricsReport, len(master.reports)),
+	}
+	if master.members != nil {
+		s.Members = append([]bool(nil), master.members...)
+	}
+	if master.learners != nil {
+		s.Learners = append([]bool(nil), master.learners...)
+	}
+	for alias, r := range master.reports {
+		s.Reports[alias] = r
+	}
+	return s
+}
+
+// setState makes s the state of the master (lock held).
+func (master *Master) setState(s *State) {
+	// gob drops the slices of zero values
+	if len(s.Registered) != master.N {
+		return
+	}
+	if s.Epoch > master.epoch {
+		master.epoch = s.Epoch
+	}
+	copy(master.nodeList, s.NodeList)
+	copy(master.addrList, s.AddrList)
+	copy(master.portList, s.PortList)
+	copy(master.rpcList, s.RPCList)
+	copy(master.registered, s.Registered)
+	copy(master.leader, s.Leader)
+	copy(master.alive, s.Alive)
+	copy(master.latencies, s.Latencies)
+	master.numRegistered = 0
+	for _, r := range master.registered {
+		if r {
+			master.numRegistered++
+		}
+	}
+	master.nextLeader = s.NextLeader
+	master.faults = s.Faults
+	master.members = s.Members
+	master.learners = s.Learners
+	if s.Reports != nil {
+		master.reports = s.Reports
+	}
+	if s.FinishInit && !master.finishInit {
+		master.finishInit = true
+		master.initCond.Broadcast()
+	}
+}
+
+// after tells whether the primary of state s takes precedence over this
+// master as primary (lock held).
+func (master *Master) after(s *State) bool {
+	if s.Epoch != master.epoch {
+		return s.Epoch > master.epoch
+	}
+	return s.Id < master.id
+}
+
+// replicate polls the other masters to copy the state of the primary, and
+// makes this master the primary, or a backup, when needed.
+func (master *Master) replicate() {
+	peers := make([]*rpc.Client, len(master.masters))
+	majority := len(master.masters)/2 + 1
+	missed := 0
+	epoch := 0 // greatest epoch known
+	for !master.isStopped() {
+		start := time.Now()
+		master.lock.Lock()
+		if epoch < master.epoch {
+			epoch = master.epoch
+		}
+		args := &GetStateArgs{Id: master.id, Epoch: master.epoch, Primary: master.primary}
+		granted := 0
+		if master.primary {
+			granted = 1
+		} else if missed >= failoverPolls || missed > 0 && master.id == 0 && !master.finishInit {
+			// the first master does not wait for others at startup
+			args.Epoch = epoch + 1
+			args.Candidate = true
+			if master.grant(args) {
+				granted = 1
+			} else {
+				args.Candidate = false
+			}
+		}
+		master.lock.Unlock()
+
+		var (
+			primary *State // of greatest epoch, then first in the config
+			before  bool   // a master before this one answered
+		)
+		for i := range master.masters {
+			if i == master.id {
+				continue
+			}
+			s, err := master.poll(peers, i, args)
+			if err != nil {
+				continue
+			}
+			if i < master.id {
+				before = true
+			}
+			if s.Epoch > epoch {
+				epoch = s.Epoch
+			}
+			if s.Granted {
+				granted++
+			}
+			if s.Primary && (primary == nil || s.Epoch > primary.Epoch) {
+				primary = s
+			}
+		}
+
+		master.lock.Lock()
+		switch {
+		case master.primary:
+			switch {
+			case primary != nil && master.after(primary):
+				master.Printf("master %d is the primary (epoch %d), becoming a backup", primary.Id, primary.Epoch)
+				master.primary = false
+				master.setState(primary)
+			case !master.hasLease():
+				master.Printf("master %d lost its lease (epoch %d), becoming a backup", master.id, master.epoch)
+				master.primary = false
+			case granted >= majority:
+				master.leaseUntil = start.Add(leaseDuration)
+				master.promised = master.leaseUntil
+			}
+			missed = 0
+		case args.Candidate && master.epoch == args.Epoch && granted >= majority:
+			master.primary = true
+			master.leaseUntil = start.Add(leaseDuration)
+			master.promised = master.leaseUntil
+			master.Printf("master %d is the primary (epoch %d)", master.id, master.epoch)
+			go master.run()
+			missed = 0
+		case primary != nil:
+			master.setState(primary)
+			missed = 0
+		case before:
+			missed = 0
+		default:
+			missed++
+		}
+		master.lock.Unlock()
+
+		time.Sleep(pollInterval)
+	}
+}
+
+// poll returns the state of master i, connecting to it if needed.
+func (master *Master) poll(peers []*rpc.Client, i int, args *GetStateArgs) (*State, error) {
+	if peers[i] == nil {
+		c, err := transport.DialHTTP(master.transport, master.masters[i], pollInterval)
+		if err != nil {
+			return nil, err
+		}
+		peers[i] = c
+	}
+	s := &State{}
+	call := peers[i].Go("Master.GetState", args, s, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		if call.Error != nil {
+			if call.Error == rpc.ErrShutdown {
+				peers[i] = nil
+			}
+			return nil, call.Error
+		}
+		return s, nil
+	case <-time.After(pollInterval):
+		peers[i].Close()
+		peers[i] = nil
+		return nil, errors.New("timeout")
+	}
+}
+
+func (master *Master) isStopped() bool {
+	master.lock.Lock()
+	defer master.lock.Unlock()
+	return master.stopped
+}
@@ -0,0 +1,189 @@
+package master
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/dlog"
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/transport"
+)
+
+// startMasters runs the masters of a cluster of n replicas, one per host of
+// the in-process network, on ports 7087, 7088...
+func startMasters(t *testing.T, net *transport.Network, n int, hosts ...string) []*Master {
+	var addrs []string
+	for i, h := range hosts {
+		addrs = append(addrs, fmt.Sprintf("%s:%d", h, 7087+i))
+	}
+	var ms []*Master
+	for i, h := range hosts {
+		m := New(n, 7087+i, dlog.New("", false))
+		m.SetTransport(net.Host(h))
+		m.SetMasters(i, addrs)
+		go m.Run()
+		ms = append(ms, m)
+	}
+	t.Cleanup(func() {
+		for _, m := range ms {
+			m.Stop()
+		}
+	})
+	return ms
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for %s", what)
+}
+
+func TestMasterFailover(t *testing.T) {
+	net := transport.NewNetwork()
+	defer net.Close()
+	ms := startMasters(t, net, 1, "10.0.2.1", "10.0.2.2", "10.0.2.3")
+
+	waitFor(t, "master 0 to be the primary", ms[0].IsPrimary)
+	if ms[1].IsPrimary() || ms[2].IsPrimary() {
+		t.Fatal("two primaries")
+	}
+	if err := ms[1].Register(&defs.RegisterArgs{Addr: "10.0.0.1", Port: 7070}, &defs.RegisterReply{}); err != ErrNotPrimary {
+		t.Errorf("backup registered a replica: %v", err)
+	}
+	reply := &defs.RegisterReply{}
+	if err := ms[0].Register(&defs.RegisterArgs{Addr: "10.0.0.1", Port: 7070}, reply); err != nil || !reply.Ready {
+		t.Fatalf("Register: %v, %+v", err, reply)
+	}
+
+	// the backup copies the registration, then takes over
+	waitFor(t, "the backup to copy the state", func() bool {
+		ms[1].lock.Lock()
+		defer ms[1].lock.Unlock()
+		return ms[1].numRegistered == 1
+	})
+	ms[0].Stop()
+	waitFor(t, "master 1 to take over", ms[1].IsPrimary)
+	gl := &defs.GetLeaderReply{LeaderId: -1}
+	if err := ms[1].GetLeader(&defs.GetLeaderArgs{}, gl); err != nil || gl.LeaderId != 0 {
+		t.Errorf("GetLeader after failover: %v, %d", err, gl.LeaderId)
+	}
+}
+
+func TestMasterRestartBecomesBackup(t *testing.T) {
+	net := transport.NewNetwork()
+	defer net.Close()
+	ms := startMasters(t, net, 1, "10.0.2.1", "10.0.2.2", "10.0.2.3")
+	waitFor(t, "master 0 to be the primary", ms[0].IsPrimary)
+
+	// master 0 crashes, master 1 takes over
+	ms[0].Stop()
+	waitFor(t, "master 1 to take over", ms[1].IsPrimary)
+
+	// master 0 restarts as a backup of master 1
+	m := New(1, 7087, dlog.New("", false))
+	m.SetTransport(net.Host("10.0.2.1"))
+	m.SetMasters(0, []string{"10.0.2.1:7087", "10.0.2.2:7088", "10.0.2.3:7089"})
+	go m.Run()
+	defer m.Stop()
+	ms[1].lock.Lock()
+	epoch := ms[1].epoch
+	ms[1].lock.Unlock()
+	waitFor(t, "master 0 to copy the epoch of master 1", func() bool {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		return m.epoch == epoch
+	})
+	time.Sleep(2 * pollInterval)
+	if m.IsPrimary() || !ms[1].IsPrimary() {
+		t.Errorf("restarted master 0 took over from master 1")
+	}
+}
+
+// cutLink delivers the packets at once, but drops those of host while cut.
+type cutLink struct {
+	host string
+	cut  atomic.Bool
+}
+
+func (l *cutLink) Send(p *transport.Packet) {
+	if !p.Close() && l.cut.Load() && strings.Contains(p.Conn, l.host) {
+		return
+	}
+	p.Deliver()
+}
+
+func TestMasterPartition(t *testing.T) {
+	net := transport.NewNetwork()
+	defer net.Close()
+	l := &cutLink{host: "10.0.2.1"}
+	net.SetLink(l)
+	ms := startMasters(t, net, 1, "10.0.2.1", "10.0.2.2", "10.0.2.3")
+	waitFor(t, "master 0 to be the primary", ms[0].IsPrimary)
+
+	// master 0 is cut off from the others: it stops acting as primary
+	// before one of them takes over
+	l.cut.Store(true)
+	waitFor(t, "master 1 to take over", func() bool {
+		primary := ms[1].IsPrimary()
+		if primary && ms[0].IsPrimary() {
+			t.Fatal("two primaries")
+		}
+		return primary
+	})
+	if err := ms[0].Register(&defs.RegisterArgs{Addr: "10.0.0.1", Port: 7070}, &defs.RegisterReply{}); err != ErrNotPrimary {
+		t.Errorf("master without a majority registered a replica: %v", err)
+	}
+
+	// and stays a backup of master 1 once it can reach it
+	l.cut.Store(false)
+	time.Sleep(2 * leaseDuration)
+	if ms[0].IsPrimary() || !ms[1].IsPrimary() {
+		t.Errorf("master 1 lost the primary role after the partition")
+	}
+}
+
+func TestMasterVotes(t *testing.T) {
+	m := New(1, 7087, dlog.New("", false))
+	m.SetMasters(1, []string{"10.0.2.1:7087", "10.0.2.2:7088", "10.0.2.3:7089"})
+
+	// a vote per epoch, and none for lower epochs
+	if !m.grant(&GetStateArgs{Id: 0, Epoch: 1, Candidate: true}) {
+		t.Fatal("no vote for the first candidate")
+	}
+	m.promised = time.Time{}
+	if m.grant(&GetStateArgs{Id: 2, Epoch: 1, Candidate: true}) {
+		t.Error("two votes in epoch 1")
+	}
+	if m.grant(&GetStateArgs{Id: 2, Epoch: 0, Primary: true}) {
+		t.Error("primary of a lower epoch acknowledged")
+	}
+
+	// no vote while the lease of an acknowledged primary may run
+	if !m.grant(&GetStateArgs{Id: 0, Epoch: 1, Primary: true}) {
+		t.Fatal("primary of epoch 1 not acknowledged")
+	}
+	if m.grant(&GetStateArgs{Id: 2, Epoch: 2, Candidate: true}) {
+		t.Error("vote during the lease of the primary")
+	}
+	m.promised = time.Now().Add(-time.Millisecond)
+	if !m.grant(&GetStateArgs{Id: 2, Epoch: 2, Candidate: true}) {
+		t.Error("no vote after the lease of the primary")
+	}
+
+	// a primary answers only with a lease
+	m.primary = true
+	if err := m.checkPrimary(); err != ErrNotPrimary {
+		t.Errorf("primary without a lease answers: %v", err)
+	}
+	m.leaseUntil = time.Now().Add(leaseDuration)
+	if err := m.checkPrimary(); err != nil {
+		t.Errorf("primary with a lease: %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package master
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/client"
+)
+
+// ClusterReport is the cluster-wide benchmark result merged from the
+// metrics reports of all client processes.
+type ClusterReport struct {
+	Protocol string `json:"protocol"`
+	// Number of clients that reported, and how many of them finished
+	Clients  int `json:"clients"`
+	Finished int `json:"finished"`
+	Expected int `json:"expected"`
+	// Longest client run duration, in seconds
+	Duration float64 `json:"duration_sec"`
+
+	Total       LevelReport `json:"total"`
+	Strong      LevelReport `json:"strong"`
+	Weak        LevelReport `json:"weak"`
+	StrongWrite LevelReport `json:"strong_write"`
+	StrongRead  LevelReport `json:"strong_read"`
+	WeakWrite   LevelReport `json:"weak_write"`
+	WeakRead    LevelReport `json:"weak_read"`
+
+	WriteBytes int64 `json:"write_bytes"`
+	ReadBytes  int64 `json:"read_bytes"`
+
+	PerClient []ClientReport `json:"per_client"`
+}
+
+// LevelReport holds the throughput and merged latency percentiles of one
+// consistency level or command type.
+type LevelReport struct {
+	// Sum of the per-client throughputs, in ops/sec
+	Throughput float64 `json:"throughput"`
+	client.LatencySummary
+}
+
+// ClientReport is the per-client part of a ClusterReport.
+type ClientReport struct {
+	Alias      string  `json:"alias"`
+	Threads    int     `json:"threads"`
+	Final      bool    `json:"final"`
+	Ops        int     `json:"ops"`
+	Duration   float64 `json:"duration_sec"`
+	Throughput float64 `json:"throughput"`
+}
+
+// ReportMetrics is called by clients to report their metrics. A newer report
+// of a client replaces its previous one; once every expected client has sent
+// its final report, the merged cluster report is written to the report path.
+func (master *Master) ReportMetrics(args *client.MetricsReport, reply *client.MetricsReportReply) error {
+	if err := master.checkPrimary(); err != nil {
+		return err
+	}
+	if args.Metrics == nil {
+		return fmt.Errorf("report from %s carries no metrics", args.Alias)
+	}
+
+	master.lock.Lock()
+	defer master.lock.Unlock()
+
+	if prev, exists := master.reports[args.Alias]; !exists || !prev.Final || args.Final {
+		// a late periodic report does not replace the final one
+		master.reports[args.Alias] = args
+	}
+
+	done := 0
+	for _, r := range master.reports {
+		if r.Final {
+			done++
+		}
+	}
+	reply.Done = done
+	reply.Expected = master.numClients
+
+	if args.Final && master.reports[args.Alias] == args {
+		master.Printf("final metrics of client %s received (%d/%d)", args.Alias, done, master.numClients)
+		if done == master.numClients && master.reportPath != "" {
+			if err := master.writeReport(master.reportPath); err != nil {
+				master.Printf("cannot write cluster report: %v", err)
+			} else {
+				master.Printf("cluster report written to %s.json and %s.csv", master.reportPath, master.reportPath)
+				master.Printf("cluster: %v", master.clusterReport())
+			}
+		}
+	}
+	return nil
+}
+
+// GetReportArgs are the arguments of Master.GetReport.
+type GetReportArgs struct{}
+
+// GetReport returns the cluster report merged from the reports received so far.
+func (master *Master) GetReport(args *GetReportArgs, reply *ClusterReport) error {
+	if err := master.checkPrimary(); err != nil {
+		return err
+	}
+	master.lock.Lock()
+	defer master.lock.Unlock()
+	*reply = *master.clusterReport()
+	return nil
+}
+
+// SetReport configures the number of clients expected to report their
+// metrics and the path prefix of the cluster report files ("" disables them).
+func (master *Master) SetReport(numClients int, path string) {
+	master.lock.Lock()
+	defer master.lock.Unlock()
+	master.numClients = numClients
+	master.reportPath = path
+}
+
+// clusterReport merges the reports received so far. Called with master.lock held.
+func (master *Master) clusterReport() *ClusterReport {
+	reports := make([]*client.MetricsReport, 0, len(master.reports))
+	for _, r := range master.reports {
+		reports = append(reports, r)
+	}
+	cr := MergeReports(reports)
+	cr.Expected = master.numClients
+	return cr
+}
+
+// MergeReports builds the cluster report of the given client reports.
+// Latency histograms are merged, so percentiles are exact cluster-wide
+// percentiles (within histogram precision), not averages of per-client ones.
+func MergeReports(reports []*client.MetricsReport) *ClusterReport {
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Alias < reports[j].Alias
+	})
+
+	cr := &ClusterReport{
+		Clients:   len(reports),
+		PerClient: []ClientReport{},
+	}
+	merged := client.NewHybridMetrics()
+	var tput [4]float64 // StrongWrite, StrongRead, WeakWrite, WeakRead
+	for _, r := range reports {
+		m := r.Metrics
+		if cr.Protocol == "" {
+			cr.Protocol = r.Protocol
+		}
+		if r.Final {
+			cr.Finished++
+		}
+		merged.StrongWriteCount += m.StrongWriteCount
+		merged.StrongReadCount += m.StrongReadCount
+		merged.WeakWriteCount += m.WeakWriteCount
+		merged.WeakReadCount += m.WeakReadCount
+		merged.WriteBytes += m.WriteBytes
+		merged.ReadBytes += m.ReadBytes
+		for t := client.StrongWrite; t <= client.WeakRead; t++ {
+			merged.Latency(t).Merge(m.Latency(t))
+		}
+
+		secs := r.Duration.Seconds()
+		ops := m.StrongWriteCount + m.StrongReadCount + m.WeakWriteCount + m.WeakReadCount
+		cl := ClientReport{
+			Alias:    r.Alias,
+			Threads:  r.Threads,
+			Final:    r.Final,
+			Ops:      ops,
+			Duration: secs,
+		}
+		if secs > 0 {
+			cl.Throughput = float64(ops) / secs
+			tput[client.StrongWrite] += float64(m.StrongWriteCount) / secs
+			tput[client.StrongRead] += float64(m.StrongReadCount) / secs
+			tput[client.WeakWrite] += float64(m.WeakWriteCount) / secs
+			tput[client.WeakRead] += float64(m.WeakReadCount) / secs
+		}
+		if secs > cr.Duration {
+			cr.Duration = secs
+		}
+		cr.PerClient = append(cr.PerClient, cl)
+	}
+
+	level := func(tput float64, hs ...*client.Histogram) LevelReport {
+		h := client.NewHistogram(client.DefaultSigFigs)
+		for _, o := range hs {
+			h.Merge(o)
+		}
+		return LevelReport{Throughput: tput, LatencySummary: h.Summary()}
+	}
+	sw, sr := merged.StrongWriteLatency, merged.StrongReadLatency
+	ww, wr := merged.WeakWriteLatency, merged.WeakReadLatency
+	cr.StrongWrite = level(tput[client.StrongWrite], sw)
+	cr.StrongRead = level(tput[client.StrongRead], sr)
+	cr.WeakWrite = level(tput[client.WeakWrite], ww)
+	cr.WeakRead = level(tput[client.WeakRead], wr)
+	cr.Strong = level(tput[client.StrongWrite]+tput[client.StrongRead], sw, sr)
+	cr.Weak = level(tput[client.WeakWrite]+tput[client.WeakRead], ww, wr)
+	cr.Total = level(cr.Strong.Throughput+cr.Weak.Throughput, sw, sr, ww, wr)
+	cr.WriteBytes = merged.WriteBytes
+	cr.ReadBytes = merged.ReadBytes
+	return cr
+}
+
+// levels returns the named level reports in CSV row order.
+func (cr *ClusterReport) levels() []struct {
+	name string
+	*LevelReport
+} {
+	return []struct {
+		name string
+		*LevelReport
+	}{
+		{"total", &cr.Total},
+		{"strong", &cr.Strong},
+		{"weak", &cr.Weak},
+		{"strong_write", &cr.StrongWrite},
+		{"strong_read", &cr.StrongRead},
+		{"weak_write", &cr.WeakWrite},
+		{"weak_read", &cr.WeakRead},
+	}
+}
+
+// WriteJSON writes the report as indented JSON.
+func (cr *ClusterReport) WriteJSON(path string) error {
+	b, err := json.MarshalIndent(cr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// WriteCSV writes one row per consistency level and command type.
+func (cr *ClusterReport) WriteCSV(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"protocol", "level", "ops", "throughput",
+		"mean", "min", "p50", "p90", "p99", "p999", "max"})
+	ms := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for _, l := range cr.levels() {
+		w.Write([]string{
+			cr.Protocol,
+			l.name,
+			strconv.FormatInt(l.Count, 10),
+			strconv.FormatFloat(l.Throughput, 'f', 2, 64),
+			ms(l.Mean), ms(l.Min), ms(l.P50), ms(l.P90), ms(l.P99), ms(l.P999), ms(l.Max),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeReport writes <path>.json and <path>.csv. Called with master.lock held.
+func (master *Master) writeReport(path string) error {
+	cr := master.clusterReport()
+	if err := cr.WriteJSON(path + ".json"); err != nil {
+		return err
+	}
+	return cr.WriteCSV(path + ".csv")
+}
+
+// String summarizes the report in the format of the client metrics output.
+func (cr *ClusterReport) String() string {
+	return fmt.Sprintf("%d clients, %v: %.2f ops/sec (strong: %.2f, weak: %.2f), p50 %.2fms, p99 %.2fms",
+		cr.Clients, time.Duration(cr.Duration*float64(time.Second)).Round(time.Millisecond),
+		cr.Total.Throughput, cr.Strong.Throughput, cr.Weak.Throughput, cr.Total.P50, cr.Total.P99)
+}
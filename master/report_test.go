@@ -0,0 +1,161 @@
+package master
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/client"
+)
+
+func testReport(alias string, final bool, duration time.Duration, strongMs, weakMs []float64) *client.MetricsReport {
+	m := client.NewHybridMetrics()
+	for _, l := range strongMs {
+		m.StrongWriteCount++
+		m.StrongWriteLatency.RecordMs(l)
+	}
+	for _, l := range weakMs {
+		m.WeakReadCount++
+		m.WeakReadLatency.RecordMs(l)
+	}
+	return &client.MetricsReport{
+		Alias:    alias,
+		Protocol: "curpht",
+		Threads:  2,
+		Final:    final,
+		Duration: duration,
+		Metrics:  m,
+	}
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) <= math.Max(math.Abs(b)*0.002, 1e-9)
+}
+
+// TestMergeReports verifies that throughput is summed and latency
+// percentiles are computed over the merged histograms.
+func TestMergeReports(t *testing.T) {
+	a := testReport("client1", true, 2*time.Second, []float64{1, 2, 3, 4}, []float64{0.5, 0.5})
+	b := testReport("client0", true, time.Second, []float64{10, 20, 30, 40}, nil)
+
+	cr := MergeReports([]*client.MetricsReport{a, b})
+
+	if cr.Clients != 2 || cr.Finished != 2 || cr.Protocol != "curpht" {
+		t.Errorf("clients/finished/protocol = %d/%d/%q", cr.Clients, cr.Finished, cr.Protocol)
+	}
+	if cr.Duration != 2 {
+		t.Errorf("Duration = %v, want 2", cr.Duration)
+	}
+	// client1: 4 strong / 2s = 2 ops/s, client0: 4 strong / 1s = 4 ops/s
+	if cr.Strong.Throughput != 6 || cr.Weak.Throughput != 1 || cr.Total.Throughput != 7 {
+		t.Errorf("throughput strong/weak/total = %v/%v/%v, want 6/1/7",
+			cr.Strong.Throughput, cr.Weak.Throughput, cr.Total.Throughput)
+	}
+	if cr.Strong.Count != 8 || cr.Weak.Count != 2 || cr.Total.Count != 10 {
+		t.Errorf("counts strong/weak/total = %d/%d/%d", cr.Strong.Count, cr.Weak.Count, cr.Total.Count)
+	}
+	// Merged strong latencies: 1 2 3 4 10 20 30 40
+	if !approx(cr.Strong.P50, 4) || !approx(cr.Strong.Max, 40) || !approx(cr.Strong.Min, 1) {
+		t.Errorf("strong p50/min/max = %v/%v/%v, want 4/1/40", cr.Strong.P50, cr.Strong.Min, cr.Strong.Max)
+	}
+	if !approx(cr.StrongWrite.P99, 40) {
+		t.Errorf("strong write p99 = %v, want 40", cr.StrongWrite.P99)
+	}
+	if !approx(cr.WeakRead.Mean, 0.5) {
+		t.Errorf("weak read mean = %v, want 0.5", cr.WeakRead.Mean)
+	}
+
+	// Per-client entries are sorted by alias
+	if len(cr.PerClient) != 2 || cr.PerClient[0].Alias != "client0" || cr.PerClient[1].Throughput != 3 {
+		t.Errorf("per client = %+v", cr.PerClient)
+	}
+}
+
+// TestReportMetrics_WritesReportWhenAllFinal verifies that the report files
+// are written once every expected client has sent its final report.
+func TestReportMetrics_WritesReportWhenAllFinal(t *testing.T) {
+	m := newTestMaster(3)
+	path := filepath.Join(t.TempDir(), "cluster")
+	m.SetReport(2, path)
+
+	reply := &client.MetricsReportReply{}
+	if err := m.ReportMetrics(testReport("client0", false, time.Second, []float64{1}, nil), reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Done != 0 || reply.Expected != 2 {
+		t.Errorf("reply = %+v, want 0/2", reply)
+	}
+	if err := m.ReportMetrics(testReport("client0", true, time.Second, []float64{1, 2}, nil), reply); err != nil {
+		t.Fatal(err)
+	}
+	// A late periodic report must not replace the final one
+	if err := m.ReportMetrics(testReport("client0", false, time.Second, []float64{1}, nil), reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Done != 1 {
+		t.Errorf("Done = %d, want 1", reply.Done)
+	}
+	if _, err := os.Stat(path + ".json"); err == nil {
+		t.Fatal("report written before all clients finished")
+	}
+
+	if err := m.ReportMetrics(testReport("client1", true, time.Second, []float64{3}, nil), reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Done != 2 {
+		t.Errorf("Done = %d, want 2", reply.Done)
+	}
+
+	b, err := os.ReadFile(path + ".json")
+	if err != nil {
+		t.Fatalf("JSON report not written: %v", err)
+	}
+	var cr ClusterReport
+	if err := json.Unmarshal(b, &cr); err != nil {
+		t.Fatal(err)
+	}
+	if cr.Total.Count != 3 || cr.Finished != 2 || cr.Expected != 2 {
+		t.Errorf("report count/finished/expected = %d/%d/%d, want 3/2/2", cr.Total.Count, cr.Finished, cr.Expected)
+	}
+
+	f, err := os.Open(path + ".csv")
+	if err != nil {
+		t.Fatalf("CSV report not written: %v", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 8 || rows[0][1] != "level" || rows[1][1] != "total" || rows[1][2] != "3" {
+		t.Errorf("unexpected CSV rows: %v", rows)
+	}
+}
+
+// TestReportMetrics_RejectsEmpty verifies that reports without metrics are rejected.
+func TestReportMetrics_RejectsEmpty(t *testing.T) {
+	m := newTestMaster(3)
+	err := m.ReportMetrics(&client.MetricsReport{Alias: "client0"}, &client.MetricsReportReply{})
+	if err == nil {
+		t.Error("expected error for report without metrics")
+	}
+}
+
+// TestGetReport verifies that partial results can be fetched at any time.
+func TestGetReport(t *testing.T) {
+	m := newTestMaster(3)
+	m.SetReport(2, "")
+	m.ReportMetrics(testReport("client0", false, time.Second, []float64{1, 2}, nil), &client.MetricsReportReply{})
+
+	var cr ClusterReport
+	if err := m.GetReport(&GetReportArgs{}, &cr); err != nil {
+		t.Fatal(err)
+	}
+	if cr.Clients != 1 || cr.Finished != 0 || cr.Total.Count != 2 || cr.Total.Throughput != 2 {
+		t.Errorf("partial report = %+v", cr)
+	}
+}
@@ -0,0 +1,70 @@
+package master
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+)
+
+// TransferLeader makes args.Target the leader, for rolling maintenance or to
+// place the leader in a given region. The master asks the leader to
+// transfer its leadership: raft, raft-ht and curp-ht leaders have the target
+// run an election at once (TimeoutNow); paxos and swift leaders let the
+// leadership go, and the master then asks the target to take over with a
+// higher ballot.
+func (master *Master) TransferLeader(args *defs.TransferLeaderArgs, reply *defs.TransferLeaderReply) error {
+	if err := master.checkPrimary(); err != nil {
+		return err
+	}
+	// no transfer during a membership change
+	master.confMu.Lock()
+	defer master.confMu.Unlock()
+
+	target := int(args.Target)
+	if target < 0 || target >= master.N {
+		return fmt.Errorf("no replica %d", target)
+	}
+	master.lock.Lock()
+	ready := master.finishInit
+	leader := master.leaderId()
+	alive := master.alive[target]
+	master.lock.Unlock()
+	if !ready {
+		return errors.New("replicas are not connected yet")
+	}
+	if leader < 0 {
+		return errors.New("no leader")
+	}
+	if target == leader {
+		reply.Leader = args.Target
+		return nil
+	}
+	if !alive {
+		return fmt.Errorf("replica %d is not alive", target)
+	}
+
+	*reply = defs.TransferLeaderReply{}
+	if err := master.nodes[leader].Call("Replica.TransferLeader", args, reply); err != nil {
+		return fmt.Errorf("replica %d: %v", leader, err)
+	}
+	if reply.Leader == -1 {
+		*reply = defs.TransferLeaderReply{}
+		if err := master.nodes[target].Call("Replica.TransferLeader", args, reply); err != nil {
+			// the former leader takes its leadership back
+			back := &defs.TransferLeaderArgs{Target: int32(leader)}
+			master.nodes[leader].Call("Replica.TransferLeader", back, &defs.TransferLeaderReply{})
+			return fmt.Errorf("replica %d: %v", target, err)
+		}
+	}
+	if reply.Leader < 0 || int(reply.Leader) >= master.N {
+		return fmt.Errorf("replica %d: invalid leader %d", leader, reply.Leader)
+	}
+
+	master.lock.Lock()
+	master.leader[leader] = false
+	master.leader[reply.Leader] = true
+	master.lock.Unlock()
+	master.Printf("replica %d is the new leader (transferred from %d)", reply.Leader, leader)
+	return nil
+}
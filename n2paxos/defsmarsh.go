@@ -0,0 +1,494 @@
+// Code generated by marshalgen; DO NOT EDIT.
+
+package n2paxos
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+func (t *CommandId) New() fastrpc.Serializable {
+	return new(CommandId)
+}
+
+func (t *CommandId) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type CommandIdCache struct {
+	mu    sync.Mutex
+	cache []*CommandId
+}
+
+func NewCommandIdCache() *CommandIdCache {
+	c := &CommandIdCache{}
+	c.cache = make([]*CommandId, 0)
+	return c
+}
+
+func (p *CommandIdCache) Get() *CommandId {
+	var t *CommandId
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &CommandId{}
+	}
+	return t
+}
+
+func (p *CommandIdCache) Put(t *CommandId) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *CommandId) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.SeqNum))
+	wire.Write(b[:8])
+}
+
+func (t *CommandId) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *M2A) New() fastrpc.Serializable {
+	return new(M2A)
+}
+
+func (t *M2A) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type M2ACache struct {
+	mu    sync.Mutex
+	cache []*M2A
+}
+
+func NewM2ACache() *M2ACache {
+	c := &M2ACache{}
+	c.cache = make([]*M2A, 0)
+	return c
+}
+
+func (p *M2ACache) Get() *M2A {
+	var t *M2A
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &M2A{}
+	}
+	return t
+}
+
+func (p *M2ACache) Put(t *M2A) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *M2A) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+	t.Cmd.Marshal(wire)
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.CmdId.SeqNum))
+	binary.LittleEndian.PutUint64(b[8:], uint64(t.CmdSlot))
+	wire.Write(b[:16])
+}
+
+func (t *M2A) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	if err := t.Cmd.Unmarshal(wire); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (t *M2B) New() fastrpc.Serializable {
+	return new(M2B)
+}
+
+func (t *M2B) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type M2BCache struct {
+	mu    sync.Mutex
+	cache []*M2B
+}
+
+func NewM2BCache() *M2BCache {
+	c := &M2BCache{}
+	c.cache = make([]*M2B, 0)
+	return c
+}
+
+func (p *M2BCache) Get() *M2B {
+	var t *M2B
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &M2B{}
+	}
+	return t
+}
+
+func (p *M2BCache) Put(t *M2B) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *M2B) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint64(b[8:], uint64(t.CmdSlot))
+	wire.Write(b[:16])
+}
+
+func (t *M2B) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (t *M2s) New() fastrpc.Serializable {
+	return new(M2s)
+}
+
+func (t *M2s) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type M2sCache struct {
+	mu    sync.Mutex
+	cache []*M2s
+}
+
+func NewM2sCache() *M2sCache {
+	c := &M2sCache{}
+	c.cache = make([]*M2s, 0)
+	return c
+}
+
+func (p *M2sCache) Get() *M2s {
+	var t *M2s
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &M2s{}
+	}
+	return t
+}
+
+func (p *M2sCache) Put(t *M2s) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *M2s) Marshal(wire io.Writer) {
+	var b [16]byte
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.TwoAs)))])
+	for i := range t.TwoAs {
+		t.TwoAs[i].Marshal(wire)
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.TwoBs)))])
+	for i := range t.TwoBs {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.TwoBs[i].Replica))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.TwoBs[i].Ballot))
+		binary.LittleEndian.PutUint64(b[8:], uint64(t.TwoBs[i].CmdSlot))
+		wire.Write(b[:16])
+	}
+}
+
+func (t *M2s) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.TwoAs = make([]M2A, n1)
+	for i := range t.TwoAs {
+		if err := t.TwoAs[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.TwoBs = make([]M2B, n2)
+	for i := range t.TwoBs {
+		if _, err := io.ReadFull(wire, b[:16]); err != nil {
+			return err
+		}
+		t.TwoBs[i].Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.TwoBs[i].Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+		t.TwoBs[i].CmdSlot = int(binary.LittleEndian.Uint64(b[8:]))
+	}
+	return nil
+}
+
+func (t *M1A) New() fastrpc.Serializable {
+	return new(M1A)
+}
+
+func (t *M1A) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type M1ACache struct {
+	mu    sync.Mutex
+	cache []*M1A
+}
+
+func NewM1ACache() *M1ACache {
+	c := &M1ACache{}
+	c.cache = make([]*M1A, 0)
+	return c
+}
+
+func (p *M1ACache) Get() *M1A {
+	var t *M1A
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &M1A{}
+	}
+	return t
+}
+
+func (p *M1ACache) Put(t *M1A) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *M1A) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+}
+
+func (t *M1A) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *M1B) New() fastrpc.Serializable {
+	return new(M1B)
+}
+
+func (t *M1B) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type M1BCache struct {
+	mu    sync.Mutex
+	cache []*M1B
+}
+
+func NewM1BCache() *M1BCache {
+	c := &M1BCache{}
+	c.cache = make([]*M1B, 0)
+	return c
+}
+
+func (p *M1BCache) Get() *M1B {
+	var t *M1B
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &M1B{}
+	}
+	return t
+}
+
+func (p *M1BCache) Put(t *M1B) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *M1B) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CBallot))
+	wire.Write(b[:12])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Cmds)))])
+	for i := range t.Cmds {
+		t.Cmds[i].Marshal(wire)
+	}
+}
+
+func (t *M1B) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CBallot = int32(binary.LittleEndian.Uint32(b[8:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Cmds = make([]state.Command, n1)
+	for i := range t.Cmds {
+		if err := t.Cmds[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *MPaxosSync) New() fastrpc.Serializable {
+	return new(MPaxosSync)
+}
+
+func (t *MPaxosSync) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MPaxosSyncCache struct {
+	mu    sync.Mutex
+	cache []*MPaxosSync
+}
+
+func NewMPaxosSyncCache() *MPaxosSyncCache {
+	c := &MPaxosSyncCache{}
+	c.cache = make([]*MPaxosSync, 0)
+	return c
+}
+
+func (p *MPaxosSyncCache) Get() *MPaxosSync {
+	var t *MPaxosSync
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MPaxosSync{}
+	}
+	return t
+}
+
+func (p *MPaxosSyncCache) Put(t *MPaxosSync) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MPaxosSync) Marshal(wire io.Writer) {
+	var b [10]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Cmds)))])
+	for i := range t.Cmds {
+		t.Cmds[i].Marshal(wire)
+	}
+}
+
+func (t *MPaxosSync) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Cmds = make([]state.Command, n1)
+	for i := range t.Cmds {
+		if err := t.Cmds[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type byteReader interface {
+	io.Reader
+	ReadByte() (c byte, err error)
+}
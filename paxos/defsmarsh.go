@@ -0,0 +1,424 @@
+// Code generated by marshalgen; DO NOT EDIT.
+
+package paxos
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+func (t *Prepare) New() fastrpc.Serializable {
+	return new(Prepare)
+}
+
+func (t *Prepare) BinarySize() (nbytes int, sizeKnown bool) {
+	return 12, true
+}
+
+type PrepareCache struct {
+	mu    sync.Mutex
+	cache []*Prepare
+}
+
+func NewPrepareCache() *PrepareCache {
+	c := &PrepareCache{}
+	c.cache = make([]*Prepare, 0)
+	return c
+}
+
+func (p *PrepareCache) Get() *Prepare {
+	var t *Prepare
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Prepare{}
+	}
+	return t
+}
+
+func (p *PrepareCache) Put(t *Prepare) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Prepare) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Ballot))
+	wire.Write(b[:12])
+}
+
+func (t *Prepare) Unmarshal(wire io.Reader) error {
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[8:]))
+	return nil
+}
+
+func (t *PrepareReply) New() fastrpc.Serializable {
+	return new(PrepareReply)
+}
+
+func (t *PrepareReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type PrepareReplyCache struct {
+	mu    sync.Mutex
+	cache []*PrepareReply
+}
+
+func NewPrepareReplyCache() *PrepareReplyCache {
+	c := &PrepareReplyCache{}
+	c.cache = make([]*PrepareReply, 0)
+	return c
+}
+
+func (p *PrepareReplyCache) Get() *PrepareReply {
+	var t *PrepareReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &PrepareReply{}
+	}
+	return t
+}
+
+func (p *PrepareReplyCache) Put(t *PrepareReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *PrepareReply) Marshal(wire io.Writer) {
+	var b [20]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.VBallot))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.DefaultBallot))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.AcceptorId))
+	wire.Write(b[:20])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
+		t.Command[i].Marshal(wire)
+	}
+}
+
+func (t *PrepareReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [20]byte
+	if _, err := io.ReadFull(wire, b[:20]); err != nil {
+		return err
+	}
+	t.Instance = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.VBallot = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.DefaultBallot = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.AcceptorId = int32(binary.LittleEndian.Uint32(b[16:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Accept) New() fastrpc.Serializable {
+	return new(Accept)
+}
+
+func (t *Accept) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type AcceptCache struct {
+	mu    sync.Mutex
+	cache []*Accept
+}
+
+func NewAcceptCache() *AcceptCache {
+	c := &AcceptCache{}
+	c.cache = make([]*Accept, 0)
+	return c
+}
+
+func (p *AcceptCache) Get() *Accept {
+	var t *Accept
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Accept{}
+	}
+	return t
+}
+
+func (p *AcceptCache) Put(t *Accept) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Accept) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Ballot))
+	wire.Write(b[:12])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
+		t.Command[i].Marshal(wire)
+	}
+}
+
+func (t *Accept) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[8:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *AcceptReply) New() fastrpc.Serializable {
+	return new(AcceptReply)
+}
+
+func (t *AcceptReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type AcceptReplyCache struct {
+	mu    sync.Mutex
+	cache []*AcceptReply
+}
+
+func NewAcceptReplyCache() *AcceptReplyCache {
+	c := &AcceptReplyCache{}
+	c.cache = make([]*AcceptReply, 0)
+	return c
+}
+
+func (p *AcceptReplyCache) Get() *AcceptReply {
+	var t *AcceptReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &AcceptReply{}
+	}
+	return t
+}
+
+func (p *AcceptReplyCache) Put(t *AcceptReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *AcceptReply) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+}
+
+func (t *AcceptReply) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Instance = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *Commit) New() fastrpc.Serializable {
+	return new(Commit)
+}
+
+func (t *Commit) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type CommitCache struct {
+	mu    sync.Mutex
+	cache []*Commit
+}
+
+func NewCommitCache() *CommitCache {
+	c := &CommitCache{}
+	c.cache = make([]*Commit, 0)
+	return c
+}
+
+func (p *CommitCache) Get() *Commit {
+	var t *Commit
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Commit{}
+	}
+	return t
+}
+
+func (p *CommitCache) Put(t *Commit) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Commit) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Ballot))
+	wire.Write(b[:12])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Command)))])
+	for i := range t.Command {
+		t.Command[i].Marshal(wire)
+	}
+}
+
+func (t *Commit) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[8:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Command = make([]state.Command, n1)
+	for i := range t.Command {
+		if err := t.Command[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *CommitShort) New() fastrpc.Serializable {
+	return new(CommitShort)
+}
+
+func (t *CommitShort) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type CommitShortCache struct {
+	mu    sync.Mutex
+	cache []*CommitShort
+}
+
+func NewCommitShortCache() *CommitShortCache {
+	c := &CommitShortCache{}
+	c.cache = make([]*CommitShort, 0)
+	return c
+}
+
+func (p *CommitShortCache) Get() *CommitShort {
+	var t *CommitShort
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &CommitShort{}
+	}
+	return t
+}
+
+func (p *CommitShortCache) Put(t *CommitShort) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *CommitShort) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Instance))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Count))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.Ballot))
+	wire.Write(b[:16])
+}
+
+func (t *CommitShort) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Instance = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Count = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[12:]))
+	return nil
+}
+
+type byteReader interface {
+	io.Reader
+	ReadByte() (c byte, err error)
+}
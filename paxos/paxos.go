@@ -2,6 +2,7 @@ package paxos
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"math"
 	"time"
@@ -30,12 +31,12 @@ type Replica struct {
 	prepareReplyChan      chan fastrpc.Serializable
 	acceptReplyChan       chan fastrpc.Serializable
 	instancesToRecover    chan int32
-	prepareRPC            uint8
-	acceptRPC             uint8
-	commitRPC             uint8
-	commitShortRPC        uint8
-	prepareReplyRPC       uint8
-	acceptReplyRPC        uint8
+	prepareRPC            fastrpc.Code
+	acceptRPC             fastrpc.Code
+	commitRPC             fastrpc.Code
+	commitShortRPC        fastrpc.Code
+	prepareReplyRPC       fastrpc.Code
+	acceptReplyRPC        fastrpc.Code
 	IsLeader              bool
 	instanceSpace         []*Instance
 	crtInstance           int32
@@ -165,6 +166,43 @@ func (r *Replica) BeTheLeader(args *defs.BeTheLeaderArgs, reply *defs.BeTheLeade
 	return nil
 }
 
+// Status is called via RPC by the admin participant. The term is the
+// highest ballot known and the applied position the last executed
+// instance.
+func (r *Replica) Status(args *defs.StatusArgs, reply *defs.StatusReply) error {
+	r.Replica.Status(args, reply)
+	reply.Role = "follower"
+	if r.IsLeader {
+		reply.Role = "leader"
+	}
+	reply.Term = r.maxRecvBallot
+	if b := r.defaultBallot[r.Id]; b > reply.Term {
+		reply.Term = b
+	}
+	reply.Log = r.crtInstance
+	reply.Applied = r.executedUpTo
+	return nil
+}
+
+// TransferLeader is called by the master via RPC to make args.Target the
+// leader. The leader stops leading and returns -1, then the master asks the
+// target to take over, which it does with a ballot higher than the ones it
+// has seen.
+func (r *Replica) TransferLeader(args *defs.TransferLeaderArgs, reply *defs.TransferLeaderReply) error {
+	if args.Target == r.Id {
+		r.BeTheLeader(nil, nil)
+		reply.Leader = r.Id
+		return nil
+	}
+	if !r.IsLeader {
+		return fmt.Errorf("replica %d is not the leader", r.Id)
+	}
+	r.IsLeader = false
+	r.Println("Handing the leadership over to", args.Target)
+	reply.Leader = -1
+	return nil
+}
+
 func (r *Replica) replyPrepare(replicaId int32, reply *PrepareReply) {
 	r.SendMsg(replicaId, r.prepareReplyRPC, reply)
 }
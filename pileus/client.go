@@ -57,7 +57,7 @@ func NewClient(b *client.BufferClient) *Client {
 		deadReplicas:      make(map[int32]bool),
 	}
 
-	t := fastrpc.NewTableId(defs.RPC_TABLE)
+	t := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	raftht.InitClientCs(&c.cs, t)
 	c.RegisterRPCTable(t)
 
@@ -74,7 +74,7 @@ func NewClient(b *client.BufferClient) *Client {
 		writeCache:        make(map[int64]cacheEntry),
 	}
 
-	t := fastrpc.NewTableId(defs.RPC_TABLE)
+	t := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	raftht.InitClientCs(&c.cs, t)
 	c.RegisterRPCTable(t)
 
@@ -75,7 +75,7 @@ func NewClient(b *client.BufferClient) *Client {
 	// Register ALL message types (strong + weak) with a single RPC table.
 	// This ensures a single reader goroutine per replica connection,
 	// avoiding the data race from using both WaitReplies and RegisterRPCTable.
-	t := fastrpc.NewTableId(defs.RPC_TABLE)
+	t := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	InitClientCs(&c.cs, t)
 	c.RegisterRPCTable(t)
 
@@ -316,8 +316,7 @@ func (c *Client) resendPropose(rid int32, cmd *defs.Propose) {
 	if w == nil {
 		return
 	}
-	w.WriteByte(defs.PROPOSE)
-	cmd.Marshal(w)
+	fastrpc.WriteFrame(w, fastrpc.Code(defs.PROPOSE), cmd)
 	w.Flush()
 }
 
@@ -349,7 +348,8 @@ func (c *Client) SendWeakWrite(key int64, value []byte) int32 {
 	return seqnum
 }
 
-// SendWeakRead sends a weak consistency read to the nearest replica.
+// SendWeakRead sends a weak consistency read to the nearest replica with the
+// log, which may be a learner.
 // Returns (value, version), client merges with local cache.
 func (c *Client) SendWeakRead(key int64) int32 {
 	seqnum := c.BufferClient.GetNextSeqnum()
@@ -357,7 +357,7 @@ func (c *Client) SendWeakRead(key int64) int32 {
 	c.mu.Lock()
 	c.weakPending[seqnum] = struct{}{}
 	c.weakPendingKeys[seqnum] = key
-	closest := c.ClosestId
+	closest := c.ReadId
 	c.mu.Unlock()
 
 	msg := &MWeakRead{
@@ -378,7 +378,7 @@ func (c *Client) SendWeakScan(key int64, count int64) int32 {
 	c.mu.Lock()
 	c.weakPending[seqnum] = struct{}{}
 	c.weakPendingKeys[seqnum] = key
-	closest := c.ClosestId
+	closest := c.ReadId
 	c.mu.Unlock()
 
 	msg := &MWeakRead{
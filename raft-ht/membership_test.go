@@ -0,0 +1,360 @@
+package raftht
+
+import (
+	"bufio"
+	"sync"
+	"testing"
+
+	"github.com/imdea-software/swiftpaxos/dlog"
+	"github.com/imdea-software/swiftpaxos/replica"
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+)
+
+// newTestMember creates a test replica whose initial members are members,
+// with no peer connections and a sender that only queues the messages.
+func newTestMember(id int32, members []bool) *Replica {
+	r := newTestReplica(id, len(members))
+	r.Replica = &replica.Replica{
+		Logger:      dlog.New("", false),
+		PeerWriters: make([]*bufio.Writer, len(members)),
+		PeerMu:      make([]sync.Mutex, len(members)),
+	}
+	r.sender = make(replica.Sender, 64)
+	for i := range r.matchIndex {
+		r.matchIndex[i] = -1
+	}
+	r.confIndex = -1
+	r.initialMembers = members
+	r.setConf(members, nil, -1)
+	return r
+}
+
+// leadTerm makes r the leader of term, with a committed entry of the term.
+func leadTerm(r *Replica, term int32) {
+	r.role = LEADER
+	r.currentTerm = term
+	r.log = append(r.log, LogEntry{Term: term})
+	r.commitIndex = int32(len(r.log) - 1)
+	r.matchIndex[r.id] = r.commitIndex
+}
+
+func newTestChange(args *defs.MembershipArgs) *confChange {
+	return &confChange{
+		args:  args,
+		reply: &defs.MembershipReply{},
+		done:  make(chan error, 1),
+	}
+}
+
+func TestLoadConfFromLog(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, true, false, false})
+	if r.votesNeeded != 2 {
+		t.Fatalf("votesNeeded = %d, want 2", r.votesNeeded)
+	}
+
+	r.log = []LogEntry{{Term: 1}, confEntry([]bool{true, true, true, true, true}, nil, 1), {Term: 1}}
+	r.loadConf()
+	if r.confIndex != 1 || r.votesNeeded != 3 || !r.isMember(4) {
+		t.Errorf("after loadConf: confIndex %d, votesNeeded %d, members %v", r.confIndex, r.votesNeeded, r.members)
+	}
+
+	// removing the configuration entry restores the initial members
+	r.truncateLog(1)
+	if r.confIndex != -1 || r.votesNeeded != 2 || r.isMember(4) {
+		t.Errorf("after truncateLog: confIndex %d, votesNeeded %d, members %v", r.confIndex, r.votesNeeded, r.members)
+	}
+}
+
+func TestConfEntryIsNotACommand(t *testing.T) {
+	e := confEntry([]bool{true, false}, []bool{false, true}, 3)
+	if !isConf(&e) {
+		t.Error("configuration entry not recognized")
+	}
+	cmd := LogEntry{Term: 3, CmdId: CommandId{ClientId: 1, SeqNum: 1}}
+	if isConf(&cmd) {
+		t.Error("client command taken for a configuration entry")
+	}
+}
+
+func TestChangeMembershipAdd(t *testing.T) {
+	r := newTestMember(0, []bool{true, false, false})
+	leadTerm(r, 1)
+
+	c := newTestChange(&defs.MembershipArgs{Add: []int32{1}})
+	r.handleConfChange(c)
+	if c.index != 1 || !r.isMember(1) || r.votesNeeded != 2 {
+		t.Fatalf("index %d, members %v, votesNeeded %d", c.index, r.members, r.votesNeeded)
+	}
+	select {
+	case err := <-c.done:
+		t.Fatalf("change answered before commit: %v", err)
+	default:
+	}
+
+	// a second change waits for the first one
+	c2 := newTestChange(&defs.MembershipArgs{Add: []int32{2}})
+	r.handleConfChange(c2)
+	if err := <-c2.done; err == nil {
+		t.Error("concurrent change accepted")
+	}
+
+	r.matchIndex[1] = c.index
+	r.advanceCommitIndex()
+	if err := <-c.done; err != nil {
+		t.Fatal(err)
+	}
+	if len(c.reply.Members) != 2 || c.reply.Leader != 0 {
+		t.Errorf("reply = %+v", c.reply)
+	}
+}
+
+func TestChangeMembershipRemoveLeader(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, true})
+	leadTerm(r, 2)
+
+	c := newTestChange(&defs.MembershipArgs{Remove: []int32{0}})
+	r.handleConfChange(c)
+	if r.isMember(0) || r.votesNeeded != 2 {
+		t.Fatalf("members %v, votesNeeded %d", r.members, r.votesNeeded)
+	}
+	// the leader no longer counts in the quorum of its removal
+	r.matchIndex[1] = c.index
+	r.advanceCommitIndex()
+	if r.commitIndex == c.index {
+		t.Fatal("removal committed by a single member")
+	}
+	r.matchIndex[2] = c.index
+	r.advanceCommitIndex()
+	if err := <-c.done; err != nil {
+		t.Fatal(err)
+	}
+	if c.reply.Leader != -1 || r.role != FOLLOWER {
+		t.Errorf("leader %d, role %d after its removal", c.reply.Leader, r.role)
+	}
+}
+
+func TestChangeMembershipRejected(t *testing.T) {
+	r := newTestMember(1, []bool{true, true, false})
+	r.knownLeader = 0
+	for _, args := range []*defs.MembershipArgs{
+		{Add: []int32{2}},
+		{Add: []int32{2}, Remove: []int32{0}},
+	} {
+		c := newTestChange(args)
+		r.handleConfChange(c)
+		if err := <-c.done; err == nil {
+			t.Errorf("%+v accepted by a follower", args)
+		}
+	}
+
+	r.role = LEADER
+	for _, args := range []*defs.MembershipArgs{
+		{Add: []int32{0}},
+		{Add: []int32{3}},
+		{Remove: []int32{2}},
+	} {
+		c := newTestChange(args)
+		r.handleConfChange(c)
+		if err := <-c.done; err == nil {
+			t.Errorf("%+v accepted", args)
+		}
+	}
+
+	// queries are answered by any replica
+	q := newTestChange(&defs.MembershipArgs{})
+	r.handleConfChange(q)
+	if err := <-q.done; err != nil || len(q.reply.Members) != 2 || q.reply.Leader != 1 {
+		t.Errorf("query: %v %+v", err, q.reply)
+	}
+}
+
+func TestNonMembersDoNotVote(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, false})
+	r.handleRequestVote(&RequestVote{CandidateId: 2, Term: 5})
+	if r.currentTerm != 0 || r.votedFor != -1 {
+		t.Errorf("vote of a spare handled: term %d, votedFor %d", r.currentTerm, r.votedFor)
+	}
+
+	r.currentTerm = 1
+	r.role = CANDIDATE
+	r.votesReceived = 1
+	r.handleRequestVoteReply(&RequestVoteReply{VoterId: 2, Term: 1, VoteGranted: 1})
+	if r.role == LEADER {
+		t.Error("vote of a spare counted")
+	}
+	r.handleRequestVoteReply(&RequestVoteReply{VoterId: 1, Term: 1, VoteGranted: 1})
+	if r.role != LEADER {
+		t.Error("not leader with a majority of the members")
+	}
+}
+
+func TestLearnerDoesNotCount(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, false})
+	leadTerm(r, 1)
+
+	c := newTestChange(&defs.MembershipArgs{Learn: []int32{2}})
+	r.handleConfChange(c)
+	if !r.isLearner(2) || r.isMember(2) || r.votesNeeded != 2 {
+		t.Fatalf("members %v, learners %v, votesNeeded %d", r.members, r.learners, r.votesNeeded)
+	}
+	// the learner gets the log
+	if !r.followsLog(2) || r.nextIndex[2] != c.index+1 {
+		t.Errorf("learner not sent the log: nextIndex %d", r.nextIndex[2])
+	}
+	// but does not count in the quorum
+	r.matchIndex[2] = c.index
+	r.advanceCommitIndex()
+	if r.commitIndex == c.index {
+		t.Fatal("committed with a learner")
+	}
+	r.matchIndex[1] = c.index
+	r.advanceCommitIndex()
+	if err := <-c.done; err != nil {
+		t.Fatal(err)
+	}
+	if len(c.reply.Members) != 2 || len(c.reply.Learners) != 1 || c.reply.Learners[0] != 2 {
+		t.Errorf("reply = %+v", c.reply)
+	}
+
+	// nor in the elections
+	r.role = FOLLOWER
+	r.handleRequestVote(&RequestVote{CandidateId: 2, Term: 5})
+	if r.currentTerm != 1 {
+		t.Errorf("vote of a learner handled: term %d", r.currentTerm)
+	}
+}
+
+func TestPromoteLearner(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, false})
+	r.role = LEADER
+	r.currentTerm = 1
+	r.learners = []bool{false, false, true}
+	r.log = []LogEntry{{Term: 1}, {Term: 1}}
+	r.commitIndex = 1
+	r.matchIndex = []int32{1, 1, 1}
+	r.nextIndex = []int32{2, 2, 2}
+
+	c := newTestChange(&defs.MembershipArgs{Add: []int32{2}})
+	r.handleConfChange(c)
+	if !r.isMember(2) || r.isLearner(2) || r.votesNeeded != 2 {
+		t.Fatalf("members %v, learners %v, votesNeeded %d", r.members, r.learners, r.votesNeeded)
+	}
+	// the promoted learner keeps the log it has received
+	if r.matchIndex[2] != 1 {
+		t.Errorf("matchIndex = %d after promotion, want 1", r.matchIndex[2])
+	}
+
+	// and the entry is stored with the new members
+	r.loadConf()
+	if !r.isMember(2) || r.isLearner(2) || r.confIndex != c.index {
+		t.Errorf("after loadConf: members %v, learners %v, confIndex %d", r.members, r.learners, r.confIndex)
+	}
+}
+
+func TestRemoveLearner(t *testing.T) {
+	r := newTestMember(0, []bool{true, false, false})
+	leadTerm(r, 1)
+	r.learners = []bool{false, true, false}
+
+	c := newTestChange(&defs.MembershipArgs{Remove: []int32{1}})
+	r.handleConfChange(c)
+	if err := <-c.done; err != nil {
+		t.Fatal(err)
+	}
+	if r.isLearner(1) || r.followsLog(1) || len(c.reply.Learners) != 0 {
+		t.Errorf("learners %v, reply %+v", r.learners, c.reply)
+	}
+
+	for _, args := range []*defs.MembershipArgs{
+		{Learn: []int32{0}},
+		{Learn: []int32{3}},
+		{Learn: []int32{1}, Add: []int32{2}},
+	} {
+		c := newTestChange(args)
+		r.handleConfChange(c)
+		if err := <-c.done; err == nil {
+			t.Errorf("%+v accepted", args)
+		}
+	}
+}
+
+// TestConfChangeAfterElection tests that a new leader starts a change once
+// an entry of its term is committed
+func TestConfChangeAfterElection(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, false})
+	r.log = []LogEntry{{Term: 1}}
+	r.commitIndex = 0
+	r.matchIndex = []int32{0, 0, -1}
+	r.nextIndex = []int32{1, 1, 1}
+	r.role = LEADER
+	r.currentTerm = 2
+
+	c := newTestChange(&defs.MembershipArgs{Add: []int32{2}})
+	r.handleConfChange(c)
+	if len(r.log) != 2 || r.log[1].CmdId != noopCmdId || r.log[1].Term != 2 {
+		t.Fatalf("log %+v, want an empty entry of term 2", r.log)
+	}
+	if r.isMember(2) || len(r.confWaiting) != 1 {
+		t.Fatalf("change started before an entry of the term: members %v", r.members)
+	}
+
+	// the empty entry commits, and the change starts
+	r.matchIndex[1] = 1
+	r.advanceCommitIndex()
+	if !r.isMember(2) || c.index != 2 || len(r.confWaiting) != 0 {
+		t.Fatalf("members %v, index %d after the empty entry", r.members, c.index)
+	}
+	r.matchIndex[1] = 2
+	r.advanceCommitIndex()
+	if err := <-c.done; err != nil {
+		t.Fatal(err)
+	}
+
+	// a waiting change fails with the leadership
+	r.currentTerm = 3
+	c = newTestChange(&defs.MembershipArgs{Remove: []int32{2}})
+	r.handleConfChange(c)
+	r.becomeFollower(4)
+	if err := <-c.done; err == nil {
+		t.Error("waiting change done after losing the leadership")
+	}
+}
+
+func TestPromoteLaggingLearner(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, false})
+	r.role = LEADER
+	r.currentTerm = 1
+	r.learners = []bool{false, false, true}
+	r.log = []LogEntry{{Term: 1}, {Term: 1}}
+	r.commitIndex = 1
+	r.matchIndex = []int32{1, 1, 0}
+	r.nextIndex = []int32{2, 2, 1}
+
+	c := newTestChange(&defs.MembershipArgs{Add: []int32{2}})
+	r.handleConfChange(c)
+	if err := <-c.done; err == nil {
+		t.Fatal("learner promoted before it caught up with the log")
+	}
+	if r.isMember(2) || !r.isLearner(2) || len(r.log) != 2 {
+		t.Errorf("members %v, learners %v, log %+v", r.members, r.learners, r.log)
+	}
+}
+
+func TestElectionSkipsLearners(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, false, false})
+	r.learners = []bool{false, false, true, false}
+	r.setConf(r.members, r.learners, -1)
+
+	r.startElection()
+	close(r.sender)
+	sent := 0
+	for arg := range r.sender {
+		if id := arg.Id(); id != 1 {
+			t.Errorf("RequestVote sent to replica %d", id)
+		}
+		sent++
+	}
+	if sent != 1 {
+		t.Errorf("%d RequestVote sent, want 1", sent)
+	}
+}
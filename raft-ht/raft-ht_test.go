@@ -5,8 +5,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/imdea-software/swiftpaxos/replica"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
 	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/sim"
 	"github.com/imdea-software/swiftpaxos/state"
 )
 
@@ -471,7 +473,7 @@ func TestInitCsWeakChannels(t *testing.T) {
 	}
 
 	// Check all RPC IDs are distinct (9 total: 5 vanilla Raft + 4 weak)
-	ids := map[uint8]string{
+	ids := map[fastrpc.Code]string{
 		cs.AppendEntriesRPC:      "appendEntries",
 		cs.AppendEntriesReplyRPC: "appendEntriesReply",
 		cs.RequestVoteRPC:        "requestVote",
@@ -954,8 +956,8 @@ func TestBecomeLeader_SetsKnownLeader(t *testing.T) {
 // TestBecomeLeader_TimerManagement verifies heartbeat timer starts and election timer stops
 func TestBecomeLeader_TimerManagement(t *testing.T) {
 	r := newTestReplica(0, 3)
-	r.electionTimer = time.NewTimer(time.Hour) // long timer so it doesn't fire
-	r.heartbeatTimer = time.NewTimer(time.Hour)
+	r.electionTimer = sim.Real.NewTimer(time.Hour) // long timer so it doesn't fire
+	r.heartbeatTimer = sim.Real.NewTimer(time.Hour)
 	r.heartbeatTimer.Stop() // simulate stopped state (as follower)
 	r.heartbeatTimeout = 100 * time.Millisecond
 
@@ -974,9 +976,9 @@ func TestBecomeLeader_TimerManagement(t *testing.T) {
 func TestBecomeFollower_TimerManagement(t *testing.T) {
 	r := newTestReplica(0, 3)
 	r.role = LEADER
-	r.electionTimer = time.NewTimer(time.Hour)
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
 	r.electionTimer.Stop() // simulate stopped state (as leader)
-	r.heartbeatTimer = time.NewTimer(time.Hour)
+	r.heartbeatTimer = sim.Real.NewTimer(time.Hour)
 
 	r.becomeFollower(5)
 
@@ -1075,7 +1077,7 @@ func TestBeTheLeader_SetsKnownLeader(t *testing.T) {
 	r := newTestReplica(2, 3)
 	r.knownLeader = -1
 
-	r.BeTheLeader(nil, nil)
+	r.beTheLeader()
 
 	if r.knownLeader != 2 {
 		t.Errorf("knownLeader = %d after BeTheLeader, want 2", r.knownLeader)
@@ -1277,3 +1279,63 @@ func TestHandleReaderDead_IsLeader(t *testing.T) {
 		t.Errorf("leader = %d after leader death, want 2", c.leader)
 	}
 }
+
+// ============================================================================
+// Membership: spares and configuration entries
+// ============================================================================
+
+func TestMembershipQuorum(t *testing.T) {
+	r := newTestReplica(0, 4)
+	r.initialMembers = []bool{true, true, true, false}
+	r.setConf(r.initialMembers, nil, -1)
+	r.role = LEADER
+	r.currentTerm = 1
+	r.log = []LogEntry{{Term: 1}}
+	r.matchIndex = []int32{0, -1, -1, 0}
+
+	// the spare does not count in the quorum
+	r.advanceCommitIndex()
+	if r.commitIndex != -1 {
+		t.Fatalf("commitIndex = %d, committed with a spare", r.commitIndex)
+	}
+
+	// once added, it does
+	r.log = append(r.log, confEntry([]bool{true, true, true, true}, nil, 1))
+	r.loadConf()
+	r.matchIndex = []int32{1, 1, -1, 1}
+	r.advanceCommitIndex()
+	if r.votesNeeded != 3 || r.commitIndex != 1 {
+		t.Errorf("votesNeeded %d, commitIndex %d", r.votesNeeded, r.commitIndex)
+	}
+}
+
+func TestTransferLeader(t *testing.T) {
+	r := newTestReplica(0, 3)
+	r.sender = make(replica.Sender, 8)
+	r.role = LEADER
+	r.currentTerm = 1
+	r.log = []LogEntry{{Term: 1}}
+	r.matchIndex = []int32{0, 0, -1}
+
+	// the target is up to date: TimeoutNow at once
+	tr := &transfer{target: 1, reply: &defs.TransferLeaderReply{}, done: make(chan error, 1)}
+	r.handleTransfer(tr)
+	if r.transfer != tr || !tr.sent {
+		t.Fatalf("transfer %v, TimeoutNow sent %v", r.transfer, tr.sent)
+	}
+	r.transferred(1)
+	if err := <-tr.done; err != nil || tr.reply.Leader != 1 || r.transfer != nil {
+		t.Errorf("transfer: %v, leader %d", err, tr.reply.Leader)
+	}
+
+	// another replica is elected
+	tr = &transfer{target: 2, reply: &defs.TransferLeaderReply{}, done: make(chan error, 1)}
+	r.handleTransfer(tr)
+	if tr.sent {
+		t.Fatal("TimeoutNow sent to a target behind the leader")
+	}
+	r.transferred(0)
+	if err := <-tr.done; err == nil {
+		t.Error("transfer succeeded with another leader")
+	}
+}
@@ -64,6 +64,10 @@ func (c *Client) waitRepliesWithFailover() {
 			time.Sleep(500 * time.Millisecond)
 			continue
 		}
+		if r.OK == defs.BUSY {
+			c.RetryBusy(r.CommandId)
+			continue
+		}
 		if r.OK != defs.TRUE {
 			// NOT_LEADER rejection. Use leader hint if available and alive.
 			if r.LeaderId >= 0 && !c.deadReplicas[int(r.LeaderId)] {
@@ -0,0 +1,422 @@
+// Code generated by marshalgen; DO NOT EDIT.
+
+package raft
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+func (t *RequestVote) New() fastrpc.Serializable {
+	return new(RequestVote)
+}
+
+func (t *RequestVote) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type RequestVoteCache struct {
+	mu    sync.Mutex
+	cache []*RequestVote
+}
+
+func NewRequestVoteCache() *RequestVoteCache {
+	c := &RequestVoteCache{}
+	c.cache = make([]*RequestVote, 0)
+	return c
+}
+
+func (p *RequestVoteCache) Get() *RequestVote {
+	var t *RequestVote
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &RequestVote{}
+	}
+	return t
+}
+
+func (p *RequestVoteCache) Put(t *RequestVote) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *RequestVote) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CandidateId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Term))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.LastLogIndex))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.LastLogTerm))
+	wire.Write(b[:16])
+}
+
+func (t *RequestVote) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.CandidateId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Term = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.LastLogIndex = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.LastLogTerm = int32(binary.LittleEndian.Uint32(b[12:]))
+	return nil
+}
+
+func (t *RequestVoteReply) New() fastrpc.Serializable {
+	return new(RequestVoteReply)
+}
+
+func (t *RequestVoteReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 12, true
+}
+
+type RequestVoteReplyCache struct {
+	mu    sync.Mutex
+	cache []*RequestVoteReply
+}
+
+func NewRequestVoteReplyCache() *RequestVoteReplyCache {
+	c := &RequestVoteReplyCache{}
+	c.cache = make([]*RequestVoteReply, 0)
+	return c
+}
+
+func (p *RequestVoteReplyCache) Get() *RequestVoteReply {
+	var t *RequestVoteReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &RequestVoteReply{}
+	}
+	return t
+}
+
+func (p *RequestVoteReplyCache) Put(t *RequestVoteReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *RequestVoteReply) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.VoterId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Term))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.VoteGranted))
+	wire.Write(b[:12])
+}
+
+func (t *RequestVoteReply) Unmarshal(wire io.Reader) error {
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.VoterId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Term = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.VoteGranted = int32(binary.LittleEndian.Uint32(b[8:]))
+	return nil
+}
+
+func (t *AppendEntries) New() fastrpc.Serializable {
+	return new(AppendEntries)
+}
+
+func (t *AppendEntries) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type AppendEntriesCache struct {
+	mu    sync.Mutex
+	cache []*AppendEntries
+}
+
+func NewAppendEntriesCache() *AppendEntriesCache {
+	c := &AppendEntriesCache{}
+	c.cache = make([]*AppendEntries, 0)
+	return c
+}
+
+func (p *AppendEntriesCache) Get() *AppendEntries {
+	var t *AppendEntries
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &AppendEntries{}
+	}
+	return t
+}
+
+func (p *AppendEntriesCache) Put(t *AppendEntries) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *AppendEntries) Marshal(wire io.Writer) {
+	var b [24]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Term))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.PrevLogIndex))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.PrevLogTerm))
+	binary.LittleEndian.PutUint32(b[16:], uint32(t.LeaderCommit))
+	binary.LittleEndian.PutUint32(b[20:], uint32(t.EntryCnt))
+	wire.Write(b[:24])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Entries)))])
+	for i := range t.Entries {
+		t.Entries[i].Marshal(wire)
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.EntryIds)))])
+	for i := range t.EntryIds {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.EntryIds[i].ClientId))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.EntryIds[i].SeqNum))
+		wire.Write(b[:8])
+	}
+}
+
+func (t *AppendEntries) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [24]byte
+	if _, err := io.ReadFull(wire, b[:24]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Term = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.PrevLogIndex = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.PrevLogTerm = int32(binary.LittleEndian.Uint32(b[12:]))
+	t.LeaderCommit = int32(binary.LittleEndian.Uint32(b[16:]))
+	t.EntryCnt = int32(binary.LittleEndian.Uint32(b[20:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Entries = make([]state.Command, n1)
+	for i := range t.Entries {
+		if err := t.Entries[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.EntryIds = make([]CommandId, n2)
+	for i := range t.EntryIds {
+		if _, err := io.ReadFull(wire, b[:8]); err != nil {
+			return err
+		}
+		t.EntryIds[i].ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.EntryIds[i].SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	}
+	return nil
+}
+
+func (t *AppendEntriesReply) New() fastrpc.Serializable {
+	return new(AppendEntriesReply)
+}
+
+func (t *AppendEntriesReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type AppendEntriesReplyCache struct {
+	mu    sync.Mutex
+	cache []*AppendEntriesReply
+}
+
+func NewAppendEntriesReplyCache() *AppendEntriesReplyCache {
+	c := &AppendEntriesReplyCache{}
+	c.cache = make([]*AppendEntriesReply, 0)
+	return c
+}
+
+func (p *AppendEntriesReplyCache) Get() *AppendEntriesReply {
+	var t *AppendEntriesReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &AppendEntriesReply{}
+	}
+	return t
+}
+
+func (p *AppendEntriesReplyCache) Put(t *AppendEntriesReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *AppendEntriesReply) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.FollowerId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Term))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Success))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.MatchIndex))
+	wire.Write(b[:16])
+}
+
+func (t *AppendEntriesReply) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.FollowerId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Term = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Success = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.MatchIndex = int32(binary.LittleEndian.Uint32(b[12:]))
+	return nil
+}
+
+func (t *RaftReply) New() fastrpc.Serializable {
+	return new(RaftReply)
+}
+
+func (t *RaftReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type RaftReplyCache struct {
+	mu    sync.Mutex
+	cache []*RaftReply
+}
+
+func NewRaftReplyCache() *RaftReplyCache {
+	c := &RaftReplyCache{}
+	c.cache = make([]*RaftReply, 0)
+	return c
+}
+
+func (p *RaftReplyCache) Get() *RaftReply {
+	var t *RaftReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &RaftReply{}
+	}
+	return t
+}
+
+func (p *RaftReplyCache) Put(t *RaftReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *RaftReply) Marshal(wire io.Writer) {
+	var b [10]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:8])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Value)))])
+	wire.Write(t.Value)
+}
+
+func (t *RaftReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Value = make([]byte, n1)
+	if _, err := io.ReadFull(wire, t.Value); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *TimeoutNow) New() fastrpc.Serializable {
+	return new(TimeoutNow)
+}
+
+func (t *TimeoutNow) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type TimeoutNowCache struct {
+	mu    sync.Mutex
+	cache []*TimeoutNow
+}
+
+func NewTimeoutNowCache() *TimeoutNowCache {
+	c := &TimeoutNowCache{}
+	c.cache = make([]*TimeoutNow, 0)
+	return c
+}
+
+func (p *TimeoutNowCache) Get() *TimeoutNow {
+	var t *TimeoutNow
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &TimeoutNow{}
+	}
+	return t
+}
+
+func (p *TimeoutNowCache) Put(t *TimeoutNow) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *TimeoutNow) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Term))
+	wire.Write(b[:8])
+}
+
+func (t *TimeoutNow) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Term = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+type byteReader interface {
+	io.Reader
+	ReadByte() (c byte, err error)
+}
@@ -0,0 +1,359 @@
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+// Membership changes
+//
+// The members of the cluster are the replicas that vote and that count in
+// the quorums, among the replicas of the config; the other replicas are
+// spares. A change adds or removes one replica (single-server changes, see
+// Ongaro's dissertation, §4.1), so that the majorities of the old and of the
+// new members intersect: the leader appends a configuration entry to its
+// log, and every replica uses the latest configuration entry of its log,
+// committed or not. The leader starts a change once the previous one is
+// committed, and steps down once its own removal is committed. A new
+// leader starts no change before an entry of its term is committed (it
+// appends an empty entry if needed, see waitForTerm): the configuration
+// entry of a former leader that it has not seen could otherwise be
+// committed with a majority disjoint from the one of its own change.
+//
+// Learners are replicas that do not vote: the leader sends them the log,
+// which they apply, e.g. to serve weak reads near clients, but they count
+// neither in the quorums nor in the elections. A spare becomes a learner,
+// and a learner becomes a member, by membership changes. The leader refuses
+// to promote a learner that has not yet replicated the committed log.
+
+// confCmdId identifies the configuration entries of the log. Their command
+// is a NONE whose value has a byte per replica, 1 for the members and 2 for
+// the learners.
+var confCmdId = CommandId{ClientId: -1, SeqNum: math.MinInt32}
+
+// noopCmdId identifies the empty entries that a leader appends to commit an
+// entry of its term. Their command is a NONE without value.
+var noopCmdId = CommandId{ClientId: -1, SeqNum: math.MinInt32 + 1}
+
+// isConf reports whether e is a configuration entry.
+func isConf(e *LogEntry) bool {
+	return e.CmdId == confCmdId && e.Command.Op == state.NONE
+}
+
+func confEntry(members, learners []bool, term int32) LogEntry {
+	v := make([]byte, len(members))
+	for i, m := range members {
+		if m {
+			v[i] = 1
+		} else if learners != nil && learners[i] {
+			v[i] = 2
+		}
+	}
+	return LogEntry{
+		Command: state.Command{Op: state.NONE, V: v},
+		Term:    term,
+		CmdId:   confCmdId,
+	}
+}
+
+// confChange is a request of the master, handled by the event loop.
+type confChange struct {
+	args  *defs.MembershipArgs
+	reply *defs.MembershipReply
+	index int32 // of the configuration entry
+	done  chan error
+}
+
+// ChangeMembership is called by the master via RPC to add, remove or make a
+// learner of a replica. It returns once the new configuration is committed.
+func (r *Replica) ChangeMembership(args *defs.MembershipArgs, reply *defs.MembershipReply) error {
+	c := &confChange{
+		args:  args,
+		reply: reply,
+		done:  make(chan error, 1),
+	}
+	r.confChan <- c
+	return <-c.done
+}
+
+// isMember reports whether replica i is a member. All replicas are members
+// if r.members is nil.
+func (r *Replica) isMember(i int32) bool {
+	return r.members == nil || r.members[i]
+}
+
+// isLearner reports whether replica i is a learner.
+func (r *Replica) isLearner(i int32) bool {
+	return r.learners != nil && r.learners[i]
+}
+
+// followsLog reports whether the leader sends the log to replica i, i.e.
+// whether it is a member or a learner.
+func (r *Replica) followsLog(i int32) bool {
+	return r.isMember(i) || r.isLearner(i)
+}
+
+// setConf makes members and learners the configuration of the replica (nil:
+// all the replicas are members, and none is a learner), held by the entry
+// index of the log (-1: the initial configuration).
+func (r *Replica) setConf(members, learners []bool, index int32) {
+	r.members = nil
+	if members != nil {
+		r.members = append([]bool(nil), members...)
+	}
+	r.learners = nil
+	if learners != nil {
+		r.learners = append([]bool(nil), learners...)
+	}
+	r.confIndex = index
+	r.votesNeeded = len(r.memberList())/2 + 1
+}
+
+// loadConf sets the configuration of the replica to the latest one of its
+// log (logMu held).
+func (r *Replica) loadConf() {
+	for i := int32(len(r.log)) - 1; i >= 0; i-- {
+		if isConf(&r.log[i]) {
+			members := make([]bool, r.n)
+			learners := make([]bool, r.n)
+			for j, b := range r.log[i].Command.V {
+				if j < r.n {
+					members[j] = b == 1
+					learners[j] = b == 2
+				}
+			}
+			r.setConf(members, learners, i)
+			return
+		}
+	}
+	r.setConf(r.initialMembers, r.initialLearners, -1)
+}
+
+// truncateLog deletes the entries of the log from index i (logMu held).
+func (r *Replica) truncateLog(i int32) {
+	r.log = r.log[:i]
+	if r.confIndex >= i {
+		r.loadConf()
+	}
+}
+
+func (r *Replica) memberList() []int32 {
+	var l []int32
+	for i := int32(0); i < int32(r.n); i++ {
+		if r.isMember(i) {
+			l = append(l, i)
+		}
+	}
+	return l
+}
+
+func (r *Replica) learnerList() []int32 {
+	var l []int32
+	for i := int32(0); i < int32(r.n); i++ {
+		if r.isLearner(i) {
+			l = append(l, i)
+		}
+	}
+	return l
+}
+
+// handleConfChange starts the change c, or answers it if it is a query.
+func (r *Replica) handleConfChange(c *confChange) {
+	leader := r.knownLeader
+	if r.role == LEADER {
+		leader = r.id
+	}
+	changes := len(c.args.Add) + len(c.args.Remove) + len(c.args.Learn)
+	if changes == 0 {
+		c.reply.Members = r.memberList()
+		c.reply.Learners = r.learnerList()
+		c.reply.Leader = leader
+		c.done <- nil
+		return
+	}
+	if changes > 1 {
+		c.done <- errors.New("a replica adds or removes one replica at a time")
+		return
+	}
+	if r.role != LEADER {
+		c.done <- fmt.Errorf("replica %d is not the leader (leader: %d)", r.id, leader)
+		return
+	}
+	if r.transfer != nil {
+		c.done <- errors.New("a leader transfer is in progress")
+		return
+	}
+	r.logMu.Lock()
+	pending := r.confIndex > r.commitIndex
+	r.logMu.Unlock()
+	if pending {
+		c.done <- errors.New("a membership change is in progress")
+		return
+	}
+
+	members := make([]bool, r.n)
+	learners := make([]bool, r.n)
+	for i := range members {
+		members[i] = r.isMember(int32(i))
+		learners[i] = r.isLearner(int32(i))
+	}
+	var id int32
+	// a learner keeps the log it has received
+	caughtUp := false
+	switch {
+	case len(c.args.Add) > 0:
+		id = c.args.Add[0]
+		if id < 0 || id >= int32(r.n) {
+			c.done <- fmt.Errorf("no replica %d", id)
+			return
+		}
+		if members[id] {
+			c.done <- fmt.Errorf("replica %d is already a member", id)
+			return
+		}
+		if learners[id] && r.matchIndex[id] < r.commitIndex {
+			c.done <- fmt.Errorf("replica %d has not caught up with the log", id)
+			return
+		}
+		caughtUp = learners[id]
+		members[id] = true
+		learners[id] = false
+	case len(c.args.Learn) > 0:
+		id = c.args.Learn[0]
+		if id < 0 || id >= int32(r.n) {
+			c.done <- fmt.Errorf("no replica %d", id)
+			return
+		}
+		if members[id] || learners[id] {
+			c.done <- fmt.Errorf("replica %d is already a member or a learner", id)
+			return
+		}
+		learners[id] = true
+	default:
+		id = c.args.Remove[0]
+		if id >= 0 && id < int32(r.n) && learners[id] {
+			learners[id] = false
+			break
+		}
+		if id < 0 || id >= int32(r.n) || !members[id] {
+			c.done <- fmt.Errorf("replica %d is not a member", id)
+			return
+		}
+		members[id] = false
+		if len(r.memberList()) == 1 {
+			c.done <- errors.New("cannot remove the last member")
+			return
+		}
+	}
+
+	if r.waitForTerm() {
+		r.confWaiting = append(r.confWaiting, c)
+		return
+	}
+
+	r.logMu.Lock()
+	r.log = append(r.log, confEntry(members, learners, r.currentTerm))
+	c.index = int32(len(r.log) - 1)
+	r.setConf(members, learners, c.index)
+	r.matchIndex[r.id] = c.index
+	r.logMu.Unlock()
+	if len(c.args.Add)+len(c.args.Learn) > 0 && !caughtUp {
+		r.nextIndex[id] = c.index + 1
+		r.matchIndex[id] = -1
+	}
+	r.println("Membership change at", c.index, "members", r.memberList(), "learners", r.learnerList())
+
+	r.confChanges = append(r.confChanges, c)
+	r.broadcastAppendEntries()
+	// a single member commits alone
+	r.advanceCommitIndex()
+}
+
+// waitForTerm tells whether the leader has not committed an entry of its
+// term yet, after appending an empty one if it has none.
+func (r *Replica) waitForTerm() bool {
+	r.logMu.Lock()
+	committed := r.commitIndex >= 0 && r.log[r.commitIndex].Term == r.currentTerm
+	last := int32(len(r.log) - 1)
+	appended := last >= 0 && r.log[last].Term == r.currentTerm
+	if !committed && !appended {
+		r.log = append(r.log, LogEntry{
+			Command: state.Command{Op: state.NONE},
+			Term:    r.currentTerm,
+			CmdId:   noopCmdId,
+		})
+		r.matchIndex[r.id] = last + 1
+	}
+	r.logMu.Unlock()
+	if !committed && !appended {
+		r.println("Empty entry at", last+1, "before a membership change")
+		r.broadcastAppendEntries()
+		// a single member commits alone
+		r.advanceCommitIndex()
+	}
+	return !committed
+}
+
+// confCommitted answers the changes committed so far, starts the ones
+// waiting for an entry of the term, and steps down if the leader is no
+// longer a member.
+func (r *Replica) confCommitted() {
+	r.logMu.Lock()
+	commitIdx := r.commitIndex
+	committedInTerm := commitIdx >= 0 && r.log[commitIdx].Term == r.currentTerm
+	r.logMu.Unlock()
+
+	n := 0
+	for _, c := range r.confChanges {
+		if c.index > commitIdx {
+			r.confChanges[n] = c
+			n++
+			continue
+		}
+		c.reply.Members = r.memberList()
+		c.reply.Learners = r.learnerList()
+		c.reply.Leader = r.id
+		if !r.isMember(r.id) {
+			c.reply.Leader = -1
+		}
+		c.done <- nil
+	}
+	r.confChanges = r.confChanges[:n]
+
+	if r.role == LEADER && committedInTerm && len(r.confWaiting) > 0 {
+		waiting := r.confWaiting
+		r.confWaiting = nil
+		for _, c := range waiting {
+			r.handleConfChange(c)
+		}
+	}
+
+	if r.role == LEADER && !r.isMember(r.id) && r.confIndex <= commitIdx {
+		r.println("Removed from the members, stepping down at term", r.currentTerm)
+		r.role = FOLLOWER
+		r.knownLeader = -1
+		if r.heartbeatTimer != nil {
+			r.heartbeatTimer.Stop()
+		}
+		if r.electionTimer != nil {
+			r.resetElectionTimer()
+		}
+	}
+}
+
+// abortConfChanges fails the changes not committed yet, once the replica
+// is no longer the leader.
+func (r *Replica) abortConfChanges() {
+	for _, c := range r.confChanges {
+		c.done <- fmt.Errorf("replica %d lost the leadership before the change was committed", r.id)
+	}
+	r.confChanges = nil
+	for _, c := range r.confWaiting {
+		c.done <- fmt.Errorf("replica %d lost the leadership before the change started", r.id)
+	}
+	r.confWaiting = nil
+}
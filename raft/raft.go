@@ -1,7 +1,6 @@
 package raft
 
 import (
-	"math/rand"
 	"sync"
 	"time"
 
@@ -9,6 +8,8 @@ import (
 	"github.com/imdea-software/swiftpaxos/dlog"
 	"github.com/imdea-software/swiftpaxos/replica"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/sim"
 	"github.com/imdea-software/swiftpaxos/state"
 )
 
@@ -55,9 +56,27 @@ type Replica struct {
 
 	// Election state
 	votesReceived int
-	votesNeeded   int
+	votesNeeded   int   // majority of the members
 	knownLeader   int32 // best-known leader ID, -1 if unknown
 
+	// Membership (see membership.go)
+	members         []bool // voting replicas, nil = all (under logMu for writes)
+	learners        []bool // non-voting replicas that get the log, nil = none
+	initialMembers  []bool
+	initialLearners []bool
+	confIndex       int32 // log index of the configuration, -1 = initial
+	confChan        chan *confChange
+	confChanges     []*confChange // changes of the leader not committed yet
+	confWaiting     []*confChange // changes waiting for an entry of the term
+
+	// Leadership transfer (see transfer.go)
+	transferChan chan *transfer
+	transfer     *transfer // of the leader, in progress
+
+	// Calls of the master and of the admin participant, run by the event loop
+	leaderChan chan chan struct{}
+	statusChan chan *statusQuery
+
 	// Communication
 	cs     CommunicationSupply
 	sender replica.Sender
@@ -65,8 +84,8 @@ type Replica struct {
 	// Timers
 	electionTimeout  time.Duration
 	heartbeatTimeout time.Duration
-	electionTimer    *time.Timer
-	heartbeatTimer   *time.Timer
+	electionTimer    *sim.Timer
+	heartbeatTimer   *sim.Timer
 
 	// Replica identity and cluster size
 	id int32
@@ -117,6 +136,14 @@ func New(alias string, id int, addrs []string, isLeader bool, f int,
 		votesReceived: 0,
 		votesNeeded:   (n / 2) + 1,
 
+		confIndex: -1,
+		confChan:  make(chan *confChange),
+
+		transferChan: make(chan *transfer),
+
+		leaderChan: make(chan chan struct{}),
+		statusChan: make(chan *statusQuery),
+
 		appendEntriesCache:      NewAppendEntriesCache(),
 		appendEntriesReplyCache: NewAppendEntriesReplyCache(),
 		requestVoteCache:        NewRequestVoteCache(),
@@ -125,7 +152,7 @@ func New(alias string, id int, addrs []string, isLeader bool, f int,
 	}
 
 	// Set timer durations
-	r.electionTimeout = time.Duration(300+rand.Intn(200)) * time.Millisecond
+	r.electionTimeout = time.Duration(300+r.clock().Int63n(200)) * time.Millisecond
 	r.heartbeatTimeout = 100 * time.Millisecond
 
 	// Set batch delay from config
@@ -133,6 +160,10 @@ func New(alias string, id int, addrs []string, isLeader bool, f int,
 		r.batchWait = conf.BatchDelayUs
 	}
 
+	r.initialMembers = conf.InitialMembers(n)
+	r.initialLearners = conf.InitialLearners(n)
+	r.setConf(r.initialMembers, r.initialLearners, -1)
+
 	// Initialize leader volatile state
 	for i := 0; i < n; i++ {
 		r.nextIndex[i] = 0
@@ -147,7 +178,7 @@ func New(alias string, id int, addrs []string, isLeader bool, f int,
 
 	// If designated as leader by master, become leader immediately at term 0
 	if isLeader {
-		r.BeTheLeader(nil, nil)
+		r.beTheLeader()
 	}
 
 	// Launch event loop
@@ -157,8 +188,21 @@ func New(alias string, id int, addrs []string, isLeader bool, f int,
 }
 
 // BeTheLeader is called by the master via RPC to designate this replica as leader.
-// For Raft, this means transitioning to LEADER state and initializing leader state.
+// The event loop makes the replica the leader.
 func (r *Replica) BeTheLeader(args *defs.BeTheLeaderArgs, reply *defs.BeTheLeaderReply) error {
+	done := make(chan struct{})
+	r.leaderChan <- done
+	<-done
+
+	if reply != nil {
+		reply.Leader = r.id
+		reply.NextLeader = r.id
+	}
+	return nil
+}
+
+// beTheLeader transitions to LEADER state and initializes leader state.
+func (r *Replica) beTheLeader() {
 	r.role = LEADER
 	r.votedFor = r.id
 
@@ -172,14 +216,44 @@ func (r *Replica) BeTheLeader(args *defs.BeTheLeaderArgs, reply *defs.BeTheLeade
 	r.matchIndex[r.id] = lastLogIndex
 
 	r.println("I am the Raft leader at term", r.currentTerm)
+}
 
-	if reply != nil {
-		reply.Leader = r.id
-		reply.NextLeader = r.id
-	}
+// roleNames are the roles reported by Status.
+var roleNames = [...]string{FOLLOWER: "follower", CANDIDATE: "candidate", LEADER: "leader"}
+
+// statusQuery is a Status call, answered by the event loop, which owns the
+// role, the term and the configuration.
+type statusQuery struct {
+	reply *defs.StatusReply
+	done  chan struct{}
+}
+
+// Status is called via RPC by the admin participant.
+func (r *Replica) Status(args *defs.StatusArgs, reply *defs.StatusReply) error {
+	r.Replica.Status(args, reply)
+	q := &statusQuery{reply: reply, done: make(chan struct{})}
+	r.statusChan <- q
+	<-q.done
 	return nil
 }
 
+// status fills reply with the state of the protocol.
+func (r *Replica) status(reply *defs.StatusReply) {
+	reply.Role = roleNames[r.role]
+	if !r.isMember(r.id) {
+		reply.Role = "spare"
+		if r.isLearner(r.id) {
+			reply.Role = "learner"
+		}
+	}
+	reply.Term = r.currentTerm
+	r.logMu.Lock()
+	reply.Log = int32(len(r.log)) - 1
+	reply.Commit = r.commitIndex
+	reply.Applied = r.lastApplied
+	r.logMu.Unlock()
+}
+
 // run is the main event loop for the Raft replica.
 // All message handling and timer events are processed in this single goroutine.
 func (r *Replica) run() {
@@ -195,7 +269,7 @@ func (r *Replica) run() {
 		batchClockChan = make(chan bool, 1)
 		go func() {
 			for !r.Shutdown {
-				time.Sleep(time.Duration(r.batchWait) * time.Microsecond)
+				r.clock().Sleep(time.Duration(r.batchWait) * time.Microsecond)
 				batchClockChan <- true
 			}
 		}()
@@ -206,8 +280,8 @@ func (r *Replica) run() {
 	// to finish ConnectToPeers and send its first heartbeat before any follower
 	// starts an election. After the first heartbeat, normal timeouts apply.
 	initialElectionTimeout := 3 * time.Second
-	r.electionTimer = time.NewTimer(initialElectionTimeout)
-	r.heartbeatTimer = time.NewTimer(r.heartbeatTimeout)
+	r.electionTimer = r.clock().NewTimer(initialElectionTimeout)
+	r.heartbeatTimer = r.clock().NewTimer(r.heartbeatTimeout)
 
 	// Leader doesn't need election timer; followers don't need heartbeat timer.
 	// Send immediate heartbeat after peer connections are established to prevent
@@ -254,13 +328,35 @@ func (r *Replica) run() {
 			rvr := m.(*RequestVoteReply)
 			r.handleRequestVoteReply(rvr)
 
+		case m := <-r.cs.timeoutNowChan:
+			r.handleTimeoutNow(m.(*TimeoutNow))
+
 		case <-r.electionTimer.C:
+			r.checkTransfer()
 			if r.role != LEADER {
-				r.startElection()
+				// spares and removed replicas do not run
+				if r.isMember(r.id) {
+					r.startElection()
+				}
 				r.resetElectionTimer()
 			}
 
+		case c := <-r.confChan:
+			r.handleConfChange(c)
+
+		case t := <-r.transferChan:
+			r.handleTransfer(t)
+
+		case done := <-r.leaderChan:
+			r.beTheLeader()
+			close(done)
+
+		case q := <-r.statusChan:
+			r.status(q.reply)
+			close(q.done)
+
 		case <-r.heartbeatTimer.C:
+			r.checkTransfer()
 			if r.role == LEADER {
 				r.sendHeartbeats()
 				r.heartbeatTimer.Reset(r.heartbeatTimeout)
@@ -276,14 +372,24 @@ func (r *Replica) println(v ...interface{}) {
 	}
 }
 
+// clock returns the clock of the base replica, or the wall clock if there
+// is none.
+func (r *Replica) clock() sim.Clock {
+	if r.Replica == nil {
+		return sim.Real
+	}
+	return sim.OrReal(r.Replica.Clock)
+}
+
 // resetElectionTimer resets the election timer with a randomized timeout.
 func (r *Replica) resetElectionTimer() {
-	timeout := time.Duration(300+rand.Intn(200)) * time.Millisecond
+	timeout := time.Duration(300+r.clock().Int63n(200)) * time.Millisecond
 	r.electionTimer.Reset(timeout)
 }
 
 // becomeFollower transitions to follower state for a new term.
 func (r *Replica) becomeFollower(term int32) {
+	r.abortConfChanges()
 	r.currentTerm = term
 	r.role = FOLLOWER
 	r.votedFor = -1
@@ -301,6 +407,7 @@ func (r *Replica) becomeFollower(term int32) {
 func (r *Replica) becomeLeader() {
 	r.role = LEADER
 	r.knownLeader = r.id
+	r.transferred(r.id)
 	r.println("Became Raft leader at term", r.currentTerm)
 
 	lastLogIndex := int32(len(r.log) - 1)
@@ -333,14 +440,19 @@ const maxBatchSize = 256
 // --- handlePropose: Batch proposals, append to log, broadcast AppendEntries ---
 
 func (r *Replica) handlePropose(propose *defs.GPropose) {
-	if r.role != LEADER {
+	if r.role != LEADER || r.transfer != nil {
 		// Reject: only leader accepts proposals, hint the known leader
+		// (or the next one during a leadership transfer)
+		leader := r.knownLeader
+		if r.transfer != nil {
+			leader = r.transfer.target
+		}
 		preply := &defs.ProposeReplyTS{
 			OK:        defs.FALSE,
 			CommandId: propose.CommandId,
 			Value:     state.NIL(),
 			Timestamp: propose.Timestamp,
-			LeaderId:  r.knownLeader,
+			LeaderId:  leader,
 		}
 		r.ReplyProposeTSDelayed(preply, propose.Reply, propose.Mutex, propose.ClientId)
 		return
@@ -412,7 +524,7 @@ func (r *Replica) broadcastAppendEntries() {
 	commitIdx := r.commitIndex
 
 	for i := int32(0); i < int32(r.n); i++ {
-		if i == r.id {
+		if i == r.id || !r.followsLog(i) {
 			continue
 		}
 		nextIdx := r.nextIndex[i]
@@ -452,25 +564,22 @@ func (r *Replica) broadcastAppendEntries() {
 	}
 	r.logMu.Unlock()
 
-	// Batched synchronous send: one lock acquisition for all writes + flush.
-	r.M.Lock()
+	// Synchronous send under the lock of each peer, which the Sender and
+	// the beacons to the peer also take.
 	for i := int32(0); i < int32(r.n); i++ {
 		if i == r.id || msgs[i] == nil {
 			continue
 		}
+		r.PeerMu[i].Lock()
+		r.M.Lock()
 		w := r.PeerWriters[i]
-		if w == nil {
-			continue
-		}
-		w.WriteByte(r.cs.appendEntriesRPC)
-		msgs[i].Marshal(w)
-	}
-	for _, w := range r.PeerWriters {
+		r.M.Unlock()
 		if w != nil {
+			fastrpc.WriteFrame(w, r.cs.appendEntriesRPC, msgs[i])
 			w.Flush()
 		}
+		r.PeerMu[i].Unlock()
 	}
-	r.M.Unlock()
 }
 
 // sendAppendEntries sends an AppendEntries RPC to a specific follower
@@ -548,6 +657,7 @@ func (r *Replica) handleAppendEntries(msg *AppendEntries) {
 
 	// Track the leader for client failover hints
 	r.knownLeader = msg.LeaderId
+	r.transferred(msg.LeaderId)
 
 	// Log consistency check, append, and commit under logMu for executeCommands safety.
 	r.logMu.Lock()
@@ -567,7 +677,7 @@ func (r *Replica) handleAppendEntries(msg *AppendEntries) {
 		}
 		if r.log[msg.PrevLogIndex].Term != msg.PrevLogTerm {
 			// Term mismatch: delete this entry and all that follow (§5.3)
-			r.log = r.log[:msg.PrevLogIndex]
+			r.truncateLog(msg.PrevLogIndex)
 			matchIdx := int32(len(r.log) - 1)
 			r.logMu.Unlock()
 			reply := r.appendEntriesReplyCache.Get()
@@ -587,7 +697,7 @@ func (r *Replica) handleAppendEntries(msg *AppendEntries) {
 		if logIdx < int32(len(r.log)) {
 			if r.log[logIdx].Term != msg.Term {
 				// Conflict: truncate from here
-				r.log = r.log[:logIdx]
+				r.truncateLog(logIdx)
 			} else {
 				continue // already have this entry
 			}
@@ -601,6 +711,9 @@ func (r *Replica) handleAppendEntries(msg *AppendEntries) {
 			entry.CmdId = msg.EntryIds[i]
 		}
 		r.log = append(r.log, entry)
+		if isConf(&entry) {
+			r.loadConf()
+		}
 	}
 
 	// Advance commitIndex if leader's commit is ahead
@@ -651,6 +764,7 @@ func (r *Replica) handleAppendEntriesReply(msg *AppendEntriesReply) {
 		}
 		// Try to advance commitIndex
 		r.advanceCommitIndex()
+		r.tryTransfer()
 	} else {
 		// Decrement nextIndex and retry
 		if msg.MatchIndex >= 0 {
@@ -676,6 +790,7 @@ func (r *Replica) handleAppendEntriesReplyBatch(first *AppendEntriesReply) {
 done:
 	if r.role == LEADER {
 		r.advanceCommitIndex()
+		r.tryTransfer()
 	}
 }
 
@@ -717,7 +832,7 @@ func (r *Replica) advanceCommitIndex() {
 		}
 		count := 0
 		for i := 0; i < r.n; i++ {
-			if r.matchIndex[i] >= candidate {
+			if r.isMember(int32(i)) && r.matchIndex[i] >= candidate {
 				count++
 			}
 		}
@@ -732,12 +847,18 @@ func (r *Replica) advanceCommitIndex() {
 
 	if advanced {
 		r.notifyCommit()
+		r.confCommitted()
 	}
 }
 
 // --- handleRequestVote: Grant vote if term higher + log up-to-date ---
 
 func (r *Replica) handleRequestVote(msg *RequestVote) {
+	// Ignore removed replicas, which do not hear from the leader anymore
+	if !r.isMember(msg.CandidateId) {
+		return
+	}
+
 	// If candidate's term is stale, reject
 	if msg.Term < r.currentTerm {
 		reply := r.requestVoteReplyCache.Get()
@@ -795,7 +916,7 @@ func (r *Replica) handleRequestVoteReply(msg *RequestVoteReply) {
 		return
 	}
 
-	if msg.VoteGranted == 1 {
+	if msg.VoteGranted == 1 && r.isMember(msg.VoterId) {
 		r.votesReceived++
 		if r.votesReceived >= r.votesNeeded {
 			r.becomeLeader()
@@ -812,6 +933,10 @@ func (r *Replica) startElection() {
 	r.votesReceived = 1 // vote for self
 
 	r.println("Starting election for term", r.currentTerm)
+	if r.votesReceived >= r.votesNeeded {
+		r.becomeLeader()
+		return
+	}
 
 	lastLogIndex := int32(len(r.log) - 1)
 	lastLogTerm := int32(0)
@@ -820,7 +945,7 @@ func (r *Replica) startElection() {
 	}
 
 	for i := int32(0); i < int32(r.n); i++ {
-		if i == r.id {
+		if i == r.id || !r.isMember(i) {
 			continue
 		}
 		rv := r.requestVoteCache.Get()
@@ -2,6 +2,7 @@ package raft
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"net"
@@ -11,6 +12,7 @@ import (
 	"github.com/imdea-software/swiftpaxos/config"
 	"github.com/imdea-software/swiftpaxos/dlog"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
 	"github.com/imdea-software/swiftpaxos/state"
 )
 
@@ -179,26 +181,34 @@ func sendCommandOnConn(t *testing.T, writer *bufio.Writer, reader *bufio.Reader,
 		Timestamp: time.Now().UnixNano(),
 	}
 
-	writer.WriteByte(defs.PROPOSE)
-	propose.Marshal(writer)
+	fastrpc.WriteFrame(writer, fastrpc.Code(defs.PROPOSE), propose)
 	if err := writer.Flush(); err != nil {
 		t.Fatalf("Failed to send propose: %v", err)
 	}
 
 	reply := &defs.ProposeReplyTS{}
-	if err := reply.Unmarshal(reader); err != nil {
+	f, err := fastrpc.ReadFrame(reader, nil)
+	if err == nil {
+		err = fastrpc.UnmarshalFrame(bytes.NewReader(f), reply)
+	}
+	if err != nil {
 		t.Fatalf("Failed to read reply: %v", err)
 	}
 	return reply
 }
 
-// connectToReplica opens a TCP connection and returns buffered reader/writer.
+// connectToReplica opens a TCP connection, runs the handshake and returns
+// buffered reader/writer.
 func connectToReplica(t *testing.T, addr string) (net.Conn, *bufio.Writer, *bufio.Reader) {
 	t.Helper()
 	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
 	if err != nil {
 		t.Fatalf("Failed to connect to %s: %v", addr, err)
 	}
+	hello := &defs.Hello{Version: defs.WireVersion, Role: defs.RoleClient}
+	if _, err := defs.Handshake(conn, hello, 30*time.Second); err != nil {
+		t.Fatalf("Failed to connect to %s: %v", addr, err)
+	}
 	conn.SetDeadline(time.Now().Add(30 * time.Second))
 	return conn, bufio.NewWriter(conn), bufio.NewReader(conn)
 }
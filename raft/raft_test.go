@@ -7,6 +7,7 @@ import (
 
 	"github.com/imdea-software/swiftpaxos/replica/defs"
 	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/sim"
 	"github.com/imdea-software/swiftpaxos/state"
 )
 
@@ -655,7 +656,7 @@ func TestInitCs(t *testing.T) {
 	}
 
 	// Check all RPC IDs are distinct
-	ids := map[uint8]string{
+	ids := map[fastrpc.Code]string{
 		cs.appendEntriesRPC:      "appendEntries",
 		cs.appendEntriesReplyRPC: "appendEntriesReply",
 		cs.requestVoteRPC:        "requestVote",
@@ -2114,8 +2115,8 @@ func TestExecuteCommands_WakesOnNotify(t *testing.T) {
 // TestBecomeLeader_TimerManagement verifies heartbeat timer starts on leader transition
 func TestBecomeLeader_TimerManagement(t *testing.T) {
 	r := newTestReplica(0, 3)
-	r.electionTimer = time.NewTimer(time.Hour)
-	r.heartbeatTimer = time.NewTimer(time.Hour)
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
+	r.heartbeatTimer = sim.Real.NewTimer(time.Hour)
 	r.heartbeatTimer.Stop()
 	r.heartbeatTimeout = 100 * time.Millisecond
 
@@ -2134,9 +2135,9 @@ func TestBecomeLeader_TimerManagement(t *testing.T) {
 func TestBecomeFollower_TimerManagement(t *testing.T) {
 	r := newTestReplica(0, 3)
 	r.role = LEADER
-	r.electionTimer = time.NewTimer(time.Hour)
+	r.electionTimer = sim.Real.NewTimer(time.Hour)
 	r.electionTimer.Stop()
-	r.heartbeatTimer = time.NewTimer(time.Hour)
+	r.heartbeatTimer = sim.Real.NewTimer(time.Hour)
 
 	r.becomeFollower(5)
 
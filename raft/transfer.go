@@ -0,0 +1,136 @@
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+)
+
+// Leadership transfer
+//
+// The master moves the leadership to a replica, the target, through the
+// leader (Ongaro's dissertation, §3.10). The leader stops taking proposals
+// and membership changes, brings the log of the target up to date, then
+// sends it a TimeoutNow, upon which the target starts an election at once:
+// its log being as up to date as the one of the leader, it wins unless
+// another replica times out first. The transfer ends when the former leader
+// hears from the target as leader, and fails after transferTimeout, the
+// leader then taking proposals again if it still leads.
+
+const transferTimeout = 2 * time.Second
+
+// transfer is a request of the master, handled by the event loop.
+type transfer struct {
+	target   int32
+	reply    *defs.TransferLeaderReply
+	deadline time.Time
+	sent     bool // TimeoutNow sent to the target
+	done     chan error
+}
+
+// TransferLeader is called by the master via RPC to make args.Target the
+// leader. It returns once the target is the leader.
+func (r *Replica) TransferLeader(args *defs.TransferLeaderArgs, reply *defs.TransferLeaderReply) error {
+	t := &transfer{
+		target: args.Target,
+		reply:  reply,
+		done:   make(chan error, 1),
+	}
+	r.transferChan <- t
+	return <-t.done
+}
+
+// handleTransfer starts the transfer t.
+func (r *Replica) handleTransfer(t *transfer) {
+	if t.target < 0 || t.target >= int32(r.n) || !r.isMember(t.target) {
+		t.done <- fmt.Errorf("replica %d is not a member", t.target)
+		return
+	}
+	if r.role != LEADER {
+		t.done <- fmt.Errorf("replica %d is not the leader (leader: %d)", r.id, r.knownLeader)
+		return
+	}
+	if t.target == r.id {
+		t.reply.Leader = r.id
+		t.done <- nil
+		return
+	}
+	if r.transfer != nil {
+		t.done <- errors.New("a leader transfer is in progress")
+		return
+	}
+	t.deadline = r.clock().Now().Add(transferTimeout)
+	r.transfer = t
+	r.println("Transferring the leadership to", t.target, "at term", r.currentTerm)
+	r.sendAppendEntries(t.target)
+	r.tryTransfer()
+}
+
+// tryTransfer sends a TimeoutNow to the target of the transfer in progress
+// once its log is up to date.
+func (r *Replica) tryTransfer() {
+	t := r.transfer
+	if t == nil || t.sent || r.role != LEADER {
+		return
+	}
+	r.logMu.Lock()
+	lastLogIndex := int32(len(r.log) - 1)
+	r.logMu.Unlock()
+	if r.matchIndex[t.target] < lastLogIndex {
+		return
+	}
+	t.sent = true
+	tn := &TimeoutNow{
+		LeaderId: r.id,
+		Term:     r.currentTerm,
+	}
+	r.sender.SendTo(t.target, tn, r.cs.timeoutNowRPC)
+}
+
+// handleTimeoutNow starts an election at once, at the request of the
+// leader.
+func (r *Replica) handleTimeoutNow(msg *TimeoutNow) {
+	if msg.Term < r.currentTerm || r.role == LEADER || !r.isMember(r.id) {
+		return
+	}
+	r.println("Leadership transferred by", msg.LeaderId, "at term", msg.Term)
+	r.startElection()
+	if r.electionTimer != nil && r.role != LEADER {
+		r.resetElectionTimer()
+	}
+}
+
+// transferred ends the transfer in progress once leader is known to lead.
+func (r *Replica) transferred(leader int32) {
+	t := r.transfer
+	if t == nil {
+		return
+	}
+	if leader != t.target {
+		r.failTransfer(fmt.Errorf("replica %d was elected instead of %d", leader, t.target))
+		return
+	}
+	r.transfer = nil
+	r.println("Leadership transferred to", leader)
+	t.reply.Leader = leader
+	t.done <- nil
+}
+
+// failTransfer ends the transfer in progress with err.
+func (r *Replica) failTransfer(err error) {
+	if r.transfer == nil {
+		return
+	}
+	r.println("Leader transfer to", r.transfer.target, "failed:", err)
+	r.transfer.done <- err
+	r.transfer = nil
+}
+
+// checkTransfer fails the transfer in progress after transferTimeout.
+func (r *Replica) checkTransfer() {
+	if r.transfer != nil && r.clock().Now().After(r.transfer.deadline) {
+		r.failTransfer(fmt.Errorf("replica %d did not become the leader in %v", r.transfer.target, transferTimeout))
+	}
+}
@@ -0,0 +1,109 @@
+package raft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+)
+
+func newTestTransfer(target int32) *transfer {
+	return &transfer{
+		target: target,
+		reply:  &defs.TransferLeaderReply{Leader: -1},
+		done:   make(chan error, 1),
+	}
+}
+
+func TestTransferLeaderWaitsForTarget(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, true})
+	r.role = LEADER
+	r.currentTerm = 1
+	r.log = []LogEntry{{Term: 1}, {Term: 1}}
+	r.matchIndex[0] = 1
+
+	tr := newTestTransfer(1)
+	r.handleTransfer(tr)
+	if r.transfer != tr || tr.sent {
+		t.Fatalf("transfer %v, TimeoutNow sent %v before the target is up to date", r.transfer, tr.sent)
+	}
+
+	// no membership change during a transfer
+	c := newTestChange(&defs.MembershipArgs{Add: []int32{2}})
+	r.handleConfChange(c)
+	if err := <-c.done; err == nil {
+		t.Error("membership change accepted during a transfer")
+	}
+
+	r.handleAppendEntriesReplyBatch(&AppendEntriesReply{FollowerId: 1, Term: 1, Success: 1, MatchIndex: 1})
+	if !tr.sent {
+		t.Fatal("no TimeoutNow once the target is up to date")
+	}
+
+	// the target wins the election and becomes the leader
+	r.handleRequestVote(&RequestVote{CandidateId: 1, Term: 2, LastLogIndex: 1, LastLogTerm: 1})
+	if r.role != FOLLOWER {
+		t.Fatalf("role %d after the election of the target", r.role)
+	}
+	r.handleAppendEntries(&AppendEntries{LeaderId: 1, Term: 2, PrevLogIndex: 1, PrevLogTerm: 1, LeaderCommit: -1})
+	if err := <-tr.done; err != nil || tr.reply.Leader != 1 {
+		t.Errorf("transfer: %v, leader %d", err, tr.reply.Leader)
+	}
+	if r.transfer != nil {
+		t.Error("transfer still in progress")
+	}
+}
+
+func TestTransferLeaderRejected(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, false})
+	r.knownLeader = 1
+	for _, target := range []int32{1, 2, 3} {
+		tr := newTestTransfer(target)
+		r.handleTransfer(tr)
+		if err := <-tr.done; err == nil {
+			t.Errorf("transfer to %d accepted", target)
+		}
+	}
+
+	// the leader is already the target
+	r.role = LEADER
+	tr := newTestTransfer(0)
+	r.handleTransfer(tr)
+	if err := <-tr.done; err != nil || tr.reply.Leader != 0 {
+		t.Errorf("transfer to the leader: %v, leader %d", err, tr.reply.Leader)
+	}
+}
+
+func TestTransferLeaderTimeout(t *testing.T) {
+	r := newTestMember(0, []bool{true, true, true})
+	r.role = LEADER
+	tr := newTestTransfer(2)
+	r.handleTransfer(tr)
+
+	r.checkTransfer()
+	if r.transfer == nil {
+		t.Fatal("transfer failed before its deadline")
+	}
+	tr.deadline = time.Now().Add(-time.Second)
+	r.checkTransfer()
+	if err := <-tr.done; err == nil {
+		t.Error("transfer did not time out")
+	}
+	if r.transfer != nil {
+		t.Error("transfer still in progress after its timeout")
+	}
+}
+
+func TestTimeoutNowStartsElection(t *testing.T) {
+	r := newTestMember(1, []bool{true, true, true})
+	r.currentTerm = 3
+
+	r.handleTimeoutNow(&TimeoutNow{LeaderId: 0, Term: 2})
+	if r.role != FOLLOWER {
+		t.Fatal("election started by a stale TimeoutNow")
+	}
+	r.handleTimeoutNow(&TimeoutNow{LeaderId: 0, Term: 3})
+	if r.role != CANDIDATE || r.currentTerm != 4 {
+		t.Errorf("role %d, term %d after TimeoutNow", r.role, r.currentTerm)
+	}
+}
@@ -0,0 +1,111 @@
+package replica
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+)
+
+// Status is called via RPC by the admin participant. Protocols with roles,
+// terms or logs override it to report them.
+func (r *Replica) Status(args *defs.StatusArgs, reply *defs.StatusReply) error {
+	reply.Id = r.Id
+	reply.Alias = r.Alias
+	reply.Term = -1
+	reply.Log = -1
+	reply.Commit = -1
+	reply.Applied = -1
+	reply.Stats = r.stats()
+	reply.ProposeQueue = len(r.ProposeChan)
+
+	r.M.Lock()
+	defer r.M.Unlock()
+	reply.ClientQueues = make(map[int32]int, len(r.ClientFastChan))
+	for id, ch := range r.ClientFastChan {
+		reply.ClientQueues[id] = len(ch) + len(r.clientOverflow[id])
+	}
+	reply.Alive = append([]bool(nil), r.Alive...)
+	reply.Ewma = append([]float64(nil), r.Ewma...)
+	reply.Latencies = append([]int64(nil), r.Latencies...)
+	return nil
+}
+
+// stats returns a copy of the counters of Stats, with the message drop
+// counters of the replica.
+func (r *Replica) stats() map[string]int {
+	r.M.Lock()
+	defer r.M.Unlock()
+	m := make(map[string]int, len(r.Stats.M)+4)
+	for k, v := range r.Stats.M {
+		m[k] = v
+	}
+	m["clientMsgDrops"] = int(atomic.LoadInt64(&r.ClientMsgDrops))
+	m["clientBusyReplies"] = int(atomic.LoadInt64(&r.ClientBusyReplies))
+	m["corruptPeerFrames"] = int(atomic.LoadInt64(&r.CorruptPeerFrames))
+	m["corruptClientFrames"] = int(atomic.LoadInt64(&r.CorruptClientFrames))
+	return m
+}
+
+// GetValue is called via RPC by the admin participant to read a key of the
+// state machine of the replica.
+func (r *Replica) GetValue(args *defs.GetValueArgs, reply *defs.GetValueReply) error {
+	reply.Value, reply.Found = r.State.Get(args.Key)
+	return nil
+}
+
+// Snapshot is called via RPC by the admin participant to write the state
+// machine of the replica, as applied so far, to a file of its snapshot
+// directory (see state.State.Save).
+func (r *Replica) Snapshot(args *defs.SnapshotArgs, reply *defs.SnapshotReply) error {
+	path, err := r.snapshotPath(args.Path)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	n, err := r.State.Save(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		return err
+	}
+	r.Println("Snapshot of", n, "keys written to", path)
+	reply.Path = path
+	reply.Keys = n
+	return nil
+}
+
+// snapshotPath returns the absolute path of the snapshot file name, which
+// must be relative to the snapshot directory and stay in it.
+func (r *Replica) snapshotPath(name string) (string, error) {
+	if name == "" {
+		name = r.Alias + ".snapshot"
+	}
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("snapshot path %s is absolute, want a path in the snapshot directory", name)
+	}
+	for _, e := range strings.Split(filepath.ToSlash(name), "/") {
+		if e == ".." {
+			return "", fmt.Errorf("snapshot path %s leaves the snapshot directory", name)
+		}
+	}
+	dir := ""
+	if r.Config != nil {
+		dir = r.Config.SnapshotDir
+	}
+	return filepath.Abs(filepath.Join(dir, name))
+}
@@ -0,0 +1,58 @@
+package defs
+
+import "github.com/imdea-software/swiftpaxos/state"
+
+// Replica RPCs of the admin participant
+
+// StatusArgs are the arguments of Replica.Status.
+type StatusArgs struct{}
+
+// StatusReply describes the state of a replica. Protocols without roles,
+// terms or logs leave Role empty and Term, Log, Commit and Applied at -1.
+type StatusReply struct {
+	Id      int32
+	Alias   string
+	Role    string // leader, follower, candidate, spare...
+	Term    int32  // term or ballot
+	Log     int32  // last index, slot or instance in the log
+	Commit  int32  // last committed index, slot or instance
+	Applied int32  // last applied index, slot or instance
+	// Counters of Stats and message drop counters. Protocols with a fast
+	// and a slow path count their commands in "fast" and "slow".
+	Stats map[string]int
+	// Proposals waiting in ProposeChan and replies waiting to be sent, by
+	// client
+	ProposeQueue int
+	ClientQueues map[int32]int
+	// Peers seen alive by the replica, with the moving average of their
+	// beacon round trips and the sum of the round trips measured by
+	// ComputeClosestPeers, in nanoseconds (MaxInt64: unreachable)
+	Alive     []bool
+	Ewma      []float64
+	Latencies []int64
+}
+
+// GetValueArgs are the arguments of Replica.GetValue.
+type GetValueArgs struct {
+	Key state.Key
+}
+
+// GetValueReply returns the value of a key in the state machine of a
+// replica.
+type GetValueReply struct {
+	Value state.Value
+	Found bool
+}
+
+// SnapshotArgs are the arguments of Replica.Snapshot: the file to write,
+// relative to the snapshot directory of the replica (default:
+// ALIAS.snapshot).
+type SnapshotArgs struct {
+	Path string
+}
+
+// SnapshotReply returns the file written and its number of keys.
+type SnapshotReply struct {
+	Path string
+	Keys int
+}
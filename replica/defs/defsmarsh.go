@@ -0,0 +1,752 @@
+// Code generated by marshalgen; DO NOT EDIT.
+
+package defs
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+func (t *Propose) New() fastrpc.Serializable {
+	return new(Propose)
+}
+
+func (t *Propose) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type ProposeCache struct {
+	mu    sync.Mutex
+	cache []*Propose
+}
+
+func NewProposeCache() *ProposeCache {
+	c := &ProposeCache{}
+	c.cache = make([]*Propose, 0)
+	return c
+}
+
+func (p *ProposeCache) Get() *Propose {
+	var t *Propose
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Propose{}
+	}
+	return t
+}
+
+func (p *ProposeCache) Put(t *Propose) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Propose) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CommandId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.ClientId))
+	wire.Write(b[:8])
+	t.Command.Marshal(wire)
+	binary.LittleEndian.PutUint64(b[0:], uint64(t.Timestamp))
+	wire.Write(b[:8])
+}
+
+func (t *Propose) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.CommandId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.ClientId = int32(binary.LittleEndian.Uint32(b[4:]))
+	if err := t.Command.Unmarshal(wire); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Timestamp = int64(binary.LittleEndian.Uint64(b[0:]))
+	return nil
+}
+
+func (t *ProposeReply) New() fastrpc.Serializable {
+	return new(ProposeReply)
+}
+
+func (t *ProposeReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 5, true
+}
+
+type ProposeReplyCache struct {
+	mu    sync.Mutex
+	cache []*ProposeReply
+}
+
+func NewProposeReplyCache() *ProposeReplyCache {
+	c := &ProposeReplyCache{}
+	c.cache = make([]*ProposeReply, 0)
+	return c
+}
+
+func (p *ProposeReplyCache) Get() *ProposeReply {
+	var t *ProposeReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &ProposeReply{}
+	}
+	return t
+}
+
+func (p *ProposeReplyCache) Put(t *ProposeReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *ProposeReply) Marshal(wire io.Writer) {
+	var b [5]byte
+	b[0] = byte(t.OK)
+	binary.LittleEndian.PutUint32(b[1:], uint32(t.CommandId))
+	wire.Write(b[:5])
+}
+
+func (t *ProposeReply) Unmarshal(wire io.Reader) error {
+	var b [5]byte
+	if _, err := io.ReadFull(wire, b[:5]); err != nil {
+		return err
+	}
+	t.OK = b[0]
+	t.CommandId = int32(binary.LittleEndian.Uint32(b[1:]))
+	return nil
+}
+
+func (t *ProposeReplyTS) New() fastrpc.Serializable {
+	return new(ProposeReplyTS)
+}
+
+func (t *ProposeReplyTS) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type ProposeReplyTSCache struct {
+	mu    sync.Mutex
+	cache []*ProposeReplyTS
+}
+
+func NewProposeReplyTSCache() *ProposeReplyTSCache {
+	c := &ProposeReplyTSCache{}
+	c.cache = make([]*ProposeReplyTS, 0)
+	return c
+}
+
+func (p *ProposeReplyTSCache) Get() *ProposeReplyTS {
+	var t *ProposeReplyTS
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &ProposeReplyTS{}
+	}
+	return t
+}
+
+func (p *ProposeReplyTSCache) Put(t *ProposeReplyTS) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *ProposeReplyTS) Marshal(wire io.Writer) {
+	var b [16]byte
+	b[0] = byte(t.OK)
+	binary.LittleEndian.PutUint32(b[1:], uint32(t.CommandId))
+	wire.Write(b[:5])
+	t.Value.Marshal(wire)
+	binary.LittleEndian.PutUint64(b[0:], uint64(t.Timestamp))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.LeaderId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.LogIndex))
+	wire.Write(b[:16])
+}
+
+func (t *ProposeReplyTS) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:5]); err != nil {
+		return err
+	}
+	t.OK = b[0]
+	t.CommandId = int32(binary.LittleEndian.Uint32(b[1:]))
+	if err := t.Value.Unmarshal(wire); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Timestamp = int64(binary.LittleEndian.Uint64(b[0:]))
+	t.LeaderId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.LogIndex = int32(binary.LittleEndian.Uint32(b[12:]))
+	return nil
+}
+
+func (t *Read) New() fastrpc.Serializable {
+	return new(Read)
+}
+
+func (t *Read) BinarySize() (nbytes int, sizeKnown bool) {
+	return 12, true
+}
+
+type ReadCache struct {
+	mu    sync.Mutex
+	cache []*Read
+}
+
+func NewReadCache() *ReadCache {
+	c := &ReadCache{}
+	c.cache = make([]*Read, 0)
+	return c
+}
+
+func (p *ReadCache) Get() *Read {
+	var t *Read
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Read{}
+	}
+	return t
+}
+
+func (p *ReadCache) Put(t *Read) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Read) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CommandId))
+	binary.LittleEndian.PutUint64(b[4:], uint64(t.Key))
+	wire.Write(b[:12])
+}
+
+func (t *Read) Unmarshal(wire io.Reader) error {
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.CommandId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Key = state.Key(binary.LittleEndian.Uint64(b[4:]))
+	return nil
+}
+
+func (t *ReadReply) New() fastrpc.Serializable {
+	return new(ReadReply)
+}
+
+func (t *ReadReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type ReadReplyCache struct {
+	mu    sync.Mutex
+	cache []*ReadReply
+}
+
+func NewReadReplyCache() *ReadReplyCache {
+	c := &ReadReplyCache{}
+	c.cache = make([]*ReadReply, 0)
+	return c
+}
+
+func (p *ReadReplyCache) Get() *ReadReply {
+	var t *ReadReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &ReadReply{}
+	}
+	return t
+}
+
+func (p *ReadReplyCache) Put(t *ReadReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *ReadReply) Marshal(wire io.Writer) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CommandId))
+	wire.Write(b[:4])
+	t.Value.Marshal(wire)
+}
+
+func (t *ReadReply) Unmarshal(wire io.Reader) error {
+	var b [4]byte
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
+		return err
+	}
+	t.CommandId = int32(binary.LittleEndian.Uint32(b[0:]))
+	if err := t.Value.Unmarshal(wire); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *ProposeAndRead) New() fastrpc.Serializable {
+	return new(ProposeAndRead)
+}
+
+func (t *ProposeAndRead) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type ProposeAndReadCache struct {
+	mu    sync.Mutex
+	cache []*ProposeAndRead
+}
+
+func NewProposeAndReadCache() *ProposeAndReadCache {
+	c := &ProposeAndReadCache{}
+	c.cache = make([]*ProposeAndRead, 0)
+	return c
+}
+
+func (p *ProposeAndReadCache) Get() *ProposeAndRead {
+	var t *ProposeAndRead
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &ProposeAndRead{}
+	}
+	return t
+}
+
+func (p *ProposeAndReadCache) Put(t *ProposeAndRead) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *ProposeAndRead) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CommandId))
+	wire.Write(b[:4])
+	t.Command.Marshal(wire)
+	binary.LittleEndian.PutUint64(b[0:], uint64(t.Key))
+	wire.Write(b[:8])
+}
+
+func (t *ProposeAndRead) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:4]); err != nil {
+		return err
+	}
+	t.CommandId = int32(binary.LittleEndian.Uint32(b[0:]))
+	if err := t.Command.Unmarshal(wire); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Key = state.Key(binary.LittleEndian.Uint64(b[0:]))
+	return nil
+}
+
+func (t *ProposeAndReadReply) New() fastrpc.Serializable {
+	return new(ProposeAndReadReply)
+}
+
+func (t *ProposeAndReadReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type ProposeAndReadReplyCache struct {
+	mu    sync.Mutex
+	cache []*ProposeAndReadReply
+}
+
+func NewProposeAndReadReplyCache() *ProposeAndReadReplyCache {
+	c := &ProposeAndReadReplyCache{}
+	c.cache = make([]*ProposeAndReadReply, 0)
+	return c
+}
+
+func (p *ProposeAndReadReplyCache) Get() *ProposeAndReadReply {
+	var t *ProposeAndReadReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &ProposeAndReadReply{}
+	}
+	return t
+}
+
+func (p *ProposeAndReadReplyCache) Put(t *ProposeAndReadReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *ProposeAndReadReply) Marshal(wire io.Writer) {
+	var b [5]byte
+	b[0] = byte(t.OK)
+	binary.LittleEndian.PutUint32(b[1:], uint32(t.CommandId))
+	wire.Write(b[:5])
+	t.Value.Marshal(wire)
+}
+
+func (t *ProposeAndReadReply) Unmarshal(wire io.Reader) error {
+	var b [5]byte
+	if _, err := io.ReadFull(wire, b[:5]); err != nil {
+		return err
+	}
+	t.OK = b[0]
+	t.CommandId = int32(binary.LittleEndian.Uint32(b[1:]))
+	if err := t.Value.Unmarshal(wire); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *Beacon) New() fastrpc.Serializable {
+	return new(Beacon)
+}
+
+func (t *Beacon) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type BeaconCache struct {
+	mu    sync.Mutex
+	cache []*Beacon
+}
+
+func NewBeaconCache() *BeaconCache {
+	c := &BeaconCache{}
+	c.cache = make([]*Beacon, 0)
+	return c
+}
+
+func (p *BeaconCache) Get() *Beacon {
+	var t *Beacon
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Beacon{}
+	}
+	return t
+}
+
+func (p *BeaconCache) Put(t *Beacon) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Beacon) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[0:], uint64(t.Timestamp))
+	wire.Write(b[:8])
+}
+
+func (t *Beacon) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Timestamp = int64(binary.LittleEndian.Uint64(b[0:]))
+	return nil
+}
+
+func (t *BeaconReply) New() fastrpc.Serializable {
+	return new(BeaconReply)
+}
+
+func (t *BeaconReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type BeaconReplyCache struct {
+	mu    sync.Mutex
+	cache []*BeaconReply
+}
+
+func NewBeaconReplyCache() *BeaconReplyCache {
+	c := &BeaconReplyCache{}
+	c.cache = make([]*BeaconReply, 0)
+	return c
+}
+
+func (p *BeaconReplyCache) Get() *BeaconReply {
+	var t *BeaconReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &BeaconReply{}
+	}
+	return t
+}
+
+func (p *BeaconReplyCache) Put(t *BeaconReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *BeaconReply) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[0:], uint64(t.Timestamp))
+	wire.Write(b[:8])
+}
+
+func (t *BeaconReply) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Timestamp = int64(binary.LittleEndian.Uint64(b[0:]))
+	return nil
+}
+
+func (t *PingArgs) New() fastrpc.Serializable {
+	return new(PingArgs)
+}
+
+func (t *PingArgs) BinarySize() (nbytes int, sizeKnown bool) {
+	return 1, true
+}
+
+type PingArgsCache struct {
+	mu    sync.Mutex
+	cache []*PingArgs
+}
+
+func NewPingArgsCache() *PingArgsCache {
+	c := &PingArgsCache{}
+	c.cache = make([]*PingArgs, 0)
+	return c
+}
+
+func (p *PingArgsCache) Get() *PingArgs {
+	var t *PingArgs
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &PingArgs{}
+	}
+	return t
+}
+
+func (p *PingArgsCache) Put(t *PingArgs) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *PingArgs) Marshal(wire io.Writer) {
+	var b [1]byte
+	b[0] = byte(t.ActAsLeader)
+	wire.Write(b[:1])
+}
+
+func (t *PingArgs) Unmarshal(wire io.Reader) error {
+	var b [1]byte
+	if _, err := io.ReadFull(wire, b[:1]); err != nil {
+		return err
+	}
+	t.ActAsLeader = b[0]
+	return nil
+}
+
+func (t *PingReply) New() fastrpc.Serializable {
+	return new(PingReply)
+}
+
+func (t *PingReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, true
+}
+
+type PingReplyCache struct {
+	mu    sync.Mutex
+	cache []*PingReply
+}
+
+func NewPingReplyCache() *PingReplyCache {
+	c := &PingReplyCache{}
+	c.cache = make([]*PingReply, 0)
+	return c
+}
+
+func (p *PingReplyCache) Get() *PingReply {
+	var t *PingReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &PingReply{}
+	}
+	return t
+}
+
+func (p *PingReplyCache) Put(t *PingReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *PingReply) Marshal(wire io.Writer) {
+}
+
+func (t *PingReply) Unmarshal(wire io.Reader) error {
+	return nil
+}
+
+func (t *BeTheLeaderArgs) New() fastrpc.Serializable {
+	return new(BeTheLeaderArgs)
+}
+
+func (t *BeTheLeaderArgs) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, true
+}
+
+type BeTheLeaderArgsCache struct {
+	mu    sync.Mutex
+	cache []*BeTheLeaderArgs
+}
+
+func NewBeTheLeaderArgsCache() *BeTheLeaderArgsCache {
+	c := &BeTheLeaderArgsCache{}
+	c.cache = make([]*BeTheLeaderArgs, 0)
+	return c
+}
+
+func (p *BeTheLeaderArgsCache) Get() *BeTheLeaderArgs {
+	var t *BeTheLeaderArgs
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &BeTheLeaderArgs{}
+	}
+	return t
+}
+
+func (p *BeTheLeaderArgsCache) Put(t *BeTheLeaderArgs) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *BeTheLeaderArgs) Marshal(wire io.Writer) {
+}
+
+func (t *BeTheLeaderArgs) Unmarshal(wire io.Reader) error {
+	return nil
+}
+
+func (t *BeTheLeaderReply) New() fastrpc.Serializable {
+	return new(BeTheLeaderReply)
+}
+
+func (t *BeTheLeaderReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type BeTheLeaderReplyCache struct {
+	mu    sync.Mutex
+	cache []*BeTheLeaderReply
+}
+
+func NewBeTheLeaderReplyCache() *BeTheLeaderReplyCache {
+	c := &BeTheLeaderReplyCache{}
+	c.cache = make([]*BeTheLeaderReply, 0)
+	return c
+}
+
+func (p *BeTheLeaderReplyCache) Get() *BeTheLeaderReply {
+	var t *BeTheLeaderReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &BeTheLeaderReply{}
+	}
+	return t
+}
+
+func (p *BeTheLeaderReplyCache) Put(t *BeTheLeaderReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *BeTheLeaderReply) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Leader))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.NextLeader))
+	wire.Write(b[:8])
+}
+
+func (t *BeTheLeaderReply) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Leader = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.NextLeader = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
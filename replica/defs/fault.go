@@ -0,0 +1,266 @@
+package defs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoints of a LinkFault other than replica ids
+const (
+	FaultAny     int32 = -1 // every replica
+	FaultClients int32 = -2 // every client
+)
+
+// LinkFault is a fault injected on the messages sent from From to To. Unlike
+// the static delays of the LatencyTable, faults are set at runtime through
+// the master.
+type LinkFault struct {
+	From int32
+	To   int32
+	// Drop every message
+	Down bool
+	// Probability that a message is dropped or delivered twice
+	Drop float64
+	Dup  float64
+	// Each message is delayed by Delay plus a uniform random duration in
+	// [0, Jitter)
+	Delay  time.Duration
+	Jitter time.Duration
+}
+
+// FaultArgs are the arguments of Master.InjectFaults and Replica.SetFaults.
+type FaultArgs struct {
+	// Remove all faults before adding the new ones
+	Heal bool
+	// Cut the links between replicas of different groups. The replicas not
+	// listed form one more group.
+	Partition [][]int32
+	Links     []LinkFault
+}
+
+// FaultReply returns the faults in place.
+type FaultReply struct {
+	Links []LinkFault
+}
+
+func faultEndpoint(id int32) string {
+	switch id {
+	case FaultAny:
+		return "*"
+	case FaultClients:
+		return "clients"
+	}
+	return strconv.Itoa(int(id))
+}
+
+func (f LinkFault) String() string {
+	s := faultEndpoint(f.From) + "->" + faultEndpoint(f.To)
+	if f.Down {
+		s += " down"
+	}
+	if f.Drop > 0 {
+		s += fmt.Sprintf(" drop=%g", f.Drop)
+	}
+	if f.Dup > 0 {
+		s += fmt.Sprintf(" dup=%g", f.Dup)
+	}
+	if f.Delay > 0 {
+		s += fmt.Sprintf(" delay=%v", f.Delay)
+	}
+	if f.Jitter > 0 {
+		s += fmt.Sprintf(" jitter=%v", f.Jitter)
+	}
+	return s
+}
+
+func (f LinkFault) matches(from, to int32) bool {
+	return (f.From == from || (f.From == FaultAny && from >= 0)) &&
+		(f.To == to || (f.To == FaultAny && to >= 0))
+}
+
+// PartitionFaults returns the links to cut between the groups of n replicas.
+func PartitionFaults(groups [][]int32, n int) ([]LinkFault, error) {
+	group := make([]int, n)
+	for i := range group {
+		group[i] = len(groups)
+	}
+	for g, ids := range groups {
+		for _, id := range ids {
+			if id < 0 || int(id) >= n {
+				return nil, fmt.Errorf("invalid replica %d in partition (N=%d)", id, n)
+			}
+			group[id] = g
+		}
+	}
+	var links []LinkFault
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if group[i] != group[j] {
+				links = append(links, LinkFault{From: int32(i), To: int32(j), Down: true})
+			}
+		}
+	}
+	return links, nil
+}
+
+// ParseFaults parses a fault command:
+//
+//	heal
+//	partition 0 1,2
+//	link FROM TO [down] [drop=P] [dup=P] [delay=D] [jitter=D]
+//
+// where FROM and TO are replica ids, "*" for every replica or "clients".
+func ParseFaults(args []string) (*FaultArgs, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing fault command")
+	}
+	switch args[0] {
+	case "heal":
+		return &FaultArgs{Heal: true}, nil
+
+	case "partition":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("partition: missing groups")
+		}
+		fa := &FaultArgs{}
+		for _, g := range args[1:] {
+			var ids []int32
+			for _, s := range strings.Split(g, ",") {
+				id, err := strconv.Atoi(s)
+				if err != nil {
+					return nil, fmt.Errorf("partition: invalid replica %q", s)
+				}
+				ids = append(ids, int32(id))
+			}
+			fa.Partition = append(fa.Partition, ids)
+		}
+		return fa, nil
+
+	case "link":
+		if len(args) < 4 {
+			return nil, fmt.Errorf("link: want FROM TO and at least one fault")
+		}
+		var (
+			f   LinkFault
+			err error
+		)
+		if f.From, err = parseEndpoint(args[1]); err != nil {
+			return nil, err
+		}
+		if f.To, err = parseEndpoint(args[2]); err != nil {
+			return nil, err
+		}
+		for _, a := range args[3:] {
+			if a == "down" {
+				f.Down = true
+				continue
+			}
+			k, v, ok := strings.Cut(a, "=")
+			if !ok {
+				return nil, fmt.Errorf("link: invalid fault %q", a)
+			}
+			switch k {
+			case "drop", "dup":
+				p, err := strconv.ParseFloat(v, 64)
+				if err != nil || p < 0 || p > 1 {
+					return nil, fmt.Errorf("link: invalid probability %q", a)
+				}
+				if k == "drop" {
+					f.Drop = p
+				} else {
+					f.Dup = p
+				}
+			case "delay", "jitter":
+				d, err := time.ParseDuration(v)
+				if err != nil || d < 0 {
+					return nil, fmt.Errorf("link: invalid duration %q", a)
+				}
+				if k == "delay" {
+					f.Delay = d
+				} else {
+					f.Jitter = d
+				}
+			default:
+				return nil, fmt.Errorf("link: unknown fault %q", k)
+			}
+		}
+		return &FaultArgs{Links: []LinkFault{f}}, nil
+	}
+	return nil, fmt.Errorf("unknown fault command %q", args[0])
+}
+
+func parseEndpoint(s string) (int32, error) {
+	switch s {
+	case "*":
+		return FaultAny, nil
+	case "clients":
+		return FaultClients, nil
+	}
+	id, err := strconv.Atoi(s)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid link endpoint %q", s)
+	}
+	return int32(id), nil
+}
+
+// FaultTable holds the faults injected in a replica. The zero value and nil
+// have no faults.
+type FaultTable struct {
+	mu    sync.RWMutex
+	links []LinkFault
+}
+
+// Set replaces the faults of the table.
+func (t *FaultTable) Set(links []LinkFault) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.links = append([]LinkFault(nil), links...)
+}
+
+// Link returns the fault of the messages from from to to. If several faults
+// match, the last one wins.
+func (t *FaultTable) Link(from, to int32) (LinkFault, bool) {
+	if t == nil {
+		return LinkFault{}, false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for i := len(t.links) - 1; i >= 0; i-- {
+		if t.links[i].matches(from, to) {
+			return t.links[i], true
+		}
+	}
+	return LinkFault{}, false
+}
+
+// Down reports whether the link from from to to is cut.
+func (t *FaultTable) Down(from, to int32) bool {
+	f, exists := t.Link(from, to)
+	return exists && f.Down
+}
+
+// Apply returns how many copies of a message from from to to are delivered
+// (0 if it is dropped, 2 if it is duplicated) and after which delay. rnd
+// returns random numbers in [0, n).
+func (t *FaultTable) Apply(from, to int32, rnd func(n int64) int64) (int, time.Duration) {
+	f, exists := t.Link(from, to)
+	if !exists {
+		return 1, 0
+	}
+	const scale = 1 << 20
+	if f.Down || (f.Drop > 0 && rnd(scale) < int64(f.Drop*scale)) {
+		return 0, 0
+	}
+	copies := 1
+	if f.Dup > 0 && rnd(scale) < int64(f.Dup*scale) {
+		copies = 2
+	}
+	delay := f.Delay
+	if f.Jitter > 0 {
+		delay += time.Duration(rnd(int64(f.Jitter)))
+	}
+	return copies, delay
+}
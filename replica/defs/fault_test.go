@@ -0,0 +1,105 @@
+package defs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFaults(t *testing.T) {
+	fa, err := ParseFaults(strings.Fields("link 0 clients drop=0.5 dup=0.1 delay=50ms jitter=10ms"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LinkFault{From: 0, To: FaultClients, Drop: 0.5, Dup: 0.1,
+		Delay: 50 * time.Millisecond, Jitter: 10 * time.Millisecond}
+	if len(fa.Links) != 1 || fa.Links[0] != want {
+		t.Errorf("got %v, want %v", fa.Links, want)
+	}
+
+	fa, err = ParseFaults(strings.Fields("partition 0 1,2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fa.Partition) != 2 || len(fa.Partition[1]) != 2 || fa.Partition[1][1] != 2 {
+		t.Errorf("got partition %v", fa.Partition)
+	}
+
+	if fa, err := ParseFaults([]string{"heal"}); err != nil || !fa.Heal {
+		t.Errorf("heal: got %v, %v", fa, err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"cut 0 1",
+		"partition",
+		"partition 0 x",
+		"link 0 1",
+		"link 0 -1 down",
+		"link 0 1 drop=2",
+		"link 0 1 delay=soon",
+		"link 0 1 slow",
+	} {
+		if _, err := ParseFaults(strings.Fields(bad)); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestPartitionFaults(t *testing.T) {
+	// {0}, {1, 2} and the unlisted {3, 4}
+	links, err := PartitionFaults([][]int32{{0}, {1, 2}}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ft := &FaultTable{}
+	ft.Set(links)
+	cut := map[[2]int32]bool{}
+	for _, l := range links {
+		cut[[2]int32{l.From, l.To}] = true
+	}
+	for _, c := range []struct {
+		from, to int32
+		down     bool
+	}{
+		{0, 1, true}, {1, 0, true}, {1, 2, false}, {2, 3, true},
+		{3, 4, false}, {4, 0, true}, {0, 0, false},
+	} {
+		if ft.Down(c.from, c.to) != c.down {
+			t.Errorf("link %d->%d: down = %v, want %v", c.from, c.to, !c.down, c.down)
+		}
+	}
+
+	if _, err := PartitionFaults([][]int32{{0, 5}}, 5); err == nil {
+		t.Error("expected an error for an unknown replica")
+	}
+}
+
+func TestFaultTableApply(t *testing.T) {
+	var ft *FaultTable
+	if n, d := ft.Apply(0, 1, nil); n != 1 || d != 0 {
+		t.Errorf("nil table: got %d, %v", n, d)
+	}
+
+	ft = &FaultTable{}
+	ft.Set([]LinkFault{
+		{From: FaultAny, To: FaultAny, Delay: time.Millisecond},
+		{From: 1, To: 0, Drop: 1},
+		{From: 2, To: 0, Dup: 1, Delay: 10 * time.Millisecond, Jitter: 5 * time.Millisecond},
+	})
+	rnd := func(n int64) int64 { return n / 2 }
+
+	if n, d := ft.Apply(0, 1, rnd); n != 1 || d != time.Millisecond {
+		t.Errorf("0->1: got %d, %v", n, d)
+	}
+	if n, _ := ft.Apply(1, 0, rnd); n != 0 {
+		t.Errorf("1->0: got %d copies, want 0", n)
+	}
+	if n, d := ft.Apply(2, 0, rnd); n != 2 || d != 12500*time.Microsecond {
+		t.Errorf("2->0: got %d, %v", n, d)
+	}
+	// FaultAny does not match the clients
+	if n, d := ft.Apply(FaultClients, 0, rnd); n != 1 || d != 0 {
+		t.Errorf("clients->0: got %d, %v", n, d)
+	}
+}
@@ -0,0 +1,215 @@
+package defs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+)
+
+// WireVersion is the version of the messages exchanged by replicas and
+// clients. It changes with any incompatible change of their encoding.
+const WireVersion uint32 = 5
+
+// helloMagic starts every Hello, so that other traffic is not mistaken
+// for one
+const helloMagic uint32 = 0x53575058 // "SWPX"
+
+// Roles of the node opening a connection to a replica
+const (
+	RoleReplica uint8 = iota
+	RoleClient
+)
+
+// HandshakeTimeout bounds the exchange of Hello messages between replicas.
+const HandshakeTimeout = 5 * time.Second
+
+// Hello is the first message of every connection to a replica, answered by
+// the Hello of the replica. Connections between nodes of different
+// protocols, wire versions or clusters are rejected.
+type Hello struct {
+	Protocol string
+	Version  uint32
+	Role     uint8
+	// Cluster id, from the config file
+	Cluster string
+	// Replica id, or client id
+	Id int32
+	// Why the handshake is rejected, only in replies
+	Error string
+	// Codes of the messages of the protocol, by name. Clients, which
+	// register their messages after connecting, send none.
+	Messages map[string]fastrpc.Code
+}
+
+func roleName(role uint8) string {
+	switch role {
+	case RoleReplica:
+		return "replica"
+	case RoleClient:
+		return "client"
+	}
+	return fmt.Sprintf("role %d", role)
+}
+
+func (h *Hello) String() string {
+	return fmt.Sprintf("%s %d (protocol %s, wire version %d, cluster %q)",
+		roleName(h.Role), h.Id, h.Protocol, h.Version, h.Cluster)
+}
+
+// Compatible returns why the nodes saying h and other cannot talk, or nil.
+func (h *Hello) Compatible(other *Hello) error {
+	switch {
+	case h.Version != other.Version:
+		return fmt.Errorf("wire version mismatch: local %d, remote %d", h.Version, other.Version)
+	case !strings.EqualFold(h.Protocol, other.Protocol):
+		return fmt.Errorf("protocol mismatch: local %s, remote %s", h.Protocol, other.Protocol)
+	case h.Cluster != other.Cluster:
+		return fmt.Errorf("cluster mismatch: local %q, remote %q", h.Cluster, other.Cluster)
+	case len(h.Messages) > 0 && len(other.Messages) > 0:
+		// replicas register the same messages, clients a part of them
+		all := h.Role == RoleReplica && other.Role == RoleReplica
+		return fastrpc.CompareMessages(h.Messages, other.Messages, all)
+	}
+	return nil
+}
+
+// Marshal writes the HELLO code and h.
+func (h *Hello) Marshal(wire io.Writer) {
+	b := make([]byte, 0, 16+len(h.Protocol)+len(h.Cluster)+len(h.Error))
+	b = append(b, HELLO)
+	b = binary.LittleEndian.AppendUint32(b, helloMagic)
+	b = binary.LittleEndian.AppendUint32(b, h.Version)
+	b = append(b, h.Role)
+	b = binary.LittleEndian.AppendUint32(b, uint32(h.Id))
+	for _, s := range []string{h.Protocol, h.Cluster, h.Error} {
+		b = appendString(b, s)
+	}
+	names := make([]string, 0, len(h.Messages))
+	for name := range h.Messages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(names)))
+	for _, name := range names {
+		b = appendString(b, name)
+		b = binary.LittleEndian.AppendUint16(b, uint16(h.Messages[name]))
+	}
+	wire.Write(b)
+}
+
+func appendString(b []byte, s string) []byte {
+	if len(s) > 0xffff {
+		s = s[:0xffff]
+	}
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func readString(wire io.Reader) (string, error) {
+	var n [2]byte
+	if _, err := io.ReadFull(wire, n[:]); err != nil {
+		return "", err
+	}
+	bs := make([]byte, binary.LittleEndian.Uint16(n[:]))
+	if _, err := io.ReadFull(wire, bs); err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+// Unmarshal reads a HELLO code and a Hello. It reads no more than the
+// message, so wire need not be buffered.
+func (h *Hello) Unmarshal(wire io.Reader) error {
+	var b [13]byte
+	if _, err := io.ReadFull(wire, b[:1]); err != nil {
+		return err
+	}
+	if b[0] != HELLO {
+		return fmt.Errorf("no handshake (first byte %d): the other end runs an older version", b[0])
+	}
+	if _, err := io.ReadFull(wire, b[:]); err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint32(b[0:]) != helloMagic {
+		return errors.New("invalid handshake")
+	}
+	h.Version = binary.LittleEndian.Uint32(b[4:])
+	h.Role = b[8]
+	h.Id = int32(binary.LittleEndian.Uint32(b[9:]))
+	var err error
+	for _, s := range []*string{&h.Protocol, &h.Cluster, &h.Error} {
+		if *s, err = readString(wire); err != nil {
+			return err
+		}
+	}
+	if _, err := io.ReadFull(wire, b[:2]); err != nil {
+		return err
+	}
+	h.Messages = nil
+	for n := binary.LittleEndian.Uint16(b[:2]); n > 0; n-- {
+		name, err := readString(wire)
+		if err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(wire, b[:2]); err != nil {
+			return err
+		}
+		if h.Messages == nil {
+			h.Messages = make(map[string]fastrpc.Code)
+		}
+		h.Messages[name] = fastrpc.Code(binary.LittleEndian.Uint16(b[:2]))
+	}
+	return nil
+}
+
+// Handshake sends hello on conn and returns the Hello of the replica at the
+// other end, or why it rejected the connection. It waits for the reply for
+// at most timeout.
+func Handshake(conn net.Conn, hello *Hello, timeout time.Duration) (*Hello, error) {
+	conn.SetDeadline(time.Now().Add(timeout))
+	defer conn.SetDeadline(time.Time{})
+	hello.Marshal(conn)
+	remote := &Hello{}
+	if err := remote.Unmarshal(conn); err != nil {
+		return nil, fmt.Errorf("handshake: %v", err)
+	}
+	if remote.Error != "" {
+		return remote, fmt.Errorf("handshake rejected by %v: %s", remote, remote.Error)
+	}
+	if err := hello.Compatible(remote); err != nil {
+		return remote, fmt.Errorf("handshake with %v: %v", remote, err)
+	}
+	return remote, nil
+}
+
+// AcceptHandshake reads from r the Hello of the node at the other end of
+// conn, checks it against hello and with check (if not nil), and replies
+// with hello, or with the reason of the rejection.
+func AcceptHandshake(conn net.Conn, r io.Reader, hello *Hello, check func(*Hello) error) (*Hello, error) {
+	conn.SetDeadline(time.Now().Add(HandshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+	remote := &Hello{}
+	if err := remote.Unmarshal(r); err != nil {
+		return nil, fmt.Errorf("handshake: %v", err)
+	}
+	err := hello.Compatible(remote)
+	if err == nil && check != nil {
+		err = check(remote)
+	}
+	reply := *hello
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	reply.Marshal(conn)
+	if err != nil {
+		return remote, fmt.Errorf("rejected handshake of %v: %v", remote, err)
+	}
+	return remote, nil
+}
@@ -0,0 +1,98 @@
+package defs
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+)
+
+func TestHelloMarshal(t *testing.T) {
+	h := Hello{Protocol: "curpht", Version: WireVersion, Role: RoleClient, Cluster: "eu", Id: -7, Error: "no",
+		Messages: map[string]fastrpc.Code{"curp-ht.MReply": 300, "curp-ht.MSync": 7}}
+	var b bytes.Buffer
+	h.Marshal(&b)
+	b.WriteByte(42)
+
+	var got Hello
+	if err := got.Unmarshal(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, h) {
+		t.Errorf("got %+v, want %+v", got, h)
+	}
+	// nothing more is read
+	if b.Len() != 1 {
+		t.Errorf("%d bytes left, want 1", b.Len())
+	}
+
+	if err := got.Unmarshal(bytes.NewReader([]byte{PROPOSE, 0, 0})); err == nil ||
+		!strings.Contains(err.Error(), "older version") {
+		t.Errorf("no handshake: got %v", err)
+	}
+}
+
+// TestHandshakeMismatch verifies that both ends of a handshake between
+// nodes of different protocols fail with the reason.
+func TestHandshakeMismatch(t *testing.T) {
+	local := &Hello{Protocol: "raftht", Version: WireVersion, Role: RoleReplica}
+	remote := &Hello{Protocol: "curpht", Version: WireVersion, Role: RoleClient, Id: 3}
+
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := AcceptHandshake(a, a, local, nil)
+		errs <- err
+	}()
+	if _, err := Handshake(b, remote, time.Second); err == nil || !strings.Contains(err.Error(), "protocol mismatch") {
+		t.Errorf("client: got %v", err)
+	}
+	if err := <-errs; err == nil || !strings.Contains(err.Error(), "protocol mismatch") {
+		t.Errorf("replica: got %v", err)
+	}
+
+	// the check of the acceptor
+	a2, b2 := net.Pipe()
+	defer a2.Close()
+	defer b2.Close()
+	remote.Protocol = "RaftHT"
+	go AcceptHandshake(a2, a2, local, func(h *Hello) error {
+		if h.Id != 3 {
+			t.Errorf("id = %d, want 3", h.Id)
+		}
+		return nil
+	})
+	got, err := Handshake(b2, remote, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Role != RoleReplica || got.Protocol != "raftht" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestHelloCompatibleMessages(t *testing.T) {
+	replica := func(m map[string]fastrpc.Code) *Hello {
+		return &Hello{Version: WireVersion, Role: RoleReplica, Messages: m}
+	}
+	a := replica(map[string]fastrpc.Code{"p.A": 300, "p.B": 400})
+	if err := a.Compatible(replica(map[string]fastrpc.Code{"p.A": 300, "p.B": 401})); err == nil ||
+		!strings.Contains(err.Error(), "p.B (local 400, remote 401)") {
+		t.Errorf("conflict: got %v", err)
+	}
+	if err := a.Compatible(replica(map[string]fastrpc.Code{"p.A": 300})); err == nil ||
+		!strings.Contains(err.Error(), "missing on the remote: p.B") {
+		t.Errorf("missing: got %v", err)
+	}
+	// clients register a part of the messages
+	client := &Hello{Version: WireVersion, Role: RoleClient, Messages: map[string]fastrpc.Code{"p.A": 300}}
+	if err := a.Compatible(client); err != nil {
+		t.Errorf("client: got %v", err)
+	}
+}
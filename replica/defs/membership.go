@@ -0,0 +1,69 @@
+package defs
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MembershipArgs are the arguments of Master.ChangeMembership and
+// Replica.ChangeMembership. The master makes learners of the replicas of
+// Learn, adds the ones of Add (learners or spares), then removes the ones
+// of Remove (members or learners), one at a time; a replica makes one
+// change at most, and only reports the members if there is none.
+type MembershipArgs struct {
+	Add    []int32
+	Remove []int32
+	Learn  []int32
+}
+
+// MembershipReply returns the members and the learners after a change and
+// the leader (-1 if unknown).
+type MembershipReply struct {
+	Members  []int32
+	Learners []int32
+	Leader   int32
+}
+
+// ParseMembership parses a membership command, one of
+//
+//	add ID...
+//	remove ID...
+//	learner ID...
+//	replace OLD NEW
+//
+// Without command, the args only ask for the members.
+func ParseMembership(args []string) (*MembershipArgs, error) {
+	ma := &MembershipArgs{}
+	if len(args) == 0 {
+		return ma, nil
+	}
+	ids := make([]int32, 0, len(args)-1)
+	for _, s := range args[1:] {
+		id, err := strconv.Atoi(s)
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("%s: invalid replica %q", args[0], s)
+		}
+		ids = append(ids, int32(id))
+	}
+	switch args[0] {
+	case "add":
+		ma.Add = ids
+	case "remove":
+		ma.Remove = ids
+	case "learner":
+		ma.Learn = ids
+	case "replace":
+		if len(ids) != 2 {
+			return nil, fmt.Errorf("replace: want OLD NEW")
+		}
+		ma.Add = ids[1:]
+		ma.Remove = ids[:1]
+		return ma, nil
+	default:
+		return nil, fmt.Errorf("unknown membership command %q", args[0])
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%s: missing replicas", args[0])
+	}
+	return ma, nil
+}
@@ -0,0 +1,37 @@
+package defs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMembership(t *testing.T) {
+	ma, err := ParseMembership(strings.Fields("replace 0 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ma.Add) != 1 || ma.Add[0] != 3 || len(ma.Remove) != 1 || ma.Remove[0] != 0 {
+		t.Errorf("replace 0 3 = %+v", ma)
+	}
+
+	ma, err = ParseMembership(strings.Fields("add 3 4"))
+	if err != nil || len(ma.Add) != 2 || ma.Remove != nil {
+		t.Errorf("add 3 4 = %+v, %v", ma, err)
+	}
+
+	ma, err = ParseMembership(strings.Fields("learner 4"))
+	if err != nil || len(ma.Learn) != 1 || ma.Learn[0] != 4 || ma.Add != nil {
+		t.Errorf("learner 4 = %+v, %v", ma, err)
+	}
+
+	ma, err = ParseMembership(nil)
+	if err != nil || len(ma.Add)+len(ma.Remove) != 0 {
+		t.Errorf("query = %+v, %v", ma, err)
+	}
+
+	for _, bad := range []string{"add", "learner", "remove x", "replace 1", "swap 0 1", "add -1"} {
+		if _, err := ParseMembership(strings.Fields(bad)); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package defs
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// TransferLeaderArgs are the arguments of Master.TransferLeader and
+// Replica.TransferLeader: the replica to make the leader.
+type TransferLeaderArgs struct {
+	Target int32
+}
+
+// TransferLeaderReply returns the leader after a transfer. The leader of a
+// ballot-based protocol (paxos, swift) returns -1 once it lets the
+// leadership go, and the master then asks the target to take over.
+type TransferLeaderReply struct {
+	Leader int32
+}
+
+// ParseTransferLeader parses the index of the replica to make the leader.
+func ParseTransferLeader(args []string) (*TransferLeaderArgs, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("leader: want one replica, got %d arguments", len(args))
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id < 0 {
+		return nil, fmt.Errorf("leader: invalid replica %q", args[0])
+	}
+	return &TransferLeaderArgs{Target: int32(id)}, nil
+}
@@ -0,0 +1,18 @@
+package defs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTransferLeader(t *testing.T) {
+	ta, err := ParseTransferLeader([]string{"2"})
+	if err != nil || ta.Target != 2 {
+		t.Errorf("2 = %+v, %v", ta, err)
+	}
+	for _, bad := range []string{"", "x", "-1", "1 2"} {
+		if _, err := ParseTransferLeader(strings.Fields(bad)); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
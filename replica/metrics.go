@@ -0,0 +1,168 @@
+package replica
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+)
+
+// Statuser is a replica that reports its status, which protocols with
+// roles, terms or logs do by overriding Replica.Status.
+type Statuser interface {
+	Status(args *defs.StatusArgs, reply *defs.StatusReply) error
+}
+
+// Handlers returns the HTTP handlers of the status of s, served on the RPC
+// port of the replica next to net/rpc and pprof: /status in JSON and
+// /metrics in the Prometheus text format. There are none without a
+// replica.
+func Handlers(s Statuser) map[string]http.Handler {
+	if s == nil {
+		return nil
+	}
+	return map[string]http.Handler{
+		"/status":  http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { serveStatus(s, w) }),
+		"/metrics": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { serveMetrics(s, w) }),
+	}
+}
+
+func serveStatus(s Statuser, w http.ResponseWriter) {
+	reply := &defs.StatusReply{}
+	if err := s.Status(&defs.StatusArgs{}, reply); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	b, err := json.MarshalIndent(reply, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(b, '\n'))
+}
+
+func serveMetrics(s Statuser, w http.ResponseWriter) {
+	reply := &defs.StatusReply{}
+	if err := s.Status(&defs.StatusArgs{}, reply); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	WriteMetrics(w, reply)
+}
+
+// WriteMetrics writes the status s in the Prometheus text format. Every
+// sample is labelled with the alias of the replica; the positions unknown
+// to the protocol (-1) are left out.
+func WriteMetrics(w io.Writer, s *defs.StatusReply) {
+	m := metricWriter{w: w, replica: strconv.Quote(s.Alias)}
+
+	role := s.Role
+	if role == "" {
+		role = "none"
+	}
+	m.family("swiftpaxos_role", "gauge", "Role of the replica (1 for the current role).")
+	m.sample("swiftpaxos_role", fmt.Sprintf("role=%q", role), 1)
+
+	positions := []struct {
+		name, help string
+		p          int32
+	}{
+		{"swiftpaxos_term", "Term or ballot of the replica.", s.Term},
+		{"swiftpaxos_log_index", "Last index, slot or instance in the log.", s.Log},
+		{"swiftpaxos_commit_index", "Last committed index, slot or instance.", s.Commit},
+		{"swiftpaxos_applied_index", "Last applied index, slot or instance.", s.Applied},
+	}
+	for _, p := range positions {
+		if p.p >= 0 {
+			m.family(p.name, "gauge", p.help)
+			m.sample(p.name, "", float64(p.p))
+		}
+	}
+
+	if fast, ok := s.Stats["fast"]; ok {
+		m.family("swiftpaxos_fast_path_total", "counter", "Commands committed on the fast path.")
+		m.sample("swiftpaxos_fast_path_total", "", float64(fast))
+	}
+	if slow, ok := s.Stats["slow"]; ok {
+		m.family("swiftpaxos_slow_path_total", "counter", "Commands committed on the slow path.")
+		m.sample("swiftpaxos_slow_path_total", "", float64(slow))
+	}
+	m.family("swiftpaxos_client_msg_drops_total", "counter", "Messages dropped because the client is unknown.")
+	m.sample("swiftpaxos_client_msg_drops_total", "", float64(s.Stats["clientMsgDrops"]))
+
+	names := make([]string, 0, len(s.Stats))
+	for name := range s.Stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	m.family("swiftpaxos_stat", "untyped", "Counters of the replica, by name.")
+	for _, name := range names {
+		m.sample("swiftpaxos_stat", fmt.Sprintf("name=%q", name), float64(s.Stats[name]))
+	}
+
+	m.family("swiftpaxos_propose_queue_length", "gauge", "Proposals waiting in ProposeChan.")
+	m.sample("swiftpaxos_propose_queue_length", "", float64(s.ProposeQueue))
+	clients := make([]int32, 0, len(s.ClientQueues))
+	for id := range s.ClientQueues {
+		clients = append(clients, id)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i] < clients[j] })
+	m.family("swiftpaxos_client_queue_length", "gauge", "Replies waiting to be sent to a client.")
+	for _, id := range clients {
+		m.sample("swiftpaxos_client_queue_length", fmt.Sprintf("client=\"%d\"", id), float64(s.ClientQueues[id]))
+	}
+
+	m.family("swiftpaxos_peer_alive", "gauge", "Whether the peer is seen alive.")
+	for i, alive := range s.Alive {
+		if int32(i) == s.Id {
+			continue
+		}
+		v := 0.0
+		if alive {
+			v = 1
+		}
+		m.sample("swiftpaxos_peer_alive", fmt.Sprintf("peer=\"%d\"", i), v)
+	}
+	m.family("swiftpaxos_peer_rtt_seconds", "gauge", "Moving average of the beacon round trips to the peer.")
+	for i, ewma := range s.Ewma {
+		if int32(i) != s.Id {
+			m.sample("swiftpaxos_peer_rtt_seconds", fmt.Sprintf("peer=\"%d\"", i), ewma/1e9)
+		}
+	}
+	m.family("swiftpaxos_peer_probe_rtt_seconds_sum", "counter", "Sum of the round trips measured to the peer when ranking the peers.")
+	for i, lat := range s.Latencies {
+		v := float64(lat) / 1e9
+		if lat == math.MaxInt64 {
+			v = math.Inf(1)
+		}
+		if int32(i) != s.Id {
+			m.sample("swiftpaxos_peer_probe_rtt_seconds_sum", fmt.Sprintf("peer=\"%d\"", i), v)
+		}
+	}
+}
+
+// metricWriter writes the samples of a replica in the Prometheus text
+// format.
+type metricWriter struct {
+	w       io.Writer
+	replica string // quoted alias
+}
+
+func (m metricWriter) family(name, typ, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (m metricWriter) sample(name, labels string, v float64) {
+	if labels != "" {
+		labels = "," + labels
+	}
+	fmt.Fprintf(m.w, "%s{replica=%s%s} %s\n", name, m.replica, labels,
+		strconv.FormatFloat(v, 'g', -1, 64))
+}
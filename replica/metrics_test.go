@@ -0,0 +1,74 @@
+package replica
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/imdea-software/swiftpaxos/replica/defs"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	var b strings.Builder
+	WriteMetrics(&b, &defs.StatusReply{
+		Id:           0,
+		Alias:        "replica0",
+		Role:         "leader",
+		Term:         3,
+		Log:          -1,
+		Commit:       41,
+		Applied:      40,
+		Stats:        map[string]int{"fast": 7, "slow": 2, "clientMsgDrops": 1},
+		ProposeQueue: 5,
+		ClientQueues: map[int32]int{12: 4},
+		Alive:        []bool{true, false},
+		Ewma:         []float64{0, 2e6},
+		Latencies:    []int64{0, math.MaxInt64},
+	})
+	out := b.String()
+	for _, want := range []string{
+		`swiftpaxos_role{replica="replica0",role="leader"} 1`,
+		`swiftpaxos_term{replica="replica0"} 3`,
+		`swiftpaxos_commit_index{replica="replica0"} 41`,
+		`swiftpaxos_applied_index{replica="replica0"} 40`,
+		`swiftpaxos_fast_path_total{replica="replica0"} 7`,
+		`swiftpaxos_slow_path_total{replica="replica0"} 2`,
+		`swiftpaxos_client_msg_drops_total{replica="replica0"} 1`,
+		`swiftpaxos_propose_queue_length{replica="replica0"} 5`,
+		`swiftpaxos_client_queue_length{replica="replica0",client="12"} 4`,
+		`swiftpaxos_peer_alive{replica="replica0",peer="1"} 0`,
+		`swiftpaxos_peer_rtt_seconds{replica="replica0",peer="1"} 0.002`,
+		`swiftpaxos_peer_probe_rtt_seconds_sum{replica="replica0",peer="1"} +Inf`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("no %s in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "swiftpaxos_log_index") {
+		t.Error("unknown log position reported")
+	}
+	if strings.Contains(out, `peer_rtt_seconds{replica="replica0",peer="0"}`) {
+		t.Error("round trip to the replica itself reported")
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	r := New("replica1", 1, 1, []string{"a:1", "b:2", "c:3"}, false, false, false, nil, nil)
+	r.Alive[0] = true
+	h := Handlers(r)["/status"]
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+
+	s := &defs.StatusReply{}
+	if err := json.Unmarshal(w.Body.Bytes(), s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Id != 1 || s.Alias != "replica1" || s.Commit != -1 || len(s.Alive) != 3 || !s.Alive[0] {
+		t.Errorf("status %+v", s)
+	}
+	if Handlers(nil) != nil {
+		t.Error("handlers without a replica")
+	}
+}
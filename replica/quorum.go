@@ -193,13 +193,10 @@ func NewQuorumsFromFile(qfile string, r *Replica) ([]Quorum, []int32, error) {
 			addr = data[1]
 		}
 
-		addr = r.Config.ReplicaAddrs[addr]
-
-		for rid := int32(0); rid < int32(r.N); rid++ {
-			paddr := strings.Split(r.PeerAddrList[rid], ":")[0]
-			if addr == paddr {
-				id = rid
-				break
+		// replicas may share a host
+		if _, exists := r.Config.ReplicaAddrs[addr]; exists {
+			if rid := r.Config.ReplicaIndex(addr); rid < r.N {
+				id = int32(rid)
 			}
 		}
 
@@ -2,9 +2,10 @@ package replica
 
 import (
 	"bufio"
-	"context"
-	"encoding/binary"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"math"
 	"net"
@@ -13,19 +14,20 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
-	"syscall"
 	"time"
 
 	"github.com/imdea-software/swiftpaxos/config"
 	"github.com/imdea-software/swiftpaxos/dlog"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
 	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/sim"
 	"github.com/imdea-software/swiftpaxos/state"
+	"github.com/imdea-software/swiftpaxos/transport"
 )
 
 type ClientSendArg = clientSendArg
 type clientSendArg struct {
-	code uint8
+	code fastrpc.Code
 	msg  fastrpc.Serializable
 }
 
@@ -57,6 +59,8 @@ type Replica struct {
 	StableStore *os.File
 	Stats       *defs.Stats
 	Shutdown    bool
+	Transport   transport.Transport
+	Clock       sim.Clock
 	Listener    net.Listener
 	ProposeChan chan *defs.GPropose
 	BeaconChan  chan *defs.GBeacon
@@ -68,16 +72,42 @@ type Replica struct {
 	Beacon  bool
 	Durable bool
 
+	// Round trips to the peers, in nanoseconds, measured with beacons: their
+	// moving average, kept up to date by probePeers, and their sum over the
+	// beacons of ComputeClosestPeers, which ranks the peers once (MaxInt64:
+	// unreachable then)
 	Ewma      []float64
 	Latencies []int64
 
-	Dt             *defs.LatencyTable
-	clientDropOnce sync.Once
+	probeOnce sync.Once // starts probePeers
+	ranked    bool      // Latencies is final
+
+	listeners    bool           // peer connections have listeners
+	reconnecting map[int32]bool // peers being dialed again
+
+	Dt              *defs.LatencyTable
+	Faults          *defs.FaultTable
+	clientSpillOnce sync.Once
+
+	// Replies queued for a client beyond which its proposals are rejected
+	// (<= 0: no limit)
+	MaxInFlight int
+	// Messages waiting for room in the per-client channels, by client
+	clientOverflow map[int32][]clientSendArg
 
 	// Message drop counter for SendClientMsgFast (Phase 77.1c)
-	ClientMsgDrops int64 // atomic: total messages dropped due to full per-client channel
+	ClientMsgDrops int64 // atomic: total messages dropped because the client is unknown
+	// atomic: total proposals rejected with a "server busy" reply
+	ClientBusyReplies int64
+	// atomic: frames received with a bad checksum or a malformed message,
+	// from peers and from clients; the connection is closed on each of them
+	CorruptPeerFrames   int64
+	CorruptClientFrames int64
 }
 
+// DefaultMaxInFlight is the default MaxInFlight of the replicas.
+const DefaultMaxInFlight = 16384
+
 func New(alias string, id, f int, addrs []string, thrifty, exec, lread bool, config *config.Config, l *dlog.Logger) *Replica {
 	n := len(addrs)
 	r := &Replica{
@@ -103,10 +133,12 @@ func New(alias string, id, f int, addrs []string, thrifty, exec, lread bool, con
 		PreferredPeerOrder: make([]int32, n),
 
 		State:       state.InitState(),
-		RPC:         fastrpc.NewTableId(defs.RPC_TABLE),
+		RPC:         fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE)),
 		StableStore: nil,
 		Stats:       &defs.Stats{M: make(map[string]int)},
 		Shutdown:    false,
+		Transport:   transport.TCP,
+		Clock:       sim.Real,
 		Listener:    nil,
 		ProposeChan: make(chan *defs.GPropose, defs.CHAN_BUFFER_SIZE),
 		BeaconChan:  make(chan *defs.GBeacon, defs.CHAN_BUFFER_SIZE),
@@ -121,7 +153,19 @@ func New(alias string, id, f int, addrs []string, thrifty, exec, lread bool, con
 		Ewma:      make([]float64, n),
 		Latencies: make([]int64, n),
 
-		Dt: defs.NewLatencyTable(defs.LatencyConf, defs.IP(), id, addrs),
+		Dt:     defs.NewLatencyTable(defs.LatencyConf, defs.IP(), id, addrs),
+		Faults: &defs.FaultTable{},
+	}
+
+	if config != nil && config.Transport != nil {
+		r.Transport = config.Transport
+	}
+	if config != nil && config.Clock != nil {
+		r.Clock = config.Clock
+	}
+	r.MaxInFlight = DefaultMaxInFlight
+	if config != nil && config.MaxInFlight != 0 {
+		r.MaxInFlight = config.MaxInFlight
 	}
 
 	for i := 0; i < r.N; i++ {
@@ -137,10 +181,46 @@ func (r *Replica) Ping(args *defs.PingArgs, reply *defs.PingReply) error {
 	return nil
 }
 
+// SetFaults replaces the faults injected in the replica. It is called by
+// the master, which expands partitions into links.
+func (r *Replica) SetFaults(args *defs.FaultArgs, reply *defs.FaultReply) error {
+	if r.Faults == nil {
+		return errors.New("fault injection is not available")
+	}
+	r.Faults.Set(args.Links)
+	reply.Links = args.Links
+	r.Printf("faults set: %v", args.Links)
+	return nil
+}
+
+// faults returns how many copies of a message from from to to are delivered
+// and after which delay, according to the injected faults.
+func (r *Replica) faults(from, to int32) (int, time.Duration) {
+	return r.Faults.Apply(from, to, sim.OrReal(r.Clock).Int63n)
+}
+
+type wireMsg interface {
+	Marshal(io.Writer)
+	Unmarshal(io.Reader) error
+}
+
+// cloneMsg copies msg into obj, for duplicated deliveries.
+func cloneMsg(obj, msg wireMsg) {
+	var b bytes.Buffer
+	msg.Marshal(&b)
+	obj.Unmarshal(&b)
+}
+
 func (r *Replica) BeTheLeader(args *defs.BeTheLeaderArgs, reply *defs.BeTheLeaderReply) error {
 	return nil
 }
 
+// TransferLeader is called by the master via RPC to make args.Target the
+// leader, in the protocols that support it.
+func (r *Replica) TransferLeader(args *defs.TransferLeaderArgs, reply *defs.TransferLeaderReply) error {
+	return errors.New("leader transfer is not supported by this protocol")
+}
+
 func (r *Replica) FastQuorumSize() int {
 	return (3*r.N)/4 + 1
 }
@@ -157,6 +237,27 @@ func (r *Replica) ReadQuorumSize() int {
 	return r.N - r.F
 }
 
+// Discard drops the messages of the RPC table but those of the channels of
+// keep, for the participants that take part in some of the messages of
+// their protocol only, e.g. CURP witnesses.
+func (r *Replica) Discard(keep ...chan fastrpc.Serializable) {
+	drained := make(map[chan fastrpc.Serializable]bool)
+	for _, ch := range keep {
+		drained[ch] = true
+	}
+	for _, code := range r.RPC.Messages() {
+		p, _ := r.RPC.Get(code)
+		if p.Chan == nil || drained[p.Chan] {
+			continue
+		}
+		drained[p.Chan] = true
+		go func(ch chan fastrpc.Serializable) {
+			for range ch {
+			}
+		}(p.Chan)
+	}
+}
+
 // setTCPKeepAlive enables TCP keepalive on a connection so the OS detects
 // dead peers within ~6-10s (3 probes × 2s interval) and delivers EOF to the reader.
 func setTCPKeepAlive(conn net.Conn) {
@@ -166,27 +267,51 @@ func setTCPKeepAlive(conn net.Conn) {
 	}
 }
 
+// hello returns the handshake of the replica (see defs.Hello).
+func (r *Replica) hello() *defs.Hello {
+	h := &defs.Hello{
+		Version: defs.WireVersion,
+		Role:    defs.RoleReplica,
+		Id:      r.Id,
+	}
+	if r.Config != nil {
+		h.Protocol = r.Config.Protocol
+		h.Cluster = r.Config.Cluster
+	}
+	if r.RPC != nil {
+		h.Messages = r.RPC.Messages()
+	}
+	return h
+}
+
+// dialPeer connects to a peer and runs the handshake.
+func (r *Replica) dialPeer(peerId int32, timeout time.Duration) (net.Conn, error) {
+	conn, err := r.Transport.Dial(r.PeerAddrList[peerId], timeout)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := defs.Handshake(conn, r.hello(), defs.HandshakeTimeout); err != nil {
+		r.Printf("OUT Peer %d: %v", peerId, err)
+		conn.Close()
+		return nil, err
+	}
+	setTCPKeepAlive(conn)
+	return conn, nil
+}
+
 func (r *Replica) ConnectToPeers() {
-	var b [4]byte
-	bs := b[:4]
 	done := make(chan bool)
 
 	go r.waitForPeerConnections(done)
 
 	for i := 0; i < int(r.Id); i++ {
 		for {
-			if conn, err := net.Dial("tcp", r.PeerAddrList[i]); err == nil {
+			if conn, err := r.dialPeer(int32(i), 0); err == nil {
 				r.Peers[i] = conn
-				setTCPKeepAlive(conn)
 				break
 			}
 			time.Sleep(1e9)
 		}
-		binary.LittleEndian.PutUint32(bs, uint32(r.Id))
-		if _, err := r.Peers[i].Write(bs); err != nil {
-			r.Println("Write id error:", err)
-			continue
-		}
 		r.Alive[i] = true
 		r.PeerReaders[i] = bufio.NewReader(r.Peers[i])
 		r.PeerWriters[i] = bufio.NewWriter(r.Peers[i])
@@ -196,6 +321,9 @@ func (r *Replica) ConnectToPeers() {
 	r.Printf("Replica %d: done connecting to peers", r.Id)
 	r.Printf("Node list %v", r.PeerAddrList)
 
+	r.M.Lock()
+	r.listeners = true
+	r.M.Unlock()
 	for rid, reader := range r.PeerReaders {
 		if int32(rid) == r.Id {
 			continue
@@ -205,26 +333,18 @@ func (r *Replica) ConnectToPeers() {
 }
 
 func (r *Replica) ConnectToPeersNoListeners() {
-	var b [4]byte
-	bs := b[:4]
 	done := make(chan bool)
 
 	go r.waitForPeerConnections(done)
 
 	for i := 0; i < int(r.Id); i++ {
 		for {
-			if conn, err := net.Dial("tcp", r.PeerAddrList[i]); err == nil {
+			if conn, err := r.dialPeer(int32(i), 0); err == nil {
 				r.Peers[i] = conn
-				setTCPKeepAlive(conn)
 				break
 			}
 			time.Sleep(1e9)
 		}
-		binary.LittleEndian.PutUint32(bs, uint32(r.Id))
-		if _, err := r.Peers[i].Write(bs); err != nil {
-			r.Println("Write id error:", err)
-			continue
-		}
 		r.Alive[i] = true
 		r.PeerReaders[i] = bufio.NewReader(r.Peers[i])
 		r.PeerWriters[i] = bufio.NewWriter(r.Peers[i])
@@ -233,12 +353,137 @@ func (r *Replica) ConnectToPeersNoListeners() {
 	r.Printf("Replica id: %d. Done connecting to peers\n", r.Id)
 }
 
+// Backoff between attempts to reconnect to a peer
+const (
+	reconnectMinBackoff = 100 * time.Millisecond
+	reconnectMaxBackoff = 5 * time.Second
+)
+
+// peerDown marks the peer dead and starts reconnecting to it, unless conn
+// has already been replaced by a new connection.
+func (r *Replica) peerDown(peerId int32, conn net.Conn) {
+	r.M.Lock()
+	current := r.Peers[peerId] == conn
+	if current {
+		r.Alive[peerId] = false
+		r.PeerWriters[peerId] = nil
+	}
+	r.M.Unlock()
+	if current {
+		r.reconnect(peerId)
+	}
+}
+
+// reconnect dials a lost peer again, with exponential backoff, until it
+// succeeds or the replica shuts down. As on startup, only the replica with
+// the higher id dials; the other one accepts the connection in
+// clientListener.
+func (r *Replica) reconnect(peerId int32) {
+	if peerId >= r.Id || r.Transport == nil {
+		return
+	}
+	r.M.Lock()
+	if r.reconnecting == nil {
+		r.reconnecting = make(map[int32]bool)
+	}
+	if r.reconnecting[peerId] {
+		r.M.Unlock()
+		return
+	}
+	r.reconnecting[peerId] = true
+	r.M.Unlock()
+
+	go func() {
+		clock := sim.OrReal(r.Clock)
+		backoff := reconnectMinBackoff
+		for !r.Shutdown {
+			clock.Sleep(backoff + time.Duration(clock.Int63n(int64(backoff/2))))
+			if backoff *= 2; backoff > reconnectMaxBackoff {
+				backoff = reconnectMaxBackoff
+			}
+
+			conn, err := r.dialPeer(peerId, peerWriteDeadline)
+			if err != nil {
+				continue
+			}
+
+			r.M.Lock()
+			delete(r.reconnecting, peerId)
+			r.M.Unlock()
+			r.Printf("OUT Reconnected to %d", peerId)
+			r.addPeer(peerId, conn, bufio.NewReader(conn))
+			return
+		}
+	}()
+}
+
+// addPeer replaces the connection to a peer with conn and, once the
+// replica listens to its peers, starts listening to it.
+func (r *Replica) addPeer(peerId int32, conn net.Conn, reader *bufio.Reader) {
+	r.PeerMu[peerId].Lock()
+	r.M.Lock()
+	old := r.Peers[peerId]
+	r.Peers[peerId] = conn
+	r.PeerReaders[peerId] = reader
+	r.PeerWriters[peerId] = bufio.NewWriter(conn)
+	r.Alive[peerId] = true
+	listen := r.listeners
+	r.M.Unlock()
+	r.PeerMu[peerId].Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	if listen {
+		go r.replicaListener(int(peerId), reader)
+	}
+}
+
+// checkPeer verifies that the peer claiming to be replica id on conn holds
+// the certificate of that replica, if the connection uses TLS.
+func (r *Replica) checkPeer(conn net.Conn, id int32) error {
+	name, isTLS, err := transport.PeerName(conn)
+	if !isTLS || r.Config == nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, exists := r.Config.ReplicaAddrs[name]; exists && r.Config.ReplicaIndex(name) == int(id) {
+		return nil
+	}
+	return fmt.Errorf("certificate of %q is not the one of replica %d", name, id)
+}
+
+// checkPeerHello returns why the replica does not accept the connection
+// of the peer saying h on conn, or nil.
+func (r *Replica) checkPeerHello(conn net.Conn, h *defs.Hello) error {
+	if h.Role != defs.RoleReplica {
+		return fmt.Errorf("replica %d is not connected to its peers yet", r.Id)
+	}
+	if h.Id < 0 || h.Id >= int32(r.N) || h.Id == r.Id {
+		return fmt.Errorf("invalid peer id %d", h.Id)
+	}
+	return r.checkPeer(conn, h.Id)
+}
+
+// acceptPeer adds the connection of a peer reconnecting after a connection
+// loss.
+func (r *Replica) acceptPeer(conn net.Conn, reader *bufio.Reader, id int32) {
+	setTCPKeepAlive(conn)
+	r.Printf("IN Reconnected to %d", id)
+	r.addPeer(id, conn, reader)
+}
+
 func (r *Replica) WaitForClientConnections() {
 	r.Println("Waiting for client connections")
 
 	for !r.Shutdown {
 		conn, err := r.Listener.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
 			r.Println("Accept error:", err)
 			continue
 		}
@@ -251,7 +496,7 @@ func (r *Replica) WaitForClientConnections() {
 // ensures we detect the failure within 1 second and mark the peer as dead.
 const peerWriteDeadline = 1 * time.Second
 
-func (r *Replica) SendMsg(peerId int32, code uint8, msg fastrpc.Serializable) {
+func (r *Replica) SendMsg(peerId int32, code fastrpc.Code, msg fastrpc.Serializable) {
 	// Use per-peer lock so writes to different peers proceed in parallel.
 	// Only hold r.M briefly to snapshot writer/conn, avoiding head-of-line blocking.
 	r.PeerMu[peerId].Lock()
@@ -266,23 +511,23 @@ func (r *Replica) SendMsg(peerId int32, code uint8, msg fastrpc.Serializable) {
 		r.Printf("Connection to %d lost!", peerId)
 		return
 	}
+	// The receiver drops the messages of a cut link anyway
+	if r.Faults.Down(r.Id, peerId) {
+		return
+	}
 
 	// Set write deadline to avoid blocking for ~2 min on dead peer's TCP connection
 	if conn != nil {
 		conn.SetWriteDeadline(time.Now().Add(peerWriteDeadline))
 	}
 
-	w.WriteByte(code)
-	msg.Marshal(w)
+	fastrpc.WriteFrame(w, code, msg)
 	if err := w.Flush(); err != nil {
 		r.Printf("Peer %d write error: %v — marking dead", peerId, err)
-		r.M.Lock()
-		r.PeerWriters[peerId] = nil
-		r.Alive[peerId] = false
-		r.M.Unlock()
 		if conn != nil {
 			conn.Close()
 		}
+		r.peerDown(peerId, conn)
 		return
 	}
 
@@ -292,7 +537,7 @@ func (r *Replica) SendMsg(peerId int32, code uint8, msg fastrpc.Serializable) {
 	}
 }
 
-func (r *Replica) SendClientMsg(id int32, code uint8, msg fastrpc.Serializable) {
+func (r *Replica) SendClientMsg(id int32, code fastrpc.Code, msg fastrpc.Serializable) {
 	r.M.Lock()
 	w := r.ClientWriters[id]
 	mu := r.ClientMu[id]
@@ -303,6 +548,11 @@ func (r *Replica) SendClientMsg(id int32, code uint8, msg fastrpc.Serializable)
 		r.Printf("Connection to client %d lost!", id)
 		return
 	}
+	copies, delay := r.faults(r.Id, defs.FaultClients)
+	if copies == 0 {
+		return
+	}
+	d += delay
 	// Inject latency for replica→client direction (non-co-located clients).
 	// clientDelay is pre-computed at registration: 0 for proxy-local clients.
 	// Use a goroutine so we don't block the Sender goroutine.
@@ -311,47 +561,107 @@ func (r *Replica) SendClientMsg(id int32, code uint8, msg fastrpc.Serializable)
 			time.Sleep(d)
 			mu.Lock()
 			defer mu.Unlock()
-			w.WriteByte(code)
-			msg.Marshal(w)
+			for i := 0; i < copies; i++ {
+				fastrpc.WriteFrame(w, code, msg)
+			}
 			w.Flush()
 		}()
 		return
 	}
 	mu.Lock()
 	defer mu.Unlock()
-	w.WriteByte(code)
-	msg.Marshal(w)
+	for i := 0; i < copies; i++ {
+		fastrpc.WriteFrame(w, code, msg)
+	}
 	w.Flush()
 }
 
 // SendClientMsgFast sends a message to a client via a dedicated per-client
 // goroutine, bypassing the Sender queue. This avoids head-of-line blocking
 // where slow remote flushes delay fast local replies.
-// Non-blocking: drops the message if the per-client channel is full, to prevent
-// the caller (run loop or Sender goroutine) from blocking on a slow/disconnected client.
-func (r *Replica) SendClientMsgFast(id int32, code uint8, msg fastrpc.Serializable) {
+// Non-blocking: if the per-client channel is full, the message waits in an
+// overflow queue instead of blocking the caller (run loop or Sender
+// goroutine). The overflow is bounded by rejecting the proposals of clients
+// with more than MaxInFlight queued replies.
+func (r *Replica) SendClientMsgFast(id int32, code fastrpc.Code, msg fastrpc.Serializable) {
+	arg := clientSendArg{code: code, msg: msg}
 	r.M.Lock()
 	ch := r.ClientFastChan[id]
-	r.M.Unlock()
 	if ch == nil {
+		r.M.Unlock()
+		atomic.AddInt64(&r.ClientMsgDrops, 1)
 		return
 	}
-	select {
-	case ch <- clientSendArg{code: code, msg: msg}:
-	default:
-		// Channel full — drop message and count it.
-		drops := atomic.AddInt64(&r.ClientMsgDrops, 1)
-		r.clientDropOnce.Do(func() {
-			r.Printf("WARNING: per-client channel full for client %d, dropping messages (buffer=%d)", id, cap(ch))
+	// keep the order of the messages behind an overflow
+	if len(r.clientOverflow[id]) == 0 {
+		select {
+		case ch <- arg:
+			r.M.Unlock()
+			return
+		default:
+		}
+	}
+	if r.clientOverflow == nil {
+		r.clientOverflow = make(map[int32][]clientSendArg)
+	}
+	q := append(r.clientOverflow[id], arg)
+	r.clientOverflow[id] = q
+	r.M.Unlock()
+
+	if len(q) == 1 {
+		r.clientSpillOnce.Do(func() {
+			r.Printf("WARNING: per-client channel full for client %d, queueing messages (buffer=%d)", id, cap(ch))
 		})
-		// Log every 1000th drop to track ongoing issues without flooding logs
-		if drops%1000 == 0 {
-			r.Printf("[MSGDROP] total=%d (client %d, buffer=%d)", drops, id, cap(ch))
+		go r.drainOverflow(id, ch)
+	}
+}
+
+// drainOverflow moves the overflow messages of a client to its channel, in
+// order, until there are none left.
+func (r *Replica) drainOverflow(id int32, ch chan clientSendArg) {
+	for {
+		r.M.Lock()
+		q := r.clientOverflow[id]
+		if len(q) == 0 {
+			delete(r.clientOverflow, id)
+			r.M.Unlock()
+			return
 		}
+		arg := q[0]
+		r.M.Unlock()
+
+		ch <- arg
+
+		r.M.Lock()
+		r.clientOverflow[id] = r.clientOverflow[id][1:]
+		r.M.Unlock()
 	}
 }
 
-func (r *Replica) SendMsgNoFlush(peerId int32, code uint8, msg fastrpc.Serializable) {
+// clientBusy reports whether a client has MaxInFlight or more replies
+// queued.
+func (r *Replica) clientBusy(id int32) bool {
+	if r.MaxInFlight <= 0 {
+		return false
+	}
+	r.M.Lock()
+	defer r.M.Unlock()
+	return len(r.ClientFastChan[id])+len(r.clientOverflow[id]) >= r.MaxInFlight
+}
+
+// replyBusy rejects a proposal with a "server busy" reply.
+func (r *Replica) replyBusy(propose *defs.Propose, w *bufio.Writer, lock *sync.Mutex) {
+	atomic.AddInt64(&r.ClientBusyReplies, 1)
+	r.ReplyProposeTS(&defs.ProposeReplyTS{
+		OK:        defs.BUSY,
+		CommandId: propose.CommandId,
+		Value:     state.NIL(),
+		Timestamp: propose.Timestamp,
+		LeaderId:  -1,
+	}, w, lock)
+}
+
+func (r *Replica) SendMsgNoFlush(peerId int32, code fastrpc.Code, msg fastrpc.Serializable) {
 	r.PeerMu[peerId].Lock()
 	defer r.PeerMu[peerId].Unlock()
 
@@ -363,8 +673,10 @@ func (r *Replica) SendMsgNoFlush(peerId int32, code uint8, msg fastrpc.Serializa
 		r.Printf("Connection to %d lost!", peerId)
 		return
 	}
-	w.WriteByte(code)
-	msg.Marshal(w)
+	if r.Faults.Down(r.Id, peerId) {
+		return
+	}
+	fastrpc.WriteFrame(w, code, msg)
 }
 
 // FlushPeers flushes buffered writes to all connected peer writers.
@@ -391,13 +703,10 @@ func (r *Replica) FlushPeers() {
 			}
 			if err := w.Flush(); err != nil {
 				r.Printf("Peer %d flush error: %v — marking dead", p, err)
-				r.M.Lock()
-				r.PeerWriters[p] = nil
-				r.Alive[p] = false
-				r.M.Unlock()
 				if conn != nil {
 					conn.Close()
 				}
+				r.peerDown(int32(p), conn)
 				return
 			}
 			if conn != nil {
@@ -409,10 +718,29 @@ func (r *Replica) FlushPeers() {
 }
 
 func (r *Replica) ReplyProposeTS(reply *defs.ProposeReplyTS, w *bufio.Writer, lock *sync.Mutex) {
+	copies, delay := r.faults(r.Id, defs.FaultClients)
+	if copies == 0 {
+		return
+	}
+	if delay > 0 {
+		go func() {
+			time.Sleep(delay)
+			lock.Lock()
+			defer lock.Unlock()
+			for i := 0; i < copies; i++ {
+				fastrpc.WriteRawFrame(w, reply)
+			}
+			w.Flush()
+		}()
+		return
+	}
+
 	lock.Lock()
 	defer lock.Unlock()
 
-	reply.Marshal(w)
+	for i := 0; i < copies; i++ {
+		fastrpc.WriteRawFrame(w, reply)
+	}
 	if err := w.Flush(); err != nil {
 		r.Printf("ReplyProposeTS flush error for cmd %d: %v", reply.CommandId, err)
 	}
@@ -427,19 +755,28 @@ func (r *Replica) ReplyProposeTSDelayed(reply *defs.ProposeReplyTS, w *bufio.Wri
 	d := r.ClientDelay[clientId]
 	r.M.Unlock()
 
+	copies, delay := r.faults(r.Id, defs.FaultClients)
+	if copies == 0 {
+		return
+	}
+	d += delay
 	if d > 0 {
 		go func() {
 			time.Sleep(d)
 			lock.Lock()
 			defer lock.Unlock()
-			reply.Marshal(w)
+			for i := 0; i < copies; i++ {
+				fastrpc.WriteRawFrame(w, reply)
+			}
 			w.Flush()
 		}()
 		return
 	}
 	lock.Lock()
 	defer lock.Unlock()
-	reply.Marshal(w)
+	for i := 0; i < copies; i++ {
+		fastrpc.WriteRawFrame(w, reply)
+	}
 	w.Flush()
 }
 
@@ -461,11 +798,10 @@ func (r *Replica) SendBeacon(peerId int32) {
 		conn.SetWriteDeadline(time.Now().Add(peerWriteDeadline))
 	}
 
-	w.WriteByte(defs.GENERIC_SMR_BEACON)
 	beacon := &defs.Beacon{
-		Timestamp: time.Now().UnixNano(),
+		Timestamp: r.Clock.Now().UnixNano(),
 	}
-	beacon.Marshal(w)
+	fastrpc.WriteFrame(w, fastrpc.Code(defs.GENERIC_SMR_BEACON), beacon)
 	if err := w.Flush(); err != nil {
 		r.Printf("Peer %d beacon write error: %v — marking dead", peerId, err)
 		r.M.Lock()
@@ -502,11 +838,10 @@ func (r *Replica) ReplyBeacon(beacon *defs.GBeacon) {
 		conn.SetWriteDeadline(time.Now().Add(peerWriteDeadline))
 	}
 
-	w.WriteByte(defs.GENERIC_SMR_BEACON_REPLY)
 	rb := &defs.BeaconReply{
 		Timestamp: beacon.Timestamp,
 	}
-	rb.Marshal(w)
+	fastrpc.WriteFrame(w, fastrpc.Code(defs.GENERIC_SMR_BEACON_REPLY), rb)
 	if err := w.Flush(); err != nil {
 		r.Printf("Peer %d beacon reply write error: %v — marking dead", rid, err)
 		r.M.Lock()
@@ -571,7 +906,7 @@ func (r *Replica) ComputeClosestPeers() []float64 {
 				r.M.Unlock()
 			}
 		}
-		time.Sleep(500 * time.Millisecond)
+		r.Clock.Sleep(500 * time.Millisecond)
 	}
 
 	quorum := make([]int32, r.N)
@@ -608,27 +943,58 @@ func (r *Replica) ComputeClosestPeers() []float64 {
 		latencies[i] = lat
 	}
 
+	r.M.Lock()
+	r.ranked = true
+	r.M.Unlock()
+	r.probeOnce.Do(func() { go r.probePeers() })
 	return latencies
 }
 
-func (r *Replica) waitForPeerConnections(done chan bool) {
-	var b [4]byte
-	bs := b[:4]
+// ProbeInterval is the interval between the beacons sent to the peers to
+// keep measuring the round trips to them (see Ewma). They update the status
+// and the metrics of the replica, not the order of PreferredPeerOrder.
+var ProbeInterval = time.Second
+
+// probePeers sends a beacon to every live peer each ProbeInterval, until
+// the replica shuts down. The round trips are measured over the peer
+// connections, without ICMP.
+func (r *Replica) probePeers() {
+	for !r.Shutdown {
+		r.Clock.Sleep(ProbeInterval)
+		for i := int32(0); i < int32(r.N); i++ {
+			r.M.Lock()
+			alive := r.Alive[i]
+			r.M.Unlock()
+			if i != r.Id && alive {
+				r.SendBeacon(i)
+			}
+		}
+	}
+}
 
+// recordRTT adds the round trip of a beacon to peer rid, in nanoseconds,
+// to the moving average of Ewma, which starts at the first round trip, and
+// to Latencies until the peers are ranked.
+func (r *Replica) recordRTT(rid int32, rtt int64) {
+	r.M.Lock()
+	defer r.M.Unlock()
+	if !r.ranked && r.Latencies[rid] != math.MaxInt64 {
+		r.Latencies[rid] += rtt
+	}
+	if r.Ewma[rid] == 0 {
+		r.Ewma[rid] = float64(rtt)
+	} else {
+		r.Ewma[rid] = 0.99*r.Ewma[rid] + 0.01*float64(rtt)
+	}
+}
+
+func (r *Replica) waitForPeerConnections(done chan bool) {
 	// Listen on all interfaces (0.0.0.0) with the replica's port.
 	// This is required for AWS where instances can only bind to private IPs
 	// but peers connect via public IPs.
-	// Use SO_REUSEADDR to avoid TIME_WAIT conflicts between consecutive benchmark runs.
 	_, port, _ := net.SplitHostPort(r.PeerAddrList[r.Id])
 	addr := "0.0.0.0:" + port
-	lc := net.ListenConfig{
-		Control: func(network, address string, c syscall.RawConn) error {
-			return c.Control(func(fd uintptr) {
-				syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
-			})
-		},
-	}
-	l, err := lc.Listen(context.Background(), "tcp", addr)
+	l, err := r.Transport.Listen(addr)
 	if err != nil {
 		r.Fatal(r.PeerAddrList[r.Id], err)
 	}
@@ -641,17 +1007,15 @@ func (r *Replica) waitForPeerConnections(done chan bool) {
 			r.Println("Accept error:", err)
 			continue
 		}
-		if _, err := io.ReadFull(conn, bs); err != nil {
-			r.Println("Connection establish error:", err)
-			conn.Close()
-			continue
-		}
-		id := int32(binary.LittleEndian.Uint32(bs))
-		if id < 0 || id >= int32(r.N) || id == r.Id {
-			r.Printf("IN Rejecting invalid peer id %d", id)
+		hello, err := defs.AcceptHandshake(conn, conn, r.hello(), func(h *defs.Hello) error {
+			return r.checkPeerHello(conn, h)
+		})
+		if err != nil {
+			r.Println("IN Connection establish error:", err)
 			conn.Close()
 			continue
 		}
+		id := hello.Id
 		if r.Peers[id] != nil {
 			r.Printf("IN Duplicate connection from %d, replacing", id)
 			r.Peers[id].Close()
@@ -671,21 +1035,27 @@ func (r *Replica) waitForPeerConnections(done chan bool) {
 
 func (r *Replica) replicaListener(rid int, reader *bufio.Reader) {
 	var (
-		msgType      uint8
+		code         fastrpc.Code
+		payload      *bytes.Reader
 		err          error = nil
 		gbeacon      defs.Beacon
 		gbeaconReply defs.BeaconReply
 	)
 
+	r.M.Lock()
+	conn := r.Peers[rid]
+	r.M.Unlock()
+
+	frames := fastrpc.NewFrameReader(reader)
 	for err == nil && !r.Shutdown {
-		if msgType, err = reader.ReadByte(); err != nil {
+		if code, payload, err = frames.NextCode(); err != nil {
 			break
 		}
 
-		switch uint8(msgType) {
+		switch code {
 
-		case defs.GENERIC_SMR_BEACON:
-			if err = gbeacon.Unmarshal(reader); err != nil {
+		case fastrpc.Code(defs.GENERIC_SMR_BEACON):
+			if err = fastrpc.UnmarshalFrame(payload, &gbeacon); err != nil {
 				break
 			}
 			r.ReplyBeacon(&defs.GBeacon{
@@ -694,36 +1064,47 @@ func (r *Replica) replicaListener(rid int, reader *bufio.Reader) {
 			})
 			break
 
-		case defs.GENERIC_SMR_BEACON_REPLY:
-			if err = gbeaconReply.Unmarshal(reader); err != nil {
+		case fastrpc.Code(defs.GENERIC_SMR_BEACON_REPLY):
+			if err = fastrpc.UnmarshalFrame(payload, &gbeaconReply); err != nil {
 				break
 			}
-			r.M.Lock()
-			r.Latencies[rid] += time.Now().UnixNano() - gbeaconReply.Timestamp
-			r.M.Unlock()
-			now := time.Now().UnixNano()
-			r.Ewma[rid] = 0.99*r.Ewma[rid] + 0.01*float64(now-gbeaconReply.Timestamp)
+			r.recordRTT(int32(rid), r.Clock.Now().UnixNano()-gbeaconReply.Timestamp)
 			break
 
 		default:
-			p, exists := r.RPC.Get(msgType)
+			p, exists := r.RPC.Get(code)
 			if exists {
 				obj := p.Obj.New()
-				if err = obj.Unmarshal(reader); err != nil {
+				if err = fastrpc.UnmarshalFrame(payload, obj); err != nil {
+					break
+				}
+				copies, delay := r.faults(int32(rid), r.Id)
+				if copies == 0 {
 					break
 				}
-				go func(obj fastrpc.Serializable) {
-					time.Sleep(r.Dt.WaitDurationID(rid))
+				var dup fastrpc.Serializable
+				if copies == 2 {
+					dup = p.Obj.New()
+					cloneMsg(dup, obj)
+				}
+				go func(obj, dup fastrpc.Serializable) {
+					time.Sleep(r.Dt.WaitDurationID(rid) + delay)
 					p.Chan <- obj
-				}(obj)
+					if dup != nil {
+						p.Chan <- dup
+					}
+				}(obj, dup)
 			} else {
-				r.Println("Warning: received unknown message type", msgType, "from peer", rid, "- closing connection")
+				r.Println("Warning: received unknown message type", code, "from peer", rid, "- closing connection")
 				err = io.ErrUnexpectedEOF
 				break
 			}
 		}
 	}
 
+	if errors.Is(err, fastrpc.ErrCorruptFrame) {
+		atomic.AddInt64(&r.CorruptPeerFrames, 1)
+	}
 	r.Printf("Peer %d reader exited (err=%v), closing connection", rid, err)
 
 	// Close the underlying TCP connection first (outside the lock).
@@ -732,14 +1113,11 @@ func (r *Replica) replicaListener(rid int, reader *bufio.Reader) {
 	// on the kernel TCP timeout. Without this, broadcastAppendEntries
 	// holds r.M while Flush() blocks, preventing RequestVote RPCs
 	// and stalling leader election.
-	if r.Peers[rid] != nil {
-		r.Peers[rid].Close()
+	if conn != nil {
+		conn.Close()
 	}
 
-	r.M.Lock()
-	r.Alive[rid] = false
-	r.PeerWriters[rid] = nil
-	r.M.Unlock()
+	r.peerDown(int32(rid), conn)
 	r.Printf("Peer %d marked dead", rid)
 }
 
@@ -747,9 +1125,29 @@ func (r *Replica) clientListener(conn net.Conn) {
 	reader := bufio.NewReader(conn)
 	writer := bufio.NewWriter(conn)
 
+	hello, err := defs.AcceptHandshake(conn, reader, r.hello(), func(h *defs.Hello) error {
+		switch h.Role {
+		case defs.RoleReplica:
+			return r.checkPeerHello(conn, h)
+		case defs.RoleClient:
+			return nil
+		}
+		return fmt.Errorf("unknown role %d", h.Role)
+	})
+	if err != nil {
+		r.Println("Connection establish error:", err)
+		conn.Close()
+		return
+	}
+	// A peer reconnecting after a connection loss
+	if hello.Role == defs.RoleReplica {
+		r.acceptPeer(conn, reader, hello.Id)
+		return
+	}
+
 	var (
-		msgType byte
-		err     error
+		code    fastrpc.Code
+		payload *bytes.Reader
 	)
 
 	r.M.Lock()
@@ -771,27 +1169,40 @@ func (r *Replica) clientListener(conn net.Conn) {
 		clientDelay = r.Dt.WaitDuration(addr)
 	}
 
+	frames := fastrpc.NewFrameReader(reader)
 	for !r.Shutdown && err == nil {
-		if msgType, err = reader.ReadByte(); err != nil {
+		if code, payload, err = frames.NextCode(); err != nil {
 			break
 		}
 
-		switch uint8(msgType) {
-		case defs.PROPOSE:
+		switch code {
+		case fastrpc.Code(defs.PROPOSE):
 			propose := &defs.Propose{}
-			if err = propose.Unmarshal(reader); err != nil {
+			if err = fastrpc.UnmarshalFrame(payload, propose); err != nil {
 				break
 			}
 			r.registerClient(propose.ClientId, writer, addr, mutex, clientDelay)
-			op := propose.Command.Op
-			if r.LRead && (op == state.GET || op == state.SCAN) {
-				r.ReplyProposeTSDelayed(&defs.ProposeReplyTS{
-					OK:        defs.TRUE,
-					CommandId: propose.CommandId,
-					Value:     propose.Command.Execute(r.State),
-					Timestamp: propose.Timestamp,
-				}, writer, mutex, propose.ClientId)
-			} else {
+			if r.clientBusy(propose.ClientId) {
+				r.replyBusy(propose, writer, mutex)
+				break
+			}
+			copies, delay := r.faults(defs.FaultClients, r.Id)
+			for i := 0; i < copies; i++ {
+				if i > 0 {
+					dup := &defs.Propose{}
+					cloneMsg(dup, propose)
+					propose = dup
+				}
+				op := propose.Command.Op
+				if r.LRead && (op == state.GET || op == state.SCAN) {
+					r.ReplyProposeTSDelayed(&defs.ProposeReplyTS{
+						OK:        defs.TRUE,
+						CommandId: propose.CommandId,
+						Value:     propose.Command.Execute(r.State),
+						Timestamp: propose.Timestamp,
+					}, writer, mutex, propose.ClientId)
+					continue
+				}
 				gp := &defs.GPropose{
 					Propose: propose,
 					Reply:   writer,
@@ -799,9 +1210,9 @@ func (r *Replica) clientListener(conn net.Conn) {
 					Proxy:   isProxy,
 					Addr:    addr,
 				}
-				if clientDelay > 0 {
+				if d := clientDelay + delay; d > 0 {
 					go func(p *defs.GPropose) {
-						time.Sleep(clientDelay)
+						time.Sleep(d)
 						r.ProposeChan <- p
 					}(gp)
 				} else {
@@ -810,34 +1221,49 @@ func (r *Replica) clientListener(conn net.Conn) {
 			}
 			break
 
-		case defs.READ:
+		case fastrpc.Code(defs.READ):
 			// TODO: do something with this
 			read := &defs.Read{}
-			if err = read.Unmarshal(reader); err != nil {
+			if err = fastrpc.UnmarshalFrame(payload, read); err != nil {
 				break
 			}
 			break
 
-		case defs.PROPOSE_AND_READ:
+		case fastrpc.Code(defs.PROPOSE_AND_READ):
 			// TODO: do something with this
 			pr := &defs.ProposeAndRead{}
-			if err = pr.Unmarshal(reader); err != nil {
+			if err = fastrpc.UnmarshalFrame(payload, pr); err != nil {
 				break
 			}
 			break
 
-		case defs.STATS:
-			r.M.Lock()
-			b, _ := json.Marshal(r.Stats)
-			r.M.Unlock()
-			writer.Write(b)
+		case fastrpc.Code(defs.GENERIC_SMR_BEACON):
+			// the client measures its round trip to the replica
+			beacon := &defs.Beacon{}
+			if err = fastrpc.UnmarshalFrame(payload, beacon); err != nil {
+				break
+			}
+			go func() {
+				time.Sleep(clientDelay)
+				mutex.Lock()
+				fastrpc.WriteFrame(writer, fastrpc.Code(defs.GENERIC_SMR_BEACON_REPLY),
+					&defs.BeaconReply{Timestamp: beacon.Timestamp})
+				writer.Flush()
+				mutex.Unlock()
+			}()
+
+		case fastrpc.Code(defs.STATS):
+			b, _ := json.Marshal(&defs.Stats{M: r.stats()})
+			mutex.Lock()
+			fastrpc.WriteFrameBytes(writer, b)
 			writer.Flush()
+			mutex.Unlock()
 
 		default:
-			p, exists := r.RPC.Get(msgType)
+			p, exists := r.RPC.Get(code)
 			if exists {
 				obj := p.Obj.New()
-				if err = obj.Unmarshal(reader); err != nil {
+				if err = fastrpc.UnmarshalFrame(payload, obj); err != nil {
 					break
 				}
 				// Register client infrastructure if the message carries a ClientId.
@@ -845,19 +1271,40 @@ func (r *Replica) clientListener(conn net.Conn) {
 				// the first message from a client is not a PROPOSE (e.g., MCausalPropose).
 				if cm, ok := obj.(interface{ GetClientId() int32 }); ok {
 					r.registerClient(cm.GetClientId(), writer, addr, mutex, clientDelay)
+					// Stop reading from a client that does not read its
+					// replies, until they are sent
+					for r.clientBusy(cm.GetClientId()) && !r.Shutdown {
+						time.Sleep(time.Millisecond)
+					}
+				}
+				copies, delay := r.faults(defs.FaultClients, r.Id)
+				if copies == 0 {
+					break
+				}
+				var dup fastrpc.Serializable
+				if copies == 2 {
+					dup = p.Obj.New()
+					cloneMsg(dup, obj)
 				}
-				go func(obj fastrpc.Serializable) {
-					time.Sleep(clientDelay)
+				go func(obj, dup fastrpc.Serializable) {
+					time.Sleep(clientDelay + delay)
 					p.Chan <- obj
-				}(obj)
+					if dup != nil {
+						p.Chan <- dup
+					}
+				}(obj, dup)
 			} else {
-				r.Println("Warning: received unknown client message", msgType, "from", conn.RemoteAddr(), "- closing connection")
+				r.Println("Warning: received unknown client message", code, "from", conn.RemoteAddr(), "- closing connection")
 				err = io.ErrUnexpectedEOF
 				break
 			}
 		}
 	}
 
+	if errors.Is(err, fastrpc.ErrCorruptFrame) {
+		atomic.AddInt64(&r.CorruptClientFrames, 1)
+		r.Println("Corrupt frame from client", conn.RemoteAddr(), "- closing connection:", err)
+	}
 	conn.Close()
 	r.Println("Client down", conn.RemoteAddr())
 }
@@ -872,7 +1319,8 @@ func (r *Replica) registerClient(clientId int32, writer *bufio.Writer, addr stri
 	r.ClientAddrs[clientId] = addr
 	r.ClientDelay[clientId] = clientDelay
 	if r.ClientMu[clientId] == nil {
-		r.ClientMu[clientId] = &sync.Mutex{}
+		// the lock of the connection, also taken by direct replies
+		r.ClientMu[clientId] = mutex
 	}
 	if r.ClientFastChan[clientId] == nil {
 		ch := make(chan clientSendArg, 131072)
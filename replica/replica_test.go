@@ -2,15 +2,26 @@ package replica
 
 import (
 	"bufio"
+	"bytes"
+	"fmt"
 	"io"
+	"math"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/imdea-software/swiftpaxos/config"
 	"github.com/imdea-software/swiftpaxos/dlog"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
 	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/sim"
+	"github.com/imdea-software/swiftpaxos/state"
+	"github.com/imdea-software/swiftpaxos/transport"
 )
 
 // mockMsg implements fastrpc.Serializable for testing.
@@ -599,3 +610,402 @@ func TestPeerMu_Independence(t *testing.T) {
 		t.Fatal("SendMsg to peer 2 was blocked by peer 1's lock (PeerMu not independent)")
 	}
 }
+
+// TestReplicaListener_Faults verifies that replicaListener drops and
+// duplicates peer messages according to the injected faults.
+func TestReplicaListener_Faults(t *testing.T) {
+	r := newTestReplica(3, 0)
+	r.Faults = &defs.FaultTable{}
+	ch := make(chan fastrpc.Serializable, 8)
+	code := r.RPC.Register(&mockMsg{}, ch)
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	rid := 1
+	r.Peers[rid] = serverConn
+	r.PeerReaders[rid] = bufio.NewReader(serverConn)
+	go r.replicaListener(rid, r.PeerReaders[rid])
+
+	expect := func(n int) {
+		t.Helper()
+		w := bufio.NewWriter(clientConn)
+		fastrpc.WriteFrame(w, code, &mockMsg{})
+		w.Flush()
+		for i := 0; i < n; i++ {
+			select {
+			case <-ch:
+			case <-time.After(time.Second):
+				t.Fatalf("got %d messages, want %d", i, n)
+			}
+		}
+		select {
+		case <-ch:
+			t.Fatalf("got more than %d messages", n)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	expect(1)
+	r.Faults.Set([]defs.LinkFault{{From: 1, To: 0, Dup: 1}})
+	expect(2)
+	// faults on other links do not apply
+	r.Faults.Set([]defs.LinkFault{{From: 2, To: 0, Down: true}})
+	expect(1)
+	r.Faults.Set([]defs.LinkFault{{From: defs.FaultAny, To: 0, Down: true}})
+	expect(0)
+}
+
+// TestReplicaListener_CorruptFrame verifies that a corrupt frame closes the
+// connection to the peer and is counted.
+func TestReplicaListener_CorruptFrame(t *testing.T) {
+	r := newTestReplica(3, 0)
+	ch := make(chan fastrpc.Serializable, 8)
+	code := r.RPC.Register(&mockMsg{}, ch)
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	rid := 1
+	r.Peers[rid] = serverConn
+	r.PeerReaders[rid] = bufio.NewReader(serverConn)
+	r.PeerWriters[rid] = bufio.NewWriter(serverConn)
+	r.Alive[rid] = true
+	done := make(chan struct{})
+	go func() {
+		r.replicaListener(rid, r.PeerReaders[rid])
+		close(done)
+	}()
+
+	var b bytes.Buffer
+	fastrpc.WriteFrame(&b, code, &mockMsg{})
+	fastrpc.WriteFrame(&b, code, &mockMsg{})
+	f := b.Bytes()
+	f[len(f)-1] ^= 0x80
+	go clientConn.Write(f)
+
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("valid frame not received")
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("replicaListener did not exit after a corrupt frame")
+	}
+	if n := atomic.LoadInt64(&r.CorruptPeerFrames); n != 1 {
+		t.Errorf("%d corrupt frames, want 1", n)
+	}
+	r.M.Lock()
+	defer r.M.Unlock()
+	if r.Alive[rid] {
+		t.Error("peer alive after a corrupt frame")
+	}
+}
+
+// TestSendClientMsg_Faults verifies that replies to clients are dropped
+// when the link to the clients is down.
+func TestSendClientMsg_Faults(t *testing.T) {
+	r := newTestReplica(3, 0)
+	r.Faults = &defs.FaultTable{}
+	r.Faults.Set([]defs.LinkFault{{From: 0, To: defs.FaultClients, Down: true}})
+
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	r.ClientWriters[7] = w
+	r.ClientMu[7] = &sync.Mutex{}
+	r.ClientDelay = map[int32]time.Duration{}
+
+	r.SendClientMsg(7, 1, &mockMsg{})
+	if b.Len() != 0 {
+		t.Errorf("wrote %d bytes to a cut client link", b.Len())
+	}
+	r.Faults.Set(nil)
+	r.SendClientMsg(7, 1, &mockMsg{})
+	if b.Len() != fastrpc.FrameHeaderSize+1 {
+		t.Errorf("wrote %d bytes, want %d", b.Len(), fastrpc.FrameHeaderSize+1)
+	}
+}
+
+// TestReconnect verifies that a replica dials a lost peer again and that
+// both replicas listen to the new connection.
+func TestReconnect(t *testing.T) {
+	n := transport.NewNetwork()
+	defer n.Close()
+	addrs := []string{"10.0.0.1:7070", "10.0.0.2:7071"}
+	replicas := make([]*Replica, 2)
+	chans := make([]chan fastrpc.Serializable, 2)
+	var code fastrpc.Code
+	for i := range replicas {
+		c := &config.Config{Transport: n.Host(fmt.Sprintf("10.0.0.%d", i+1))}
+		replicas[i] = New(fmt.Sprintf("replica%d", i), i, 0, addrs, false, false, false, c, dlog.New("", false))
+		chans[i] = make(chan fastrpc.Serializable, 8)
+		code = replicas[i].RPC.Register(&mockMsg{}, chans[i])
+	}
+
+	connected := make(chan bool)
+	go func() {
+		replicas[0].ConnectToPeers()
+		go replicas[0].WaitForClientConnections()
+		connected <- true
+	}()
+	replicas[1].ConnectToPeers()
+	<-connected
+
+	// both ways
+	expect := func(from, to int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			replicas[from].SendMsg(int32(to), code, &mockMsg{})
+			select {
+			case <-chans[to]:
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+		t.Fatalf("no message from %d to %d", from, to)
+	}
+	expect(0, 1)
+	expect(1, 0)
+
+	// a connection loss is detected by both ends
+	replicas[1].M.Lock()
+	replicas[1].Peers[0].Close()
+	replicas[1].M.Unlock()
+
+	expect(1, 0)
+	expect(0, 1)
+	for i, r := range replicas {
+		r.M.Lock()
+		if !r.Alive[1-i] {
+			t.Errorf("replica %d: peer %d is not alive after reconnecting", i, 1-i)
+		}
+		r.M.Unlock()
+	}
+}
+
+// TestSendClientMsgFast_Overflow verifies that messages to a client whose
+// channel is full are queued in order instead of being dropped.
+func TestSendClientMsgFast_Overflow(t *testing.T) {
+	r := newTestReplica(3, 0)
+	r.MaxInFlight = 5
+	ch := make(chan ClientSendArg, 2)
+	r.ClientFastChan = map[int32]chan ClientSendArg{1: ch}
+
+	for i := 0; i < 10; i++ {
+		r.SendClientMsgFast(1, fastrpc.Code(i), &mockMsg{})
+	}
+	if !r.clientBusy(1) {
+		t.Error("client with 10 queued messages is not busy")
+	}
+	for i := 0; i < 10; i++ {
+		select {
+		case arg := <-ch:
+			if arg.code != fastrpc.Code(i) {
+				t.Fatalf("message %d has code %d", i, arg.code)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("message %d lost", i)
+		}
+	}
+	if n := atomic.LoadInt64(&r.ClientMsgDrops); n != 0 {
+		t.Errorf("%d messages dropped", n)
+	}
+}
+
+// TestClientListener_Busy verifies that the proposals of a client with
+// MaxInFlight queued replies are rejected with a busy reply.
+func TestClientListener_Busy(t *testing.T) {
+	r := newTestReplica(3, 0)
+	r.Config = &config.Config{Proxy: &config.ProxyInfo{}}
+	r.MaxInFlight = 2
+	r.ClientAddrs = make(map[int32]string)
+	r.ClientDelay = make(map[int32]time.Duration)
+	r.ProposeChan = make(chan *defs.GPropose, 1)
+	// replies queued but not sent
+	ch := make(chan ClientSendArg, 8)
+	r.ClientFastChan = map[int32]chan ClientSendArg{5: ch}
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go r.clientListener(serverConn)
+	if _, err := defs.Handshake(clientConn, &defs.Hello{Version: defs.WireVersion, Role: defs.RoleClient}, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	propose := func(id int32) {
+		w := bufio.NewWriter(clientConn)
+		fastrpc.WriteFrame(w, fastrpc.Code(defs.PROPOSE), &defs.Propose{CommandId: id, ClientId: 5, Command: state.Command{Op: state.PUT, V: state.NIL()}})
+		w.Flush()
+	}
+
+	propose(1)
+	select {
+	case p := <-r.ProposeChan:
+		if p.CommandId != 1 {
+			t.Errorf("proposed %d, want 1", p.CommandId)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("proposal not admitted")
+	}
+
+	r.SendClientMsgFast(5, 0, &mockMsg{})
+	r.SendClientMsgFast(5, 0, &mockMsg{})
+	propose(2)
+	reply := &defs.ProposeReplyTS{}
+	f, err := fastrpc.ReadFrame(clientConn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fastrpc.UnmarshalFrame(bytes.NewReader(f), reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.OK != defs.BUSY || reply.CommandId != 2 {
+		t.Errorf("got reply OK=%d for %d, want busy for 2", reply.OK, reply.CommandId)
+	}
+	if n := atomic.LoadInt64(&r.ClientBusyReplies); n != 1 {
+		t.Errorf("%d busy replies, want 1", n)
+	}
+}
+
+// TestClientListener_Handshake verifies that clients of another protocol or
+// cluster, and clients without a handshake, are rejected.
+func TestClientListener_Handshake(t *testing.T) {
+	r := newTestReplica(3, 0)
+	r.Config = &config.Config{Protocol: "raftht", Cluster: "a", Proxy: &config.ProxyInfo{}}
+
+	for _, c := range []struct {
+		hello *defs.Hello
+		want  string
+	}{
+		{&defs.Hello{Protocol: "curpht", Version: defs.WireVersion, Cluster: "a"}, "protocol mismatch"},
+		{&defs.Hello{Protocol: "raftht", Version: defs.WireVersion, Cluster: "b"}, "cluster mismatch"},
+		{&defs.Hello{Protocol: "raftht", Version: defs.WireVersion + 1, Cluster: "a"}, "wire version mismatch"},
+	} {
+		serverConn, clientConn := net.Pipe()
+		go r.clientListener(serverConn)
+		c.hello.Role = defs.RoleClient
+		_, err := defs.Handshake(clientConn, c.hello, time.Second)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("handshake error = %v, want %s", err, c.want)
+		}
+		// the replica closed the connection
+		if _, err := clientConn.Read(make([]byte, 1)); err == nil {
+			t.Errorf("%s: connection still open", c.want)
+		}
+		clientConn.Close()
+	}
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go r.clientListener(serverConn)
+	w := bufio.NewWriter(clientConn)
+	fastrpc.WriteFrame(w, fastrpc.Code(defs.PROPOSE), &defs.Propose{ClientId: 5})
+	go w.Flush()
+	clientConn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadAll(clientConn); err != nil {
+		t.Errorf("connection without handshake not closed: %v", err)
+	}
+}
+
+// TestRecordRTT verifies that the round trips of the beacons start and
+// update the moving averages of Ewma, and add up in Latencies unless the
+// peer was found unreachable or the peers are ranked.
+func TestRecordRTT(t *testing.T) {
+	r := newTestReplica(3, 0)
+	r.Latencies[2] = math.MaxInt64
+
+	r.recordRTT(1, 1000)
+	if r.Ewma[1] != 1000 || r.Latencies[1] != 1000 {
+		t.Errorf("first round trip: ewma %v, sum %d", r.Ewma[1], r.Latencies[1])
+	}
+	r.recordRTT(1, 2000)
+	if r.Ewma[1] != 1010 || r.Latencies[1] != 3000 {
+		t.Errorf("second round trip: ewma %v, sum %d", r.Ewma[1], r.Latencies[1])
+	}
+	r.recordRTT(2, 1000)
+	if r.Latencies[2] != math.MaxInt64 {
+		t.Errorf("sum %d after an unreachable peer", r.Latencies[2])
+	}
+
+	// once the peers are ranked, only the moving averages change
+	r.ranked = true
+	r.recordRTT(1, 1000)
+	if r.Ewma[1] == 1010 || r.Latencies[1] != 3000 {
+		t.Errorf("after the ranking: ewma %v, sum %d", r.Ewma[1], r.Latencies[1])
+	}
+}
+
+// TestProbePeers verifies that beacons are sent periodically to the live
+// peers only.
+func TestProbePeers(t *testing.T) {
+	defer func(d time.Duration) { ProbeInterval = d }(ProbeInterval)
+	ProbeInterval = 10 * time.Millisecond
+
+	r := newTestReplica(3, 0)
+	r.Clock = sim.Real
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	r.Peers[1] = serverConn
+	r.PeerWriters[1] = bufio.NewWriter(serverConn)
+	r.Alive[1] = true
+	go r.probePeers()
+	defer func() { r.Shutdown = true }()
+
+	frames := fastrpc.NewFrameReader(clientConn)
+	for i := 0; i < 2; i++ {
+		code, payload, err := frames.NextCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := &defs.Beacon{}
+		if code != fastrpc.Code(defs.GENERIC_SMR_BEACON) || fastrpc.UnmarshalFrame(payload, b) != nil {
+			t.Fatalf("message %d instead of a beacon", code)
+		}
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	r := &Replica{RPC: fastrpc.NewTable()}
+	kept := make(chan fastrpc.Serializable, 1)
+	dropped := make(chan fastrpc.Serializable)
+	r.RPC.RegisterName("kept", &mockMsg{}, kept)
+	r.RPC.RegisterName("dropped", &mockMsg{}, dropped)
+	r.Discard(kept)
+
+	select {
+	case dropped <- &mockMsg{}:
+	case <-time.After(time.Second):
+		t.Fatal("message not discarded")
+	}
+	kept <- &mockMsg{}
+	select {
+	case kept <- &mockMsg{}:
+		t.Error("kept message discarded")
+	default:
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestReplica(3, 0)
+	r.Alias = "replica0"
+	r.Config = &config.Config{SnapshotDir: dir}
+	r.State = state.InitState()
+
+	reply := &defs.SnapshotReply{}
+	if err := r.Snapshot(&defs.SnapshotArgs{}, reply); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "replica0.snapshot"); reply.Path != want {
+		t.Errorf("snapshot written to %s, want %s", reply.Path, want)
+	}
+	for _, p := range []string{"/tmp/x.snapshot", "../x.snapshot", "a/../../x.snapshot", "a/../x.snapshot", ".."} {
+		if err := r.Snapshot(&defs.SnapshotArgs{Path: p}, reply); err == nil {
+			t.Errorf("snapshot path %q accepted", p)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "x.snapshot")); err == nil {
+		t.Error("snapshot written out of the snapshot directory")
+	}
+}
@@ -22,7 +22,7 @@ const ARGS_NUM = defs.CHAN_BUFFER_SIZE
 
 type SendArg struct {
 	msg      fastrpc.Serializable
-	rpc      uint8
+	rpc      fastrpc.Code
 	quorum   Quorum
 	sendType SendType
 	id       int32
@@ -32,8 +32,11 @@ type SendArg struct {
 // Id returns the target replica/client ID for this send argument (for testing).
 func (a SendArg) Id() int32 { return a.id }
 
+// Msg returns the message of this send argument (for testing).
+func (a SendArg) Msg() fastrpc.Serializable { return a.msg }
+
 // Rpc returns the RPC code for this send argument (for testing).
-func (a SendArg) Rpc() uint8 { return a.rpc }
+func (a SendArg) Rpc() fastrpc.Code { return a.rpc }
 
 // SendType returns the send type for this send argument (for testing).
 func (a SendArg) GetSendType() SendType { return a.sendType }
@@ -70,7 +73,7 @@ func NewSender(r *Replica) Sender {
 }
 
 func (s Sender) SendToAllAndFree(msg fastrpc.Serializable,
-	rpc uint8, free func()) {
+	rpc fastrpc.Code, free func()) {
 	s <- SendArg{
 		msg:      msg,
 		rpc:      rpc,
@@ -79,7 +82,7 @@ func (s Sender) SendToAllAndFree(msg fastrpc.Serializable,
 	}
 }
 
-func (s Sender) SendToAllExecptAndFree(except int32, msg fastrpc.Serializable, rpc uint8, free func()) {
+func (s Sender) SendToAllExecptAndFree(except int32, msg fastrpc.Serializable, rpc fastrpc.Code, free func()) {
 	s <- SendArg{
 		msg:      msg,
 		rpc:      rpc,
@@ -90,7 +93,7 @@ func (s Sender) SendToAllExecptAndFree(except int32, msg fastrpc.Serializable, r
 }
 
 func (s Sender) SendToQuorumAndFree(q Quorum,
-	msg fastrpc.Serializable, rpc uint8, free func()) {
+	msg fastrpc.Serializable, rpc fastrpc.Code, free func()) {
 	s <- SendArg{
 		msg:      msg,
 		rpc:      rpc,
@@ -101,7 +104,7 @@ func (s Sender) SendToQuorumAndFree(q Quorum,
 }
 
 func (s Sender) SendExceptAndFree(q Quorum,
-	msg fastrpc.Serializable, rpc uint8, free func()) {
+	msg fastrpc.Serializable, rpc fastrpc.Code, free func()) {
 	s <- SendArg{
 		msg:      msg,
 		rpc:      rpc,
@@ -112,7 +115,7 @@ func (s Sender) SendExceptAndFree(q Quorum,
 }
 
 func (s Sender) SendToClientAndFree(cid int32,
-	msg fastrpc.Serializable, rpc uint8, free func()) {
+	msg fastrpc.Serializable, rpc fastrpc.Code, free func()) {
 	s <- SendArg{
 		msg:      msg,
 		rpc:      rpc,
@@ -123,7 +126,7 @@ func (s Sender) SendToClientAndFree(cid int32,
 }
 
 func (s Sender) SendToAndFree(id int32,
-	msg fastrpc.Serializable, rpc uint8, free func()) {
+	msg fastrpc.Serializable, rpc fastrpc.Code, free func()) {
 	s <- SendArg{
 		msg:      msg,
 		rpc:      rpc,
@@ -133,31 +136,31 @@ func (s Sender) SendToAndFree(id int32,
 	}
 }
 
-func (s Sender) SendToAll(msg fastrpc.Serializable, rpc uint8) {
+func (s Sender) SendToAll(msg fastrpc.Serializable, rpc fastrpc.Code) {
 	s.SendToAllAndFree(msg, rpc, nil)
 }
 
-func (s Sender) SendToAllExecpt(except int32, msg fastrpc.Serializable, rpc uint8) {
+func (s Sender) SendToAllExecpt(except int32, msg fastrpc.Serializable, rpc fastrpc.Code) {
 	s.SendToAllExecptAndFree(except, msg, rpc, nil)
 }
 
-func (s Sender) SendToQuorum(q Quorum, msg fastrpc.Serializable, rpc uint8) {
+func (s Sender) SendToQuorum(q Quorum, msg fastrpc.Serializable, rpc fastrpc.Code) {
 	s.SendToQuorumAndFree(q, msg, rpc, nil)
 }
 
-func (s Sender) SendExcept(q Quorum, msg fastrpc.Serializable, rpc uint8) {
+func (s Sender) SendExcept(q Quorum, msg fastrpc.Serializable, rpc fastrpc.Code) {
 	s.SendExceptAndFree(q, msg, rpc, nil)
 }
 
-func (s Sender) SendToClient(cid int32, msg fastrpc.Serializable, rpc uint8) {
+func (s Sender) SendToClient(cid int32, msg fastrpc.Serializable, rpc fastrpc.Code) {
 	s.SendToClientAndFree(cid, msg, rpc, nil)
 }
 
-func (s Sender) SendTo(id int32, msg fastrpc.Serializable, rpc uint8) {
+func (s Sender) SendTo(id int32, msg fastrpc.Serializable, rpc fastrpc.Code) {
 	s.SendToAndFree(id, msg, rpc, nil)
 }
 
-func sendToAll(r *Replica, msg fastrpc.Serializable, rpc uint8) {
+func sendToAll(r *Replica, msg fastrpc.Serializable, rpc fastrpc.Code) {
 	for p := int32(0); p < int32(r.N); p++ {
 		r.M.Lock()
 		alive := r.Alive[p]
@@ -169,7 +172,7 @@ func sendToAll(r *Replica, msg fastrpc.Serializable, rpc uint8) {
 	}
 }
 
-func sendToAllExcept(r *Replica, except int32, msg fastrpc.Serializable, rpc uint8) {
+func sendToAllExcept(r *Replica, except int32, msg fastrpc.Serializable, rpc fastrpc.Code) {
 	for p := int32(0); p < int32(r.N); p++ {
 		if p == except {
 			continue
@@ -185,7 +188,7 @@ func sendToAllExcept(r *Replica, except int32, msg fastrpc.Serializable, rpc uin
 }
 
 func sendToQuorum(r *Replica, q Quorum,
-	msg fastrpc.Serializable, rpc uint8) {
+	msg fastrpc.Serializable, rpc fastrpc.Code) {
 	for p := int32(0); p < int32(r.N); p++ {
 		if !q.Contains(p) {
 			continue
@@ -201,7 +204,7 @@ func sendToQuorum(r *Replica, q Quorum,
 }
 
 func sendExcept(r *Replica, q Quorum,
-	msg fastrpc.Serializable, rpc uint8) {
+	msg fastrpc.Serializable, rpc fastrpc.Code) {
 	for p := int32(0); p < int32(r.N); p++ {
 		if q.Contains(p) {
 			continue
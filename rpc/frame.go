@@ -0,0 +1,156 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sync"
+)
+
+// Messages are sent in frames: the length of the payload and its CRC32C
+// (Castagnoli), four bytes each in little endian, followed by the payload,
+// i.e. the code of the message, if it has one, and the message.
+const FrameHeaderSize = 8
+
+// MaxFrameSize is the largest payload of a frame.
+const MaxFrameSize = 64 << 20
+
+// ErrCorruptFrame is returned (wrapped) by ReadFrame and UnmarshalFrame
+// when a frame is malformed or does not match its checksum.
+var ErrCorruptFrame = errors.New("corrupt frame")
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var framePool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// WriteFrame writes code and msg to w in a frame.
+func WriteFrame(w io.Writer, code Code, msg Serializable) error {
+	b := framePool.Get().(*bytes.Buffer)
+	b.Reset()
+	b.Write(make([]byte, FrameHeaderSize))
+	WriteCode(b, code)
+	msg.Marshal(b)
+	err := writeFrame(w, b)
+	framePool.Put(b)
+	return err
+}
+
+// WriteRawFrame writes msg to w in a frame, without a code (e.g. the
+// replies to the proposals of the clients).
+func WriteRawFrame(w io.Writer, msg Serializable) error {
+	b := framePool.Get().(*bytes.Buffer)
+	b.Reset()
+	b.Write(make([]byte, FrameHeaderSize))
+	msg.Marshal(b)
+	err := writeFrame(w, b)
+	framePool.Put(b)
+	return err
+}
+
+// WriteFrameBytes writes payload to w in a frame.
+func WriteFrameBytes(w io.Writer, payload []byte) error {
+	var h [FrameHeaderSize]byte
+	binary.LittleEndian.PutUint32(h[:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(h[4:], crc32.Checksum(payload, crcTable))
+	if _, err := w.Write(h[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// writeFrame writes the frame in b, whose first FrameHeaderSize bytes are
+// left for the header.
+func writeFrame(w io.Writer, b *bytes.Buffer) error {
+	f := b.Bytes()
+	payload := f[FrameHeaderSize:]
+	if len(payload) > MaxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds %d bytes", len(payload), MaxFrameSize)
+	}
+	binary.LittleEndian.PutUint32(f[:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(f[4:], crc32.Checksum(payload, crcTable))
+	_, err := w.Write(f)
+	return err
+}
+
+// ReadFrame reads a frame from r and returns its payload, stored in buf if
+// it is large enough. Errors reading from r are returned as is, and
+// malformed frames are reported with ErrCorruptFrame.
+func ReadFrame(r io.Reader, buf []byte) ([]byte, error) {
+	var h [FrameHeaderSize]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return nil, err
+	}
+	n := binary.LittleEndian.Uint32(h[:4])
+	if n > MaxFrameSize {
+		return nil, fmt.Errorf("%w: length %d exceeds %d bytes", ErrCorruptFrame, n, MaxFrameSize)
+	}
+	if uint32(cap(buf)) < n {
+		buf = make([]byte, n)
+	}
+	buf = buf[:n]
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if sum := crc32.Checksum(buf, crcTable); sum != binary.LittleEndian.Uint32(h[4:]) {
+		return nil, fmt.Errorf("%w: checksum %08x, want %08x", ErrCorruptFrame, sum, binary.LittleEndian.Uint32(h[4:]))
+	}
+	return buf, nil
+}
+
+// UnmarshalFrame unmarshals msg from the rest of the payload of a frame,
+// which it must span exactly.
+func UnmarshalFrame(payload *bytes.Reader, msg Serializable) error {
+	if err := msg.Unmarshal(payload); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrCorruptFrame, TypeName(msg), err)
+	}
+	if payload.Len() > 0 {
+		return fmt.Errorf("%w: %d bytes after %s", ErrCorruptFrame, payload.Len(), TypeName(msg))
+	}
+	return nil
+}
+
+// FrameReader reads the frames of a connection, reusing its buffer.
+type FrameReader struct {
+	r       io.Reader
+	buf     []byte
+	payload bytes.Reader
+}
+
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{r: r}
+}
+
+// Next reads the next frame and returns a reader of its payload, valid
+// until the following call.
+func (f *FrameReader) Next() (*bytes.Reader, error) {
+	b, err := ReadFrame(f.r, f.buf)
+	if err != nil {
+		return nil, err
+	}
+	f.buf = b
+	f.payload.Reset(b)
+	return &f.payload, nil
+}
+
+// NextCode reads the next frame and returns the code of its message and a
+// reader of the message.
+func (f *FrameReader) NextCode() (Code, *bytes.Reader, error) {
+	payload, err := f.Next()
+	if err != nil {
+		return 0, nil, err
+	}
+	code, err := ReadCode(payload)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: no message code", ErrCorruptFrame)
+	}
+	return code, payload, nil
+}
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+type msgBytes struct{ b []byte }
+
+func (m *msgBytes) Marshal(w io.Writer) {
+	w.Write([]byte{byte(len(m.b))})
+	w.Write(m.b)
+}
+
+func (m *msgBytes) Unmarshal(r io.Reader) error {
+	var n [1]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return err
+	}
+	m.b = make([]byte, n[0])
+	_, err := io.ReadFull(r, m.b)
+	return err
+}
+
+func (*msgBytes) New() Serializable { return &msgBytes{} }
+
+func TestFrames(t *testing.T) {
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	WriteFrame(w, 0x1234, &msgBytes{[]byte("abc")})
+	WriteRawFrame(w, &msgBytes{[]byte("de")})
+	WriteFrameBytes(w, []byte("{}"))
+	w.Flush()
+
+	f := NewFrameReader(&b)
+	code, payload, err := f.NextCode()
+	if err != nil || code != 0x1234 {
+		t.Fatalf("NextCode = %d, %v", code, err)
+	}
+	m := &msgBytes{}
+	if err := UnmarshalFrame(payload, m); err != nil || string(m.b) != "abc" {
+		t.Errorf("UnmarshalFrame = %q, %v", m.b, err)
+	}
+	if payload, err = f.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnmarshalFrame(payload, m); err != nil || string(m.b) != "de" {
+		t.Errorf("UnmarshalFrame = %q, %v", m.b, err)
+	}
+	if payload, err = f.Next(); err != nil || payload.Len() != 2 {
+		t.Errorf("Next = %v, %v", payload, err)
+	}
+	if _, err = f.Next(); err != io.EOF {
+		t.Errorf("Next at the end = %v, want EOF", err)
+	}
+}
+
+func TestCorruptFrames(t *testing.T) {
+	frame := func() []byte {
+		var b bytes.Buffer
+		WriteFrame(&b, 7, &msgBytes{[]byte("abc")})
+		return b.Bytes()
+	}
+	read := func(f []byte, m Serializable) error {
+		_, payload, err := NewFrameReader(bytes.NewReader(f)).NextCode()
+		if err != nil {
+			return err
+		}
+		return UnmarshalFrame(payload, m)
+	}
+
+	f := frame()
+	f[FrameHeaderSize+2] ^= 1
+	if err := read(f, &msgBytes{}); !errors.Is(err, ErrCorruptFrame) {
+		t.Errorf("flipped bit: got %v", err)
+	}
+	f = frame()
+	f[3] = 0xff
+	if err := read(f, &msgBytes{}); !errors.Is(err, ErrCorruptFrame) {
+		t.Errorf("bad length: got %v", err)
+	}
+	// a message shorter than its frame
+	var b bytes.Buffer
+	WriteFrameBytes(&b, []byte{7, 1, 'a', 'b'})
+	if err := read(b.Bytes(), &msgBytes{}); !errors.Is(err, ErrCorruptFrame) {
+		t.Errorf("trailing bytes: got %v", err)
+	}
+	// and longer
+	b.Reset()
+	WriteFrameBytes(&b, []byte{7, 3, 'a'})
+	if err := read(b.Bytes(), &msgBytes{}); !errors.Is(err, ErrCorruptFrame) {
+		t.Errorf("truncated message: got %v", err)
+	}
+	// a truncated frame is a connection error
+	if err := read(frame()[:5], &msgBytes{}); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated frame: got %v", err)
+	}
+}
@@ -1,6 +1,13 @@
 package rpc
 
-import "io"
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
 
 type Serializable interface {
 	Marshal(io.Writer)
@@ -8,41 +15,170 @@ type Serializable interface {
 	New() Serializable
 }
 
+// Code identifies the type of a message on the wire. Codes below 0x80 take
+// one byte, the others two (see WriteCode).
+type Code uint16
+
+// MaxCode is the largest message code.
+const MaxCode Code = 0x7fff
+
 type Pair struct {
+	Name string
 	Obj  Serializable
 	Chan chan Serializable
 }
 
+// Table maps message codes to message types. Messages are registered by
+// name, and the code of a message is derived from its name, so peers need
+// not register their messages in the same order.
 type Table struct {
-	id    uint8
-	pairs map[uint8]Pair
+	id    Code // first code of the registered messages
+	pairs map[Code]Pair
+	names map[string]Code
 }
 
 func NewTable() *Table {
-	return &Table{
-		id:    0,
-		pairs: make(map[uint8]Pair),
-	}
+	return NewTableId(0)
 }
 
-func NewTableId(id uint8) *Table {
+// NewTableId returns a table whose codes are at least id, leaving the codes
+// below to messages handled without the table.
+func NewTableId(id Code) *Table {
 	return &Table{
 		id:    id,
-		pairs: make(map[uint8]Pair),
+		pairs: make(map[Code]Pair),
+		names: make(map[string]Code),
 	}
 }
 
-func (t *Table) Register(obj Serializable, notify chan Serializable) uint8 {
-	id := t.id
-	t.id++
+// TypeName returns the name under which Register registers obj: the last
+// element of the path of its package and its type, e.g. "curp-ht.MReply".
+func TypeName(obj Serializable) string {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	pkg := t.PkgPath()
+	return pkg[strings.LastIndex(pkg, "/")+1:] + "." + t.Name()
+}
+
+// Register registers obj under the name of its type (see TypeName) and
+// returns its code.
+func (t *Table) Register(obj Serializable, notify chan Serializable) Code {
+	return t.RegisterName(TypeName(obj), obj, notify)
+}
+
+// RegisterName registers obj under name and returns its code, a hash of
+// name. It panics if another message has the same name or code; one of two
+// messages whose names collide is registered with RegisterId instead.
+func (t *Table) RegisterName(name string, obj Serializable, notify chan Serializable) Code {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return t.RegisterId(t.id+Code(h.Sum32()%uint32(MaxCode-t.id+1)), name, obj, notify)
+}
+
+// RegisterId registers obj under name with the code id. It panics if
+// another message has the same name or code.
+func (t *Table) RegisterId(id Code, name string, obj Serializable, notify chan Serializable) Code {
+	if id < t.id || id > MaxCode {
+		panic(fmt.Sprintf("rpc: code %d of %s out of [%d, %d]", id, name, t.id, MaxCode))
+	}
+	if _, exists := t.names[name]; exists {
+		panic(fmt.Sprintf("rpc: %s registered twice", name))
+	}
+	if other, exists := t.pairs[id]; exists {
+		panic(fmt.Sprintf("rpc: %s and %s have the same code %d", other.Name, name, id))
+	}
 	t.pairs[id] = Pair{
+		Name: name,
 		Obj:  obj,
 		Chan: notify,
 	}
+	t.names[name] = id
 	return id
 }
 
-func (t *Table) Get(id uint8) (Pair, bool) {
+func (t *Table) Get(id Code) (Pair, bool) {
 	p, exists := t.pairs[id]
 	return p, exists
 }
+
+// Messages returns the codes of the registered messages by name.
+func (t *Table) Messages() map[string]Code {
+	m := make(map[string]Code, len(t.names))
+	for name, id := range t.names {
+		m[name] = id
+	}
+	return m
+}
+
+// CompareMessages returns the messages with different codes in the tables
+// of a local and a remote node and, if all is set, the messages registered
+// by one node only, or nil if there are none.
+func CompareMessages(local, remote map[string]Code, all bool) error {
+	var diff, onlyLocal, onlyRemote []string
+	for name, id := range local {
+		rid, exists := remote[name]
+		if !exists {
+			onlyLocal = append(onlyLocal, name)
+		} else if rid != id {
+			diff = append(diff, fmt.Sprintf("%s (local %d, remote %d)", name, id, rid))
+		}
+	}
+	for name := range remote {
+		if _, exists := local[name]; !exists {
+			onlyRemote = append(onlyRemote, name)
+		}
+	}
+	var errs []string
+	if len(diff) > 0 {
+		sort.Strings(diff)
+		errs = append(errs, "messages with different codes: "+strings.Join(diff, ", "))
+	}
+	if all && len(onlyLocal) > 0 {
+		sort.Strings(onlyLocal)
+		errs = append(errs, "messages missing on the remote: "+strings.Join(onlyLocal, ", "))
+	}
+	if all && len(onlyRemote) > 0 {
+		sort.Strings(onlyRemote)
+		errs = append(errs, "messages missing locally: "+strings.Join(onlyRemote, ", "))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(errs, "; "))
+}
+
+// WriteCode writes c in one byte if it is below 0x80, and else in two bytes,
+// the first one with its high bit set.
+func WriteCode(w io.ByteWriter, c Code) error {
+	if c < 0x80 {
+		return w.WriteByte(byte(c))
+	}
+	if err := w.WriteByte(0x80 | byte(c>>8)); err != nil {
+		return err
+	}
+	return w.WriteByte(byte(c))
+}
+
+// ReadCode reads a code written by WriteCode.
+func ReadCode(r io.ByteReader) (Code, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return CodeFrom(b, r)
+}
+
+// CodeFrom returns the code whose first byte is b, reading its second byte
+// from r if it has one.
+func CodeFrom(b byte, r io.ByteReader) (Code, error) {
+	if b < 0x80 {
+		return Code(b), nil
+	}
+	lo, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return Code(b&0x7f)<<8 | Code(lo), nil
+}
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+type msgA struct{}
+
+func (*msgA) Marshal(io.Writer)         {}
+func (*msgA) Unmarshal(io.Reader) error { return nil }
+func (*msgA) New() Serializable         { return &msgA{} }
+
+type msgB struct{ msgA }
+
+func (*msgB) New() Serializable { return &msgB{} }
+
+func TestTypeName(t *testing.T) {
+	if name := TypeName(&msgA{}); name != "rpc.msgA" {
+		t.Errorf("TypeName = %q, want rpc.msgA", name)
+	}
+}
+
+// TestRegisterOrder verifies that codes do not depend on the order of
+// registration.
+func TestRegisterOrder(t *testing.T) {
+	t1, t2 := NewTableId(10), NewTableId(10)
+	a1 := t1.Register(&msgA{}, nil)
+	b1 := t1.Register(&msgB{}, nil)
+	b2 := t2.Register(&msgB{}, nil)
+	a2 := t2.Register(&msgA{}, nil)
+	if a1 != a2 || b1 != b2 {
+		t.Errorf("codes %d %d and %d %d", a1, b1, a2, b2)
+	}
+	if a1 < 10 || b1 < 10 {
+		t.Errorf("codes %d %d below the first code", a1, b1)
+	}
+	if p, exists := t1.Get(b1); !exists || p.Name != "rpc.msgB" {
+		t.Errorf("Get(%d) = %v, %v", b1, p, exists)
+	}
+	if err := CompareMessages(t1.Messages(), t2.Messages(), true); err != nil {
+		t.Error(err)
+	}
+}
+
+func expectPanic(t *testing.T, want string, f func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), want) {
+			t.Errorf("panic %v, want %q", r, want)
+		}
+	}()
+	f()
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	tb := NewTableId(10)
+	a := tb.Register(&msgA{}, nil)
+	expectPanic(t, "registered twice", func() { tb.Register(&msgA{}, nil) })
+	expectPanic(t, "same code", func() { tb.RegisterId(a, "other", &msgB{}, nil) })
+	expectPanic(t, "out of", func() { tb.RegisterId(5, "low", &msgB{}, nil) })
+
+	// more than 256 messages
+	for i := 0; i < 1000; i++ {
+		tb.RegisterId(Code(1000+i), fmt.Sprintf("m%d", i), &msgB{}, nil)
+	}
+	if len(tb.Messages()) != 1001 {
+		t.Errorf("%d messages, want 1001", len(tb.Messages()))
+	}
+}
+
+func TestCompareMessages(t *testing.T) {
+	local := map[string]Code{"a": 1, "b": 2, "c": 3}
+	remote := map[string]Code{"a": 1, "b": 4, "d": 5}
+	err := CompareMessages(local, remote, true)
+	for _, want := range []string{"b (local 2, remote 4)", "missing on the remote: c", "missing locally: d"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %q", err, want)
+		}
+	}
+	err = CompareMessages(local, remote, false)
+	if err == nil || strings.Contains(err.Error(), "missing") {
+		t.Errorf("conflicts only: got %v", err)
+	}
+}
+
+func TestCodeEncoding(t *testing.T) {
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	codes := []Code{0, 1, 0x7f, 0x80, 0xff, 0x100, 0x1234, MaxCode}
+	for _, c := range codes {
+		WriteCode(w, c)
+	}
+	w.Flush()
+	// small codes keep their one-byte encoding
+	if b.Bytes()[0] != 0 || b.Bytes()[2] != 0x7f {
+		t.Errorf("one-byte codes encoded as % x", b.Bytes()[:3])
+	}
+	r := bufio.NewReader(&b)
+	for _, want := range codes {
+		c, err := ReadCode(r)
+		if err != nil || c != want {
+			t.Errorf("ReadCode = %d, %v, want %d", c, err, want)
+		}
+	}
+}
@@ -1,16 +1,11 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"net"
-	"net/http"
 	_ "net/http/pprof" // Enable pprof endpoints for profiling
 	"net/rpc"
-	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/imdea-software/swiftpaxos/config"
@@ -29,159 +24,175 @@ import (
 	"github.com/imdea-software/swiftpaxos/mongotunable"
 	"github.com/imdea-software/swiftpaxos/pileus"
 	"github.com/imdea-software/swiftpaxos/pileusht"
+	"github.com/imdea-software/swiftpaxos/replica"
 	"github.com/imdea-software/swiftpaxos/replica/defs"
 	"github.com/imdea-software/swiftpaxos/swift"
+	"github.com/imdea-software/swiftpaxos/transport"
 )
 
+// protocols are the protocols that runReplica starts.
+var protocols = []string{
+	"swiftpaxos", "curp", "curpht", "curpho", "fastpaxos", "n2paxos", "paxos",
+	"epaxos", "epaxosswift", "epaxosho", "raft", "raftht", "mongotunable",
+	"pileus", "pileusht",
+}
+
 func runReplica(c *config.Config, logger *dlog.Logger) {
-	// Derive port and replica index from alias.
-	// e.g., "replica0" → port 7070, index 0; "replica3" → port 7073, index 3
-	port := 7070
-	aliasIdx := 0
-	for i, ch := range c.Alias {
-		if ch >= '0' && ch <= '9' {
-			idx, err := strconv.Atoi(c.Alias[i:])
-			if err == nil {
-				port = 7070 + idx
-				aliasIdx = idx
-			}
-			break
-		}
-	}
+	// The ports are those of the config, or else derived from the alias,
+	// e.g., "replica3" → index 3, port 7073, RPC port 8073.
+	idx := c.ReplicaIndex(c.Alias)
+	port := c.ReplicaPort(c.Alias)
+	rpcPort := c.ReplicaRPCPort(c.Alias)
 
-	log.Printf("Server starting on port %d", port)
-	maddr := fmt.Sprintf("%s:%d", c.MasterAddr, c.MasterPort)
+	log.Printf("Server starting on port %d (RPC port %d)", port, rpcPort)
 	addr := c.ReplicaAddrs[c.Alias]
-	replicaId, nodeList, isLeader := registerWithMaster(addr, maddr, port, aliasIdx)
-	f := (len(c.ReplicaAddrs) - 1) / 2
+	tr := transport.OrTCP(c.Transport)
+	replicaId, nodeList, isLeader := registerWithMaster(tr, addr, c.MasterList(), port, rpcPort, idx)
+	f := (c.Voters(len(c.ReplicaAddrs)) - 1) / 2
 	log.Printf("Tolerating %d max. failures", f)
 
+	srv := rpc.NewServer()
+	var rep replica.Statuser
 	switch strings.ToLower(c.Protocol) {
 	case "swiftpaxos":
 		log.Println("Starting SwiftPaxos replica...")
 		if c.MaxDescRoutines > 0 {
 			swift.MaxDescRoutines = c.MaxDescRoutines
 		}
-		rep := swift.New(c.Alias, replicaId, nodeList, !c.Noop,
+		rep = swift.New(c.Alias, replicaId, nodeList, !c.Noop,
 			c.Optread, true, false, 1, f, c, logger, nil)
-		rpc.Register(rep)
+		srv.Register(rep)
 	case "curp":
+		if c.IsWitness(c.Alias) {
+			log.Println("Starting CURP witness...")
+			rep = curp.NewWitness(c.Alias, replicaId, nodeList, f, c, logger)
+			srv.RegisterName("Replica", rep)
+			break
+		}
 		log.Println("Starting optimized CURP replica...")
 		if c.MaxDescRoutines > 0 {
 			curp.MaxDescRoutines = c.MaxDescRoutines
 		}
-		rep := curp.New(c.Alias, replicaId, nodeList, !c.Noop,
+		rep = curp.New(c.Alias, replicaId, nodeList, !c.Noop,
 			1, f, true, c, logger)
-		rpc.Register(rep)
+		srv.Register(rep)
 	case "curpht":
+		if c.IsWitness(c.Alias) {
+			log.Println("Starting CURP-HT (Hybrid Transparency) witness...")
+			rep = curpht.NewWitness(c.Alias, replicaId, nodeList, f, c, logger)
+			srv.RegisterName("Replica", rep)
+			break
+		}
 		log.Println("Starting CURP-HT (Hybrid Transparency) replica...")
 		if c.MaxDescRoutines > 0 {
 			curpht.MaxDescRoutines = c.MaxDescRoutines
 		}
-		rep := curpht.New(c.Alias, replicaId, nodeList, !c.Noop,
+		rep = curpht.New(c.Alias, replicaId, nodeList, !c.Noop,
 			1, f, true, c, logger)
-		rpc.Register(rep)
+		srv.Register(rep)
 	case "curpho":
+		if c.IsWitness(c.Alias) {
+			log.Println("Starting CURP-HO (Hybrid Optimal) witness...")
+			rep = curpho.NewWitness(c.Alias, replicaId, nodeList, f, c, logger)
+			srv.RegisterName("Replica", rep)
+			break
+		}
 		log.Println("Starting CURP-HO (Hybrid Optimal) replica...")
 		if c.MaxDescRoutines > 0 {
 			curpho.MaxDescRoutines = c.MaxDescRoutines
 		}
-		rep := curpho.New(c.Alias, replicaId, nodeList, !c.Noop,
+		rep = curpho.New(c.Alias, replicaId, nodeList, !c.Noop,
 			1, f, true, c, logger)
-		rpc.Register(rep)
+		srv.Register(rep)
 	case "fastpaxos":
 		log.Println("Starting Fast Paxos replica...")
-		rep := fastpaxos.New(c.Alias, replicaId, nodeList, !c.Noop, f, c, logger)
-		rpc.Register(rep)
+		rep = fastpaxos.New(c.Alias, replicaId, nodeList, !c.Noop, f, c, logger)
+		srv.Register(rep)
 	case "n2paxos":
 		log.Println("Starting N²Paxos replica...")
-		rep := n2paxos.New(c.Alias, replicaId, nodeList, !c.Noop, 1, f, c, logger)
-		rpc.Register(rep)
+		rep = n2paxos.New(c.Alias, replicaId, nodeList, !c.Noop, 1, f, c, logger)
+		srv.Register(rep)
 	case "paxos":
 		log.Println("Starting Paxos replica...")
-		rep := paxos.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
-		rpc.Register(rep)
+		rep = paxos.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
+		srv.Register(rep)
 	case "epaxos":
 		log.Println("Starting EPaxos replica...")
-		rep := epaxos.New(c.Alias, replicaId, nodeList, !c.Noop, false, false, 0, false, f, c, logger)
-		rpc.Register(rep)
+		rep = epaxos.New(c.Alias, replicaId, nodeList, !c.Noop, false, false, 0, false, f, c, logger)
+		srv.Register(rep)
 	case "epaxosswift":
 		log.Println("Starting EPaxos-Swift replica...")
-		rep := epaxosswift.New(c.Alias, replicaId, nodeList, !c.Noop, false, false, 0, false, f, c, logger)
-		rpc.Register(rep)
+		rep = epaxosswift.New(c.Alias, replicaId, nodeList, !c.Noop, false, false, 0, false, f, c, logger)
+		srv.Register(rep)
 	case "epaxosho":
 		log.Println("Starting EPaxos-HO replica...")
-		rep := epaxosho.New(c.Alias, replicaId, nodeList, !c.Noop, false, false, 0, f, c, logger)
-		rpc.Register(rep)
+		rep = epaxosho.New(c.Alias, replicaId, nodeList, !c.Noop, false, false, 0, f, c, logger)
+		srv.Register(rep)
 	case "raft":
 		log.Println("Starting Raft replica...")
-		rep := raft.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
-		rpc.Register(rep)
+		rep = raft.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
+		srv.Register(rep)
 	case "raftht":
 		log.Println("Starting Raft-HT replica...")
-		rep := raftht.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
-		rpc.Register(rep)
+		rep = raftht.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
+		srv.Register(rep)
 	case "mongotunable":
 		log.Println("Starting MongoDB-Tunable replica...")
-		rep := mongotunable.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
-		rpc.Register(rep)
+		rep = mongotunable.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
+		srv.Register(rep)
 	case "pileus":
 		log.Println("Starting Pileus replica...")
-		rep := pileus.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
-		rpc.Register(rep)
+		rep = pileus.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
+		srv.Register(rep)
 	case "pileusht":
 		log.Println("Starting Pileus-HT replica...")
-		rep := pileusht.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
-		rpc.Register(rep)
+		rep = pileusht.New(c.Alias, replicaId, nodeList, isLeader, f, c, logger)
+		srv.Register(rep)
 	}
 
-	rpc.HandleHTTP()
 	// Listen on all interfaces (0.0.0.0) for RPC.
 	// Required for AWS where instances bind to private IPs but peers connect via public IPs.
-	// Use SO_REUSEADDR to avoid TIME_WAIT conflicts between consecutive benchmark runs.
-	lc := net.ListenConfig{
-		Control: func(network, address string, c syscall.RawConn) error {
-			return c.Control(func(fd uintptr) {
-				syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
-			})
-		},
-	}
-	l, err := lc.Listen(context.Background(), "tcp", fmt.Sprintf("0.0.0.0:%d", port+1000))
+	l, err := tr.Listen(fmt.Sprintf("0.0.0.0:%d", rpcPort))
 	if err != nil {
 		log.Fatal("listen error:", err)
 	}
-	http.Serve(l, nil)
+	transport.ServeRPCWith(l, srv, replica.Handlers(rep))
 }
 
-func registerWithMaster(addr, mAddr string, port int, replicaId int) (int, []string, bool) {
+// registerWithMaster registers the replica with the primary master, trying
+// the masters of mAddrs in turn.
+func registerWithMaster(tr transport.Transport, addr string, mAddrs []string, port, rpcPort int, replicaId int) (int, []string, bool) {
 	var reply defs.RegisterReply
 	args := &defs.RegisterArgs{
 		Addr:      addr,
 		Port:      port,
+		RPCPort:   rpcPort,
 		ReplicaId: replicaId,
 	}
-	log.Printf("connecting to: %v", mAddr)
 
-	for {
-		mcli, err := rpc.DialHTTP("tcp", mAddr)
-		if err == nil {
-			for {
-				// TODO: This is an active wait...
-				err = mcli.Call("Master.Register", args, &reply)
-				if err == nil {
-					if reply.Ready {
-						break
-					}
-					time.Sleep(4)
-				} else {
-					log.Printf("%v", err)
-				}
+	for m := 0; ; m = (m + 1) % len(mAddrs) {
+		log.Printf("connecting to: %v", mAddrs[m])
+		mcli, err := transport.DialHTTP(tr, mAddrs[m], 3*time.Second)
+		if err != nil {
+			log.Printf("%v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		for {
+			// TODO: This is an active wait...
+			err = mcli.Call("Master.Register", args, &reply)
+			if err != nil || reply.Ready {
+				break
 			}
+			time.Sleep(4)
+		}
+		mcli.Close()
+		if err == nil {
 			break
-		} else {
-			log.Printf("%v", err)
 		}
-		time.Sleep(4)
+		// a backup or a lost master
+		log.Printf("%v", err)
+		time.Sleep(100 * time.Millisecond)
 	}
 
 	return reply.ReplicaId, reply.NodeList, reply.IsLeader
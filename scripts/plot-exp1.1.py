@@ -15,7 +15,6 @@ Data sources:
 """
 
 import json
-import numpy as np
 import os
 import sys
 sys.path.insert(0, os.path.dirname(os.path.abspath(__file__)))
@@ -111,14 +110,8 @@ def plot_cdf(ax, base, wg, wg_label):
         marker = PROTOCOL_MARKERS[proto]
         label  = PROTOCOL_LABELS[proto]
 
-        # Combine all latencies into one list
-        all_lats = (lats.get('strong_write', []) +
-                    lats.get('strong_read', []) +
-                    lats.get('weak_write', []) +
-                    lats.get('weak_read', []))
-        if all_lats:
-            sorted_lats = np.sort(all_lats)
-            cdf = np.arange(1, len(sorted_lats) + 1) / len(sorted_lats)
+        sorted_lats, cdf = latency_cdf(lats)
+        if sorted_lats:
             lw = 3.0 if proto == 'raftht' else 2.5
             ax.plot(sorted_lats, cdf, color=color, linewidth=lw,
                     label=label, zorder=CDF_ZORDER.get(proto, 3))
@@ -24,10 +24,15 @@ EPAXOSHO_KILL_TIME = 45
 
 
 def load_tput(base, result_dir):
-    path = os.path.join(base, 'results', result_dir, 'tput-aggregated.csv')
-    with open(path) as f:
-        rows = list(csv.DictReader(f))
-    tputs = [(int(r['timestamp']), int(r['total_ops'])) for r in rows]
+    ts = load_timeseries(os.path.join(base, 'results', result_dir))
+    if ts is not None:
+        tputs = [(r['timestamp'], r['ops']) for r in ts]
+    else:
+        # legacy runs: TPUT lines extracted from client logs
+        path = os.path.join(base, 'results', result_dir, 'tput-aggregated.csv')
+        with open(path) as f:
+            rows = list(csv.DictReader(f))
+        tputs = [(int(r['timestamp']), int(r['total_ops'])) for r in rows]
     t0 = tputs[0][0]
     return [ts - t0 - 1 for ts, _ in tputs], [tp / 1000 for _, tp in tputs]
 
@@ -15,7 +15,6 @@ Data sources:
 """
 
 import json
-import numpy as np
 import os
 import sys
 sys.path.insert(0, os.path.dirname(os.path.abspath(__file__)))
@@ -98,13 +97,8 @@ def plot_cdf(ax, base, wg, wg_label):
         color  = PROTOCOL_COLORS[proto]
         label  = PROTOCOL_LABELS[proto]
 
-        all_lats = (lats.get('strong_write', []) +
-                    lats.get('strong_read', []) +
-                    lats.get('weak_write', []) +
-                    lats.get('weak_read', []))
-        if all_lats:
-            sorted_lats = np.sort(all_lats)
-            cdf = np.arange(1, len(sorted_lats) + 1) / len(sorted_lats)
+        sorted_lats, cdf = latency_cdf(lats)
+        if sorted_lats:
             ax.plot(sorted_lats, cdf, color=color, linewidth=2.5,
                     label=label, zorder=3)
 
@@ -149,6 +149,60 @@ def pareto_frontier(xs, ys):
     peak_idx = max(range(len(xs)), key=lambda i: xs[i])
     return xs[:peak_idx + 1], ys[:peak_idx + 1]
 
+def latency_cdf(lats, kinds=('strong_write', 'strong_read', 'weak_write', 'weak_read')):
+    """Combined latency CDF of a latencies.json file.
+
+    Accepts both the legacy format (raw per-request latency arrays) and the
+    histogram format ({"count": N, "cdf": [[ms, fraction], ...]}).
+    Returns (latencies, cdf) lists, both empty if there are no samples.
+    """
+    points = []  # (latency_ms, weight)
+    for kind in kinds:
+        entry = lats.get(kind)
+        if not entry:
+            continue
+        if isinstance(entry, list):
+            points.extend((v, 1) for v in entry)
+            continue
+        count, prev = entry.get('count', 0), 0.0
+        for ms, frac in entry.get('cdf') or []:
+            points.append((ms, (frac - prev) * count))
+            prev = frac
+    total = sum(w for _, w in points)
+    if total <= 0:
+        return [], []
+    points.sort()
+    xs, ys, seen = [], [], 0.0
+    for ms, w in points:
+        seen += w
+        xs.append(ms)
+        ys.append(seen / total)
+    return xs, ys
+
+def load_timeseries(result_dir):
+    """Load and sum the per-second timeseries-<alias>.csv files of all clients.
+
+    Returns a list of dicts sorted by time with keys 'timestamp' (unix
+    seconds), 'ops', 'errors', 'stalled', 'inflight' and '<type>_ops' for
+    each command type, or None if the directory has no time series.
+    """
+    import glob
+    paths = sorted(glob.glob(os.path.join(result_dir, 'timeseries-*.csv')))
+    if not paths:
+        return None
+    keys = ['ops', 'errors', 'stalled', 'inflight',
+            'strong_write_ops', 'strong_read_ops', 'weak_write_ops', 'weak_read_ops']
+    by_ts = {}
+    for path in paths:
+        for row in load_csv(path):
+            # intervals are aligned to the wall clock; the final partial
+            # interval of a client is folded into its second
+            ts = (int(row['timestamp_ms']) + 999) // 1000
+            acc = by_ts.setdefault(ts, dict.fromkeys(keys, 0))
+            for k in keys:
+                acc[k] += int(row[k])
+    return [dict(timestamp=ts, **by_ts[ts]) for ts in sorted(by_ts)]
+
 def kops_formatter(x, _):
     """Format throughput axis as Kops/sec."""
     return f'{x/1000:.0f}'
@@ -0,0 +1,101 @@
+// Package sim runs participants on a simulated network and clock, so that
+// the delivery order, delays and losses of messages and the firing of
+// timers are all derived from a single seed.
+package sim
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Clock provides the time, timers and random numbers of a participant.
+// Protocol code that uses a Clock instead of the time and math/rand
+// packages can run under a Simulator.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) *Timer
+	NewTicker(d time.Duration) *Ticker
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	// Int63n returns a random number in [0, n)
+	Int63n(n int64) int64
+}
+
+// Timer is the timer of a Clock. Like a time.Timer, it sends the current
+// time on C when it expires.
+type Timer struct {
+	C <-chan time.Time
+
+	stop  func() bool
+	reset func(time.Duration) bool
+}
+
+// Stop prevents the timer from firing. It returns false if the timer had
+// already expired or been stopped.
+func (t *Timer) Stop() bool {
+	return t.stop()
+}
+
+// Reset changes the timer to expire after d. It returns true if the timer
+// had been active.
+func (t *Timer) Reset(d time.Duration) bool {
+	return t.reset(d)
+}
+
+// Ticker is the ticker of a Clock. Like a time.Ticker, it sends the current
+// time on C every period.
+type Ticker struct {
+	C <-chan time.Time
+
+	stop  func()
+	reset func(time.Duration)
+}
+
+// Stop turns off the ticker.
+func (t *Ticker) Stop() {
+	t.stop()
+}
+
+// Reset stops the ticker and resets its period to d.
+func (t *Ticker) Reset(d time.Duration) {
+	t.reset(d)
+}
+
+// Real is the wall clock, with the timers of the time package.
+var Real Clock = realClock{}
+
+// OrReal returns c, or Real if c is nil.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) *Timer {
+	t := time.NewTimer(d)
+	return &Timer{C: t.C, stop: t.Stop, reset: t.Reset}
+}
+
+func (realClock) NewTicker(d time.Duration) *Ticker {
+	t := time.NewTicker(d)
+	return &Ticker{C: t.C, stop: t.Stop, reset: t.Reset}
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (realClock) Int63n(n int64) int64 {
+	return rand.Int63n(n)
+}
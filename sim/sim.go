@@ -0,0 +1,423 @@
+package sim
+
+import (
+	"container/heap"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/imdea-software/swiftpaxos/transport"
+)
+
+// Epoch is the virtual time at which a simulation starts.
+var Epoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Simulator runs participants on an in-process network and on virtual
+// clocks. It executes one event at a time, a packet delivery or a timer
+// firing, and then waits until the participants are idle before choosing
+// the next one. Virtual time jumps to the next event, so long timeouts cost
+// no wall-clock time.
+//
+// The events produced while handling an event are ordered by the name of
+// their connection or timer, and their delays, losses and duplicates are
+// drawn from the seed. Hence a run is replayed exactly by using the same
+// seed, as long as each participant handles an event within Idle and
+// takes all its time and timers from its Clock.
+//
+// Packets are the writes of the connections. Dropping or duplicating one
+// keeps the byte stream consistent only if writes carry whole messages, as
+// with the replicas' SendMsg, which flushes every message.
+type Simulator struct {
+	// The delay of each packet is drawn uniformly from [MinDelay, MaxDelay]
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// Probability that a packet is dropped or delivered twice
+	DropRate float64
+	DupRate  float64
+	// Wall-clock time without activity after which the participants are
+	// considered done with the last event
+	Idle time.Duration
+
+	seed int64
+	net  *transport.Network
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	now      time.Time
+	seq      uint64
+	activity uint64
+	wake     chan struct{}
+	pending  []*event
+	queue    eventQueue
+	last     map[string]time.Time // last delivery time, by connection end
+	timers   map[string]int       // timers created, by node
+	trace    []string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New creates a simulator with the given seed and its network.
+func New(seed int64) *Simulator {
+	s := &Simulator{
+		MinDelay: time.Millisecond,
+		MaxDelay: 10 * time.Millisecond,
+		Idle:     5 * time.Millisecond,
+
+		seed:   seed,
+		net:    transport.NewNetwork(),
+		rng:    rand.New(rand.NewSource(seed)),
+		now:    Epoch,
+		wake:   make(chan struct{}, 1),
+		last:   make(map[string]time.Time),
+		timers: make(map[string]int),
+	}
+	s.net.SetLink(s)
+	return s
+}
+
+// Seed returns the seed of the simulation.
+func (s *Simulator) Seed() int64 {
+	return s.seed
+}
+
+// Network returns the network whose packets the simulator delivers.
+func (s *Simulator) Network() *transport.Network {
+	return s.net
+}
+
+// Now returns the virtual time.
+func (s *Simulator) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+// Trace returns the events executed so far, one line per event.
+func (s *Simulator) Trace() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.trace...)
+}
+
+// Start starts executing events. Participants may be created before: their
+// packets and timers wait until the simulation starts.
+func (s *Simulator) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run()
+}
+
+// Stop stops executing events and closes the network.
+func (s *Simulator) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	s.net.Close()
+}
+
+// Send implements transport.Link.
+func (s *Simulator) Send(p *transport.Packet) {
+	reply := 0
+	if p.Reply {
+		reply = 1
+	}
+	s.mu.Lock()
+	s.pending = append(s.pending, &event{
+		key:    fmt.Sprintf("p %s %d %09d", p.Conn, reply, p.Seq),
+		packet: p,
+	})
+	s.active()
+	s.mu.Unlock()
+}
+
+// Clock returns the clock of a participant. Its random numbers are drawn
+// from a source derived from the seed and the node name.
+func (s *Simulator) Clock(node string) Clock {
+	h := fnv.New64a()
+	h.Write([]byte(node))
+	return &simClock{
+		s:    s,
+		node: node,
+		rng:  rand.New(rand.NewSource(s.seed ^ int64(h.Sum64()))),
+	}
+}
+
+// active records activity of the participants. Called with s.mu held.
+func (s *Simulator) active() {
+	s.activity++
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Simulator) run() {
+	defer close(s.done)
+	for {
+		if !s.waitIdle() {
+			return
+		}
+		s.mu.Lock()
+		s.schedule()
+		if s.queue.Len() == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+			case <-s.stop:
+				return
+			}
+			continue
+		}
+		e := heap.Pop(&s.queue).(*event)
+		if e.canceled {
+			s.mu.Unlock()
+			continue
+		}
+		if e.at.After(s.now) {
+			s.now = e.at
+		}
+		s.fire(e)
+		s.mu.Unlock()
+	}
+}
+
+// waitIdle waits until there has been no activity for s.Idle. It returns
+// false if the simulator is stopped.
+func (s *Simulator) waitIdle() bool {
+	for {
+		s.mu.Lock()
+		a := s.activity
+		s.mu.Unlock()
+		select {
+		case <-time.After(s.Idle):
+		case <-s.stop:
+			return false
+		}
+		s.mu.Lock()
+		idle := a == s.activity
+		s.mu.Unlock()
+		if idle {
+			return true
+		}
+	}
+}
+
+// schedule orders the events produced since the last step and draws their
+// delays and faults. Called with s.mu held.
+func (s *Simulator) schedule() {
+	sort.SliceStable(s.pending, func(i, j int) bool {
+		return s.pending[i].key < s.pending[j].key
+	})
+	for _, e := range s.pending {
+		if e.canceled {
+			continue
+		}
+		p := e.packet
+		if p == nil {
+			s.push(e)
+			continue
+		}
+		if !p.Close() && s.DropRate > 0 && s.rng.Float64() < s.DropRate {
+			s.record("drop", e)
+			continue
+		}
+		delay := s.MinDelay
+		if s.MaxDelay > s.MinDelay {
+			delay += time.Duration(s.rng.Int63n(int64(s.MaxDelay - s.MinDelay + 1)))
+		}
+		// deliver the packets of a connection in order
+		end := fmt.Sprint(p.Conn, p.Reply)
+		e.at = s.now.Add(delay)
+		if last := s.last[end]; e.at.Before(last) {
+			e.at = last
+		}
+		s.last[end] = e.at
+		s.push(e)
+		if !p.Close() && s.DupRate > 0 && s.rng.Float64() < s.DupRate {
+			s.push(&event{at: e.at, key: e.key, packet: p, dup: true})
+		}
+	}
+	s.pending = nil
+}
+
+// push adds e to the queue. Called with s.mu held.
+func (s *Simulator) push(e *event) {
+	e.seq = s.seq
+	s.seq++
+	heap.Push(&s.queue, e)
+}
+
+// fire executes e. Called with s.mu held.
+func (s *Simulator) fire(e *event) {
+	if e.packet != nil {
+		if e.dup {
+			s.record("dup", e)
+		} else {
+			s.record("deliver", e)
+		}
+		e.packet.Deliver()
+		return
+	}
+	t := e.timer
+	s.record("timer", e)
+	t.ev = nil
+	select {
+	case t.c <- s.now:
+	default:
+	}
+	if t.period > 0 {
+		t.ev = &event{at: s.now.Add(t.period), key: e.key, timer: t}
+		s.push(t.ev)
+	}
+}
+
+// record adds e to the trace. Called with s.mu held.
+func (s *Simulator) record(what string, e *event) {
+	line := fmt.Sprintf("%v %s %s", s.now.Sub(Epoch), what, e.key[2:])
+	if e.packet != nil && !e.packet.Close() {
+		line += fmt.Sprintf(" %dB", len(e.packet.Data))
+	} else if e.packet != nil {
+		line += " close"
+	}
+	s.trace = append(s.trace, line)
+}
+
+type event struct {
+	at  time.Time
+	seq uint64
+	// orders the events produced during the same step
+	key string
+
+	packet *transport.Packet
+	dup    bool
+	timer  *simTimer
+
+	canceled bool
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// simClock is the Clock of one node of a Simulator.
+type simClock struct {
+	s    *Simulator
+	node string
+	rng  *rand.Rand // guarded by s.mu
+}
+
+func (c *simClock) Now() time.Time {
+	return c.s.Now()
+}
+
+func (c *simClock) newTimer(d, period time.Duration) *simTimer {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := &simTimer{
+		s:      s,
+		key:    fmt.Sprintf("t %s %06d", c.node, s.timers[c.node]),
+		c:      make(chan time.Time, 1),
+		period: period,
+	}
+	s.timers[c.node]++
+	t.arm(d)
+	return t
+}
+
+func (c *simClock) NewTimer(d time.Duration) *Timer {
+	t := c.newTimer(d, 0)
+	return &Timer{C: t.c, stop: t.Stop, reset: t.Reset}
+}
+
+func (c *simClock) NewTicker(d time.Duration) *Ticker {
+	if d <= 0 {
+		panic("sim: non-positive interval for NewTicker")
+	}
+	t := c.newTimer(d, d)
+	return &Ticker{
+		C:    t.c,
+		stop: func() { t.Stop() },
+		reset: func(d time.Duration) {
+			t.s.mu.Lock()
+			t.period = d
+			t.s.mu.Unlock()
+			t.Reset(d)
+		},
+	}
+}
+
+func (c *simClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C
+}
+
+func (c *simClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+func (c *simClock) Int63n(n int64) int64 {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	return c.rng.Int63n(n)
+}
+
+// simTimer is a timer or ticker of a simClock.
+type simTimer struct {
+	s      *Simulator
+	key    string
+	c      chan time.Time
+	period time.Duration
+	ev     *event // next firing, nil if stopped or expired
+}
+
+// arm schedules the next firing after d. Called with s.mu held.
+func (t *simTimer) arm(d time.Duration) {
+	t.ev = &event{at: t.s.now.Add(d), key: t.key, timer: t}
+	t.s.pending = append(t.s.pending, t.ev)
+	t.s.active()
+}
+
+// disarm cancels the next firing. Called with s.mu held.
+func (t *simTimer) disarm() bool {
+	t.s.active()
+	if t.ev == nil {
+		return false
+	}
+	t.ev.canceled = true
+	t.ev = nil
+	return true
+}
+
+func (t *simTimer) Stop() bool {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	return t.disarm()
+}
+
+func (t *simTimer) Reset(d time.Duration) bool {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	active := t.disarm()
+	t.arm(d)
+	return active
+}
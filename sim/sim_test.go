@@ -0,0 +1,210 @@
+package sim
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// gossip is a node that forwards counters to random peers, on messages and
+// on random timeouts
+type gossip struct {
+	s     *Simulator
+	name  string
+	clock Clock
+	peers []net.Conn
+	in    chan uint64
+}
+
+func startGossip(t *testing.T, s *Simulator, n int) {
+	nodes := make([]*gossip, n)
+	for i := range nodes {
+		name := fmt.Sprintf("node%d", i)
+		nodes[i] = &gossip{s: s, name: name, clock: s.Clock(name), in: make(chan uint64, 16)}
+		l, err := s.Network().Host(name).Listen(name + ":1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func(g *gossip) {
+			for {
+				c, err := l.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					var b [8]byte
+					for {
+						if _, err := io.ReadFull(c, b[:]); err != nil {
+							return
+						}
+						g.in <- binary.LittleEndian.Uint64(b[:])
+					}
+				}()
+			}
+		}(nodes[i])
+	}
+	for i, g := range nodes {
+		for j := range nodes {
+			if j == i {
+				continue
+			}
+			c, err := s.Network().Host(g.name).Dial(nodes[j].name+":1", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.peers = append(g.peers, c)
+		}
+		go g.run()
+	}
+}
+
+func (g *gossip) send(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	g.peers[g.clock.Int63n(int64(len(g.peers)))].Write(b[:])
+}
+
+func (g *gossip) run() {
+	timer := g.clock.NewTimer(time.Duration(g.clock.Int63n(int64(time.Second))))
+	for {
+		select {
+		case v := <-g.in:
+			if v < 1000 && g.clock.Int63n(2) == 0 {
+				g.send(v + 1)
+			}
+		case <-timer.C:
+			g.send(0)
+			timer.Reset(time.Duration(g.clock.Int63n(int64(time.Second))))
+		}
+	}
+}
+
+// runGossip returns the first n events of a gossip simulation
+func runGossip(t *testing.T, seed int64, n int) []string {
+	s := New(seed)
+	s.DropRate = 0.05
+	s.DupRate = 0.05
+	s.Idle = time.Millisecond
+	defer s.Stop()
+	startGossip(t, s, 4)
+	s.Start()
+
+	deadline := time.Now().Add(20 * time.Second)
+	for len(s.Trace()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d events executed", len(s.Trace()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return s.Trace()[:n]
+}
+
+// TestSimReplay tests that a seed determines the whole schedule
+func TestSimReplay(t *testing.T) {
+	a := runGossip(t, 42, 300)
+	b := runGossip(t, 42, 300)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("runs with the same seed diverge at event %d: %q vs %q", i, a[i], b[i])
+		}
+	}
+
+	c := runGossip(t, 43, 300)
+	same := true
+	for i := range a {
+		same = same && a[i] == c[i]
+	}
+	if same {
+		t.Error("runs with different seeds have the same schedule")
+	}
+}
+
+// TestSimTimers tests virtual time, timers and tickers
+func TestSimTimers(t *testing.T) {
+	s := New(1)
+	defer s.Stop()
+	s.Idle = time.Millisecond
+	c := s.Clock("n")
+
+	hour := c.NewTimer(time.Hour)
+	stopped := c.NewTimer(time.Minute)
+	tick := c.NewTicker(10 * time.Minute)
+	if !stopped.Stop() || stopped.Stop() {
+		t.Error("Stop should report whether the timer was active")
+	}
+	s.Start()
+
+	start := time.Now()
+	at := <-hour.C
+	if at.Sub(Epoch) != time.Hour || c.Now().Sub(Epoch) != time.Hour {
+		t.Errorf("timer fired at %v (now %v), want 1h", at.Sub(Epoch), c.Now().Sub(Epoch))
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("a 1h virtual timer took %v", time.Since(start))
+	}
+	select {
+	case <-stopped.C:
+		t.Error("stopped timer fired")
+	default:
+	}
+
+	// The ticker fired at 10m, ..., 60m; only one tick is buffered
+	if at := <-tick.C; at.Sub(Epoch)%(10*time.Minute) != 0 {
+		t.Errorf("tick at %v", at.Sub(Epoch))
+	}
+	tick.Stop()
+
+	c.Sleep(time.Minute)
+	if d := c.Now().Sub(Epoch); d < 61*time.Minute {
+		t.Errorf("slept until %v, want at least 61m", d)
+	}
+}
+
+// TestSimDelays tests that packets are delivered in order after their delay
+func TestSimDelays(t *testing.T) {
+	s := New(7)
+	defer s.Stop()
+	s.MinDelay = 20 * time.Millisecond
+	s.MaxDelay = 50 * time.Millisecond
+	s.Idle = time.Millisecond
+
+	l, err := s.Network().Host("a").Listen("a:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Dials wait for the listener instead of failing
+	dialed := make(chan net.Conn)
+	go func() {
+		c, _ := s.Network().Host("b").Dial("c:1", 0)
+		dialed <- c
+	}()
+	if _, err := s.Network().Host("c").Listen("c:1"); err != nil {
+		t.Fatal(err)
+	}
+	if c := <-dialed; c == nil {
+		t.Fatal("dial did not wait for the listener")
+	}
+
+	c, err := s.Network().Host("b").Dial("a:1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, _ := l.Accept()
+	s.Start()
+
+	for i := byte(0); i < 10; i++ {
+		c.Write([]byte{i})
+	}
+	buf := make([]byte, 1)
+	for i := byte(0); i < 10; i++ {
+		if _, err := io.ReadFull(srv, buf); err != nil || buf[0] != i {
+			t.Fatalf("read %v, %v; want %d", buf[0], err, i)
+		}
+	}
+	if d := s.Now().Sub(Epoch); d < 20*time.Millisecond || d > 50*time.Millisecond {
+		t.Errorf("delivered at %v, want within [20ms, 50ms]", d)
+	}
+}
@@ -1,10 +1,12 @@
 package state
 
 import (
+	"bufio"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"sort"
 
 	"strconv"
 	"sync"
@@ -278,3 +280,65 @@ func (t *Value) Unmarshal(r io.Reader) error {
 	*t = Value(bs)
 	return nil
 }
+
+// Get returns the value of key k and whether it is in the store.
+func (st *State) Get(k Key) (Value, bool) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	v, ok := st.Store[k]
+	return v, ok
+}
+
+// Save writes the store to w, by increasing key: the number of keys, then
+// each key and its value. It returns the number of keys.
+func (st *State) Save(w io.Writer) (int, error) {
+	st.mutex.Lock()
+	store := make(map[Key]Value, len(st.Store))
+	for k, v := range st.Store {
+		store[k] = v
+	}
+	st.mutex.Unlock()
+
+	keys := make([]Key, 0, len(store))
+	for k := range store {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	bw := bufio.NewWriter(w)
+	n := Key(len(keys))
+	n.Marshal(bw)
+	for _, k := range keys {
+		v := store[k]
+		k.Marshal(bw)
+		v.Marshal(bw)
+	}
+	return len(keys), bw.Flush()
+}
+
+// Load replaces the store with the one written by Save to r.
+func (st *State) Load(r io.Reader) error {
+	br := bufio.NewReader(r)
+	var n Key
+	if err := n.Unmarshal(br); err != nil {
+		return err
+	}
+	store := make(map[Key]Value, n)
+	for i := Key(0); i < n; i++ {
+		var (
+			k Key
+			v Value
+		)
+		if err := k.Unmarshal(br); err != nil {
+			return err
+		}
+		if err := v.Unmarshal(br); err != nil {
+			return err
+		}
+		store[k] = v
+	}
+	st.mutex.Lock()
+	st.Store = store
+	st.mutex.Unlock()
+	return nil
+}
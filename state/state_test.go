@@ -579,3 +579,37 @@ func BenchmarkStateScaling(b *testing.B) {
 		})
 	}
 }
+
+// TestSaveLoad tests that Load restores the store written by Save
+func TestSaveLoad(t *testing.T) {
+	st := InitState()
+	for k := Key(0); k < 10; k++ {
+		cmd := Command{Op: PUT, K: 10 - k, V: Value(fmt.Sprint("v", k))}
+		cmd.Execute(st)
+	}
+
+	var buf bytes.Buffer
+	n, err := st.Save(&buf)
+	if err != nil || n != 10 {
+		t.Fatalf("Save = %d, %v", n, err)
+	}
+	data := buf.Bytes()
+	restored := InitState()
+	restored.Store[42] = Value("stale")
+	if err := restored.Load(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.Store) != 10 {
+		t.Errorf("%d keys restored, want 10", len(restored.Store))
+	}
+	if v, ok := restored.Get(1); !ok || string(v) != "v9" {
+		t.Errorf("Get(1) = %q, %v", v, ok)
+	}
+	if _, ok := restored.Get(42); ok {
+		t.Error("Load kept a key of the previous store")
+	}
+
+	if err := restored.Load(bytes.NewReader(data[:3])); err == nil {
+		t.Error("truncated snapshot loaded")
+	}
+}
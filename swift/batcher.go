@@ -130,7 +130,7 @@ func NewBatcher(r *Replica, size int,
 
 				var (
 					m   fastrpc.Serializable
-					rpc uint8
+					rpc fastrpc.Code
 				)
 				if ballot != -1 {
 					m = optAcks
@@ -238,7 +238,7 @@ func NewBatcher(r *Replica, size int,
 
 				var (
 					m   fastrpc.Serializable
-					rpc uint8
+					rpc fastrpc.Code
 				)
 				if ballot != -1 {
 					m = optAcks
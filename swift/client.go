@@ -50,7 +50,7 @@ func NewClient(b *client.BufferClient, repNum int) *Client {
 		alreadySlow: make(map[CommandId]struct{}),
 	}
 
-	t := fastrpc.NewTableId(defs.RPC_TABLE)
+	t := fastrpc.NewTableId(fastrpc.Code(defs.RPC_TABLE))
 	initCs(&c.cs, t)
 	c.RegisterRPCTable(t)
 
@@ -0,0 +1,1502 @@
+// Code generated by marshalgen; DO NOT EDIT.
+
+package swift
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	fastrpc "github.com/imdea-software/swiftpaxos/rpc"
+	"github.com/imdea-software/swiftpaxos/state"
+)
+
+func (t *CommandId) New() fastrpc.Serializable {
+	return new(CommandId)
+}
+
+func (t *CommandId) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type CommandIdCache struct {
+	mu    sync.Mutex
+	cache []*CommandId
+}
+
+func NewCommandIdCache() *CommandIdCache {
+	c := &CommandIdCache{}
+	c.cache = make([]*CommandId, 0)
+	return c
+}
+
+func (p *CommandIdCache) Get() *CommandId {
+	var t *CommandId
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &CommandId{}
+	}
+	return t
+}
+
+func (p *CommandIdCache) Put(t *CommandId) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *CommandId) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.SeqNum))
+	wire.Write(b[:8])
+}
+
+func (t *CommandId) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *MFastAck) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MFastAckCache struct {
+	mu    sync.Mutex
+	cache []*MFastAck
+}
+
+func NewMFastAckCache() *MFastAckCache {
+	c := &MFastAckCache{}
+	c.cache = make([]*MFastAck, 0)
+	return c
+}
+
+func (p *MFastAckCache) Get() *MFastAck {
+	var t *MFastAck
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MFastAck{}
+	}
+	return t
+}
+
+func (p *MFastAckCache) Put(t *MFastAck) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MFastAck) Marshal(wire io.Writer) {
+	var b [32]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Dep)))])
+	for i := range t.Dep {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Dep[i].ClientId))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.Dep[i].SeqNum))
+		wire.Write(b[:8])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Checksum)))])
+	for i := range t.Checksum {
+		copy(b[0:32], t.Checksum[i].H[:])
+		wire.Write(b[:32])
+	}
+	binary.LittleEndian.PutUint64(b[0:], uint64(t.Seqnum))
+	wire.Write(b[:8])
+}
+
+func (t *MFastAck) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [32]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Dep = make([]CommandId, n1)
+	for i := range t.Dep {
+		if _, err := io.ReadFull(wire, b[:8]); err != nil {
+			return err
+		}
+		t.Dep[i].ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.Dep[i].SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Checksum = make([]SHash, n2)
+	for i := range t.Checksum {
+		if _, err := io.ReadFull(wire, b[:32]); err != nil {
+			return err
+		}
+		copy(t.Checksum[i].H[:], b[0:32])
+	}
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Seqnum = int(binary.LittleEndian.Uint64(b[0:]))
+	return nil
+}
+
+func (t *MFastAckClient) New() fastrpc.Serializable {
+	return new(MFastAckClient)
+}
+
+func (t *MFastAckClient) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MFastAckClientCache struct {
+	mu    sync.Mutex
+	cache []*MFastAckClient
+}
+
+func NewMFastAckClientCache() *MFastAckClientCache {
+	c := &MFastAckClientCache{}
+	c.cache = make([]*MFastAckClient, 0)
+	return c
+}
+
+func (p *MFastAckClientCache) Get() *MFastAckClient {
+	var t *MFastAckClient
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MFastAckClient{}
+	}
+	return t
+}
+
+func (p *MFastAckClientCache) Put(t *MFastAckClient) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MFastAckClient) Marshal(wire io.Writer) {
+	var b [32]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Checksum)))])
+	for i := range t.Checksum {
+		copy(b[0:32], t.Checksum[i].H[:])
+		wire.Write(b[:32])
+	}
+}
+
+func (t *MFastAckClient) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [32]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Checksum = make([]SHash, n1)
+	for i := range t.Checksum {
+		if _, err := io.ReadFull(wire, b[:32]); err != nil {
+			return err
+		}
+		copy(t.Checksum[i].H[:], b[0:32])
+	}
+	return nil
+}
+
+func (t *MSlowAck) New() fastrpc.Serializable {
+	return new(MSlowAck)
+}
+
+func (t *MSlowAck) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MSlowAckCache struct {
+	mu    sync.Mutex
+	cache []*MSlowAck
+}
+
+func NewMSlowAckCache() *MSlowAckCache {
+	c := &MSlowAckCache{}
+	c.cache = make([]*MSlowAck, 0)
+	return c
+}
+
+func (p *MSlowAckCache) Get() *MSlowAck {
+	var t *MSlowAck
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MSlowAck{}
+	}
+	return t
+}
+
+func (p *MSlowAckCache) Put(t *MSlowAck) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MSlowAck) Marshal(wire io.Writer) {
+	var b [32]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Dep)))])
+	for i := range t.Dep {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Dep[i].ClientId))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.Dep[i].SeqNum))
+		wire.Write(b[:8])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Checksum)))])
+	for i := range t.Checksum {
+		copy(b[0:32], t.Checksum[i].H[:])
+		wire.Write(b[:32])
+	}
+}
+
+func (t *MSlowAck) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [32]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Dep = make([]CommandId, n1)
+	for i := range t.Dep {
+		if _, err := io.ReadFull(wire, b[:8]); err != nil {
+			return err
+		}
+		t.Dep[i].ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.Dep[i].SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Checksum = make([]SHash, n2)
+	for i := range t.Checksum {
+		if _, err := io.ReadFull(wire, b[:32]); err != nil {
+			return err
+		}
+		copy(t.Checksum[i].H[:], b[0:32])
+	}
+	return nil
+}
+
+func (t *MLightSlowAck) New() fastrpc.Serializable {
+	return new(MLightSlowAck)
+}
+
+func (t *MLightSlowAck) BinarySize() (nbytes int, sizeKnown bool) {
+	return 16, true
+}
+
+type MLightSlowAckCache struct {
+	mu    sync.Mutex
+	cache []*MLightSlowAck
+}
+
+func NewMLightSlowAckCache() *MLightSlowAckCache {
+	c := &MLightSlowAckCache{}
+	c.cache = make([]*MLightSlowAck, 0)
+	return c
+}
+
+func (p *MLightSlowAckCache) Get() *MLightSlowAck {
+	var t *MLightSlowAck
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MLightSlowAck{}
+	}
+	return t
+}
+
+func (p *MLightSlowAckCache) Put(t *MLightSlowAck) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MLightSlowAck) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+}
+
+func (t *MLightSlowAck) Unmarshal(wire io.Reader) error {
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	return nil
+}
+
+func (t *MAcks) New() fastrpc.Serializable {
+	return new(MAcks)
+}
+
+func (t *MAcks) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MAcksCache struct {
+	mu    sync.Mutex
+	cache []*MAcks
+}
+
+func NewMAcksCache() *MAcksCache {
+	c := &MAcksCache{}
+	c.cache = make([]*MAcks, 0)
+	return c
+}
+
+func (p *MAcksCache) Get() *MAcks {
+	var t *MAcks
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MAcks{}
+	}
+	return t
+}
+
+func (p *MAcksCache) Put(t *MAcks) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MAcks) Marshal(wire io.Writer) {
+	var b [16]byte
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.FastAcks)))])
+	for i := range t.FastAcks {
+		t.FastAcks[i].Marshal(wire)
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.LightSlowAcks)))])
+	for i := range t.LightSlowAcks {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.LightSlowAcks[i].Replica))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.LightSlowAcks[i].Ballot))
+		binary.LittleEndian.PutUint32(b[8:], uint32(t.LightSlowAcks[i].CmdId.ClientId))
+		binary.LittleEndian.PutUint32(b[12:], uint32(t.LightSlowAcks[i].CmdId.SeqNum))
+		wire.Write(b[:16])
+	}
+}
+
+func (t *MAcks) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.FastAcks = make([]MFastAck, n1)
+	for i := range t.FastAcks {
+		if err := t.FastAcks[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.LightSlowAcks = make([]MLightSlowAck, n2)
+	for i := range t.LightSlowAcks {
+		if _, err := io.ReadFull(wire, b[:16]); err != nil {
+			return err
+		}
+		t.LightSlowAcks[i].Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.LightSlowAcks[i].Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+		t.LightSlowAcks[i].CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+		t.LightSlowAcks[i].CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	}
+	return nil
+}
+
+func (t *Ack) New() fastrpc.Serializable {
+	return new(Ack)
+}
+
+func (t *Ack) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type AckCache struct {
+	mu    sync.Mutex
+	cache []*Ack
+}
+
+func NewAckCache() *AckCache {
+	c := &AckCache{}
+	c.cache = make([]*Ack, 0)
+	return c
+}
+
+func (p *AckCache) Get() *Ack {
+	var t *Ack
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &Ack{}
+	}
+	return t
+}
+
+func (p *AckCache) Put(t *Ack) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *Ack) Marshal(wire io.Writer) {
+	var b [32]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:8])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Dep)))])
+	for i := range t.Dep {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Dep[i].ClientId))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.Dep[i].SeqNum))
+		wire.Write(b[:8])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Checksum)))])
+	for i := range t.Checksum {
+		copy(b[0:32], t.Checksum[i].H[:])
+		wire.Write(b[:32])
+	}
+	binary.LittleEndian.PutUint64(b[0:], uint64(t.Seqnum))
+	wire.Write(b[:8])
+}
+
+func (t *Ack) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [32]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Dep = make([]CommandId, n1)
+	for i := range t.Dep {
+		if _, err := io.ReadFull(wire, b[:8]); err != nil {
+			return err
+		}
+		t.Dep[i].ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.Dep[i].SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Checksum = make([]SHash, n2)
+	for i := range t.Checksum {
+		if _, err := io.ReadFull(wire, b[:32]); err != nil {
+			return err
+		}
+		copy(t.Checksum[i].H[:], b[0:32])
+	}
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Seqnum = int(binary.LittleEndian.Uint64(b[0:]))
+	return nil
+}
+
+func (t *MOptAcks) New() fastrpc.Serializable {
+	return new(MOptAcks)
+}
+
+func (t *MOptAcks) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MOptAcksCache struct {
+	mu    sync.Mutex
+	cache []*MOptAcks
+}
+
+func NewMOptAcksCache() *MOptAcksCache {
+	c := &MOptAcksCache{}
+	c.cache = make([]*MOptAcks, 0)
+	return c
+}
+
+func (p *MOptAcksCache) Get() *MOptAcks {
+	var t *MOptAcks
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MOptAcks{}
+	}
+	return t
+}
+
+func (p *MOptAcksCache) Put(t *MOptAcks) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MOptAcks) Marshal(wire io.Writer) {
+	var b [10]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Acks)))])
+	for i := range t.Acks {
+		t.Acks[i].Marshal(wire)
+	}
+}
+
+func (t *MOptAcks) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Acks = make([]Ack, n1)
+	for i := range t.Acks {
+		if err := t.Acks[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *MReply) New() fastrpc.Serializable {
+	return new(MReply)
+}
+
+func (t *MReply) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MReplyCache struct {
+	mu    sync.Mutex
+	cache []*MReply
+}
+
+func NewMReplyCache() *MReplyCache {
+	c := &MReplyCache{}
+	c.cache = make([]*MReply, 0)
+	return c
+}
+
+func (p *MReplyCache) Get() *MReply {
+	var t *MReply
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MReply{}
+	}
+	return t
+}
+
+func (p *MReplyCache) Put(t *MReply) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MReply) Marshal(wire io.Writer) {
+	var b [32]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Checksum)))])
+	for i := range t.Checksum {
+		copy(b[0:32], t.Checksum[i].H[:])
+		wire.Write(b[:32])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Rep)))])
+	wire.Write(t.Rep)
+}
+
+func (t *MReply) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [32]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Checksum = make([]SHash, n1)
+	for i := range t.Checksum {
+		if _, err := io.ReadFull(wire, b[:32]); err != nil {
+			return err
+		}
+		copy(t.Checksum[i].H[:], b[0:32])
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Rep = make([]byte, n2)
+	if _, err := io.ReadFull(wire, t.Rep); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *MAccept) New() fastrpc.Serializable {
+	return new(MAccept)
+}
+
+func (t *MAccept) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MAcceptCache struct {
+	mu    sync.Mutex
+	cache []*MAccept
+}
+
+func NewMAcceptCache() *MAcceptCache {
+	c := &MAcceptCache{}
+	c.cache = make([]*MAccept, 0)
+	return c
+}
+
+func (p *MAcceptCache) Get() *MAccept {
+	var t *MAccept
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MAccept{}
+	}
+	return t
+}
+
+func (p *MAcceptCache) Put(t *MAccept) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MAccept) Marshal(wire io.Writer) {
+	var b [16]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.CmdId.ClientId))
+	binary.LittleEndian.PutUint32(b[12:], uint32(t.CmdId.SeqNum))
+	wire.Write(b[:16])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Rep)))])
+	wire.Write(t.Rep)
+}
+
+func (t *MAccept) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [16]byte
+	if _, err := io.ReadFull(wire, b[:16]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.CmdId.ClientId = int32(binary.LittleEndian.Uint32(b[8:]))
+	t.CmdId.SeqNum = int32(binary.LittleEndian.Uint32(b[12:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Rep = make([]byte, n1)
+	if _, err := io.ReadFull(wire, t.Rep); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *MNewLeader) New() fastrpc.Serializable {
+	return new(MNewLeader)
+}
+
+func (t *MNewLeader) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type MNewLeaderCache struct {
+	mu    sync.Mutex
+	cache []*MNewLeader
+}
+
+func NewMNewLeaderCache() *MNewLeaderCache {
+	c := &MNewLeaderCache{}
+	c.cache = make([]*MNewLeader, 0)
+	return c
+}
+
+func (p *MNewLeaderCache) Get() *MNewLeader {
+	var t *MNewLeader
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MNewLeader{}
+	}
+	return t
+}
+
+func (p *MNewLeaderCache) Put(t *MNewLeader) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MNewLeader) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+}
+
+func (t *MNewLeader) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *MNewLeaderAck) New() fastrpc.Serializable {
+	return new(MNewLeaderAck)
+}
+
+func (t *MNewLeaderAck) BinarySize() (nbytes int, sizeKnown bool) {
+	return 12, true
+}
+
+type MNewLeaderAckCache struct {
+	mu    sync.Mutex
+	cache []*MNewLeaderAck
+}
+
+func NewMNewLeaderAckCache() *MNewLeaderAckCache {
+	c := &MNewLeaderAckCache{}
+	c.cache = make([]*MNewLeaderAck, 0)
+	return c
+}
+
+func (p *MNewLeaderAckCache) Get() *MNewLeaderAck {
+	var t *MNewLeaderAck
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MNewLeaderAck{}
+	}
+	return t
+}
+
+func (p *MNewLeaderAckCache) Put(t *MNewLeaderAck) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MNewLeaderAck) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Cballot))
+	wire.Write(b[:12])
+}
+
+func (t *MNewLeaderAck) Unmarshal(wire io.Reader) error {
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Cballot = int32(binary.LittleEndian.Uint32(b[8:]))
+	return nil
+}
+
+func (t *SDep) New() fastrpc.Serializable {
+	return new(SDep)
+}
+
+func (t *SDep) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type SDepCache struct {
+	mu    sync.Mutex
+	cache []*SDep
+}
+
+func NewSDepCache() *SDepCache {
+	c := &SDepCache{}
+	c.cache = make([]*SDep, 0)
+	return c
+}
+
+func (p *SDepCache) Get() *SDep {
+	var t *SDep
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &SDep{}
+	}
+	return t
+}
+
+func (p *SDepCache) Put(t *SDep) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *SDep) Marshal(wire io.Writer) {
+	var b [10]byte
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Dep)))])
+	for i := range t.Dep {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Dep[i].ClientId))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.Dep[i].SeqNum))
+		wire.Write(b[:8])
+	}
+}
+
+func (t *SDep) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [8]byte
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Dep = make([]CommandId, n1)
+	for i := range t.Dep {
+		if _, err := io.ReadFull(wire, b[:8]); err != nil {
+			return err
+		}
+		t.Dep[i].ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.Dep[i].SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	}
+	return nil
+}
+
+func (t *MNewLeaderAckN) New() fastrpc.Serializable {
+	return new(MNewLeaderAckN)
+}
+
+func (t *MNewLeaderAckN) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MNewLeaderAckNCache struct {
+	mu    sync.Mutex
+	cache []*MNewLeaderAckN
+}
+
+func NewMNewLeaderAckNCache() *MNewLeaderAckNCache {
+	c := &MNewLeaderAckNCache{}
+	c.cache = make([]*MNewLeaderAckN, 0)
+	return c
+}
+
+func (p *MNewLeaderAckNCache) Get() *MNewLeaderAckN {
+	var t *MNewLeaderAckN
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MNewLeaderAckN{}
+	}
+	return t
+}
+
+func (p *MNewLeaderAckNCache) Put(t *MNewLeaderAckN) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MNewLeaderAckN) Marshal(wire io.Writer) {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.Cballot))
+	wire.Write(b[:12])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.CmdIds)))])
+	for i := range t.CmdIds {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.CmdIds[i].ClientId))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.CmdIds[i].SeqNum))
+		wire.Write(b[:8])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Phases)))])
+	for i := range t.Phases {
+		binary.LittleEndian.PutUint64(b[0:], uint64(t.Phases[i]))
+		wire.Write(b[:8])
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Cmds)))])
+	for i := range t.Cmds {
+		t.Cmds[i].Marshal(wire)
+	}
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Deps)))])
+	for i := range t.Deps {
+		t.Deps[i].Marshal(wire)
+	}
+}
+
+func (t *MNewLeaderAckN) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [12]byte
+	if _, err := io.ReadFull(wire, b[:12]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	t.Cballot = int32(binary.LittleEndian.Uint32(b[8:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.CmdIds = make([]CommandId, n1)
+	for i := range t.CmdIds {
+		if _, err := io.ReadFull(wire, b[:8]); err != nil {
+			return err
+		}
+		t.CmdIds[i].ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.CmdIds[i].SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	}
+	n2, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Phases = make([]int, n2)
+	for i := range t.Phases {
+		if _, err := io.ReadFull(wire, b[:8]); err != nil {
+			return err
+		}
+		t.Phases[i] = int(binary.LittleEndian.Uint64(b[0:]))
+	}
+	n3, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Cmds = make([]state.Command, n3)
+	for i := range t.Cmds {
+		if err := t.Cmds[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	n4, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Deps = make([]SDep, n4)
+	for i := range t.Deps {
+		if err := t.Deps[i].Unmarshal(wire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *MShareState) New() fastrpc.Serializable {
+	return new(MShareState)
+}
+
+func (t *MShareState) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type MShareStateCache struct {
+	mu    sync.Mutex
+	cache []*MShareState
+}
+
+func NewMShareStateCache() *MShareStateCache {
+	c := &MShareStateCache{}
+	c.cache = make([]*MShareState, 0)
+	return c
+}
+
+func (p *MShareStateCache) Get() *MShareState {
+	var t *MShareState
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MShareState{}
+	}
+	return t
+}
+
+func (p *MShareStateCache) Put(t *MShareState) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MShareState) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+}
+
+func (t *MShareState) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *MLightSync) New() fastrpc.Serializable {
+	return new(MLightSync)
+}
+
+func (t *MLightSync) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type MLightSyncCache struct {
+	mu    sync.Mutex
+	cache []*MLightSync
+}
+
+func NewMLightSyncCache() *MLightSyncCache {
+	c := &MLightSyncCache{}
+	c.cache = make([]*MLightSync, 0)
+	return c
+}
+
+func (p *MLightSyncCache) Get() *MLightSync {
+	var t *MLightSync
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MLightSync{}
+	}
+	return t
+}
+
+func (p *MLightSyncCache) Put(t *MLightSync) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MLightSync) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+}
+
+func (t *MLightSync) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *MCollect) New() fastrpc.Serializable {
+	return new(MCollect)
+}
+
+func (t *MCollect) BinarySize() (nbytes int, sizeKnown bool) {
+	return 0, false
+}
+
+type MCollectCache struct {
+	mu    sync.Mutex
+	cache []*MCollect
+}
+
+func NewMCollectCache() *MCollectCache {
+	c := &MCollectCache{}
+	c.cache = make([]*MCollect, 0)
+	return c
+}
+
+func (p *MCollectCache) Get() *MCollect {
+	var t *MCollect
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MCollect{}
+	}
+	return t
+}
+
+func (p *MCollectCache) Put(t *MCollect) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MCollect) Marshal(wire io.Writer) {
+	var b [10]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+	wire.Write(b[:binary.PutVarint(b[:], int64(len(t.Ids)))])
+	for i := range t.Ids {
+		binary.LittleEndian.PutUint32(b[0:], uint32(t.Ids[i].ClientId))
+		binary.LittleEndian.PutUint32(b[4:], uint32(t.Ids[i].SeqNum))
+		wire.Write(b[:8])
+	}
+}
+
+func (t *MCollect) Unmarshal(rr io.Reader) error {
+	wire, ok := rr.(byteReader)
+	if !ok {
+		wire = bufio.NewReader(rr)
+	}
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	n1, err := binary.ReadVarint(wire)
+	if err != nil {
+		return err
+	}
+	t.Ids = make([]CommandId, n1)
+	for i := range t.Ids {
+		if _, err := io.ReadFull(wire, b[:8]); err != nil {
+			return err
+		}
+		t.Ids[i].ClientId = int32(binary.LittleEndian.Uint32(b[0:]))
+		t.Ids[i].SeqNum = int32(binary.LittleEndian.Uint32(b[4:]))
+	}
+	return nil
+}
+
+func (t *MPing) New() fastrpc.Serializable {
+	return new(MPing)
+}
+
+func (t *MPing) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type MPingCache struct {
+	mu    sync.Mutex
+	cache []*MPing
+}
+
+func NewMPingCache() *MPingCache {
+	c := &MPingCache{}
+	c.cache = make([]*MPing, 0)
+	return c
+}
+
+func (p *MPingCache) Get() *MPing {
+	var t *MPing
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MPing{}
+	}
+	return t
+}
+
+func (p *MPingCache) Put(t *MPing) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MPing) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+}
+
+func (t *MPing) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *MPingRep) New() fastrpc.Serializable {
+	return new(MPingRep)
+}
+
+func (t *MPingRep) BinarySize() (nbytes int, sizeKnown bool) {
+	return 8, true
+}
+
+type MPingRepCache struct {
+	mu    sync.Mutex
+	cache []*MPingRep
+}
+
+func NewMPingRepCache() *MPingRepCache {
+	c := &MPingRepCache{}
+	c.cache = make([]*MPingRep, 0)
+	return c
+}
+
+func (p *MPingRepCache) Get() *MPingRep {
+	var t *MPingRep
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &MPingRep{}
+	}
+	return t
+}
+
+func (p *MPingRepCache) Put(t *MPingRep) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *MPingRep) Marshal(wire io.Writer) {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.Replica))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ballot))
+	wire.Write(b[:8])
+}
+
+func (t *MPingRep) Unmarshal(wire io.Reader) error {
+	var b [8]byte
+	if _, err := io.ReadFull(wire, b[:8]); err != nil {
+		return err
+	}
+	t.Replica = int32(binary.LittleEndian.Uint32(b[0:]))
+	t.Ballot = int32(binary.LittleEndian.Uint32(b[4:]))
+	return nil
+}
+
+func (t *SHash) New() fastrpc.Serializable {
+	return new(SHash)
+}
+
+func (t *SHash) BinarySize() (nbytes int, sizeKnown bool) {
+	return 32, true
+}
+
+type SHashCache struct {
+	mu    sync.Mutex
+	cache []*SHash
+}
+
+func NewSHashCache() *SHashCache {
+	c := &SHashCache{}
+	c.cache = make([]*SHash, 0)
+	return c
+}
+
+func (p *SHashCache) Get() *SHash {
+	var t *SHash
+	p.mu.Lock()
+	if len(p.cache) > 0 {
+		t = p.cache[len(p.cache)-1]
+		p.cache = p.cache[0:(len(p.cache) - 1)]
+	}
+	p.mu.Unlock()
+	if t == nil {
+		t = &SHash{}
+	}
+	return t
+}
+
+func (p *SHashCache) Put(t *SHash) {
+	p.mu.Lock()
+	p.cache = append(p.cache, t)
+	p.mu.Unlock()
+}
+
+func (t *SHash) Marshal(wire io.Writer) {
+	var b [32]byte
+	copy(b[0:32], t.H[:])
+	wire.Write(b[:32])
+}
+
+func (t *SHash) Unmarshal(wire io.Reader) error {
+	var b [32]byte
+	if _, err := io.ReadFull(wire, b[:32]); err != nil {
+		return err
+	}
+	copy(t.H[:], b[0:32])
+	return nil
+}
+
+type byteReader interface {
+	io.Reader
+	ReadByte() (c byte, err error)
+}
@@ -224,6 +224,36 @@ func (r *Replica) BeTheLeader(_ *defs.BeTheLeaderArgs, reply *defs.BeTheLeaderRe
 	return nil
 }
 
+// Status is called via RPC by the admin participant. The term is the
+// ballot.
+func (r *Replica) Status(args *defs.StatusArgs, reply *defs.StatusReply) error {
+	r.Replica.Status(args, reply)
+	reply.Role = "follower"
+	if r.leader() == r.Id {
+		reply.Role = "leader"
+	}
+	reply.Term = r.ballot
+	return nil
+}
+
+// TransferLeader is called by the master via RPC to make args.Target the
+// leader. The leader returns -1, then the master asks the target to take
+// over, which it does with the next ballot it leads.
+func (r *Replica) TransferLeader(args *defs.TransferLeaderArgs, reply *defs.TransferLeaderReply) error {
+	if args.Target != r.Id {
+		if r.leader() != r.Id {
+			return fmt.Errorf("replica %d is not the leader (leader: %d)", r.Id, r.leader())
+		}
+		reply.Leader = -1
+		return nil
+	}
+	if r.leader() != r.Id {
+		r.recover <- -1
+	}
+	reply.Leader = r.Id
+	return nil
+}
+
 func (r *Replica) run() {
 	r.ConnectToPeers()
 	latencies := r.ComputeClosestPeers()
@@ -748,6 +778,13 @@ func (r *Replica) handleMsg(m interface{}, desc *commandDesc, cmdId CommandId) b
 		r.history[msg].cmd = desc.cmd
 		r.history[msg].dep = desc.dep
 		r.history[msg].slowPath = desc.slowPath
+		r.M.Lock()
+		if desc.slowPath {
+			r.Stats.M["slow"]++
+		} else {
+			r.Stats.M["fast"]++
+		}
+		r.M.Unlock()
 		r.history[msg].defered = desc.defered
 		desc.active = false
 		desc.slowPathH.Free()
@@ -0,0 +1,90 @@
+package transport
+
+import (
+	"net"
+	"os"
+)
+
+// Link carries the data written on the connections of a Network. Without a
+// Link, data is readable by the peer as soon as it is written; with one,
+// each write becomes a Packet that the Link delivers (or not) when it
+// decides, e.g. to simulate delays and faults.
+type Link interface {
+	// Send is called for each packet written. It must not block; the
+	// packets of a connection must be delivered in the order they are sent.
+	Send(p *Packet)
+}
+
+// Packet is the data of one write on a connection of a Network with a Link,
+// or the close of the connection.
+type Packet struct {
+	// Name of the connection, "<dialing host>><dialed address>#<n>",
+	// where n counts the connections between them
+	Conn string
+	// Sent by the accepting end of the connection
+	Reply bool
+	// Index of the packet among the packets sent by the same end
+	Seq int
+	// Data written, nil if the packet closes the connection
+	Data []byte
+
+	conn *memConn
+}
+
+// Close tells whether the packet closes the connection.
+func (p *Packet) Close() bool {
+	return p.Data == nil
+}
+
+// Deliver makes the packet readable by the peer. Data delivered to an end
+// that is closed is discarded.
+func (p *Packet) Deliver() {
+	if p.Close() {
+		p.conn.closeWrite()
+		return
+	}
+	w := p.conn.wr
+	w.mu.Lock()
+	if !w.rclosed && !w.wclosed {
+		w.buf = append(w.buf, p.Data...)
+	}
+	w.mu.Unlock()
+	signal(w.readable)
+}
+
+// SetLink makes l carry the data of the connections dialed from now on.
+func (n *Network) SetLink(l Link) {
+	n.mu.Lock()
+	n.link = l
+	n.mu.Unlock()
+}
+
+// packet returns the next packet of c. Called with c.wmu held.
+func (c *memConn) packet(data []byte) *Packet {
+	p := &Packet{Conn: c.name, Reply: c.reply, Seq: c.seq, Data: data, conn: c}
+	c.seq++
+	return p
+}
+
+// send hands b to the link. Like a socket with unlimited buffers, the write
+// does not wait for the peer. Called with c.wmu held.
+func (c *memConn) send(b []byte) (int, error) {
+	select {
+	case <-c.done:
+		return 0, c.opErr("write", net.ErrClosed)
+	case <-c.writeDeadline.wait():
+		return 0, c.opErr("write", os.ErrDeadlineExceeded)
+	default:
+	}
+	c.wr.mu.Lock()
+	closed := c.wr.rclosed
+	c.wr.mu.Unlock()
+	if closed {
+		return 0, c.opErr("write", errBrokenPipe)
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	c.link.Send(c.packet(append([]byte{}, b...)))
+	return len(b), nil
+}
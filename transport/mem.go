@@ -0,0 +1,512 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// backlog is the number of dialed connections a listener queues before
+// they are accepted.
+const backlog = 128
+
+// pipeBufferSize is the number of bytes a connection buffers in each
+// direction before writes block, like a socket buffer.
+const pipeBufferSize = 4 << 20
+
+var (
+	errRefused    = errors.New("connection refused")
+	errAddrInUse  = errors.New("address already in use")
+	errNetClosed  = errors.New("network closed")
+	errBrokenPipe = errors.New("broken pipe")
+)
+
+// Network is an in-process network. Participants attached to the same
+// Network with Host can listen and dial each other as if they were on
+// different machines, without using sockets.
+//
+// All hosts share one port space: a listener on 0.0.0.0:port accepts
+// connections dialed to any host on that port.
+type Network struct {
+	mu        sync.Mutex
+	listeners map[string]*memListener // by host:port
+	conns     map[*memConn]struct{}
+	pairs     map[string]int // connections dialed from a host to an address
+	nextPort  int
+	closed    bool
+	link      Link
+	listening chan struct{} // closed and replaced on every Listen
+}
+
+// NewNetwork creates an empty in-process network.
+func NewNetwork() *Network {
+	return &Network{
+		listeners: make(map[string]*memListener),
+		conns:     make(map[*memConn]struct{}),
+		pairs:     make(map[string]int),
+		nextPort:  40000,
+		listening: make(chan struct{}),
+	}
+}
+
+// Host returns the transport of a participant running on host. Its
+// connections have local addresses on host, so that peers see host as
+// their remote address.
+func (n *Network) Host(host string) *Endpoint {
+	return &Endpoint{net: n, host: host}
+}
+
+// Close closes all listeners and connections of the network. Later dials
+// and listens fail.
+func (n *Network) Close() error {
+	n.mu.Lock()
+	n.closed = true
+	ls := make([]*memListener, 0, len(n.listeners))
+	for _, l := range n.listeners {
+		ls = append(ls, l)
+	}
+	cs := make([]*memConn, 0, len(n.conns))
+	for c := range n.conns {
+		cs = append(cs, c)
+	}
+	n.mu.Unlock()
+
+	for _, l := range ls {
+		l.Close()
+	}
+	for _, c := range cs {
+		c.Close()
+	}
+	return nil
+}
+
+// lookup returns the listener for addr: an exact match, or else a
+// wildcard listener on the same port. Called with n.mu held.
+func (n *Network) lookup(host, port string) *memListener {
+	if l, exists := n.listeners[net.JoinHostPort(host, port)]; exists {
+		return l
+	}
+	return n.listeners[net.JoinHostPort("0.0.0.0", port)]
+}
+
+// Endpoint is the Transport of one host of a Network.
+type Endpoint struct {
+	net  *Network
+	host string
+}
+
+// Dial connects to the listener at addr. It fails immediately if there is
+// none, like a refused TCP connection, unless the network has a Link: then
+// it waits for the listener until the timeout, so that connection retries
+// do not depend on how fast the participants start.
+func (e *Endpoint) Dial(addr string, timeout time.Duration) (net.Conn, error) {
+	opErr := func(err error) error {
+		return &net.OpError{Op: "dial", Net: "mem", Addr: memAddr(addr), Err: err}
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, opErr(err)
+	}
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+
+	n := e.net
+	n.mu.Lock()
+	var l *memListener
+	for {
+		if n.closed {
+			n.mu.Unlock()
+			return nil, opErr(errNetClosed)
+		}
+		if l = n.lookup(host, port); l != nil {
+			break
+		}
+		if n.link == nil {
+			n.mu.Unlock()
+			return nil, opErr(errRefused)
+		}
+		listening := n.listening
+		n.mu.Unlock()
+		select {
+		case <-listening:
+		case <-expired:
+			return nil, opErr(os.ErrDeadlineExceeded)
+		}
+		n.mu.Lock()
+	}
+	local := memAddr(net.JoinHostPort(e.host, strconv.Itoa(n.nextPort)))
+	n.nextPort++
+	client, server := newPipe(n, local, memAddr(addr))
+	pair := e.host + ">" + addr
+	client.name = pair + "#" + strconv.Itoa(n.pairs[pair])
+	server.name = client.name
+	server.reply = true
+	n.pairs[pair]++
+	client.link, server.link = n.link, n.link
+	n.conns[client] = struct{}{}
+	n.conns[server] = struct{}{}
+	n.mu.Unlock()
+
+	select {
+	case l.accept <- server:
+		if !isClosed(l.done) {
+			return client, nil
+		}
+		// the listener was closed after it was looked up
+		err = errRefused
+	case <-l.done:
+		err = errRefused
+	case <-expired:
+		err = os.ErrDeadlineExceeded
+	}
+	client.Close()
+	server.Close()
+	return nil, opErr(err)
+}
+
+// Listen listens on addr. A port of 0 picks a free port.
+func (e *Endpoint) Listen(addr string) (net.Listener, error) {
+	opErr := func(err error) error {
+		return &net.OpError{Op: "listen", Net: "mem", Addr: memAddr(addr), Err: err}
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, opErr(err)
+	}
+	if host == "" {
+		host = "0.0.0.0"
+	}
+
+	n := e.net
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return nil, opErr(errNetClosed)
+	}
+	if port == "0" {
+		port = strconv.Itoa(n.nextPort)
+		n.nextPort++
+	}
+	for a := range n.listeners {
+		h, p, _ := net.SplitHostPort(a)
+		if p == port && (h == host || h == "0.0.0.0" || host == "0.0.0.0") {
+			return nil, opErr(errAddrInUse)
+		}
+	}
+	l := &memListener{
+		net:    n,
+		addr:   memAddr(net.JoinHostPort(host, port)),
+		accept: make(chan *memConn, backlog),
+		done:   make(chan struct{}),
+	}
+	n.listeners[string(l.addr)] = l
+	close(n.listening)
+	n.listening = make(chan struct{})
+	return l, nil
+}
+
+type memAddr string
+
+func (a memAddr) Network() string { return "mem" }
+func (a memAddr) String() string  { return string(a) }
+
+type memListener struct {
+	net    *Network
+	addr   memAddr
+	accept chan *memConn
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (l *memListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.accept:
+		return c, nil
+	case <-l.done:
+		return nil, &net.OpError{Op: "accept", Net: "mem", Addr: l.addr, Err: net.ErrClosed}
+	}
+}
+
+func (l *memListener) Close() error {
+	l.once.Do(func() {
+		l.net.mu.Lock()
+		if l.net.listeners[string(l.addr)] == l {
+			delete(l.net.listeners, string(l.addr))
+		}
+		l.net.mu.Unlock()
+		close(l.done)
+		// refuse the connections that were not accepted
+		for {
+			select {
+			case c := <-l.accept:
+				c.Close()
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (l *memListener) Addr() net.Addr { return l.addr }
+
+// pipe is one direction of a connection.
+type pipe struct {
+	mu       sync.Mutex
+	buf      []byte
+	wclosed  bool          // the writing end is closed: EOF once buf is read
+	rclosed  bool          // the reading end is closed: writes fail
+	readable chan struct{} // signaled when data arrives or an end closes
+	writable chan struct{} // signaled when buf is drained or an end closes
+}
+
+func newPipeBuffer() *pipe {
+	return &pipe{
+		readable: make(chan struct{}, 1),
+		writable: make(chan struct{}, 1),
+	}
+}
+
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// memConn is one end of an in-process connection.
+type memConn struct {
+	net           *Network
+	local, remote memAddr
+	rd, wr        *pipe
+	wmu           sync.Mutex // makes concurrent writes atomic, as on a socket
+
+	// With a Link, writes are handed to it as packets
+	link  Link
+	name  string // name of the connection, the same for both ends
+	reply bool   // the accepting end
+	seq   int    // packets written, guarded by wmu
+
+	readDeadline  deadline
+	writeDeadline deadline
+
+	once sync.Once
+	done chan struct{}
+}
+
+func newPipe(n *Network, local, remote memAddr) (*memConn, *memConn) {
+	a, b := newPipeBuffer(), newPipeBuffer()
+	c1 := &memConn{net: n, local: local, remote: remote, rd: a, wr: b, done: make(chan struct{})}
+	c2 := &memConn{net: n, local: remote, remote: local, rd: b, wr: a, done: make(chan struct{})}
+	return c1, c2
+}
+
+func (c *memConn) opErr(op string, err error) error {
+	return &net.OpError{Op: op, Net: "mem", Source: c.local, Addr: c.remote, Err: err}
+}
+
+func (c *memConn) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	p := c.rd
+	for {
+		select {
+		case <-c.done:
+			return 0, c.opErr("read", net.ErrClosed)
+		case <-c.readDeadline.wait():
+			return 0, c.opErr("read", os.ErrDeadlineExceeded)
+		default:
+		}
+
+		p.mu.Lock()
+		if len(p.buf) > 0 {
+			n := copy(b, p.buf)
+			p.buf = p.buf[n:]
+			if len(p.buf) == 0 {
+				p.buf = nil
+			}
+			p.mu.Unlock()
+			signal(p.writable)
+			return n, nil
+		}
+		if p.wclosed {
+			p.mu.Unlock()
+			return 0, io.EOF
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-p.readable:
+		case <-c.done:
+		case <-c.readDeadline.wait():
+		}
+	}
+}
+
+func (c *memConn) Write(b []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	p := c.wr
+	if c.link != nil {
+		return c.send(b)
+	}
+	written := 0
+	for len(b) > 0 {
+		select {
+		case <-c.done:
+			return written, c.opErr("write", net.ErrClosed)
+		case <-c.writeDeadline.wait():
+			return written, c.opErr("write", os.ErrDeadlineExceeded)
+		default:
+		}
+
+		p.mu.Lock()
+		if p.rclosed {
+			p.mu.Unlock()
+			return written, c.opErr("write", errBrokenPipe)
+		}
+		if space := pipeBufferSize - len(p.buf); space > 0 {
+			k := len(b)
+			if k > space {
+				k = space
+			}
+			p.buf = append(p.buf, b[:k]...)
+			p.mu.Unlock()
+			signal(p.readable)
+			b = b[k:]
+			written += k
+			continue
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-p.writable:
+		case <-c.done:
+		case <-c.writeDeadline.wait():
+		}
+	}
+	return written, nil
+}
+
+// Close closes the connection. The peer reads the data already written,
+// then EOF; its writes fail.
+func (c *memConn) Close() error {
+	c.once.Do(func() {
+		close(c.done)
+
+		if c.link != nil {
+			c.wmu.Lock()
+			c.link.Send(c.packet(nil))
+			c.wmu.Unlock()
+		} else {
+			c.closeWrite()
+		}
+
+		c.rd.mu.Lock()
+		c.rd.rclosed = true
+		c.rd.buf = nil
+		c.rd.mu.Unlock()
+		signal(c.rd.writable)
+
+		c.net.mu.Lock()
+		delete(c.net.conns, c)
+		c.net.mu.Unlock()
+	})
+	return nil
+}
+
+// closeWrite makes the peer read EOF once it has read the data written.
+func (c *memConn) closeWrite() {
+	c.wr.mu.Lock()
+	c.wr.wclosed = true
+	c.wr.mu.Unlock()
+	signal(c.wr.readable)
+}
+
+func (c *memConn) LocalAddr() net.Addr  { return c.local }
+func (c *memConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *memConn) SetDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	c.writeDeadline.set(t)
+	return nil
+}
+
+func (c *memConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	return nil
+}
+
+func (c *memConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.set(t)
+	return nil
+}
+
+// deadline is a channel that is closed when a deadline expires, as in
+// net.Pipe.
+type deadline struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	expired chan struct{}
+}
+
+func (d *deadline) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.expired == nil {
+		d.expired = make(chan struct{})
+	}
+	if d.timer != nil && !d.timer.Stop() {
+		<-d.expired // wait for the timer callback to close it
+	}
+	d.timer = nil
+
+	closed := isClosed(d.expired)
+	if t.IsZero() {
+		if closed {
+			d.expired = make(chan struct{})
+		}
+		return
+	}
+	if dur := time.Until(t); dur > 0 {
+		if closed {
+			d.expired = make(chan struct{})
+		}
+		expired := d.expired
+		d.timer = time.AfterFunc(dur, func() { close(expired) })
+		return
+	}
+	if !closed {
+		close(d.expired)
+	}
+}
+
+func (d *deadline) wait() chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.expired == nil {
+		d.expired = make(chan struct{})
+	}
+	return d.expired
+}
+
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,211 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TLS returns a transport that runs TLS with config over t.
+func TLS(t Transport, config *tls.Config) Transport {
+	return tlsTransport{t: OrTCP(t), config: config}
+}
+
+type tlsTransport struct {
+	t      Transport
+	config *tls.Config
+}
+
+func (tt tlsTransport) Dial(addr string, timeout time.Duration) (net.Conn, error) {
+	conn, err := tt.t.Dial(addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := tls.Client(conn, tt.config)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := c.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (tt tlsTransport) Listen(addr string) (net.Listener, error) {
+	l, err := tt.t.Listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	return tls.NewListener(l, tt.config), nil
+}
+
+// Unwrap returns the transport under TLS.
+func (tt tlsTransport) Unwrap() Transport {
+	return tt.t
+}
+
+// LoadTLS returns the TLS config of the node alias, with mutual
+// authentication: dir holds the certificate of the cluster authority,
+// ca.pem, and the certificate and key of the node, <alias>.pem and
+// <alias>.key. The common name of a node certificate is the alias of the
+// node.
+//
+// Nodes are addressed by IP in the config file, so certificates are
+// verified against the authority only, not against host names.
+func LoadTLS(dir, alias string) (*tls.Config, error) {
+	if alias == "" {
+		return nil, errors.New("TLS requires the alias of the participant")
+	}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, alias+".pem"), filepath.Join(dir, alias+".key"))
+	if err != nil {
+		return nil, err
+	}
+	ca, err := os.ReadFile(filepath.Join(dir, "ca.pem"))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate in %s", filepath.Join(dir, "ca.pem"))
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+		// the server chain is checked by VerifyConnection
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("tls: no peer certificate")
+			}
+			opts := x509.VerifyOptions{
+				Roots:         pool,
+				Intermediates: x509.NewCertPool(),
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+			}
+			for _, c := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(c)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}, nil
+}
+
+// PeerName returns the common name of the certificate of the other end of
+// a TLS connection, completing the handshake if needed. The second result
+// is false for other connections.
+func PeerName(conn net.Conn) (string, bool, error) {
+	c, ok := conn.(*tls.Conn)
+	if !ok {
+		return "", false, nil
+	}
+	if err := c.Handshake(); err != nil {
+		return "", true, err
+	}
+	certs := c.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", true, errors.New("tls: no peer certificate")
+	}
+	return certs[0].Subject.CommonName, true, nil
+}
+
+// GenerateCerts creates in dir the certificates read by LoadTLS for the
+// given aliases, signed by a new authority, or by the one already in dir
+// (ca.pem and ca.key).
+func GenerateCerts(dir string, aliases []string, validity time.Duration) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	caCert, caKey, err := loadCA(dir)
+	if os.IsNotExist(err) {
+		caCert, caKey, err = newCert(dir, "ca", "swiftpaxos-ca", nil, nil, validity)
+	}
+	if err != nil {
+		return err
+	}
+	for _, alias := range aliases {
+		if _, _, err := newCert(dir, alias, alias, caCert, caKey, validity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	pair, err := tls.LoadX509KeyPair(filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca.key"))
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("ca.key is not an ECDSA key")
+	}
+	return cert, key, nil
+}
+
+// newCert writes <name>.pem and <name>.key, a certificate for cn signed by
+// ca, or self-signed if ca is nil.
+func newCert(dir, name, cn string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, validity time.Duration) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if ca == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		ca, caKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".key"),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
@@ -0,0 +1,164 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// tlsPair returns the TLS transports of hosts a and b of n, with the
+// certificates of aliasA and aliasB from the authorities in dirA and dirB
+func tlsPair(t *testing.T, n *Network, dirA, aliasA, dirB, aliasB string) (Transport, Transport) {
+	ca, err := LoadTLS(dirA, aliasA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb, err := LoadTLS(dirB, aliasB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return TLS(n.Host("10.0.0.1"), ca), TLS(n.Host("10.0.0.2"), cb)
+}
+
+// TestTLS tests a mutually authenticated connection over the in-process
+// transport
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	if err := GenerateCerts(dir, []string{"replica0", "client0"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	n := NewNetwork()
+	defer n.Close()
+	server, cl := tlsPair(t, n, dir, "replica0", dir, "client0")
+
+	l, err := server.Listen("0.0.0.0:7070")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	names := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			names <- err.Error()
+			return
+		}
+		defer conn.Close()
+		name, isTLS, err := PeerName(conn)
+		if err != nil || !isTLS {
+			names <- "no peer name"
+			return
+		}
+		names <- name
+		io.Copy(conn, conn)
+	}()
+
+	conn, err := cl.Dial("10.0.0.1:7070", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if name, _, _ := PeerName(conn); name != "replica0" {
+		t.Errorf("server name = %q, want replica0", name)
+	}
+	if name := <-names; name != "client0" {
+		t.Errorf("client name = %q, want client0", name)
+	}
+	conn.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("echo = %q, %v", buf, err)
+	}
+
+	if _, isTLS, _ := PeerName(&net.TCPConn{}); isTLS {
+		t.Error("plain connection reported as TLS")
+	}
+}
+
+// TestTLSOtherAuthority tests that nodes with certificates of another
+// authority are rejected on both sides
+func TestTLSOtherAuthority(t *testing.T) {
+	dir, other := t.TempDir(), t.TempDir()
+	if err := GenerateCerts(dir, []string{"replica0", "replica1"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateCerts(other, []string{"replica1"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	n := NewNetwork()
+	defer n.Close()
+
+	for _, c := range []struct {
+		name              string
+		serverDir, cliDir string
+	}{
+		{"client", dir, other},
+		{"server", other, dir},
+	} {
+		server, cl := tlsPair(t, n, c.serverDir, "replica1", c.cliDir, "replica1")
+		l, err := server.Listen("0.0.0.0:7070")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			PeerName(conn)
+			conn.Close()
+		}()
+		conn, err := cl.Dial("10.0.0.1:7070", time.Second)
+		if err == nil {
+			// TLS 1.3 clients complete the handshake before the server
+			// verifies their certificate
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			_, err = conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+		if err == nil {
+			t.Errorf("untrusted %s: connection accepted", c.name)
+		}
+		l.Close()
+	}
+}
+
+// TestGenerateCertsKeepsAuthority tests that certificates added later are
+// signed by the existing authority
+func TestGenerateCertsKeepsAuthority(t *testing.T) {
+	dir := t.TempDir()
+	if err := GenerateCerts(dir, []string{"replica0"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateCerts(dir, []string{"replica1"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	n := NewNetwork()
+	defer n.Close()
+	server, cl := tlsPair(t, n, dir, "replica0", dir, "replica1")
+	l, err := server.Listen("0.0.0.0:7070")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			PeerName(conn)
+			defer conn.Close()
+			conn.Write([]byte{1})
+		}
+	}()
+	conn, err := cl.Dial("10.0.0.1:7070", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := LoadTLS(dir, ""); err == nil {
+		t.Error("expected an error without an alias")
+	}
+}
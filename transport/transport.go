@@ -0,0 +1,104 @@
+// Package transport abstracts the network used by replicas, clients and the
+// master, so that a whole cluster can run over TCP or inside one process.
+package transport
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/rpc"
+	"syscall"
+	"time"
+)
+
+// Transport opens the connections between the participants of a cluster.
+// Addresses are "host:port" strings, as in the config file.
+type Transport interface {
+	// Dial connects to addr. A zero timeout means no timeout.
+	Dial(addr string, timeout time.Duration) (net.Conn, error)
+	// Listen accepts connections on addr. A host of "" or 0.0.0.0 listens
+	// on all interfaces.
+	Listen(addr string) (net.Listener, error)
+}
+
+// TCP is the transport used when none is configured.
+var TCP Transport = tcpTransport{}
+
+// OrTCP returns t, or TCP if t is nil.
+func OrTCP(t Transport) Transport {
+	if t == nil {
+		return TCP
+	}
+	return t
+}
+
+type tcpTransport struct{}
+
+func (tcpTransport) Dial(addr string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("tcp", addr, timeout)
+}
+
+// Listen sets SO_REUSEADDR to avoid TIME_WAIT conflicts between consecutive
+// benchmark runs.
+func (tcpTransport) Listen(addr string) (net.Listener, error) {
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			return c.Control(func(fd uintptr) {
+				syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
+			})
+		},
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
+// DialHTTP connects to a net/rpc server serving HTTP at addr, like
+// rpc.DialHTTP, over the given transport. The timeout (0 = none) bounds
+// both the connection and the handshake.
+func DialHTTP(t Transport, addr string, timeout time.Duration) (*rpc.Client, error) {
+	conn, err := OrTCP(t).Dial(addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	if err := ConnectRPC(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return rpc.NewClient(conn), nil
+}
+
+// ConnectRPC performs the HTTP CONNECT handshake of net/rpc on conn.
+func ConnectRPC(conn net.Conn) error {
+	io.WriteString(conn, "CONNECT "+rpc.DefaultRPCPath+" HTTP/1.0\n\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	if err != nil {
+		return err
+	}
+	if resp.Status != "200 Connected to Go RPC" {
+		return errors.New("unexpected HTTP response: " + resp.Status)
+	}
+	return nil
+}
+
+// ServeRPC serves srv over HTTP on l until l is closed. Requests to
+// /debug/ are passed to http.DefaultServeMux (e.g., for pprof).
+func ServeRPC(l net.Listener, srv *rpc.Server) error {
+	return ServeRPCWith(l, srv, nil)
+}
+
+// ServeRPCWith is ServeRPC with the handlers of the paths of handlers.
+func ServeRPCWith(l net.Listener, srv *rpc.Server, handlers map[string]http.Handler) error {
+	mux := http.NewServeMux()
+	mux.Handle(rpc.DefaultRPCPath, srv)
+	mux.Handle("/debug/", http.DefaultServeMux)
+	for path, h := range handlers {
+		mux.Handle(path, h)
+	}
+	return http.Serve(l, mux)
+}
@@ -0,0 +1,227 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type Echo struct{}
+
+func (Echo) Upper(args *string, reply *string) error {
+	*reply = strings.ToUpper(*args)
+	return nil
+}
+
+// testServeRPC serves an Echo RPC server on l and calls it through t
+func testServeRPC(t *testing.T, tr Transport, l net.Listener, addr string) {
+	srv := rpc.NewServer()
+	srv.Register(Echo{})
+	go ServeRPC(l, srv)
+	defer l.Close()
+
+	cl, err := DialHTTP(tr, addr, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+	var reply string
+	if err := cl.Call("Echo.Upper", "hello", &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply != "HELLO" {
+		t.Errorf("reply = %q, want HELLO", reply)
+	}
+}
+
+// TestTCPServeRPC tests net/rpc over HTTP on the TCP transport
+func TestTCPServeRPC(t *testing.T) {
+	l, err := TCP.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testServeRPC(t, TCP, l, l.Addr().String())
+}
+
+// TestMemServeRPC tests net/rpc over HTTP on the in-process transport
+func TestMemServeRPC(t *testing.T) {
+	n := NewNetwork()
+	defer n.Close()
+	l, err := n.Host("10.0.0.1").Listen("0.0.0.0:8070")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testServeRPC(t, n.Host("10.0.0.2"), l, "10.0.0.1:8070")
+}
+
+// TestMemDialAccept tests addresses and data exchange of a connection
+func TestMemDialAccept(t *testing.T) {
+	n := NewNetwork()
+	defer n.Close()
+	l, err := n.Host("10.0.0.1").Listen("10.0.0.1:7070")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := n.Host("10.0.0.2").Dial("10.0.0.1:7070", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host, _, _ := net.SplitHostPort(s.RemoteAddr().String()); host != "10.0.0.2" {
+		t.Errorf("server sees remote %v, want host 10.0.0.2", s.RemoteAddr())
+	}
+	if c.RemoteAddr().String() != "10.0.0.1:7070" || s.LocalAddr().String() != "10.0.0.1:7070" {
+		t.Errorf("addresses: client remote %v, server local %v", c.RemoteAddr(), s.LocalAddr())
+	}
+
+	go c.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(s, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("read %q, %v", buf, err)
+	}
+
+	// The peer reads buffered data, then EOF
+	s.Write([]byte("bye"))
+	s.Close()
+	if b, err := io.ReadAll(c); err != nil || string(b) != "bye" {
+		t.Errorf("read %q, %v after close, want bye and EOF", b, err)
+	}
+	if _, err := c.Write([]byte("x")); err == nil {
+		t.Error("write to a closed peer succeeded")
+	}
+	c.Close()
+	if _, err := c.Read(buf); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("read on closed conn: %v, want net.ErrClosed", err)
+	}
+}
+
+// TestMemListenDial tests wildcard listeners, refused dials and address conflicts
+func TestMemListenDial(t *testing.T) {
+	n := NewNetwork()
+	defer n.Close()
+	h := n.Host("10.0.0.1")
+
+	if _, err := h.Dial("10.0.0.1:7070", 0); err == nil {
+		t.Error("dial without listener succeeded")
+	}
+
+	l, err := h.Listen(":7070")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Listen("10.0.0.1:7070"); err == nil {
+		t.Error("listening twice on the same port succeeded")
+	}
+	if _, err := n.Host("10.0.0.9").Dial("10.0.0.3:7070", time.Second); err != nil {
+		t.Errorf("dial to wildcard listener: %v", err)
+	}
+
+	l.Close()
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("accept on closed listener: %v, want net.ErrClosed", err)
+	}
+	if _, err := h.Dial("10.0.0.1:7070", 0); err == nil {
+		t.Error("dial to closed listener succeeded")
+	}
+	if _, err := h.Listen("10.0.0.1:7070"); err != nil {
+		t.Errorf("listen after close: %v", err)
+	}
+
+	n.Close()
+	if _, err := h.Listen("10.0.0.1:7071"); err == nil {
+		t.Error("listen on closed network succeeded")
+	}
+}
+
+// TestMemDeadline tests read and write deadlines
+func TestMemDeadline(t *testing.T) {
+	n := NewNetwork()
+	defer n.Close()
+	l, _ := n.Host("a").Listen("a:1")
+	c, err := n.Host("b").Dial("a:1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := l.Accept()
+
+	c.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	start := time.Now()
+	_, err = c.Read(make([]byte, 1))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("read error %v, want deadline exceeded", err)
+	}
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("read error %v is not a timeout", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("read returned after %v", time.Since(start))
+	}
+
+	// Clearing the deadline makes reads block again until data arrives
+	c.SetReadDeadline(time.Time{})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.Write([]byte{1})
+	}()
+	if _, err := c.Read(make([]byte, 1)); err != nil {
+		t.Errorf("read after clearing deadline: %v", err)
+	}
+
+	// Writes block once the peer's buffer is full
+	c.SetWriteDeadline(time.Now().Add(50 * time.Millisecond))
+	written, err := c.Write(make([]byte, pipeBufferSize+1))
+	if !errors.Is(err, os.ErrDeadlineExceeded) || written != pipeBufferSize {
+		t.Errorf("write = %d, %v; want %d and deadline exceeded", written, err, pipeBufferSize)
+	}
+}
+
+// TestMemConcurrentWrites tests that concurrent writes are not interleaved
+func TestMemConcurrentWrites(t *testing.T) {
+	n := NewNetwork()
+	defer n.Close()
+	l, _ := n.Host("a").Listen("a:1")
+	c, err := n.Host("b").Dial("a:1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := l.Accept()
+
+	const size = pipeBufferSize / 2
+	var wg sync.WaitGroup
+	for _, b := range []byte{'x', 'y', 'z'} {
+		wg.Add(1)
+		go func(b byte) {
+			defer wg.Done()
+			c.Write(bytes.Repeat([]byte{b}, size))
+		}(b)
+	}
+	go func() {
+		wg.Wait()
+		c.Close()
+	}()
+
+	data, err := io.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 3*size {
+		t.Fatalf("read %d bytes, want %d", len(data), 3*size)
+	}
+	for i := 0; i < 3; i++ {
+		chunk := data[i*size : (i+1)*size]
+		if bytes.Count(chunk, chunk[:1]) != size {
+			t.Errorf("write %d interleaved with another one", i)
+		}
+	}
+}